@@ -12,43 +12,93 @@ import (
 	"log/slog"
 	"math/big"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Peltoche/onlyfun/assets"
 	"github.com/Peltoche/onlyfun/internal/server"
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/logger"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
+	"github.com/Peltoche/onlyfun/internal/tools/password"
 	"github.com/Peltoche/onlyfun/internal/tools/response"
 	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/home"
 	"github.com/Peltoche/onlyfun/internal/web/html"
 	"github.com/spf13/afero"
 )
 
 var (
-	ErrConflictTLSConfig = errors.New("can't use --self-signed-cert and --tls-key at the same time")
-	ErrDevFlagRequire    = errors.New("this flag require the --dev flag setup")
+	ErrConflictTLSConfig   = errors.New("can't use --self-signed-cert and --tls-key at the same time")
+	ErrDevFlagRequire      = errors.New("this flag require the --dev flag setup")
+	ErrInvalidRegistration = errors.New("invalid registration mode")
+	ErrInvalidDuration     = errors.New("the duration must be positive")
+	ErrOIDCIssuerRequired  = errors.New("this flag require the --oidc-issuer flag setup")
+	ErrMissingOIDCFlag     = errors.New("this flag is required with --oidc-issuer")
+	ErrInvalidGroupRole    = errors.New("invalid group mapping, expected GROUP=ROLE")
+	ErrInvalidMaxAttempts  = errors.New("the number of attempts must be positive")
+	ErrInvalidPublicURL    = errors.New("expected an absolute http(s) url")
+	ErrInvalidPasswordCost = errors.New("the argon2 cost must be positive")
+	ErrInvalidParallelism  = errors.New("the parallelism must be between 1 and 255")
+	ErrInvalidProxy        = errors.New("expected an IP or a CIDR")
+	ErrInvalidQuota        = errors.New("invalid quota, expected ROLE=COUNT with a COUNT of -1 or more")
 )
 
 type flags struct {
-	LogLevel       string
-	Folder         string
-	TLSCert        string
-	TLSKey         string
-	HTTPHost       string
-	HTTPHostnames  []string
-	HTTPPort       int
-	MemoryFS       bool
-	SelfSignedCert bool
-	Debug          bool
-	Dev            bool
-	HotReload      bool
-	PrintVersion   bool
-	PrintHelp      bool
+	LogLevel            string
+	Folder              string
+	TLSCert             string
+	TLSKey              string
+	HTTPHost            string
+	TrustedProxies      string
+	Registration        string
+	OIDCIssuer          string
+	OIDCClientID        string
+	OIDCSecret          string
+	OIDCRedirect        string
+	OIDCRole            string
+	OIDCGroups          string
+	OIDCGroupRoles      string
+	InvitationQuotas    string
+	PublicURL           string
+	SMTPHost            string
+	SMTPUsername        string
+	SMTPPassword        string
+	MailFrom            string
+	MailFolder          string
+	HTTPHostnames       []string
+	HTTPPort            int
+	SMTPPort            int
+	LoginAttempts       int
+	PasswordMemory      int
+	PasswordIterations  int
+	PasswordParallelism int
+	LoginLockout        time.Duration
+	SessionLife         time.Duration
+	SessionIdle         time.Duration
+	MemoryFS            bool
+	SelfSignedCert      bool
+	Debug               bool
+	Dev                 bool
+	HotReload           bool
+	PrintVersion        bool
+	PrintHelp           bool
 }
 
 func NewConfigFromFlags(flags *flags) (server.Config, error) {
@@ -76,6 +126,54 @@ func NewConfigFromFlags(flags *flags) (server.Config, error) {
 		logLevel = slog.LevelDebug
 	}
 
+	registration := users.RegistrationMode(strings.ToLower(flags.Registration))
+	if !slices.Contains(users.RegistrationModes, registration) {
+		return server.Config{}, fmt.Errorf("--registration %q: %w", flags.Registration, ErrInvalidRegistration)
+	}
+
+	if flags.SessionLife <= 0 {
+		return server.Config{}, fmt.Errorf("--session-lifetime %s: %w", flags.SessionLife, ErrInvalidDuration)
+	}
+
+	if flags.SessionIdle <= 0 {
+		return server.Config{}, fmt.Errorf("--session-idle-timeout %s: %w", flags.SessionIdle, ErrInvalidDuration)
+	}
+
+	if flags.LoginAttempts <= 0 {
+		return server.Config{}, fmt.Errorf("--login-max-attempts %d: %w", flags.LoginAttempts, ErrInvalidMaxAttempts)
+	}
+
+	if flags.LoginLockout <= 0 {
+		return server.Config{}, fmt.Errorf("--login-lockout-duration %s: %w", flags.LoginLockout, ErrInvalidDuration)
+	}
+
+	if flags.PasswordMemory <= 0 {
+		return server.Config{}, fmt.Errorf("--password-memory %d: %w", flags.PasswordMemory, ErrInvalidPasswordCost)
+	}
+
+	if flags.PasswordIterations <= 0 {
+		return server.Config{}, fmt.Errorf("--password-iterations %d: %w", flags.PasswordIterations, ErrInvalidPasswordCost)
+	}
+
+	if flags.PasswordParallelism <= 0 || flags.PasswordParallelism > 255 {
+		return server.Config{}, fmt.Errorf("--password-parallelism %d: %w", flags.PasswordParallelism, ErrInvalidParallelism)
+	}
+
+	trustedProxies, err := parseTrustedProxies(flags.TrustedProxies)
+	if err != nil {
+		return server.Config{}, err
+	}
+
+	invitationQuotas, err := parseInvitationQuotas(flags.InvitationQuotas)
+	if err != nil {
+		return server.Config{}, err
+	}
+
+	identitiesCfg, err := newIdentitiesConfig(flags)
+	if err != nil {
+		return server.Config{}, err
+	}
+
 	var fs afero.Fs
 	var storagePath string
 	if flags.MemoryFS {
@@ -87,7 +185,7 @@ func NewConfigFromFlags(flags *flags) (server.Config, error) {
 		storagePath = path.Join(flags.Folder, "db.sqlite")
 	}
 
-	err := fs.MkdirAll(flags.Folder, 0o755)
+	err = fs.MkdirAll(flags.Folder, 0o755)
 	if err != nil && !errors.Is(err, os.ErrExist) {
 		return server.Config{}, fmt.Errorf("failed to create %q: %w", flags.Folder, err)
 	}
@@ -105,15 +203,21 @@ func NewConfigFromFlags(flags *flags) (server.Config, error) {
 
 	isTLSEnabled := flags.TLSCert != "" || flags.TLSKey != ""
 
+	publicURL, err := newPublicURL(flags, isTLSEnabled)
+	if err != nil {
+		return server.Config{}, err
+	}
+
 	return server.Config{
 		FS: fs,
 		Listener: router.Config{
-			Addr:      net.JoinHostPort(flags.HTTPHost, strconv.Itoa(flags.HTTPPort)),
-			TLS:       isTLSEnabled,
-			Secure:    !flags.Dev,
-			CertFile:  flags.TLSCert,
-			KeyFile:   flags.TLSKey,
-			HostNames: flags.HTTPHostnames,
+			Addr:           net.JoinHostPort(flags.HTTPHost, strconv.Itoa(flags.HTTPPort)),
+			TLS:            isTLSEnabled,
+			Secure:         !flags.Dev,
+			CertFile:       flags.TLSCert,
+			KeyFile:        flags.TLSKey,
+			HostNames:      flags.HTTPHostnames,
+			TrustedProxies: trustedProxies,
 		},
 		Storage: sqlstorage.Config{
 			Path: storagePath,
@@ -129,12 +233,171 @@ func NewConfigFromFlags(flags *flags) (server.Config, error) {
 				Level:  logLevel,
 				Output: os.Stderr,
 			},
+			Password: password.Config{
+				Memory:      uint32(flags.PasswordMemory),
+				Iterations:  uint32(flags.PasswordIterations),
+				Parallelism: uint8(flags.PasswordParallelism),
+			},
 		},
 		Folder: server.Folder(flags.Folder),
 		HTML: html.Config{
 			PrettyRender: flags.Dev,
 			HotReload:    flags.HotReload,
 		},
+		Users: users.Config{
+			Registration: registration,
+		},
+		WebSessions: websessions.Config{
+			Lifetime:    flags.SessionLife,
+			IdleTimeout: flags.SessionIdle,
+		},
+		Identities: identitiesCfg,
+		Invitations: invitations.Config{
+			Quotas: invitationQuotas,
+		},
+		LoginAttempts: loginattempts.Config{
+			MaxAttempts:     flags.LoginAttempts,
+			LockoutDuration: flags.LoginLockout,
+		},
+		Mailer: mailer.Config{
+			Host:     flags.SMTPHost,
+			Port:     flags.SMTPPort,
+			Username: flags.SMTPUsername,
+			Password: secret.NewText(flags.SMTPPassword),
+			From:     flags.MailFrom,
+			Folder:   flags.MailFolder,
+		},
+		Recovery: recovery.Config{
+			BaseURL:       publicURL,
+			PurgeInterval: recovery.DefaultPurgeInterval,
+		},
+		Bans: bans.Config{
+			PurgeInterval: bans.DefaultPurgeInterval,
+		},
+		Home: home.Config{
+			BaseURL: publicURL,
+		},
+	}, nil
+}
+
+// newPublicURL returns the url used to build the absolute links, like the
+// ones sent by e-mail or the feeds ones, without any trailing slash.
+func newPublicURL(flags *flags, isTLSEnabled bool) (string, error) {
+	if flags.PublicURL == "" {
+		scheme := "http"
+		if isTLSEnabled {
+			scheme = "https"
+		}
+
+		host := flags.HTTPHost
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+
+		return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(flags.HTTPPort))), nil
+	}
+
+	u, err := url.Parse(flags.PublicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("--public-url %q: %w", flags.PublicURL, ErrInvalidPublicURL)
+	}
+
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// parseTrustedProxies parses the comma separated list of IPs and CIDRs given
+// to --trusted-proxies.
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	res := []netip.Prefix{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			res = append(res, prefix.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("--trusted-proxies %q: %w", entry, ErrInvalidProxy)
+		}
+
+		res = append(res, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+	}
+
+	return res, nil
+}
+
+// parseInvitationQuotas parses the comma separated ROLE=COUNT quotas given to
+// --invitation-quotas. It returns nil when empty so the
+// [invitations.DefaultQuotas] apply.
+func parseInvitationQuotas(raw string) (map[perms.Role]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	res := map[perms.Role]int{}
+	for _, entry := range strings.Split(raw, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		role, rawCount, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		count, err := strconv.Atoi(strings.TrimSpace(rawCount))
+		if !ok || role == "" || err != nil || count < invitations.Unlimited {
+			return nil, fmt.Errorf("--invitation-quotas %q: %w", entry, ErrInvalidQuota)
+		}
+
+		res[perms.Role(role)] = count
+	}
+
+	return res, nil
+}
+
+func newIdentitiesConfig(flags *flags) (identities.Config, error) {
+	if flags.OIDCIssuer == "" {
+		if flags.OIDCClientID != "" || flags.OIDCRedirect != "" || flags.OIDCGroupRoles != "" {
+			return identities.Config{}, fmt.Errorf("--oidc-*: %w", ErrOIDCIssuerRequired)
+		}
+
+		return identities.Config{}, nil
+	}
+
+	if flags.OIDCClientID == "" {
+		return identities.Config{}, fmt.Errorf("--oidc-client-id: %w", ErrMissingOIDCFlag)
+	}
+
+	if flags.OIDCRedirect == "" {
+		return identities.Config{}, fmt.Errorf("--oidc-redirect-url: %w", ErrMissingOIDCFlag)
+	}
+
+	groupRoles := []identities.GroupRole{}
+	for _, mapping := range strings.Split(flags.OIDCGroupRoles, ",") {
+		if strings.TrimSpace(mapping) == "" {
+			continue
+		}
+
+		group, role, ok := strings.Cut(mapping, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || role == "" {
+			return identities.Config{}, fmt.Errorf("--oidc-group-roles %q: %w", mapping, ErrInvalidGroupRole)
+		}
+
+		groupRoles = append(groupRoles, identities.GroupRole{Group: group, Role: perms.Role(role)})
+	}
+
+	return identities.Config{
+		Issuer:       flags.OIDCIssuer,
+		ClientID:     flags.OIDCClientID,
+		ClientSecret: secret.NewText(flags.OIDCSecret),
+		RedirectURL:  flags.OIDCRedirect,
+		DefaultRole:  perms.Role(flags.OIDCRole),
+		GroupsClaim:  flags.OIDCGroups,
+		GroupRoles:   groupRoles,
 	}, nil
 }
 
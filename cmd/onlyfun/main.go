@@ -9,7 +9,14 @@ import (
 	"path"
 
 	"github.com/Peltoche/onlyfun/internal/server"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
 	"github.com/Peltoche/onlyfun/internal/tools/buildinfos"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
+	"github.com/Peltoche/onlyfun/internal/tools/password"
 	"github.com/adrg/xdg"
 )
 
@@ -115,6 +122,36 @@ func parseFlags(args []string, defaultFolder string, output io.Writer) (*flags,
 
 	fs.IntVar(&flags.HTTPPort, "http-port", 5764, "Web server port number.")
 	fs.StringVar(&flags.HTTPHost, "http-host", "0.0.0.0", "Web server IP address")
+	fs.StringVar(&flags.TrustedProxies, "trusted-proxies", "", "Comma separated IPs or CIDRs of the reverse proxies allowed to set the X-Forwarded-For and X-Real-IP headers")
+
+	fs.DurationVar(&flags.SessionLife, "session-lifetime", websessions.DefaultLifetime, "Maximum DURATION of a login session")
+	fs.DurationVar(&flags.SessionIdle, "session-idle-timeout", websessions.DefaultIdleTimeout, "DURATION of inactivity after which a login session expires")
+
+	fs.IntVar(&flags.LoginAttempts, "login-max-attempts", loginattempts.DefaultMaxAttempts, "Number of consecutive failed logins locking an account")
+	fs.DurationVar(&flags.LoginLockout, "login-lockout-duration", loginattempts.DefaultLockoutDuration, "DURATION an account stays locked after too many failed logins")
+
+	fs.IntVar(&flags.PasswordMemory, "password-memory", password.DefaultMemory, "Memory used to hash a password with argon2id, in KiB")
+	fs.IntVar(&flags.PasswordIterations, "password-iterations", password.DefaultIterations, "Number of argon2id iterations to hash a password")
+	fs.IntVar(&flags.PasswordParallelism, "password-parallelism", password.DefaultParallelism, "Number of argon2id threads to hash a password (1-255)")
+
+	fs.StringVar(&flags.PublicURL, "public-url", "", "Public URL of the server used in the absolute links, like the e-mails or the feeds ones, default to the local address")
+	fs.StringVar(&flags.SMTPHost, "smtp-host", "", "SMTP server HOST used to send the e-mails")
+	fs.IntVar(&flags.SMTPPort, "smtp-port", mailer.DefaultSMTPPort, "SMTP server port number")
+	fs.StringVar(&flags.SMTPUsername, "smtp-username", "", "SMTP USERNAME, no authentication if empty")
+	fs.StringVar(&flags.SMTPPassword, "smtp-password", "", "SMTP PASSWORD")
+	fs.StringVar(&flags.MailFrom, "mail-from", "onlyfun@localhost", "Sender ADDRESS of the e-mails")
+	fs.StringVar(&flags.MailFolder, "mail-folder", "", "Write the e-mails as .eml files into FOLDER instead of sending them, ignored with --smtp-host")
+
+	fs.StringVar(&flags.Registration, "registration", string(users.RegistrationClosed), "Self-service registration MODE (open, approval, closed)")
+	fs.StringVar(&flags.InvitationQuotas, "invitation-quotas", "", "Comma separated ROLE=COUNT invitation quotas, -1 for unlimited, default to admin=-1,moderator=10,user=3")
+
+	fs.StringVar(&flags.OIDCIssuer, "oidc-issuer", "", "URL of the OpenID Connect provider, enables the login with it")
+	fs.StringVar(&flags.OIDCClientID, "oidc-client-id", "", "OpenID Connect client ID")
+	fs.StringVar(&flags.OIDCSecret, "oidc-client-secret", "", "OpenID Connect client SECRET")
+	fs.StringVar(&flags.OIDCRedirect, "oidc-redirect-url", "", "URL of the /login/oidc/callback page registered on the provider")
+	fs.StringVar(&flags.OIDCRole, "oidc-default-role", string(perms.DefaultUserRole), "ROLE given to the OpenID Connect users without any mapped group")
+	fs.StringVar(&flags.OIDCGroups, "oidc-groups-claim", identities.DefaultGroupsClaim, "ID token CLAIM listing the groups of the user")
+	fs.StringVar(&flags.OIDCGroupRoles, "oidc-group-roles", "", "Comma separated GROUP=ROLE mappings, the first matching group wins")
 
 	fs.BoolVar(&flags.PrintVersion, "version", false, "version for onlyfun")
 	fs.BoolVar(&flags.PrintHelp, "help", false, "help for onlyfun")
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS votes (
+  "post_id" INTEGER NOT NULL,
+  "user_id" TEXT NOT NULL,
+  "value" INTEGER NOT NULL,
+  "voted_at" TEXT NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_votes_post_id_user_id ON votes(post_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_votes_user_id ON votes(user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN "upvotes" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN "downvotes" INTEGER NOT NULL DEFAULT 0;
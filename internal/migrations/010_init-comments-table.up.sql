@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS comments (
+  "id" INTEGER PRIMARY KEY,
+  "post_id" INTEGER NOT NULL,
+  "parent_id" INTEGER,
+  "status" TEXT NOT NULL,
+  "content" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+  FOREIGN KEY(parent_id) REFERENCES comments(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+  FOREIGN KEY(created_by) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_id ON comments(post_id, id);
+CREATE INDEX IF NOT EXISTS idx_comments_created_by ON comments(created_by);
+
+CREATE TABLE IF NOT EXISTS comment_moderations(
+  "id" INTEGER PRIMARY KEY,
+  "comment_id" INTEGER NOT NULL,
+  "reason" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  FOREIGN KEY(comment_id) REFERENCES comments(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+  FOREIGN KEY(created_by) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE INDEX IF NOT EXISTS idx_comment_moderations_comment_id ON comment_moderations(comment_id);
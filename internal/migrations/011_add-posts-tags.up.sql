@@ -0,0 +1,10 @@
+ALTER TABLE posts ADD COLUMN "tags" TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS post_tags (
+  "post_id" INTEGER NOT NULL,
+  "tag" TEXT NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_tags_tag_post_id ON post_tags(tag, post_id);
+CREATE INDEX IF NOT EXISTS idx_post_tags_post_id ON post_tags(post_id);
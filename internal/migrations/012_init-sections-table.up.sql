@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS sections (
+  "name" TEXT NOT NULL,
+  "description" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  FOREIGN KEY(created_by) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sections_name ON sections(name);
@@ -0,0 +1,45 @@
+-- The fts5 module is only available with the "sqlite_fts5" build tag of
+-- github.com/mattn/go-sqlite3.
+CREATE VIRTUAL TABLE IF NOT EXISTS posts_search USING fts5(
+  "title",
+  "tags",
+  "comments",
+  tokenize='porter unicode61'
+);
+
+-- The rowid is the post id.
+INSERT INTO posts_search(rowid, title, tags, comments)
+SELECT id, title, tags, COALESCE((SELECT group_concat(content, ' ') FROM comments
+  WHERE comments.post_id = posts.id AND comments.status = 'published'), '')
+FROM posts;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_after_post_insert AFTER INSERT ON posts
+BEGIN
+  INSERT INTO posts_search(rowid, title, tags, comments) VALUES (new.id, new.title, new.tags, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_after_post_update AFTER UPDATE OF title, tags ON posts
+BEGIN
+  UPDATE posts_search SET title = new.title, tags = new.tags WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_after_post_delete AFTER DELETE ON posts
+BEGIN
+  DELETE FROM posts_search WHERE rowid = old.id;
+END;
+
+-- Only the published comments are searchable, the whole list is rebuilt
+-- each time a comment is added, deleted or moderated.
+CREATE TRIGGER IF NOT EXISTS posts_search_after_comment_insert AFTER INSERT ON comments
+BEGIN
+  UPDATE posts_search SET comments = COALESCE((SELECT group_concat(content, ' ') FROM comments
+    WHERE comments.post_id = new.post_id AND comments.status = 'published'), '')
+  WHERE rowid = new.post_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_after_comment_update AFTER UPDATE OF status, content ON comments
+BEGIN
+  UPDATE posts_search SET comments = COALESCE((SELECT group_concat(content, ' ') FROM comments
+    WHERE comments.post_id = new.post_id AND comments.status = 'published'), '')
+  WHERE rowid = new.post_id;
+END;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS invitations (
+  "code" TEXT NOT NULL,
+  "max_uses" INTEGER NOT NULL,
+  "uses" INTEGER NOT NULL,
+  "expires_at" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  FOREIGN KEY(created_by) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_code ON invitations(code);
+CREATE INDEX IF NOT EXISTS idx_invitations_created_by ON invitations(created_by);
@@ -0,0 +1,4 @@
+ALTER TABLE web_sessions ADD COLUMN "remember" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE web_sessions ADD COLUMN "last_seen_at" TEXT NOT NULL DEFAULT '';
+
+UPDATE web_sessions SET last_seen_at = created_at;
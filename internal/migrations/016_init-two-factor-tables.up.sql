@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS two_factor_secrets (
+  "user_id" TEXT NOT NULL,
+  "secret" TEXT NOT NULL,
+  "last_step" INTEGER NOT NULL,
+  "enabled_at" TEXT,
+  "created_at" TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_secrets_user_id ON two_factor_secrets(user_id);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+  "hash" TEXT NOT NULL,
+  "user_id" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_hash ON two_factor_recovery_codes(hash);
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes(user_id);
+
+CREATE TABLE IF NOT EXISTS two_factor_required_roles (
+  "role" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_required_roles_role ON two_factor_required_roles(role);
+
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+  "token" TEXT NOT NULL,
+  "user_id" TEXT NOT NULL,
+  "remember" INTEGER NOT NULL,
+  "attempts" INTEGER NOT NULL,
+  "expires_at" TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_challenges_token ON two_factor_challenges(token);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS identities (
+  "issuer" TEXT NOT NULL,
+  "subject" TEXT NOT NULL,
+  "user_id" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_identities_issuer_subject ON identities(issuer, subject);
+CREATE INDEX IF NOT EXISTS idx_identities_user_id ON identities(user_id);
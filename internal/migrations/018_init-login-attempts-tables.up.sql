@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS login_counters (
+  "kind" TEXT NOT NULL,
+  "key" TEXT NOT NULL,
+  "failures" INTEGER NOT NULL,
+  "last_failure_at" TEXT NOT NULL,
+  "locked_until" TEXT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_counters_kind_key ON login_counters(kind, key);
+
+CREATE TABLE IF NOT EXISTS login_failures (
+  "username" TEXT NOT NULL,
+  "ip" TEXT NOT NULL,
+  "user_agent" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL
+) STRICT;
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_created_at ON login_failures(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN "email" TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
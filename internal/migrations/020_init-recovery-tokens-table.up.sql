@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS recovery_tokens (
+  "hash" TEXT NOT NULL,
+  "kind" TEXT NOT NULL,
+  "user_id" TEXT NOT NULL,
+  "email" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "expires_at" TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_tokens_hash ON recovery_tokens(hash);
+CREATE INDEX IF NOT EXISTS idx_recovery_tokens_user_id ON recovery_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_tokens_expires_at ON recovery_tokens(expires_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS bans (
+  "user_id" TEXT NOT NULL,
+  "reason" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  "expires_at" TEXT,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+  FOREIGN KEY(created_by) REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bans_user_id ON bans(user_id);
+CREATE INDEX IF NOT EXISTS idx_bans_created_by ON bans(created_by);
+CREATE INDEX IF NOT EXISTS idx_bans_expires_at ON bans(expires_at);
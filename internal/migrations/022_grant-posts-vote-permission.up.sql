@@ -0,0 +1,6 @@
+-- The instances created before the votes only have the permissions seeded at
+-- their first boot.
+UPDATE permissions
+SET permissions = CASE WHEN permissions = '' THEN 'posts.vote' ELSE permissions || ',posts.vote' END
+WHERE role IN ('admin', 'moderator', 'user')
+  AND instr(',' || permissions || ',', ',posts.vote,') = 0;
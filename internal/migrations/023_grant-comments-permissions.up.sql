@@ -0,0 +1,11 @@
+-- The instances created before the comments only have the permissions seeded
+-- at their first boot.
+UPDATE permissions
+SET permissions = CASE WHEN permissions = '' THEN 'comments.write' ELSE permissions || ',comments.write' END
+WHERE role IN ('admin', 'moderator', 'user')
+  AND instr(',' || permissions || ',', ',comments.write,') = 0;
+
+UPDATE permissions
+SET permissions = CASE WHEN permissions = '' THEN 'comments.moderate' ELSE permissions || ',comments.moderate' END
+WHERE role IN ('admin', 'moderator')
+  AND instr(',' || permissions || ',', ',comments.moderate,') = 0;
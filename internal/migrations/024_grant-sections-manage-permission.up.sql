@@ -0,0 +1,6 @@
+-- The instances created before the sections only have the permissions seeded
+-- at their first boot.
+UPDATE permissions
+SET permissions = CASE WHEN permissions = '' THEN 'sections.manage' ELSE permissions || ',sections.manage' END
+WHERE role = 'admin'
+  AND instr(',' || permissions || ',', ',sections.manage,') = 0;
@@ -0,0 +1,6 @@
+-- The instances created before the users management only have the
+-- permissions seeded at their first boot.
+UPDATE permissions
+SET permissions = CASE WHEN permissions = '' THEN 'users.manage' ELSE permissions || ',users.manage' END
+WHERE role = 'admin'
+  AND instr(',' || permissions || ',', ',users.manage,') = 0;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS feed_ranks (
+  "feed" TEXT NOT NULL,
+  "ranked_at" TEXT NOT NULL,
+  "post_id" INTEGER NOT NULL,
+  "rank" REAL NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feed_ranks_feed_ranked_at_rank_post_id ON feed_ranks(feed, ranked_at, rank, post_id);
+CREATE INDEX IF NOT EXISTS idx_feed_ranks_ranked_at ON feed_ranks(ranked_at);
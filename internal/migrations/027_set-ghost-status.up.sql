@@ -0,0 +1,3 @@
+-- The ghost account was created as an active user: it showed up in the
+-- searches and could be given a role or banned.
+UPDATE users SET status = 'ghost' WHERE username = '[deleted]';
@@ -0,0 +1,18 @@
+-- The required roles referenced the roles by name without any foreign key:
+-- a renamed or deleted role left its row behind and a new role with the same
+-- name inherited the requirement. The rows of the missing roles are dropped.
+CREATE TABLE two_factor_required_roles_new (
+  "role" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  FOREIGN KEY(role) REFERENCES permissions(role) ON UPDATE CASCADE ON DELETE CASCADE
+) STRICT;
+
+INSERT INTO two_factor_required_roles_new (role, created_at)
+SELECT role, created_at FROM two_factor_required_roles
+WHERE role IN (SELECT role FROM permissions);
+
+DROP TABLE two_factor_required_roles;
+
+ALTER TABLE two_factor_required_roles_new RENAME TO two_factor_required_roles;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_required_roles_role ON two_factor_required_roles(role);
@@ -0,0 +1,38 @@
+-- The users referenced their role with "ON UPDATE RESTRICT": a role could
+-- only be renamed before any user had it. The table is rebuilt with a
+-- cascading foreign key.
+--
+-- The other tables reference the users, so the table can't be renamed
+-- without rewriting their foreign keys and it can't be dropped while they
+-- have rows. The foreign keys are deferred to the end of the migration: the
+-- users are dropped and then inserted back, which resolves the references
+-- of the other tables to the new table.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE users_old AS SELECT * FROM users;
+
+DROP TABLE users;
+
+CREATE TABLE users (
+  "id" TEXT NOT NULL,
+  "username" TEXT NOT NULL,
+  "password" TEXT NOT NULL,
+  "role" TEXT NOT NULL,
+  "status" TEXT NOT NULL,
+  "password_changed_at" TEXT NOT NULL,
+  "avatar" TEXT NOT NULL,
+  "created_at" TEXT NOT NULL,
+  "created_by" TEXT NOT NULL,
+  "email" TEXT,
+  FOREIGN KEY(role) REFERENCES permissions(role) ON UPDATE CASCADE ON DELETE RESTRICT
+  FOREIGN KEY(avatar) REFERENCES medias(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_id ON users(id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
+
+INSERT INTO users (id, username, password, role, status, password_changed_at, avatar, created_at, created_by, email)
+SELECT id, username, password, role, status, password_changed_at, avatar, created_at, created_by, email FROM users_old;
+
+DROP TABLE users_old;
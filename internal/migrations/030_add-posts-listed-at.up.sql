@@ -0,0 +1,7 @@
+ALTER TABLE posts ADD COLUMN "listed_at" TEXT;
+
+-- The date of the listing was not saved, the posts already listed use their
+-- creation date.
+UPDATE posts SET listed_at = created_at WHERE status = 'listed';
+
+CREATE INDEX IF NOT EXISTS idx_posts_status_listed_at ON posts(status, listed_at);
@@ -0,0 +1,6 @@
+-- The registrations were approved with the users.manage permission until they
+-- got their own, the roles keep what they were allowed to do.
+UPDATE permissions
+SET permissions = permissions || ',registrations.manage'
+WHERE instr(',' || permissions || ',', ',users.manage,') > 0
+  AND instr(',' || permissions || ',', ',registrations.manage,') = 0;
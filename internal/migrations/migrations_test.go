@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -47,3 +50,177 @@ func TestRunMigrationTwice(t *testing.T) {
 	err = Run(db, tools)
 	require.NoError(t, err)
 }
+
+func TestGrantPermissionsMigrations(t *testing.T) {
+	db := newTestStorage(t)
+
+	// The roles of an instance created before the permissions were added.
+	migrateTo(t, db, 21)
+	_, err := db.Exec(`INSERT INTO permissions (role, permissions) VALUES
+  ('admin', 'posts.upload,moderation'),
+  ('moderator', 'posts.upload,moderation'),
+  ('user', 'posts.upload'),
+  ('custom', '')`)
+	require.NoError(t, err)
+
+	err = Run(db, tools.NewMock(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"admin":     "posts.upload,moderation,posts.vote,comments.write,comments.moderate,sections.manage,users.manage,registrations.manage",
+		"moderator": "posts.upload,moderation,posts.vote,comments.write,comments.moderate",
+		"user":      "posts.upload,posts.vote,comments.write",
+		"custom":    "",
+	}, getAllPermissions(t, db))
+}
+
+func TestGhostStatusMigration(t *testing.T) {
+	db := newTestStorage(t)
+
+	// The ghost of an instance created before it had its own status.
+	migrateTo(t, db, 26)
+	_, err := db.Exec(`INSERT INTO users (id, username, password, role, status, password_changed_at, avatar, created_at, created_by) VALUES
+  ('ghost-id', '[deleted]', 'some-hash', 'user', 'active', '', 'some-avatar', '', 'ghost-id'),
+  ('john-id', 'john', 'some-hash', 'user', 'active', '', 'some-avatar', '', 'john-id')`)
+	require.NoError(t, err)
+
+	err = Run(db, tools.NewMock(t))
+	require.NoError(t, err)
+
+	rows, err := db.Query(`SELECT username, status FROM users`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := map[string]string{}
+	for rows.Next() {
+		var username, status string
+		require.NoError(t, rows.Scan(&username, &status))
+		res[username] = status
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[string]string{"[deleted]": "ghost", "john": "active"}, res)
+}
+
+func TestTwoFactorRequiredRolesMigration(t *testing.T) {
+	db := newTestStorage(t)
+
+	// A requirement left behind by a role deleted before the foreign key.
+	migrateTo(t, db, 27)
+	_, err := db.Exec(`INSERT INTO permissions (role, permissions) VALUES ('custom', '')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO two_factor_required_roles (role, created_at) VALUES
+  ('custom', '2024-01-01'),
+  ('deleted', '2024-01-01')`)
+	require.NoError(t, err)
+
+	err = Run(db, tools.NewMock(t))
+	require.NoError(t, err)
+
+	var roles []string
+	rows, err := db.Query(`SELECT role FROM two_factor_required_roles`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var role string
+		require.NoError(t, rows.Scan(&role))
+		roles = append(roles, role)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"custom"}, roles)
+}
+
+func TestUsersRoleCascadeMigration(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=true")
+	require.NoError(t, err)
+
+	// A user with a session, referencing the users table being rebuilt.
+	migrateTo(t, db, 28)
+	_, err = db.Exec(`INSERT INTO permissions (role, permissions) VALUES ('custom', '')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO medias (id, size, mimetype, type, checksum, uploaded_at) VALUES
+  ('some-avatar', 42, 'image/png', 'image', 'some-checksum', '')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (id, username, password, role, status, password_changed_at, avatar, created_at, created_by, email) VALUES
+  ('john-id', 'john', 'some-hash', 'custom', 'active', '', 'some-avatar', '', 'john-id', 'john@example.com')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO web_sessions (token, user_id, ip, device, created_at) VALUES
+  ('some-token', 'john-id', '', '', '')`)
+	require.NoError(t, err)
+
+	err = Run(db, tools.NewMock(t))
+	require.NoError(t, err)
+
+	rows, err := db.Query(`SELECT "table", on_update, on_delete FROM pragma_foreign_key_list('users')`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := map[string][2]string{}
+	for rows.Next() {
+		var table, onUpdate, onDelete string
+		require.NoError(t, rows.Scan(&table, &onUpdate, &onDelete))
+		res[table] = [2]string{onUpdate, onDelete}
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[string][2]string{
+		"permissions": {"CASCADE", "RESTRICT"},
+		"medias":      {"RESTRICT", "RESTRICT"},
+	}, res)
+
+	// The role of a user can now be renamed.
+	_, err = db.Exec(`UPDATE permissions SET role = 'renamed' WHERE role = 'custom'`)
+	require.NoError(t, err)
+
+	var role, email string
+	err = db.QueryRow(`SELECT role, email FROM users WHERE id = 'john-id'`).Scan(&role, &email)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", role)
+	assert.Equal(t, "john@example.com", email)
+
+	// The sessions still reference the user.
+	_, err = db.Exec(`DELETE FROM users WHERE id = 'john-id'`)
+	require.ErrorContains(t, err, "FOREIGN KEY constraint failed")
+
+	var indexes int
+	err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_schema WHERE type = 'index' AND tbl_name = 'users'`).Scan(&indexes)
+	require.NoError(t, err)
+	assert.Equal(t, 3, indexes)
+}
+
+func migrateTo(t *testing.T, db *sql.DB, version uint) {
+	t.Helper()
+
+	d, err := iofs.New(fs, ".")
+	require.NoError(t, err)
+
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	require.NoError(t, err)
+
+	m, err := migrate.NewWithInstance("iofs", d, "sqlite3", driver)
+	require.NoError(t, err)
+
+	err = m.Migrate(version)
+	require.NoError(t, err)
+}
+
+func getAllPermissions(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+
+	rows, err := db.Query(`SELECT role, permissions FROM permissions`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := map[string]string{}
+	for rows.Next() {
+		var role, perms string
+		require.NoError(t, rows.Scan(&role, &perms))
+		res[role] = perms
+	}
+
+	require.NoError(t, rows.Err())
+
+	return res
+}
@@ -9,19 +9,32 @@ import (
 
 	"github.com/Peltoche/onlyfun/assets"
 	"github.com/Peltoche/onlyfun/internal/migrations"
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/moderations"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/search"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
 	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/services/utilities"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
 	"github.com/Peltoche/onlyfun/internal/services/websessions"
 	"github.com/Peltoche/onlyfun/internal/tasks"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/logger"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
 	"github.com/Peltoche/onlyfun/internal/tools/router"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/admin"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/api"
 	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
 	"github.com/Peltoche/onlyfun/internal/web/handlers/home"
 	"github.com/Peltoche/onlyfun/internal/web/handlers/moderation"
@@ -36,13 +49,22 @@ type Folder string
 
 type Config struct {
 	fx.Out
-	Tools    tools.Config
-	FS       afero.Fs
-	Storage  sqlstorage.Config
-	Folder   Folder
-	Listener router.Config
-	HTML     html.Config
-	Assets   assets.Config
+	Tools         tools.Config
+	FS            afero.Fs
+	Storage       sqlstorage.Config
+	Folder        Folder
+	Listener      router.Config
+	HTML          html.Config
+	Assets        assets.Config
+	Users         users.Config
+	Invitations   invitations.Config
+	WebSessions   websessions.Config
+	Identities    identities.Config
+	LoginAttempts loginattempts.Config
+	Mailer        mailer.Config
+	Recovery      recovery.Config
+	Bans          bans.Config
+	Home          home.Config
 }
 
 func start(ctx context.Context, cfg Config, invoke fx.Option) *fx.App {
@@ -76,19 +98,32 @@ func start(ctx context.Context, cfg Config, invoke fx.Option) *fx.App {
 			fx.Annotate(tools.NewToolbox, fx.As(new(tools.Tools))),
 			fx.Annotate(html.NewRenderer, fx.As(new(html.Writer))),
 			sqlstorage.Init,
+			mailer.Init,
 			auth.NewAuthenticator,
 
 			// Services
 			fx.Annotate(users.Init, fx.As(new(users.Service))),
-			fx.Annotate(websessions.Init, fx.As(new(websessions.Service))),
+			fx.Annotate(websessions.Init, fx.As(new(websessions.Service)), fx.As(new(users.SessionRevoker))),
 			fx.Annotate(posts.Init, fx.As(new(posts.Service))),
+			fx.Annotate(votes.Init, fx.As(new(votes.Service))),
+			fx.Annotate(comments.Init, fx.As(new(comments.Service))),
+			fx.Annotate(sections.Init, fx.As(new(sections.Service))),
+			fx.Annotate(search.Init, fx.As(new(search.Service))),
 			fx.Annotate(medias.Init, fx.As(new(medias.Service))),
 			fx.Annotate(perms.Init, fx.As(new(perms.Service))),
 			fx.Annotate(moderations.Init, fx.As(new(moderations.Service))),
-			fx.Annotate(taskrunner.Init, fx.ParamTags(`group:"taskrunners"`), fx.As(new(taskrunner.Service))),
+			fx.Annotate(invitations.Init, fx.As(new(invitations.Service))),
+			fx.Annotate(twofactor.Init, fx.As(new(twofactor.Service))),
+			fx.Annotate(identities.Init, fx.As(new(identities.Service))),
+			fx.Annotate(loginattempts.Init, fx.As(new(loginattempts.Service))),
+			fx.Annotate(recovery.Init, fx.As(new(recovery.Service))),
+			fx.Annotate(bans.Init, fx.As(new(bans.Service))),
+			fx.Annotate(taskrunner.Init, fx.As(new(taskrunner.Service))),
 
 			// TasksRunners
 			AsTaskRunner(tasks.NewPostModerateTaskRunner),
+			AsTaskRunner(tasks.NewUserDeleteTaskRunner),
+			AsTaskRunner(tasks.NewPasswordResetSendTaskRunner),
 
 			// Middlewares
 			middlewares.NewBootstrapMiddleware,
@@ -97,12 +132,41 @@ func start(ctx context.Context, cfg Config, invoke fx.Option) *fx.App {
 			AsRoute(assets.NewHTTPHandler),
 			AsRoute(utilities.NewHTTPHandler),
 
+			// JSON API
+			AsRoute(api.NewSessionsHandler),
+			AsRoute(api.NewPostsHandler),
+			AsRoute(api.NewUsersHandler),
+			AsRoute(api.NewModerationHandler),
+
 			// Web Pages
 			AsRoute(auth.NewLoginPage),
+			AsRoute(auth.NewTwoFactorLoginPage),
+			AsRoute(auth.NewOIDCLoginPage),
 			AsRoute(auth.NewBootstrapPage),
+			AsRoute(auth.NewRegisterPage),
+			AsRoute(auth.NewPasswordResetPage),
 			AsRoute(home.NewListingPage),
 			AsRoute(home.NewSubmitPage),
+			AsRoute(home.NewVoteHandler),
+			AsRoute(home.NewPostPage),
+			AsRoute(home.NewSearchPage),
+			AsRoute(home.NewFeedHandler),
+			AsRoute(home.NewProfilePage),
+			AsRoute(home.NewInvitationsPage),
+			AsRoute(home.NewDevicesPage),
+			AsRoute(home.NewTwoFactorPage),
+			AsRoute(home.NewEmailPage),
+			AsRoute(home.NewAvatarPage),
 			AsRoute(moderation.NewModerationHandler),
+			AsRoute(admin.NewSectionsPage),
+			AsRoute(admin.NewRolesPage),
+			AsRoute(admin.NewRegistrationsPage),
+			AsRoute(admin.NewInvitationsPage),
+			AsRoute(admin.NewUsersPage),
+			AsRoute(admin.NewUserDevicesPage),
+			AsRoute(admin.NewTwoFactorPage),
+			AsRoute(admin.NewLoginAttemptsPage),
+			AsRoute(admin.NewBansPage),
 
 			// HTTP Router / HTTP Server
 			router.InitMiddlewares,
@@ -110,6 +174,12 @@ func start(ctx context.Context, cfg Config, invoke fx.Option) *fx.App {
 		),
 
 		fx.Invoke(migrations.Run),
+		fx.Invoke(websessions.RunPurgeJob),
+		fx.Invoke(loginattempts.RunPurgeJob),
+		fx.Invoke(recovery.RunPurgeJob),
+		fx.Invoke(bans.RunPurgeJob),
+		fx.Invoke(posts.RunRanksJob),
+		fx.Invoke(fx.Annotate(taskrunner.RunWorker, fx.ParamTags(``, ``, `group:"taskrunners"`))),
 
 		invoke,
 	)
@@ -0,0 +1,44 @@
+package bans
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const DefaultPurgeInterval = time.Hour
+
+type Config struct {
+	// PurgeInterval is the delay between two removals of the expired bans.
+	PurgeInterval time.Duration
+}
+
+type Service interface {
+	Create(ctx context.Context, cmd *CreateCmd) (*Ban, error)
+	// GetActive returns the ban of the user or an error wrapping
+	// [ErrNotBanned] if there is none.
+	GetActive(ctx context.Context, userID uuid.UUID) (*Ban, error)
+	EnsureNotBanned(ctx context.Context, userID uuid.UUID) error
+	GetAll(ctx context.Context) ([]Ban, error)
+	Lift(ctx context.Context, cmd *LiftCmd) error
+	DeleteAll(ctx context.Context, user *users.User) error
+	Reassign(ctx context.Context, from, to *users.User) error
+	PurgeExpired(ctx context.Context) error
+}
+
+func Init(
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	permsSvc perms.Service,
+	webSessions websessions.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(tools, storage, permsSvc, webSessions)
+}
@@ -0,0 +1,59 @@
+package bans
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+// Ban forbids a user to log in and to upload posts. A ban without an
+// expiration is permanent.
+type Ban struct {
+	userID    uuid.UUID
+	reason    string
+	createdAt time.Time
+	createdBy uuid.UUID
+	expiresAt *time.Time
+}
+
+func (b Ban) UserID() uuid.UUID     { return b.userID }
+func (b Ban) Reason() string        { return b.reason }
+func (b Ban) CreatedAt() time.Time  { return b.createdAt }
+func (b Ban) CreatedBy() uuid.UUID  { return b.createdBy }
+func (b Ban) ExpiresAt() *time.Time { return b.expiresAt }
+func (b Ban) IsPermanent() bool     { return b.expiresAt == nil }
+
+func (b Ban) IsExpired(now time.Time) bool {
+	return b.expiresAt != nil && !now.Before(*b.expiresAt)
+}
+
+// CreateCmd bans the Target. A zero Duration makes the ban permanent.
+type CreateCmd struct {
+	User     *users.User
+	Target   *users.User
+	Reason   string
+	Duration time.Duration
+}
+
+func (t CreateCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Target, v.Required),
+		v.Field(&t.Reason, v.Required, v.Length(3, 1000)),
+		v.Field(&t.Duration, v.Min(time.Duration(0))),
+	)
+}
+
+type LiftCmd struct {
+	User   *users.User
+	Target uuid.UUID
+}
+
+func (t LiftCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Target, v.Required),
+	)
+}
@@ -0,0 +1,71 @@
+package bans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeBanBuilder struct {
+	t   testing.TB
+	ban *Ban
+}
+
+func NewFakeBan(t testing.TB) *FakeBanBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now())
+
+	return &FakeBanBuilder{
+		t: t,
+		ban: &Ban{
+			userID:    uuidProvider.New(),
+			reason:    gofakeit.Sentence(5),
+			createdAt: createdAt,
+			createdBy: uuidProvider.New(),
+			expiresAt: nil,
+		},
+	}
+}
+
+func (f *FakeBanBuilder) WithUser(user *users.User) *FakeBanBuilder {
+	f.ban.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeBanBuilder) CreatedBy(user *users.User) *FakeBanBuilder {
+	f.ban.createdBy = user.ID()
+
+	return f
+}
+
+func (f *FakeBanBuilder) ExpiresAt(expiresAt time.Time) *FakeBanBuilder {
+	f.ban.expiresAt = &expiresAt
+
+	return f
+}
+
+func (f *FakeBanBuilder) Build() *Ban {
+	return f.ban
+}
+
+func (f *FakeBanBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Ban {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	ban := f.Build()
+
+	err := storage.Save(ctx, ban)
+	require.NoError(f.t, err)
+
+	return ban
+}
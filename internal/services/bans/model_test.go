@@ -0,0 +1,50 @@
+package bans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Ban_Getters(t *testing.T) {
+	ban := NewFakeBan(t).Build()
+
+	assert.Equal(t, ban.userID, ban.UserID())
+	assert.Equal(t, ban.reason, ban.Reason())
+	assert.Equal(t, ban.createdAt, ban.CreatedAt())
+	assert.Equal(t, ban.createdBy, ban.CreatedBy())
+	assert.Equal(t, ban.expiresAt, ban.ExpiresAt())
+}
+
+func Test_Ban_IsExpired(t *testing.T) {
+	now := time.Now()
+
+	t.Run("permanent", func(t *testing.T) {
+		ban := NewFakeBan(t).Build()
+
+		assert.True(t, ban.IsPermanent())
+		assert.False(t, ban.IsExpired(now.Add(time.Hour*24*365)))
+	})
+
+	t.Run("temporary", func(t *testing.T) {
+		ban := NewFakeBan(t).ExpiresAt(now).Build()
+
+		assert.False(t, ban.IsPermanent())
+		assert.False(t, ban.IsExpired(now.Add(-time.Second)))
+		assert.True(t, ban.IsExpired(now))
+	})
+}
+
+func Test_CreateCmd_Validate(t *testing.T) {
+	err := CreateCmd{
+		User:     users.NewFakeUser(t).Build(),
+		Target:   users.NewFakeUser(t).Build(),
+		Reason:   "no",
+		Duration: -time.Hour,
+	}.Validate()
+
+	require.EqualError(t, err, "Duration: must be no less than 0s; Reason: the length must be between 3 and 1000.")
+}
@@ -0,0 +1,22 @@
+package bans
+
+import (
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/periodic"
+	"go.uber.org/fx"
+)
+
+// RunPurgeJob removes periodically the expired bans for as long as the
+// application is running.
+func RunPurgeJob(lc fx.Lifecycle, cfg Config, svc Service, tools tools.Tools) {
+	interval := cfg.PurgeInterval
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+
+	periodic.Register(lc, tools.Logger(), periodic.Job{
+		Name:     "bans-purge",
+		Interval: interval,
+		Run:      svc.PurgeExpired,
+	})
+}
@@ -0,0 +1,186 @@
+package bans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var (
+	ErrBanned      = errors.New("user banned")
+	ErrNotBanned   = errors.New("user not banned")
+	ErrAdminTarget = errors.New("can't ban a user managing the users")
+)
+
+type storage interface {
+	Save(ctx context.Context, ban *Ban) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Ban, error)
+	GetAllActive(ctx context.Context, now time.Time) ([]Ban, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+	UpdateCreator(ctx context.Context, from, to uuid.UUID) error
+	RemoveExpired(ctx context.Context, now time.Time) error
+}
+
+type service struct {
+	storage     storage
+	permsSvc    perms.Service
+	webSessions websessions.Service
+	clock       clock.Clock
+}
+
+func newService(tools tools.Tools, storage storage, permsSvc perms.Service, webSessions websessions.Service) *service {
+	return &service{
+		storage:     storage,
+		permsSvc:    permsSvc,
+		webSessions: webSessions,
+		clock:       tools.Clock(),
+	}
+}
+
+// Create bans the target and logs it out of all its devices. An existing
+// ban of the target is replaced.
+func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Ban, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.ManageUsers) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.ManageUsers))
+	}
+
+	if cmd.Target.Status() == users.Ghost {
+		return nil, errs.BadRequest(users.ErrGhost, "this account can't be banned")
+	}
+
+	// The admins can't lock each other out: the role must be changed first.
+	if s.permsSvc.IsAuthorized(cmd.Target, perms.ManageUsers) {
+		return nil, errs.BadRequest(ErrAdminTarget, "an admin can't be banned")
+	}
+
+	now := s.clock.Now()
+
+	ban := Ban{
+		userID:    cmd.Target.ID(),
+		reason:    cmd.Reason,
+		createdAt: now,
+		createdBy: cmd.User.ID(),
+		expiresAt: nil,
+	}
+
+	if cmd.Duration > 0 {
+		expiresAt := now.Add(cmd.Duration)
+		ban.expiresAt = &expiresAt
+	}
+
+	err = s.storage.Save(ctx, &ban)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Save: %w", err))
+	}
+
+	// XXX:MULTI-WRITE
+	err = s.webSessions.DeleteAll(ctx, cmd.Target.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke the sessions: %w", err)
+	}
+
+	return &ban, nil
+}
+
+// GetActive returns the ban of the user. An expired ban is ignored.
+func (s *service) GetActive(ctx context.Context, userID uuid.UUID) (*Ban, error) {
+	ban, err := s.storage.GetByUserID(ctx, userID)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(ErrNotBanned)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByUserID: %w", err))
+	}
+
+	if ban.IsExpired(s.clock.Now()) {
+		return nil, errs.NotFound(ErrNotBanned)
+	}
+
+	return ban, nil
+}
+
+// EnsureNotBanned returns an error wrapping [ErrBanned] if the user has an
+// active ban.
+func (s *service) EnsureNotBanned(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.GetActive(ctx, userID)
+	switch {
+	case err == nil:
+		return errs.Unauthorized(ErrBanned, "you are banned")
+	case errors.Is(err, ErrNotBanned):
+		return nil
+	default:
+		return err
+	}
+}
+
+// GetAll returns the active bans, the most recent first.
+func (s *service) GetAll(ctx context.Context) ([]Ban, error) {
+	res, err := s.storage.GetAllActive(ctx, s.clock.Now())
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetAllActive: %w", err))
+	}
+
+	return res, nil
+}
+
+func (s *service) Lift(ctx context.Context, cmd *LiftCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.ManageUsers))
+	}
+
+	err = s.storage.Delete(ctx, cmd.Target)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Delete: %w", err))
+	}
+
+	return nil
+}
+
+// DeleteAll removes the ban of the user.
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	err := s.storage.Delete(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Delete: %w", err))
+	}
+
+	return nil
+}
+
+// Reassign transfers all the bans issued by the user "from" to the user "to".
+func (s *service) Reassign(ctx context.Context, from, to *users.User) error {
+	err := s.storage.UpdateCreator(ctx, from.ID(), to.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateCreator: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) PurgeExpired(ctx context.Context) error {
+	err := s.storage.RemoveExpired(ctx, s.clock.Now())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveExpired: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,457 @@
+package bans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBansService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Create success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+		now := time.Now()
+		var saved *Ban
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		permsMock.On("IsAuthorized", target, perms.ManageUsers).Return(false).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*Ban)
+		}).Return(nil).Once()
+		webSessionsMock.On("DeleteAll", mock.Anything, target.ID()).Return(nil).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:     admin,
+			Target:   target,
+			Reason:   "some-reason",
+			Duration: time.Hour,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, saved, res)
+		assert.Equal(t, target.ID(), res.UserID())
+		assert.Equal(t, admin.ID(), res.CreatedBy())
+		assert.Equal(t, "some-reason", res.Reason())
+		assert.Equal(t, now, res.CreatedAt())
+		assert.Equal(t, now.Add(time.Hour), *res.ExpiresAt())
+	})
+
+	t.Run("Create without duration is permanent", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		permsMock.On("IsAuthorized", target, perms.ManageUsers).Return(false).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+		webSessionsMock.On("DeleteAll", mock.Anything, target.ID()).Return(nil).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{User: admin, Target: target, Reason: "some-reason"})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.True(t, res.IsPermanent())
+	})
+
+	t.Run("Create without the ManageUsers permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{User: user, Target: target, Reason: "some-reason"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Create with an admin target", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		permsMock.On("IsAuthorized", target, perms.ManageUsers).Return(true).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{User: admin, Target: target, Reason: "some-reason"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrAdminTarget)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Create with the ghost as target", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).WithUsername(users.GhostUsername).WithStatus(users.Ghost).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{User: admin, Target: ghost, Reason: "some-reason"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, users.ErrGhost)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Create with a Save error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, webSessionsMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		permsMock.On("IsAuthorized", target, perms.ManageUsers).Return(false).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{User: admin, Target: target, Reason: "some-reason"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, res)
+	})
+
+	t.Run("GetActive success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		now := time.Now()
+		ban := NewFakeBan(t).ExpiresAt(now.Add(time.Hour)).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, ban.UserID()).Return(ban, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := svc.GetActive(ctx, ban.UserID())
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, ban, res)
+	})
+
+	t.Run("GetActive with an expired ban", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		now := time.Now()
+		ban := NewFakeBan(t).ExpiresAt(now.Add(-time.Hour)).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, ban.UserID()).Return(ban, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := svc.GetActive(ctx, ban.UserID())
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrNotBanned)
+		assert.Nil(t, res)
+	})
+
+	t.Run("GetActive not found", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+
+		// Run
+		res, err := svc.GetActive(ctx, user.ID())
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrNotBanned)
+		assert.Nil(t, res)
+	})
+
+	t.Run("EnsureNotBanned with a banned user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		ban := NewFakeBan(t).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, ban.UserID()).Return(ban, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+
+		// Run
+		err := svc.EnsureNotBanned(ctx, ban.UserID())
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrBanned)
+	})
+
+	t.Run("EnsureNotBanned with a user not banned", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+
+		// Run
+		err := svc.EnsureNotBanned(ctx, user.ID())
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("EnsureNotBanned with a storage error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetByUserID", mock.Anything, user.ID()).Return(nil, assert.AnError).Once()
+
+		// Run
+		err := svc.EnsureNotBanned(ctx, user.ID())
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		now := time.Now()
+		bans := []Ban{*NewFakeBan(t).Build(), *NewFakeBan(t).Build()}
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetAllActive", mock.Anything, now).Return(bans, nil).Once()
+
+		// Run
+		res, err := svc.GetAll(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, bans, res)
+	})
+
+	t.Run("Lift success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, websessions.NewMockService(t))
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storageMock.On("Delete", mock.Anything, target.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.Lift(ctx, &LiftCmd{User: admin, Target: target.ID()})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Lift without the ManageUsers permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock, websessions.NewMockService(t))
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		err := svc.Lift(ctx, &LiftCmd{User: user, Target: target.ID()})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("Delete", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Reassign success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		from := users.NewFakeUser(t).Build()
+		to := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("UpdateCreator", mock.Anything, from.ID(), to.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.Reassign(ctx, from, to)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("PurgeExpired success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(tools, storageMock, perms.NewMockService(t), websessions.NewMockService(t))
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveExpired", mock.Anything, now).Return(nil).Once()
+
+		// Run
+		err := svc.PurgeExpired(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,170 @@
+package bans
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "bans"
+
+var errNotFound = errors.New("not found")
+
+var allFields = []string{"user_id", "reason", "created_at", "created_by", "expires_at"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+// Save creates the ban or replaces the one of the same user.
+func (s *sqlStorage) Save(ctx context.Context, ban *Ban) error {
+	var expiresAt *sqlstorage.SQLTime
+	if ban.expiresAt != nil {
+		expiresAt = ptr.To(sqlstorage.SQLTime(*ban.expiresAt))
+	}
+
+	_, err := sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(ban.userID,
+			ban.reason,
+			ptr.To(sqlstorage.SQLTime(ban.createdAt)),
+			ban.createdBy,
+			expiresAt).
+		Suffix(`ON CONFLICT(user_id) DO UPDATE SET
+			reason = excluded.reason,
+			created_at = excluded.created_at,
+			created_by = excluded.created_by,
+			expires_at = excluded.expires_at`).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetByUserID(ctx context.Context, userID uuid.UUID) (*Ban, error) {
+	row := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		QueryRowContext(ctx)
+
+	return s.scan(row)
+}
+
+// GetAllActive returns the bans not expired at the given time, the most
+// recent first.
+func (s *sqlStorage) GetAllActive(ctx context.Context, now time.Time) ([]Ban, error) {
+	rows, err := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Or{
+			sq.Eq{"expires_at": nil},
+			sq.Expr("julianday(expires_at) > julianday(?)", ptr.To(sqlstorage.SQLTime(now))),
+		}).
+		OrderBy("created_at DESC").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Ban{}
+	for rows.Next() {
+		ban, err := s.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) Delete(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) UpdateCreator(ctx context.Context, from, to uuid.UUID) error {
+	_, err := sq.Update(tableName).
+		Set("created_by", to).
+		Where(sq.Eq{"created_by": from}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) RemoveExpired(ctx context.Context, now time.Time) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Expr("julianday(expires_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(now)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) scan(row sq.RowScanner) (*Ban, error) {
+	var res Ban
+	var sqlCreatedAt sqlstorage.SQLTime
+	var sqlExpiresAt *sqlstorage.SQLTime
+
+	err := row.Scan(
+		&res.userID,
+		&res.reason,
+		&sqlCreatedAt,
+		&res.createdBy,
+		&sqlExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	res.createdAt = sqlCreatedAt.Time()
+	if sqlExpiresAt != nil {
+		res.expiresAt = ptr.To(sqlExpiresAt.Time())
+	}
+
+	return &res, nil
+}
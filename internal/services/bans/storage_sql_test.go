@@ -0,0 +1,155 @@
+package bans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBansSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newUsers := func(t *testing.T, db sqlstorage.Querier) (*users.User, *users.User) {
+		t.Helper()
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+
+		return users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db),
+			users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+	}
+
+	t.Run("Save and GetByUserID success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		admin, user := newUsers(t, db)
+		ban := NewFakeBan(t).WithUser(user).CreatedBy(admin).ExpiresAt(time.Now().Add(time.Hour)).Build()
+
+		err := store.Save(ctx, ban)
+		require.NoError(t, err)
+
+		res, err := store.GetByUserID(ctx, user.ID())
+		require.NoError(t, err)
+		assert.Equal(t, ban.userID, res.userID)
+		assert.Equal(t, ban.reason, res.reason)
+		assert.WithinDuration(t, ban.createdAt, res.createdAt, time.Millisecond)
+		assert.WithinDuration(t, *ban.expiresAt, *res.expiresAt, time.Millisecond)
+	})
+
+	t.Run("Save replaces the existing ban", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		admin, user := newUsers(t, db)
+		NewFakeBan(t).WithUser(user).CreatedBy(admin).ExpiresAt(time.Now().Add(time.Hour)).BuildAndStore(ctx, db)
+		ban := NewFakeBan(t).WithUser(user).CreatedBy(admin).BuildAndStore(ctx, db)
+
+		res, err := store.GetByUserID(ctx, user.ID())
+		require.NoError(t, err)
+		assert.Equal(t, ban.reason, res.reason)
+		assert.Nil(t, res.expiresAt)
+	})
+
+	t.Run("GetByUserID not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		_, user := newUsers(t, db)
+
+		res, err := store.GetByUserID(ctx, user.ID())
+		require.ErrorIs(t, err, errNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("GetAllActive and RemoveExpired", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now()
+		admin, user := newUsers(t, db)
+		ban := NewFakeBan(t).WithUser(user).CreatedBy(admin).ExpiresAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
+
+		res, err := store.GetAllActive(ctx, now.Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, ban.userID, res[0].userID)
+
+		res, err = store.GetAllActive(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, res)
+
+		err = store.RemoveExpired(ctx, now)
+		require.NoError(t, err)
+
+		_, err = store.GetByUserID(ctx, user.ID())
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("RemoveExpired keeps the permanent bans", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		admin, user := newUsers(t, db)
+		NewFakeBan(t).WithUser(user).CreatedBy(admin).BuildAndStore(ctx, db)
+
+		err := store.RemoveExpired(ctx, time.Now().Add(time.Hour*24*365))
+		require.NoError(t, err)
+
+		res, err := store.GetAllActive(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Len(t, res, 1)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		admin, user := newUsers(t, db)
+		NewFakeBan(t).WithUser(user).CreatedBy(admin).BuildAndStore(ctx, db)
+
+		err := store.Delete(ctx, user.ID())
+		require.NoError(t, err)
+
+		_, err = store.GetByUserID(ctx, user.ID())
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("UpdateCreator success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		admin, user := newUsers(t, db)
+		NewFakeBan(t).WithUser(user).CreatedBy(admin).BuildAndStore(ctx, db)
+
+		err := store.UpdateCreator(ctx, admin.ID(), user.ID())
+		require.NoError(t, err)
+
+		res, err := store.GetByUserID(ctx, user.ID())
+		require.NoError(t, err)
+		assert.Equal(t, user.ID(), res.createdBy)
+	})
+}
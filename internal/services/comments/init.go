@@ -0,0 +1,26 @@
+package comments
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+type Service interface {
+	Create(ctx context.Context, cmd *CreateCmd) (*Comment, error)
+	GetByID(ctx context.Context, commentID uint) (*Comment, error)
+	GetPostThreads(ctx context.Context, post *posts.Post) ([]Thread, error)
+	Delete(ctx context.Context, cmd *DeleteCmd) error
+	Moderate(ctx context.Context, cmd *ModerationCmd) (*Moderation, error)
+	Reassign(ctx context.Context, from, to *users.User) error
+}
+
+func Init(tools tools.Tools, db sqlstorage.Querier, permsSvc perms.Service) Service {
+	storage := newSqlStorage(db)
+
+	return newService(tools, storage, permsSvc)
+}
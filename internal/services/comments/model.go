@@ -0,0 +1,106 @@
+package comments
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	Published Status = "published"
+	Deleted   Status = "deleted"
+	Moderated Status = "moderated"
+)
+
+type Status string
+
+type Comment struct {
+	id        uint
+	postID    uint
+	parentID  *uint
+	status    Status
+	content   string
+	createdAt time.Time
+	createdBy uuid.UUID
+}
+
+func (c Comment) ID() uint             { return c.id }
+func (c Comment) PostID() uint         { return c.postID }
+func (c Comment) Status() Status       { return c.status }
+func (c Comment) Content() string      { return c.content }
+func (c Comment) CreatedAt() time.Time { return c.createdAt }
+func (c Comment) CreatedBy() uuid.UUID { return c.createdBy }
+func (c Comment) IsPublished() bool    { return c.status == Published }
+
+// ParentID returns the id of the replied comment or 0 for a root comment.
+func (c Comment) ParentID() uint {
+	if c.parentID == nil {
+		return 0
+	}
+
+	return *c.parentID
+}
+
+// Thread is a comment with all its replies.
+type Thread struct {
+	Comment Comment
+	Replies []Thread
+}
+
+type Moderation struct {
+	id        uint
+	commentID uint
+	reason    string
+	createdAt time.Time
+	createdBy uuid.UUID
+}
+
+func (m *Moderation) ID() uint             { return m.id }
+func (m *Moderation) CommentID() uint      { return m.commentID }
+func (m *Moderation) Reason() string       { return m.reason }
+func (m *Moderation) CreatedAt() time.Time { return m.createdAt }
+func (m *Moderation) CreatedBy() uuid.UUID { return m.createdBy }
+
+type CreateCmd struct {
+	User    *users.User
+	Post    *posts.Post
+	Parent  *Comment
+	Content string
+}
+
+func (t CreateCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Post, v.Required),
+		v.Field(&t.Content, v.Required, v.Length(1, 2000)),
+	)
+}
+
+type DeleteCmd struct {
+	User    *users.User
+	Comment *Comment
+}
+
+func (t DeleteCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Comment, v.Required),
+	)
+}
+
+type ModerationCmd struct {
+	User    *users.User
+	Comment *Comment
+	Reason  string
+}
+
+func (t ModerationCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Comment, v.Required),
+		v.Field(&t.Reason, v.Required, v.Length(5, 300)),
+	)
+}
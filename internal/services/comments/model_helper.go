@@ -0,0 +1,87 @@
+package comments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeCommentBuilder struct {
+	t       testing.TB
+	comment *Comment
+}
+
+func NewFakeComment(t testing.TB) *FakeCommentBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*1000), time.Now())
+
+	return &FakeCommentBuilder{
+		t: t,
+		comment: &Comment{
+			id:        gofakeit.Uint(),
+			postID:    gofakeit.Uint(),
+			parentID:  nil,
+			status:    Published,
+			content:   gofakeit.LoremIpsumSentence(gofakeit.Number(1, 20)),
+			createdAt: createdAt,
+			createdBy: uuidProvider.New(),
+		},
+	}
+}
+
+func (f *FakeCommentBuilder) WithPost(post *posts.Post) *FakeCommentBuilder {
+	f.comment.postID = post.ID()
+
+	return f
+}
+
+func (f *FakeCommentBuilder) ReplyTo(parent *Comment) *FakeCommentBuilder {
+	f.comment.postID = parent.postID
+	f.comment.parentID = &parent.id
+
+	return f
+}
+
+func (f *FakeCommentBuilder) WithContent(content string) *FakeCommentBuilder {
+	f.comment.content = content
+
+	return f
+}
+
+func (f *FakeCommentBuilder) WithStatus(status Status) *FakeCommentBuilder {
+	f.comment.status = status
+
+	return f
+}
+
+func (f *FakeCommentBuilder) CreatedBy(user *users.User) *FakeCommentBuilder {
+	f.comment.createdBy = user.ID()
+
+	return f
+}
+
+func (f *FakeCommentBuilder) Build() *Comment {
+	return f.comment
+}
+
+func (f *FakeCommentBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Comment {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	comment := f.Build()
+
+	err := storage.Save(ctx, comment)
+	require.NoError(f.t, err)
+
+	return comment
+}
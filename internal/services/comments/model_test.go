@@ -0,0 +1,102 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Comment_Getters(t *testing.T) {
+	c := NewFakeComment(t).Build()
+
+	assert.Equal(t, c.id, c.ID())
+	assert.Equal(t, c.postID, c.PostID())
+	assert.Equal(t, c.status, c.Status())
+	assert.Equal(t, c.content, c.Content())
+	assert.Equal(t, c.createdAt, c.CreatedAt())
+	assert.Equal(t, c.createdBy, c.CreatedBy())
+	assert.True(t, c.IsPublished())
+	assert.Equal(t, uint(0), c.ParentID())
+}
+
+func Test_Comment_ParentID(t *testing.T) {
+	parent := NewFakeComment(t).Build()
+	reply := NewFakeComment(t).ReplyTo(parent).Build()
+
+	assert.Equal(t, parent.ID(), reply.ParentID())
+	assert.Equal(t, parent.PostID(), reply.PostID())
+}
+
+func Test_Moderation_Getters(t *testing.T) {
+	m := Moderation{
+		id:        12,
+		commentID: 42,
+		reason:    "some-reason",
+		createdAt: NewFakeComment(t).Build().createdAt,
+		createdBy: NewFakeComment(t).Build().createdBy,
+	}
+
+	assert.Equal(t, m.id, m.ID())
+	assert.Equal(t, m.commentID, m.CommentID())
+	assert.Equal(t, m.reason, m.Reason())
+	assert.Equal(t, m.createdAt, m.CreatedAt())
+	assert.Equal(t, m.createdBy, m.CreatedBy())
+}
+
+func Test_CreateCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(CreateCmd))
+}
+
+func Test_CreateCmd_Validate_success(t *testing.T) {
+	err := CreateCmd{
+		User:    users.NewFakeUser(t).Build(),
+		Post:    posts.NewFakePost(t).Build(),
+		Parent:  nil,
+		Content: "some comment",
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_CreateCmd_Validate_with_an_empty_content(t *testing.T) {
+	err := CreateCmd{
+		User:    users.NewFakeUser(t).Build(),
+		Post:    posts.NewFakePost(t).Build(),
+		Parent:  nil,
+		Content: "",
+	}.Validate()
+
+	require.EqualError(t, err, "Content: cannot be blank.")
+}
+
+func Test_DeleteCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(DeleteCmd))
+}
+
+func Test_ModerationCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(ModerationCmd))
+}
+
+func Test_ModerationCmd_Validate_success(t *testing.T) {
+	err := ModerationCmd{
+		User:    users.NewFakeUser(t).Build(),
+		Comment: NewFakeComment(t).Build(),
+		Reason:  "some reason",
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_ModerationCmd_Validate_with_a_reason_too_short(t *testing.T) {
+	err := ModerationCmd{
+		User:    users.NewFakeUser(t).Build(),
+		Comment: NewFakeComment(t).Build(),
+		Reason:  "foo",
+	}.Validate()
+
+	require.EqualError(t, err, "Reason: the length must be between 5 and 300.")
+}
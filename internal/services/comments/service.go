@@ -0,0 +1,205 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var (
+	ErrPostNotListed   = errors.New("post not listed")
+	ErrInvalidParent   = errors.New("the parent comment doesn't belong to the post")
+	ErrCommentRemoved  = errors.New("comment removed")
+	ErrNotCommentOwner = errors.New("not the comment owner")
+)
+
+type storage interface {
+	Save(ctx context.Context, comment *Comment) error
+	GetByID(ctx context.Context, commentID uint) (*Comment, error)
+	GetAllForPost(ctx context.Context, postID uint) ([]Comment, error)
+	Update(ctx context.Context, comment *Comment) error
+	SaveModeration(ctx context.Context, moderation *Moderation) error
+	UpdateCreator(ctx context.Context, from, to uuid.UUID) error
+}
+
+type service struct {
+	storage  storage
+	permsSvc perms.Service
+	clock    clock.Clock
+}
+
+func newService(tools tools.Tools, storage storage, permsSvc perms.Service) *service {
+	return &service{
+		storage:  storage,
+		permsSvc: permsSvc,
+		clock:    tools.Clock(),
+	}
+}
+
+func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Comment, error) {
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.WriteComment) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.WriteComment))
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	if cmd.Post.Status() != posts.Listed {
+		return nil, errs.BadRequest(ErrPostNotListed, "post not listed")
+	}
+
+	comment := Comment{
+		// id: set by the db
+		postID:    cmd.Post.ID(),
+		parentID:  nil,
+		status:    Published,
+		content:   cmd.Content,
+		createdAt: s.clock.Now(),
+		createdBy: cmd.User.ID(),
+	}
+
+	if cmd.Parent != nil {
+		if cmd.Parent.postID != cmd.Post.ID() {
+			return nil, errs.BadRequest(ErrInvalidParent, "invalid parent comment")
+		}
+
+		if !cmd.Parent.IsPublished() {
+			return nil, errs.BadRequest(ErrCommentRemoved, "the parent comment have been removed")
+		}
+
+		comment.parentID = &cmd.Parent.id
+	}
+
+	err = s.storage.Save(ctx, &comment)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Save the comment: %w", err))
+	}
+
+	return &comment, nil
+}
+
+func (s *service) GetByID(ctx context.Context, commentID uint) (*Comment, error) {
+	res, err := s.storage.GetByID(ctx, commentID)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(fmt.Errorf("comment %d not found", commentID))
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByID: %w", err))
+	}
+
+	return res, nil
+}
+
+// GetPostThreads returns all the comments of the given post organized by thread.
+//
+// The removed comments are kept in order to not break the threads, it's the
+// caller's responsibility to hide their content.
+func (s *service) GetPostThreads(ctx context.Context, post *posts.Post) ([]Thread, error) {
+	comments, err := s.storage.GetAllForPost(ctx, post.ID())
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetAllForPost: %w", err))
+	}
+
+	// The comments are sorted by id so a parent is always before its replies.
+	children := make(map[uint][]Comment, len(comments))
+	for _, comment := range comments {
+		children[comment.ParentID()] = append(children[comment.ParentID()], comment)
+	}
+
+	return buildThreads(children, 0), nil
+}
+
+func buildThreads(children map[uint][]Comment, parentID uint) []Thread {
+	res := make([]Thread, len(children[parentID]))
+
+	for i, comment := range children[parentID] {
+		res[i] = Thread{
+			Comment: comment,
+			Replies: buildThreads(children, comment.id),
+		}
+	}
+
+	return res
+}
+
+// Delete soft-deletes a comment. Only the comment author can delete its own
+// comments, the moderators must use [Service.Moderate].
+func (s *service) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if cmd.Comment.createdBy != cmd.User.ID() {
+		return errs.Unauthorized(ErrNotCommentOwner)
+	}
+
+	if !cmd.Comment.IsPublished() {
+		return nil
+	}
+
+	cmd.Comment.status = Deleted
+
+	err = s.storage.Update(ctx, cmd.Comment)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Update the comment %d: %w", cmd.Comment.id, err))
+	}
+
+	return nil
+}
+
+// Moderate removes a comment and keeps a trace of the moderation reason.
+func (s *service) Moderate(ctx context.Context, cmd *ModerationCmd) (*Moderation, error) {
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.ModerateComment) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.ModerateComment))
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	moderation := Moderation{
+		// id: set by the db
+		commentID: cmd.Comment.id,
+		reason:    cmd.Reason,
+		createdAt: s.clock.Now(),
+		createdBy: cmd.User.ID(),
+	}
+
+	err = s.storage.SaveModeration(ctx, &moderation)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to SaveModeration: %w", err))
+	}
+
+	cmd.Comment.status = Moderated
+
+	// XXX:MULTI-WRITE
+	err = s.storage.Update(ctx, cmd.Comment)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Update the comment %d: %w", cmd.Comment.id, err))
+	}
+
+	return &moderation, nil
+}
+
+// Reassign transfers all the comments and comment moderations created by the user "from" to the user "to".
+func (s *service) Reassign(ctx context.Context, from, to *users.User) error {
+	err := s.storage.UpdateCreator(ctx, from.ID(), to.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateCreator: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,517 @@
+package comments
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Comments_Service(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Create success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		comment := Comment{
+			postID:    post.ID(),
+			parentID:  nil,
+			status:    Published,
+			content:   "some comment",
+			createdAt: now,
+			createdBy: user.ID(),
+		}
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Save", ctx, &comment).Return(nil).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  nil,
+			Content: "some comment",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &comment, res)
+	})
+
+	t.Run("Create a reply success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		parent := NewFakeComment(t).WithPost(post).Build()
+
+		comment := Comment{
+			postID:    post.ID(),
+			parentID:  &parent.id,
+			status:    Published,
+			content:   "some reply",
+			createdAt: now,
+			createdBy: user.ID(),
+		}
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Save", ctx, &comment).Return(nil).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  parent,
+			Content: "some reply",
+		})
+		require.NoError(t, err)
+		require.Equal(t, parent.ID(), res.ParentID())
+	})
+
+	t.Run("Create without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(false).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  nil,
+			Content: "some comment",
+		})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  nil,
+			Content: "",
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a post not listed", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Uploaded).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  nil,
+			Content: "some comment",
+		})
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrPostNotListed)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a parent from an other post", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		parent := NewFakeComment(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  parent,
+			Content: "some reply",
+		})
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidParent)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a removed parent", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		parent := NewFakeComment(t).WithPost(post).WithStatus(Moderated).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  parent,
+			Content: "some reply",
+		})
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrCommentRemoved)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.WriteComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Save", ctx, &Comment{
+			postID:    post.ID(),
+			parentID:  nil,
+			status:    Published,
+			content:   "some comment",
+			createdAt: now,
+			createdBy: user.ID(),
+		}).Return(fmt.Errorf("some-error")).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  nil,
+			Content: "some comment",
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("GetByID success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		comment := NewFakeComment(t).Build()
+
+		storage.On("GetByID", ctx, comment.ID()).Return(comment, nil).Once()
+
+		res, err := svc.GetByID(ctx, comment.ID())
+		require.NoError(t, err)
+		require.Equal(t, comment, res)
+	})
+
+	t.Run("GetByID not found", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		storage.On("GetByID", ctx, uint(42)).Return(nil, errNotFound).Once()
+
+		res, err := svc.GetByID(ctx, 42)
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("GetByID with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		storage.On("GetByID", ctx, uint(42)).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, err := svc.GetByID(ctx, 42)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("GetPostThreads success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		post := posts.NewFakePost(t).Build()
+		root1 := NewFakeComment(t).WithPost(post).Build()
+		root2 := NewFakeComment(t).WithPost(post).Build()
+		reply1 := NewFakeComment(t).ReplyTo(root1).Build()
+		reply2 := NewFakeComment(t).ReplyTo(reply1).Build()
+
+		storage.On("GetAllForPost", ctx, post.ID()).
+			Return([]Comment{*root1, *root2, *reply1, *reply2}, nil).Once()
+
+		res, err := svc.GetPostThreads(ctx, post)
+		require.NoError(t, err)
+		require.Equal(t, []Thread{
+			{Comment: *root1, Replies: []Thread{
+				{Comment: *reply1, Replies: []Thread{
+					{Comment: *reply2, Replies: []Thread{}},
+				}},
+			}},
+			{Comment: *root2, Replies: []Thread{}},
+		}, res)
+	})
+
+	t.Run("GetPostThreads with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		post := posts.NewFakePost(t).Build()
+
+		storage.On("GetAllForPost", ctx, post.ID()).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, err := svc.GetPostThreads(ctx, post)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).CreatedBy(user).Build()
+
+		expected := *comment
+		expected.status = Deleted
+
+		storage.On("Update", ctx, &expected).Return(nil).Once()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Comment: comment})
+		require.NoError(t, err)
+		require.Equal(t, Deleted, comment.Status())
+	})
+
+	t.Run("Delete a comment already removed", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).CreatedBy(user).WithStatus(Moderated).Build()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Comment: comment})
+		require.NoError(t, err)
+		require.Equal(t, Moderated, comment.Status())
+	})
+
+	t.Run("Delete the comment of someone else", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).Build()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Comment: comment})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrNotCommentOwner)
+	})
+
+	t.Run("Delete with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).CreatedBy(user).Build()
+
+		storage.On("Update", ctx, comment).Return(fmt.Errorf("some-error")).Once()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Comment: comment})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
+	t.Run("Moderate success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).Build()
+
+		moderation := Moderation{
+			commentID: comment.ID(),
+			reason:    "some reason",
+			createdAt: now,
+			createdBy: user.ID(),
+		}
+
+		expected := *comment
+		expected.status = Moderated
+
+		permsSvc.On("IsAuthorized", user, perms.ModerateComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("SaveModeration", ctx, &moderation).Return(nil).Once()
+		storage.On("Update", ctx, &expected).Return(nil).Once()
+
+		res, err := svc.Moderate(ctx, &ModerationCmd{
+			User:    user,
+			Comment: comment,
+			Reason:  "some reason",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &moderation, res)
+		require.Equal(t, Moderated, comment.Status())
+	})
+
+	t.Run("Moderate without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ModerateComment).Return(false).Once()
+
+		res, err := svc.Moderate(ctx, &ModerationCmd{
+			User:    user,
+			Comment: comment,
+			Reason:  "some reason",
+		})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.Nil(t, res)
+	})
+
+	t.Run("Moderate with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ModerateComment).Return(true).Once()
+
+		res, err := svc.Moderate(ctx, &ModerationCmd{
+			User:    user,
+			Comment: comment,
+			Reason:  "", // Reason is required
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+	})
+
+	t.Run("Moderate with a SaveModeration error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		comment := NewFakeComment(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ModerateComment).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("SaveModeration", ctx, &Moderation{
+			commentID: comment.ID(),
+			reason:    "some reason",
+			createdAt: now,
+			createdBy: user.ID(),
+		}).Return(fmt.Errorf("some-error")).Once()
+
+		res, err := svc.Moderate(ctx, &ModerationCmd{
+			User:    user,
+			Comment: comment,
+			Reason:  "some reason",
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+		require.Equal(t, Published, comment.Status())
+	})
+	t.Run("Reassign success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(nil).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reassign with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(assert.AnError).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
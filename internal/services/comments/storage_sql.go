@@ -0,0 +1,179 @@
+package comments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const (
+	tableName           = "comments"
+	moderationTableName = "comment_moderations"
+)
+
+var errNotFound = errors.New("not found")
+
+var (
+	allFields           = []string{"id", "post_id", "parent_id", "status", "content", "created_at", "created_by"}
+	allModerationFields = []string{"id", "comment_id", "reason", "created_at", "created_by"}
+)
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) Save(ctx context.Context, c *Comment) error {
+	var id uint
+
+	err := sq.
+		Insert(tableName).
+		Columns(allFields[1:]...). // Remove the id, it will be autogenerated
+		Values(
+			c.postID,
+			c.parentID,
+			c.status,
+			c.content,
+			ptr.To(sqlstorage.SQLTime(c.createdAt)),
+			c.createdBy).
+		Suffix("RETURNING \"id\"").
+		RunWith(s.db).
+		ScanContext(ctx, &id)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	c.id = id
+
+	return nil
+}
+
+func (s *sqlStorage) GetByID(ctx context.Context, commentID uint) (*Comment, error) {
+	row := sq.Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"id": commentID}).
+		RunWith(s.db).
+		QueryRowContext(ctx)
+
+	return s.scanRow(row)
+}
+
+func (s *sqlStorage) GetAllForPost(ctx context.Context, postID uint) ([]Comment, error) {
+	rows, err := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"post_id": postID}).
+		OrderBy("id").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []Comment{}
+
+	for rows.Next() {
+		res, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, *res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (s *sqlStorage) Update(ctx context.Context, comment *Comment) error {
+	_, err := sq.Update(tableName).
+		SetMap(map[string]any{
+			"status":  comment.status,
+			"content": comment.content,
+		}).
+		Where(sq.Eq{"id": comment.id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) SaveModeration(ctx context.Context, m *Moderation) error {
+	var id uint
+
+	err := sq.
+		Insert(moderationTableName).
+		Columns(allModerationFields[1:]...). // Remove the id, it will be autogenerated
+		Values(
+			m.commentID,
+			m.reason,
+			ptr.To(sqlstorage.SQLTime(m.createdAt)),
+			m.createdBy).
+		Suffix("RETURNING \"id\"").
+		RunWith(s.db).
+		ScanContext(ctx, &id)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	m.id = id
+
+	return nil
+}
+
+func (s *sqlStorage) scanRow(row sq.RowScanner) (*Comment, error) {
+	var res Comment
+	var sqlCreatedAt sqlstorage.SQLTime
+
+	err := row.Scan(
+		&res.id,
+		&res.postID,
+		&res.parentID,
+		&res.status,
+		&res.content,
+		&sqlCreatedAt,
+		&res.createdBy,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	res.createdAt = sqlCreatedAt.Time()
+
+	return &res, nil
+}
+
+func (s *sqlStorage) UpdateCreator(ctx context.Context, from, to uuid.UUID) error {
+	for _, table := range []string{tableName, moderationTableName} {
+		_, err := sq.Update(table).
+			Set("created_by", to).
+			Where(sq.Eq{"created_by": from}).
+			RunWith(s.db).
+			ExecContext(ctx)
+		if err != nil {
+			return fmt.Errorf("sql error on %q: %w", table, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package comments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Save and GetByID success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		parent := NewFakeComment(t).WithPost(post).CreatedBy(user).BuildAndStore(ctx, db)
+		comment := NewFakeComment(t).ReplyTo(parent).CreatedBy(user).Build()
+
+		// Run
+		err := store.Save(ctx, comment)
+		require.NoError(t, err)
+
+		// Asserts
+		res, err := store.GetByID(ctx, comment.ID())
+		require.NoError(t, err)
+		require.Equal(t, comment, res)
+		require.Equal(t, parent.ID(), res.ParentID())
+	})
+
+	t.Run("GetByID not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetByID(ctx, 42)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("GetAllForPost success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		otherPost := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		comment1 := NewFakeComment(t).WithPost(post).CreatedBy(user).BuildAndStore(ctx, db)
+		_ = NewFakeComment(t).WithPost(otherPost).CreatedBy(user).BuildAndStore(ctx, db)
+		comment2 := NewFakeComment(t).ReplyTo(comment1).CreatedBy(user).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetAllForPost(ctx, post.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, []Comment{*comment1, *comment2}, res)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		comment := NewFakeComment(t).WithPost(post).CreatedBy(user).BuildAndStore(ctx, db)
+
+		comment.status = Deleted
+
+		// Run
+		err := store.Update(ctx, comment)
+		require.NoError(t, err)
+
+		// Asserts
+		res, err := store.GetByID(ctx, comment.ID())
+		require.NoError(t, err)
+		require.Equal(t, Deleted, res.Status())
+	})
+
+	t.Run("SaveModeration success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		comment := NewFakeComment(t).WithPost(post).CreatedBy(user).BuildAndStore(ctx, db)
+
+		moderation := &Moderation{
+			commentID: comment.ID(),
+			reason:    "some-reason",
+			createdAt: time.Now(),
+			createdBy: user.ID(),
+		}
+
+		// Run
+		err := store.SaveModeration(ctx, moderation)
+
+		// Asserts
+		require.NoError(t, err)
+		require.NotZero(t, moderation.ID())
+	})
+	t.Run("UpdateCreator success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		ghost := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		comment := NewFakeComment(t).WithPost(post).CreatedBy(user).BuildAndStore(ctx, db)
+
+		// Run
+		err := store.UpdateCreator(ctx, user.ID(), ghost.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetByID(ctx, comment.ID())
+		require.NoError(t, err)
+		require.Equal(t, ghost.ID(), res.CreatedBy())
+	})
+}
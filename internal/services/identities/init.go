@@ -0,0 +1,58 @@
+package identities
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+// Config of the OpenID Connect login.
+type Config struct {
+	// Issuer is the url of the OpenID Connect provider. The login with the
+	// provider is disabled when empty.
+	Issuer       string
+	ClientID     string
+	ClientSecret secret.Text
+	// RedirectURL is the url of the "/login/oidc/callback" page, as
+	// registered on the provider.
+	RedirectURL string
+	// DefaultRole is given to the users without any mapped group.
+	// [perms.DefaultUserRole] is used when empty.
+	DefaultRole perms.Role
+	// GroupsClaim is the ID token claim listing the groups of the user.
+	// [DefaultGroupsClaim] is used when empty.
+	GroupsClaim string
+	// GroupRoles maps the provider groups to the roles, the first matching
+	// group wins. When set, the role of the users is synchronized with their
+	// groups at each login. The roles are matched by name, a renamed role must
+	// be renamed here and in DefaultRole too.
+	GroupRoles []GroupRole
+}
+
+type Service interface {
+	IsEnabled() bool
+	StartLogin(ctx context.Context) (*Authorization, error)
+	FinishLogin(ctx context.Context, cmd *FinishLoginCmd) (*users.User, error)
+	// DeleteAll unlinks the user from all its external identities.
+	DeleteAll(ctx context.Context, user *users.User) error
+}
+
+func Init(
+	cfg Config,
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	usersSvc users.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	var provider provider
+	if cfg.Issuer != "" {
+		provider = newOIDCProvider(cfg)
+	}
+
+	return newService(cfg, tools, storage, provider, usersSvc)
+}
@@ -0,0 +1,72 @@
+package identities
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// DefaultGroupsClaim is the ID token claim listing the groups of the
+	// user when none is configured.
+	DefaultGroupsClaim = "groups"
+
+	// FallbackUsername is used when the provider gives no usable username.
+	FallbackUsername = "user"
+)
+
+// Identity links a user to an account of the OpenID Connect provider. An
+// account is identified by its issuer and its subject.
+type Identity struct {
+	createdAt time.Time
+	issuer    string
+	subject   string
+	userID    uuid.UUID
+}
+
+func (i Identity) Issuer() string       { return i.issuer }
+func (i Identity) Subject() string      { return i.subject }
+func (i Identity) UserID() uuid.UUID    { return i.userID }
+func (i Identity) CreatedAt() time.Time { return i.createdAt }
+
+// GroupRole gives a role to the members of a provider group.
+type GroupRole struct {
+	Group string
+	Role  perms.Role
+}
+
+// Authorization is a login started with StartLogin. The client must keep
+// the State, the Verifier and the Nonce until the provider redirects it to
+// the callback.
+type Authorization struct {
+	// URL is the provider authorization page.
+	URL      string
+	State    string
+	Verifier secret.Text
+	Nonce    string
+}
+
+// Claims are the informations about the user given by the provider inside
+// a verified ID token.
+type Claims struct {
+	Subject  string
+	Username string
+	Groups   []string
+}
+
+type FinishLoginCmd struct {
+	Code     string
+	Verifier secret.Text
+	Nonce    string
+}
+
+func (t FinishLoginCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Code, v.Required),
+		v.Field(&t.Verifier, v.Required),
+		v.Field(&t.Nonce, v.Required),
+	)
+}
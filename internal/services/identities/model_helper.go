@@ -0,0 +1,69 @@
+package identities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeIdentityBuilder struct {
+	t        testing.TB
+	identity *Identity
+}
+
+func NewFakeIdentity(t testing.TB) *FakeIdentityBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+
+	return &FakeIdentityBuilder{
+		t: t,
+		identity: &Identity{
+			issuer:    "https://" + gofakeit.DomainName(),
+			subject:   string(uuidProvider.New()),
+			userID:    uuidProvider.New(),
+			createdAt: gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now()),
+		},
+	}
+}
+
+func (f *FakeIdentityBuilder) WithIssuer(issuer string) *FakeIdentityBuilder {
+	f.identity.issuer = issuer
+
+	return f
+}
+
+func (f *FakeIdentityBuilder) WithSubject(subject string) *FakeIdentityBuilder {
+	f.identity.subject = subject
+
+	return f
+}
+
+func (f *FakeIdentityBuilder) WithUser(user *users.User) *FakeIdentityBuilder {
+	f.identity.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeIdentityBuilder) Build() *Identity {
+	return f.identity
+}
+
+func (f *FakeIdentityBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Identity {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	identity := f.Build()
+
+	err := storage.Save(ctx, identity)
+	require.NoError(f.t, err)
+
+	return identity
+}
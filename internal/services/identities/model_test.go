@@ -0,0 +1,30 @@
+package identities
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Identity_Getters(t *testing.T) {
+	i := NewFakeIdentity(t).Build()
+
+	assert.Equal(t, i.issuer, i.Issuer())
+	assert.Equal(t, i.subject, i.Subject())
+	assert.Equal(t, i.userID, i.UserID())
+	assert.Equal(t, i.createdAt, i.CreatedAt())
+}
+
+func Test_FinishLoginCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(FinishLoginCmd))
+}
+
+func Test_FinishLoginCmd_Validate(t *testing.T) {
+	require.NoError(t, FinishLoginCmd{Code: "some-code", Verifier: secret.NewText("some-verifier"), Nonce: "some-nonce"}.Validate())
+	require.Error(t, FinishLoginCmd{Code: "", Verifier: secret.NewText("some-verifier"), Nonce: "some-nonce"}.Validate())
+	require.Error(t, FinishLoginCmd{Code: "some-code", Verifier: secret.NewText(""), Nonce: "some-nonce"}.Validate())
+	require.Error(t, FinishLoginCmd{Code: "some-code", Verifier: secret.NewText("some-verifier"), Nonce: ""}.Validate())
+}
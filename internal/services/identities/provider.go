@@ -0,0 +1,152 @@
+package identities
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+)
+
+var (
+	errMissingIDToken = errors.New("missing id_token")
+	errInvalidNonce   = errors.New("invalid nonce")
+)
+
+// oidcProvider is the [provider] configured with the discovery document of
+// the issuer. The discovery is done at the first use in order to not prevent
+// the server start when the provider is unavailable.
+type oidcProvider struct {
+	cfg         Config
+	groupsClaim string
+
+	lock     sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func newOIDCProvider(cfg Config) *oidcProvider {
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = DefaultGroupsClaim
+	}
+
+	return &oidcProvider{
+		cfg:         cfg,
+		groupsClaim: groupsClaim,
+	}
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, nonce string, verifier secret.Text) (string, error) {
+	cfg, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier.Raw())), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, verifier secret.Text, nonce string) (*Claims, error) {
+	cfg, idTokenVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(verifier.Raw()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange the code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errMissingIDToken
+	}
+
+	idToken, err := idTokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the id_token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, errInvalidNonce
+	}
+
+	var raw map[string]any
+	err = idToken.Claims(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the claims: %w", err)
+	}
+
+	return &Claims{
+		Subject:  idToken.Subject,
+		Username: usernameClaim(raw),
+		Groups:   stringsClaim(raw[p.groupsClaim]),
+	}, nil
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover the provider: %w", err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret.Raw(),
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+
+	return p.oauth2, p.verifier, nil
+}
+
+// usernameClaim returns the username wanted by the user, the local part of
+// its email or its name, the first one set.
+func usernameClaim(raw map[string]any) string {
+	if username, ok := raw["preferred_username"].(string); ok && username != "" {
+		return username
+	}
+
+	if email, ok := raw["email"].(string); ok && email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		return local
+	}
+
+	name, _ := raw["name"].(string)
+
+	return name
+}
+
+// stringsClaim accepts a claim given either as a list or as a single string.
+func stringsClaim(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case []any:
+		res := make([]string, 0, len(claim))
+		for _, v := range claim {
+			if s, ok := v.(string); ok {
+				res = append(res, s)
+			}
+		}
+
+		return res
+	default:
+		return []string{}
+	}
+}
@@ -0,0 +1,271 @@
+package identities
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+)
+
+const (
+	fakeProviderKeyID        = "fake-key"
+	fakeProviderClientID     = "onlyfun"
+	fakeProviderClientSecret = "some-client-secret"
+	fakeProviderRedirectURL  = "https://onlyfun.test/login/oidc/callback"
+)
+
+// FakeProvider is a local stand-in OpenID Connect provider for the tests. Its
+// authorization endpoint logs in the configured user without any form and
+// its token endpoint enforces PKCE with the S256 method.
+type FakeProvider struct {
+	t      testing.TB
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	lock     sync.Mutex
+	codes    map[string]fakeAuthorization
+	subject  string
+	username string
+	groups   []string
+}
+
+type fakeAuthorization struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	subject     string
+	username    string
+	groups      []string
+}
+
+func NewFakeProvider(t testing.TB) *FakeProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &FakeProvider{
+		t:        t,
+		key:      key,
+		codes:    map[string]fakeAuthorization{},
+		subject:  "some-subject",
+		username: "some-username",
+		groups:   []string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.serveDiscovery)
+	mux.HandleFunc("GET /authorize", p.serveAuthorize)
+	mux.HandleFunc("POST /token", p.serveToken)
+	mux.HandleFunc("GET /jwks", p.serveJWKS)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// WithUser sets the user logged in by the next authorizations.
+func (p *FakeProvider) WithUser(subject string, username string, groups ...string) *FakeProvider {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.subject = subject
+	p.username = username
+	p.groups = groups
+
+	return p
+}
+
+func (p *FakeProvider) Issuer() string {
+	return p.server.URL
+}
+
+// Config returns a configuration using this provider.
+func (p *FakeProvider) Config() Config {
+	return Config{
+		Issuer:       p.Issuer(),
+		ClientID:     fakeProviderClientID,
+		ClientSecret: secret.NewText(fakeProviderClientSecret),
+		RedirectURL:  fakeProviderRedirectURL,
+	}
+}
+
+// Authorize follows the authorization url like a browser and returns the
+// callback url where the provider redirects the user.
+func (p *FakeProvider) Authorize(authURL string) *url.URL {
+	p.t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	res, err := client.Get(authURL)
+	require.NoError(p.t, err)
+	defer res.Body.Close()
+	require.Equal(p.t, http.StatusFound, res.StatusCode)
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(p.t, err)
+
+	return callback
+}
+
+func (p *FakeProvider) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	p.writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{string(jose.RS256)},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *FakeProvider) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != fakeProviderClientID ||
+		query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomToken()
+
+	p.lock.Lock()
+	p.codes[code] = fakeAuthorization{
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURI: query.Get("redirect_uri"),
+		subject:     p.subject,
+		username:    p.username,
+		groups:      p.groups,
+	}
+	p.lock.Unlock()
+
+	callback, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	callback.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+func (p *FakeProvider) serveToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+
+	if clientID != fakeProviderClientID || clientSecret != fakeProviderClientSecret {
+		p.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.lock.Lock()
+	auth, ok := p.codes[r.FormValue("code")]
+	// A code is usable only once.
+	delete(p.codes, r.FormValue("code"))
+	p.lock.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+
+	if !ok ||
+		r.FormValue("grant_type") != "authorization_code" ||
+		r.FormValue("redirect_uri") != auth.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != auth.challenge {
+		p.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := p.sign(map[string]any{
+		"iss":                p.Issuer(),
+		"sub":                auth.subject,
+		"aud":                fakeProviderClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              auth.nonce,
+		"preferred_username": auth.username,
+		DefaultGroupsClaim:   auth.groups,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomToken(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *FakeProvider) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	p.writeJSON(w, http.StatusOK, jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{{
+			Key:       &p.key.PublicKey,
+			KeyID:     fakeProviderKeyID,
+			Algorithm: string(jose.RS256),
+			Use:       "sig",
+		}},
+	})
+}
+
+// sign is called from the server goroutines so it can't fail the test.
+func (p *FakeProvider) sign(claims map[string]any) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: p.key, KeyID: fakeProviderKeyID},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.CompactSerialize()
+}
+
+func (p *FakeProvider) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,135 @@
+package identities
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestOIDCProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// authorize runs the login on the stand-in provider and returns the
+	// authorization code.
+	authorize := func(t *testing.T, fake *FakeProvider, provider *oidcProvider, verifier secret.Text) string {
+		t.Helper()
+
+		authURL, err := provider.AuthCodeURL(ctx, "some-state", "some-nonce", verifier)
+		require.NoError(t, err)
+
+		callback := fake.Authorize(authURL)
+		assert.Equal(t, "some-state", callback.Query().Get("state"))
+
+		return callback.Query().Get("code")
+	}
+
+	t.Run("AuthCodeURL uses PKCE", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t)
+		provider := newOIDCProvider(fake.Config())
+
+		authURL, err := provider.AuthCodeURL(ctx, "some-state", "some-nonce", secret.NewText(oauth2.GenerateVerifier()))
+		require.NoError(t, err)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, fake.Issuer()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+		assert.NotEmpty(t, u.Query().Get("code_challenge"))
+		assert.Equal(t, "some-nonce", u.Query().Get("nonce"))
+		assert.Equal(t, "some-state", u.Query().Get("state"))
+	})
+
+	t.Run("Exchange success", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t).WithUser("some-subject", "jane.doe", "admins", "staff")
+		provider := newOIDCProvider(fake.Config())
+		verifier := secret.NewText(oauth2.GenerateVerifier())
+
+		code := authorize(t, fake, provider, verifier)
+
+		res, err := provider.Exchange(ctx, code, verifier, "some-nonce")
+		require.NoError(t, err)
+		assert.Equal(t, &Claims{
+			Subject:  "some-subject",
+			Username: "jane.doe",
+			Groups:   []string{"admins", "staff"},
+		}, res)
+	})
+
+	t.Run("Exchange with an invalid verifier", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t)
+		provider := newOIDCProvider(fake.Config())
+
+		code := authorize(t, fake, provider, secret.NewText(oauth2.GenerateVerifier()))
+
+		res, err := provider.Exchange(ctx, code, secret.NewText(oauth2.GenerateVerifier()), "some-nonce")
+		require.Error(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Exchange with an invalid nonce", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t)
+		provider := newOIDCProvider(fake.Config())
+		verifier := secret.NewText(oauth2.GenerateVerifier())
+
+		code := authorize(t, fake, provider, verifier)
+
+		res, err := provider.Exchange(ctx, code, verifier, "another-nonce")
+		require.ErrorIs(t, err, errInvalidNonce)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Exchange with a code already used", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t)
+		provider := newOIDCProvider(fake.Config())
+		verifier := secret.NewText(oauth2.GenerateVerifier())
+
+		code := authorize(t, fake, provider, verifier)
+
+		_, err := provider.Exchange(ctx, code, verifier, "some-nonce")
+		require.NoError(t, err)
+
+		res, err := provider.Exchange(ctx, code, verifier, "some-nonce")
+		require.Error(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Exchange with an unavailable provider", func(t *testing.T) {
+		t.Parallel()
+
+		provider := newOIDCProvider(Config{Issuer: "http://127.0.0.1:1", ClientID: "onlyfun"})
+
+		res, err := provider.Exchange(ctx, "some-code", secret.NewText("some-verifier"), "some-nonce")
+		require.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func Test_stringsClaim(t *testing.T) {
+	assert.Equal(t, []string{"admins"}, stringsClaim("admins"))
+	assert.Equal(t, []string{"admins", "staff"}, stringsClaim([]any{"admins", 42, "staff"}))
+	assert.Equal(t, []string{}, stringsClaim(nil))
+}
+
+func Test_usernameClaim(t *testing.T) {
+	assert.Equal(t, "jane", usernameClaim(map[string]any{"preferred_username": "jane", "email": "doe@example.com"}))
+	assert.Equal(t, "doe", usernameClaim(map[string]any{"email": "doe@example.com", "name": "Jane Doe"}))
+	assert.Equal(t, "Jane Doe", usernameClaim(map[string]any{"name": "Jane Doe"}))
+	assert.Equal(t, "", usernameClaim(map[string]any{}))
+}
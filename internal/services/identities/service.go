@@ -0,0 +1,274 @@
+package identities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const (
+	usernameMaxLength = 20
+
+	// maxUsernameAttempts is the number of suffixes tried when the username
+	// given by the provider is already taken.
+	maxUsernameAttempts = 100
+)
+
+var (
+	ErrDisabled            = fmt.Errorf("openid connect login disabled")
+	ErrInvalidLogin        = fmt.Errorf("invalid openid connect login")
+	ErrInactiveUser        = fmt.Errorf("inactive user")
+	ErrNoUsernameAvailable = fmt.Errorf("no username available")
+)
+
+type storage interface {
+	Save(ctx context.Context, identity *Identity) error
+	GetByIssuerAndSubject(ctx context.Context, issuer string, subject string) (*Identity, error)
+	DeleteAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// provider is the OpenID Connect provider, used with the authorization code
+// flow and PKCE.
+type provider interface {
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier secret.Text) (string, error)
+	// Exchange swaps the authorization code for an ID token and returns its
+	// claims once verified.
+	Exchange(ctx context.Context, code string, verifier secret.Text, nonce string) (*Claims, error)
+}
+
+type service struct {
+	storage     storage
+	provider    provider
+	users       users.Service
+	clock       clock.Clock
+	uuid        uuid.Service
+	issuer      string
+	defaultRole perms.Role
+	groupRoles  []GroupRole
+}
+
+func newService(cfg Config, tools tools.Tools, storage storage, provider provider, users users.Service) *service {
+	defaultRole := cfg.DefaultRole
+	if defaultRole == "" {
+		defaultRole = perms.DefaultUserRole
+	}
+
+	return &service{
+		storage:     storage,
+		provider:    provider,
+		users:       users,
+		clock:       tools.Clock(),
+		uuid:        tools.UUID(),
+		issuer:      cfg.Issuer,
+		defaultRole: defaultRole,
+		groupRoles:  cfg.GroupRoles,
+	}
+}
+
+func (s *service) IsEnabled() bool {
+	return s.provider != nil
+}
+
+// StartLogin generates the state, the nonce and the PKCE verifier of a new
+// login and returns the provider url where the user must be redirected.
+func (s *service) StartLogin(ctx context.Context) (*Authorization, error) {
+	if !s.IsEnabled() {
+		return nil, errs.NotFound(ErrDisabled)
+	}
+
+	state := string(s.uuid.New())
+	nonce := string(s.uuid.New())
+	verifier := secret.NewText(oauth2.GenerateVerifier())
+
+	url, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to build the authorization url: %w", err))
+	}
+
+	return &Authorization{
+		URL:      url,
+		State:    state,
+		Verifier: verifier,
+		Nonce:    nonce,
+	}, nil
+}
+
+// FinishLogin validates the authorization code given by the provider and
+// returns the linked user. The user is created at its first login.
+func (s *service) FinishLogin(ctx context.Context, cmd *FinishLoginCmd) (*users.User, error) {
+	if !s.IsEnabled() {
+		return nil, errs.NotFound(ErrDisabled)
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	claims, err := s.provider.Exchange(ctx, cmd.Code, cmd.Verifier, cmd.Nonce)
+	if err != nil {
+		return nil, errs.Unauthorized(fmt.Errorf("%w: %w", ErrInvalidLogin, err), "the login with your identity provider failed")
+	}
+
+	identity, err := s.storage.GetByIssuerAndSubject(ctx, s.issuer, claims.Subject)
+	if errors.Is(err, errNotFound) {
+		return s.createUser(ctx, claims)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByIssuerAndSubject: %w", err))
+	}
+
+	user, err := s.users.GetByID(ctx, identity.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	if user.Status() != users.Active {
+		return nil, errs.Unauthorized(ErrInactiveUser, "your account is not active")
+	}
+
+	return s.syncRole(ctx, user, claims)
+}
+
+func (s *service) createUser(ctx context.Context, claims *Claims) (*users.User, error) {
+	// A first login is a registration, the already linked users can still
+	// log in.
+	if s.users.RegistrationMode() == users.RegistrationClosed {
+		return nil, errs.Unauthorized(users.ErrRegistrationClosed, "registration is closed")
+	}
+
+	username, err := s.findUsername(ctx, claims.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.CreateExternal(ctx, &users.CreateExternalCmd{
+		Role:     ptr.To(s.roleFor(claims.Groups)),
+		Username: username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the user: %w", err)
+	}
+
+	err = s.storage.Save(ctx, &Identity{
+		issuer:    s.issuer,
+		subject:   claims.Subject,
+		userID:    user.ID(),
+		createdAt: s.clock.Now(),
+	})
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to save the identity: %w", err))
+	}
+
+	return user, nil
+}
+
+// syncRole gives to the user the role mapped to its groups. The provider is
+// the source of truth only when some groups are mapped. The last admin keeps
+// its role, the instance would be left without anyone to manage it.
+func (s *service) syncRole(ctx context.Context, user *users.User, claims *Claims) (*users.User, error) {
+	if len(s.groupRoles) == 0 {
+		return user, nil
+	}
+
+	role := s.roleFor(claims.Groups)
+	if user.Role() != nil && *user.Role() == role {
+		return user, nil
+	}
+
+	err := s.users.UpdateExternalRole(ctx, &users.UpdateExternalRoleCmd{UserID: user.ID(), Role: role})
+	if errors.Is(err, users.ErrLastAdmin) {
+		return user, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to UpdateExternalRole: %w", err)
+	}
+
+	user, err = s.users.GetByID(ctx, user.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *service) roleFor(groups []string) perms.Role {
+	for _, groupRole := range s.groupRoles {
+		if slices.Contains(groups, groupRole.Group) {
+			return groupRole.Role
+		}
+	}
+
+	return s.defaultRole
+}
+
+// findUsername returns the first free username based on the one given by
+// the provider. A numeric suffix is added when it is already taken.
+func (s *service) findUsername(ctx context.Context, wanted string) (string, error) {
+	base := sanitizeUsername(wanted)
+
+	for i := 1; i <= maxUsernameAttempts; i++ {
+		username := base
+		if i > 1 {
+			suffix := "-" + strconv.Itoa(i)
+			username = base[:min(len(base), usernameMaxLength-len(suffix))] + suffix
+		}
+
+		_, err := s.users.GetByUsername(ctx, username)
+		if errors.Is(err, errs.ErrNotFound) {
+			return username, nil
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("failed to GetByUsername: %w", err)
+		}
+	}
+
+	return "", errs.Internal(ErrNoUsernameAvailable)
+}
+
+// sanitizeUsername converts any value into a username matching
+// [users.UsernameRegexp].
+func sanitizeUsername(username string) string {
+	res := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			return r
+		default:
+			return '-'
+		}
+	}, username)
+
+	res = strings.Trim(res, "-")
+	res = strings.Trim(res[:min(len(res), usernameMaxLength)], "-")
+	if res == "" {
+		return FallbackUsername
+	}
+
+	return res
+}
+
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	err := s.storage.DeleteAllForUser(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteAllForUser: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,466 @@
+package identities
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentitiesService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cfg := Config{
+		Issuer:      "https://some.issuer",
+		DefaultRole: perms.DefaultUserRole,
+		GroupRoles: []GroupRole{
+			{Group: "admins", Role: perms.DefaultAdminRole},
+			{Group: "moderators", Role: perms.DefaultModeratorRole},
+		},
+	}
+
+	finishLoginCmd := &FinishLoginCmd{
+		Code:     "some-code",
+		Verifier: secret.NewText("some-verifier"),
+		Nonce:    "some-nonce",
+	}
+
+	t.Run("IsEnabled without provider", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, nil, usersMock)
+
+		assert.False(t, svc.IsEnabled())
+	})
+
+	t.Run("StartLogin success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Mocks
+		tools.UUIDMock.On("New").Return(uuid.UUID("6a4a6e1f-7d8b-4f0c-9a5e-1b2c3d4e5f60")).Once()
+		tools.UUIDMock.On("New").Return(uuid.UUID("0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f")).Once()
+		providerMock.On("AuthCodeURL", mock.Anything,
+			"6a4a6e1f-7d8b-4f0c-9a5e-1b2c3d4e5f60",
+			"0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f",
+			mock.Anything).Return("https://some.issuer/authorize?foo=bar", nil).Once()
+
+		// Run
+		res, err := svc.StartLogin(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, "https://some.issuer/authorize?foo=bar", res.URL)
+		assert.Equal(t, "6a4a6e1f-7d8b-4f0c-9a5e-1b2c3d4e5f60", res.State)
+		assert.Equal(t, "0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f", res.Nonce)
+		assert.NotEmpty(t, res.Verifier.Raw())
+	})
+
+	t.Run("StartLogin while disabled", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, nil, usersMock)
+
+		// Run
+		res, err := svc.StartLogin(ctx)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrDisabled)
+		assert.Nil(t, res)
+	})
+
+	t.Run("FinishLogin creates the user at the first login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).WithUsername("jane-doe").WithRole(ptr.To(perms.DefaultModeratorRole)).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane.doe", Groups: []string{"staff", "moderators"}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(nil, errNotFound).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		usersMock.On("GetByUsername", mock.Anything, "jane-doe").
+			Return(nil, errs.NotFound(errors.New("not found"))).Once()
+		usersMock.On("CreateExternal", mock.Anything, &users.CreateExternalCmd{
+			Role:     ptr.To(perms.DefaultModeratorRole),
+			Username: "jane-doe",
+		}).Return(user, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, &Identity{
+			issuer:    "https://some.issuer",
+			subject:   "some-subject",
+			userID:    user.ID(),
+			createdAt: now,
+		}).Return(nil).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("FinishLogin with a taken username adds a suffix", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		now := time.Now()
+		existing := users.NewFakeUser(t).WithUsername("jane").Build()
+		user := users.NewFakeUser(t).WithUsername("jane-2").WithRole(ptr.To(perms.DefaultUserRole)).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(nil, errNotFound).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		usersMock.On("GetByUsername", mock.Anything, "jane").Return(existing, nil).Once()
+		usersMock.On("GetByUsername", mock.Anything, "jane-2").
+			Return(nil, errs.NotFound(errors.New("not found"))).Once()
+		usersMock.On("CreateExternal", mock.Anything, &users.CreateExternalCmd{
+			Role:     ptr.To(perms.DefaultUserRole),
+			Username: "jane-2",
+		}).Return(user, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("FinishLogin with a linked user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(Config{Issuer: "https://some.issuer"}, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		identity := NewFakeIdentity(t).WithIssuer("https://some.issuer").WithSubject("some-subject").WithUser(user).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(identity, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("FinishLogin synchronizes the role with the groups", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		updatedUser := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultUserRole)).Build()
+		identity := NewFakeIdentity(t).WithIssuer("https://some.issuer").WithSubject("some-subject").WithUser(user).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{"staff"}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(identity, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		usersMock.On("UpdateExternalRole", mock.Anything, &users.UpdateExternalRoleCmd{
+			UserID: user.ID(),
+			Role:   perms.DefaultUserRole,
+		}).Return(nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(updatedUser, nil).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, updatedUser, res)
+	})
+
+	t.Run("FinishLogin with the last admin keeps its role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		identity := NewFakeIdentity(t).WithIssuer("https://some.issuer").WithSubject("some-subject").WithUser(user).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{"staff"}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(identity, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		usersMock.On("UpdateExternalRole", mock.Anything, &users.UpdateExternalRoleCmd{
+			UserID: user.ID(),
+			Role:   perms.DefaultUserRole,
+		}).Return(errs.Unauthorized(users.ErrLastAdmin, "the last admin can't lose its role")).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("FinishLogin of a new user with the registration closed", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(nil, errNotFound).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, users.ErrRegistrationClosed)
+		assert.Nil(t, res)
+	})
+
+	t.Run("FinishLogin with an inactive user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+		identity := NewFakeIdentity(t).WithIssuer("https://some.issuer").WithSubject("some-subject").WithUser(user).Build()
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(&Claims{Subject: "some-subject", Username: "jane", Groups: []string{}}, nil).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, "https://some.issuer", "some-subject").
+			Return(identity, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrInactiveUser)
+		assert.Nil(t, res)
+	})
+
+	t.Run("FinishLogin with an exchange error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		providerMock := newMockProvider(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, providerMock, usersMock)
+
+		// Mocks
+		providerMock.On("Exchange", mock.Anything, "some-code", secret.NewText("some-verifier"), "some-nonce").
+			Return(nil, errInvalidNonce).Once()
+
+		// Run
+		res, err := svc.FinishLogin(ctx, finishLoginCmd)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrInvalidLogin)
+		require.ErrorIs(t, err, errInvalidNonce)
+		assert.Nil(t, res)
+	})
+
+	t.Run("FinishLogin with the stand-in provider", func(t *testing.T) {
+		t.Parallel()
+
+		fake := NewFakeProvider(t).WithUser("some-subject", "jane", "admins")
+		cfg := fake.Config()
+		cfg.GroupRoles = []GroupRole{{Group: "admins", Role: perms.DefaultAdminRole}}
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, newOIDCProvider(cfg), usersMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).WithUsername("jane").WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Mocks
+		tools.UUIDMock.On("New").Return(uuid.UUID("6a4a6e1f-7d8b-4f0c-9a5e-1b2c3d4e5f60")).Once()
+		tools.UUIDMock.On("New").Return(uuid.UUID("0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f")).Once()
+		storageMock.On("GetByIssuerAndSubject", mock.Anything, fake.Issuer(), "some-subject").
+			Return(nil, errNotFound).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		usersMock.On("GetByUsername", mock.Anything, "jane").
+			Return(nil, errs.NotFound(errors.New("not found"))).Once()
+		usersMock.On("CreateExternal", mock.Anything, &users.CreateExternalCmd{
+			Role:     ptr.To(perms.DefaultAdminRole),
+			Username: "jane",
+		}).Return(user, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, &Identity{
+			issuer:    fake.Issuer(),
+			subject:   "some-subject",
+			userID:    user.ID(),
+			createdAt: now,
+		}).Return(nil).Once()
+
+		// Run
+		authorization, err := svc.StartLogin(ctx)
+		require.NoError(t, err)
+
+		callback := fake.Authorize(authorization.URL)
+		require.Equal(t, authorization.State, callback.Query().Get("state"))
+
+		res, err := svc.FinishLogin(ctx, &FinishLoginCmd{
+			Code:     callback.Query().Get("code"),
+			Verifier: authorization.Verifier,
+			Nonce:    authorization.Nonce,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, nil, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteAll with a storage error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, nil, usersMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID()).Return(assert.AnError).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_sanitizeUsername(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"jane", "jane"},
+		{"jane.doe", "jane-doe"},
+		{"Jane Doe", "Jane-Doe"},
+		{"_jane_", "jane"},
+		{"élodie", "lodie"},
+		{"a-very-long-username-from-the-provider", "a-very-long-username"},
+		{"a-very-long-usernam-", "a-very-long-usernam"},
+		{"...", FallbackUsername},
+		{"", FallbackUsername},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.expected, sanitizeUsername(test.input))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "identities"
+
+var errNotFound = errors.New("not found")
+
+var allFields = []string{"issuer", "subject", "user_id", "created_at"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) Save(ctx context.Context, identity *Identity) error {
+	_, err := sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(
+			identity.issuer,
+			identity.subject,
+			identity.userID,
+			ptr.To(sqlstorage.SQLTime(identity.createdAt))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetByIssuerAndSubject(ctx context.Context, issuer string, subject string) (*Identity, error) {
+	var res Identity
+	var sqlCreatedAt sqlstorage.SQLTime
+
+	err := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"issuer": issuer, "subject": subject}).
+		RunWith(s.db).
+		ScanContext(ctx,
+			&res.issuer,
+			&res.subject,
+			&res.userID,
+			&sqlCreatedAt,
+		)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+
+	res.createdAt = sqlCreatedAt.Time()
+
+	return &res, nil
+}
+
+func (s *sqlStorage) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package identities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentitiesSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, db sqlstorage.Querier) *users.User {
+		t.Helper()
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+
+		return users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+	}
+
+	t.Run("Save and GetByIssuerAndSubject success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+
+		identity := NewFakeIdentity(t).WithUser(user).Build()
+		identity.createdAt = time.Now().UTC().Round(time.Millisecond)
+
+		err := store.Save(ctx, identity)
+		require.NoError(t, err)
+
+		res, err := store.GetByIssuerAndSubject(ctx, identity.issuer, identity.subject)
+		require.NoError(t, err)
+		require.Equal(t, identity, res)
+	})
+
+	t.Run("GetByIssuerAndSubject with another issuer", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		identity := NewFakeIdentity(t).WithUser(user).WithIssuer("https://some.issuer").BuildAndStore(ctx, db)
+
+		res, err := store.GetByIssuerAndSubject(ctx, "https://another.issuer", identity.subject)
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("Save the same subject twice fails", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		identity := NewFakeIdentity(t).WithUser(user).BuildAndStore(ctx, db)
+
+		err := store.Save(ctx, NewFakeIdentity(t).
+			WithUser(user).
+			WithIssuer(identity.issuer).
+			WithSubject(identity.subject).
+			Build())
+		require.Error(t, err)
+	})
+
+	t.Run("DeleteAllForUser success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		identity := NewFakeIdentity(t).WithUser(user).BuildAndStore(ctx, db)
+
+		err := store.DeleteAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+
+		res, err := store.GetByIssuerAndSubject(ctx, identity.issuer, identity.subject)
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+}
@@ -0,0 +1,44 @@
+package invitations
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+// Config of the invitations service.
+type Config struct {
+	// Quotas overrides the [DefaultQuotas] when set. The roles are matched by
+	// name, a renamed role must be renamed here too.
+	Quotas map[perms.Role]int
+}
+
+type Service interface {
+	Create(ctx context.Context, cmd *CreateCmd) (*Invitation, error)
+	GetByCode(ctx context.Context, code string) (*Invitation, error)
+	GetAll(ctx context.Context) ([]Invitation, error)
+	GetAllCreatedBy(ctx context.Context, user *users.User) ([]Invitation, error)
+	RemainingQuota(ctx context.Context, user *users.User) (int, error)
+	Revoke(ctx context.Context, cmd *RevokeCmd) error
+	Redeem(ctx context.Context, cmd *RedeemCmd) (*users.User, error)
+	// DeleteAll removes all the invitations created by the user. The
+	// accounts already created with them are kept.
+	DeleteAll(ctx context.Context, user *users.User) error
+}
+
+func Init(
+	cfg Config,
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	usersSvc users.Service,
+	permsSvc perms.Service,
+	bansSvc bans.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(cfg, tools, storage, usersSvc, permsSvc, bansSvc)
+}
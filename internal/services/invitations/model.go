@@ -0,0 +1,92 @@
+package invitations
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	MaxUses     = 100
+	MinLifetime = time.Hour
+	MaxLifetime = 90 * 24 * time.Hour
+)
+
+// Unlimited is the quota of the roles allowed to create as many invitations
+// as they want.
+const Unlimited = -1
+
+// DefaultQuotas is the number of usable invitations a user can have at the
+// same time, by role. The roles not listed can't create any invitation.
+var DefaultQuotas = map[perms.Role]int{
+	perms.DefaultAdminRole:     Unlimited,
+	perms.DefaultModeratorRole: 10,
+	perms.DefaultUserRole:      3,
+}
+
+// Invitation is a code letting someone create an account, whatever the
+// registration mode. A code can be used [Invitation.MaxUses] times before
+// its expiration.
+type Invitation struct {
+	code      string
+	maxUses   int
+	uses      int
+	expiresAt time.Time
+	createdAt time.Time
+	createdBy uuid.UUID
+}
+
+func (i Invitation) Code() string         { return i.code }
+func (i Invitation) MaxUses() int         { return i.maxUses }
+func (i Invitation) Uses() int            { return i.uses }
+func (i Invitation) ExpiresAt() time.Time { return i.expiresAt }
+func (i Invitation) CreatedAt() time.Time { return i.createdAt }
+func (i Invitation) CreatedBy() uuid.UUID { return i.createdBy }
+
+// IsUsable returns true if the invitation can still be redeemed at the given time.
+func (i Invitation) IsUsable(now time.Time) bool {
+	return i.uses < i.maxUses && now.Before(i.expiresAt)
+}
+
+type CreateCmd struct {
+	CreatedBy *users.User
+	MaxUses   int
+	Lifetime  time.Duration
+}
+
+func (t CreateCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.CreatedBy, v.Required),
+		v.Field(&t.MaxUses, v.Required, v.Min(1), v.Max(MaxUses)),
+		v.Field(&t.Lifetime, v.Required, v.Min(MinLifetime), v.Max(MaxLifetime)),
+	)
+}
+
+type RevokeCmd struct {
+	User       *users.User
+	Invitation *Invitation
+}
+
+func (t RevokeCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Invitation, v.Required),
+	)
+}
+
+// RedeemCmd represents the creation of an account with an invitation code.
+type RedeemCmd struct {
+	Code     string
+	Username string
+	Password secret.Text
+}
+
+func (t RedeemCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Code, v.Required),
+	)
+}
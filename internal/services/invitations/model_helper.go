@@ -0,0 +1,73 @@
+package invitations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeInvitationBuilder struct {
+	t          testing.TB
+	invitation *Invitation
+}
+
+func NewFakeInvitation(t testing.TB) *FakeInvitationBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now())
+
+	return &FakeInvitationBuilder{
+		t: t,
+		invitation: &Invitation{
+			code:      string(uuidProvider.New()),
+			maxUses:   1,
+			uses:      0,
+			expiresAt: createdAt.Add(7 * 24 * time.Hour),
+			createdAt: createdAt,
+			createdBy: uuidProvider.New(),
+		},
+	}
+}
+
+func (f *FakeInvitationBuilder) CreatedBy(user *users.User) *FakeInvitationBuilder {
+	f.invitation.createdBy = user.ID()
+
+	return f
+}
+
+func (f *FakeInvitationBuilder) WithUses(uses int, maxUses int) *FakeInvitationBuilder {
+	f.invitation.uses = uses
+	f.invitation.maxUses = maxUses
+
+	return f
+}
+
+func (f *FakeInvitationBuilder) ExpiresAt(expiresAt time.Time) *FakeInvitationBuilder {
+	f.invitation.expiresAt = expiresAt
+
+	return f
+}
+
+func (f *FakeInvitationBuilder) Build() *Invitation {
+	return f.invitation
+}
+
+func (f *FakeInvitationBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Invitation {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	invitation := f.Build()
+
+	err := storage.Save(ctx, invitation)
+	require.NoError(f.t, err)
+
+	return invitation
+}
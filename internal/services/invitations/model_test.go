@@ -0,0 +1,51 @@
+package invitations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Invitation_Getters(t *testing.T) {
+	i := NewFakeInvitation(t).Build()
+
+	assert.Equal(t, i.code, i.Code())
+	assert.Equal(t, i.maxUses, i.MaxUses())
+	assert.Equal(t, i.uses, i.Uses())
+	assert.Equal(t, i.expiresAt, i.ExpiresAt())
+	assert.Equal(t, i.createdAt, i.CreatedAt())
+	assert.Equal(t, i.createdBy, i.CreatedBy())
+}
+
+func Test_Invitation_IsUsable(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, NewFakeInvitation(t).WithUses(1, 2).ExpiresAt(now.Add(time.Hour)).Build().IsUsable(now))
+	assert.False(t, NewFakeInvitation(t).WithUses(2, 2).ExpiresAt(now.Add(time.Hour)).Build().IsUsable(now))
+	assert.False(t, NewFakeInvitation(t).WithUses(0, 2).ExpiresAt(now.Add(-time.Hour)).Build().IsUsable(now))
+}
+
+func Test_CreateCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(CreateCmd))
+}
+
+func Test_CreateCmd_Validate(t *testing.T) {
+	user := users.NewFakeUser(t).Build()
+
+	require.NoError(t, CreateCmd{CreatedBy: user, MaxUses: 1, Lifetime: 24 * time.Hour}.Validate())
+	require.Error(t, CreateCmd{CreatedBy: user, MaxUses: MaxUses + 1, Lifetime: 24 * time.Hour}.Validate())
+	require.Error(t, CreateCmd{CreatedBy: user, MaxUses: 1, Lifetime: time.Minute}.Validate())
+	require.Error(t, CreateCmd{CreatedBy: user, MaxUses: 1, Lifetime: MaxLifetime + time.Hour}.Validate())
+}
+
+func Test_RevokeCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(RevokeCmd))
+}
+
+func Test_RedeemCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(RedeemCmd))
+}
@@ -0,0 +1,254 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("invitation quota exceeded")
+	ErrInvalidCode   = errors.New("invalid invitation code")
+)
+
+type storage interface {
+	Save(ctx context.Context, invitation *Invitation) error
+	GetByCode(ctx context.Context, code string) (*Invitation, error)
+	GetAll(ctx context.Context) ([]Invitation, error)
+	GetAllCreatedBy(ctx context.Context, userID uuid.UUID) ([]Invitation, error)
+	IncrementUses(ctx context.Context, code string, now time.Time) error
+	DecrementUses(ctx context.Context, code string) error
+	Delete(ctx context.Context, code string) error
+	DeleteAllCreatedBy(ctx context.Context, userID uuid.UUID) error
+}
+
+type service struct {
+	storage  storage
+	usersSvc users.Service
+	permsSvc perms.Service
+	bansSvc  bans.Service
+	clock    clock.Clock
+	uuid     uuid.Service
+	quotas   map[perms.Role]int
+}
+
+func newService(cfg Config, tools tools.Tools, storage storage, usersSvc users.Service, permsSvc perms.Service, bansSvc bans.Service) *service {
+	quotas := cfg.Quotas
+	if quotas == nil {
+		quotas = DefaultQuotas
+	}
+
+	return &service{
+		storage:  storage,
+		usersSvc: usersSvc,
+		permsSvc: permsSvc,
+		bansSvc:  bansSvc,
+		clock:    tools.Clock(),
+		uuid:     tools.UUID(),
+		quotas:   quotas,
+	}
+}
+
+// Create generates a new invitation code if the user didn't reach the quota
+// of its role.
+func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Invitation, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	remaining, err := s.RemainingQuota(ctx, cmd.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the remaining quota: %w", err)
+	}
+
+	if remaining == 0 {
+		return nil, errs.BadRequest(ErrQuotaExceeded, "you can't create more invitations")
+	}
+
+	now := s.clock.Now()
+
+	invitation := Invitation{
+		code:      string(s.uuid.New()),
+		maxUses:   cmd.MaxUses,
+		uses:      0,
+		expiresAt: now.Add(cmd.Lifetime),
+		createdAt: now,
+		createdBy: cmd.CreatedBy.ID(),
+	}
+
+	err = s.storage.Save(ctx, &invitation)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Save the invitation: %w", err))
+	}
+
+	return &invitation, nil
+}
+
+func (s *service) GetByCode(ctx context.Context, code string) (*Invitation, error) {
+	res, err := s.storage.GetByCode(ctx, code)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(err)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByCode: %w", err))
+	}
+
+	return res, nil
+}
+
+// GetAll returns all the invitations, the most recent first.
+func (s *service) GetAll(ctx context.Context) ([]Invitation, error) {
+	res, err := s.storage.GetAll(ctx)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetAll: %w", err))
+	}
+
+	return res, nil
+}
+
+// GetAllCreatedBy returns all the invitations created by the user, the most
+// recent first.
+func (s *service) GetAllCreatedBy(ctx context.Context, user *users.User) ([]Invitation, error) {
+	res, err := s.storage.GetAllCreatedBy(ctx, user.ID())
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetAllCreatedBy: %w", err))
+	}
+
+	return res, nil
+}
+
+// RemainingQuota returns the number of invitations the user can still create
+// or [Unlimited]. Only the usable invitations count against the quota.
+func (s *service) RemainingQuota(ctx context.Context, user *users.User) (int, error) {
+	role := user.Role()
+	if role == nil {
+		return 0, nil
+	}
+
+	quota := s.quotas[*role]
+	switch {
+	case quota == Unlimited:
+		return Unlimited, nil
+	case quota <= 0:
+		return 0, nil
+	}
+
+	invitations, err := s.storage.GetAllCreatedBy(ctx, user.ID())
+	if err != nil {
+		return 0, errs.Internal(fmt.Errorf("failed to GetAllCreatedBy: %w", err))
+	}
+
+	now := s.clock.Now()
+	for _, invitation := range invitations {
+		if invitation.IsUsable(now) {
+			quota--
+		}
+	}
+
+	return max(quota, 0), nil
+}
+
+// Revoke deletes the invitation. Only its creator and the users allowed to
+// manage the users can revoke an invitation.
+func (s *service) Revoke(ctx context.Context, cmd *RevokeCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if cmd.Invitation.createdBy != cmd.User.ID() && !s.permsSvc.IsAuthorized(cmd.User, perms.ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("user %q can't revoke the invitations of %q", cmd.User.ID(), cmd.Invitation.createdBy))
+	}
+
+	err = s.storage.Delete(ctx, cmd.Invitation.code)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Delete the invitation: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	err := s.storage.DeleteAllCreatedBy(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteAllCreatedBy: %w", err))
+	}
+
+	return nil
+}
+
+// Redeem consumes a use of the invitation and registers a new account
+// created by the inviter. The use is given back if the registration fails.
+func (s *service) Redeem(ctx context.Context, cmd *RedeemCmd) (*users.User, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	invitation, err := s.storage.GetByCode(ctx, cmd.Code)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.BadRequest(ErrInvalidCode, "invalid or expired invitation code")
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByCode: %w", err))
+	}
+
+	inviter, err := s.usersSvc.GetByID(ctx, invitation.createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the inviter: %w", err)
+	}
+
+	// The codes of a banned, pending or deleted inviter can't be used
+	// anymore.
+	if inviter.Status() != users.Active {
+		return nil, errs.BadRequest(ErrInvalidCode, "invalid or expired invitation code")
+	}
+
+	err = s.bansSvc.EnsureNotBanned(ctx, inviter.ID())
+	if errors.Is(err, bans.ErrBanned) {
+		return nil, errs.BadRequest(ErrInvalidCode, "invalid or expired invitation code")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to EnsureNotBanned: %w", err)
+	}
+
+	// The increment checks the invitation state in the same statement so two
+	// concurrent registrations can't consume the same last use.
+	err = s.storage.IncrementUses(ctx, invitation.code, s.clock.Now())
+	if errors.Is(err, errNotFound) {
+		return nil, errs.BadRequest(ErrInvalidCode, "invalid or expired invitation code")
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to IncrementUses: %w", err))
+	}
+
+	user, err := s.usersSvc.Register(ctx, &users.RegisterCmd{
+		InvitedBy: inviter,
+		Username:  cmd.Username,
+		Password:  cmd.Password,
+	})
+	if err != nil {
+		releaseErr := s.storage.DecrementUses(ctx, invitation.code)
+		if releaseErr != nil {
+			return nil, errs.Internal(fmt.Errorf("failed to DecrementUses after %w: %w", err, releaseErr))
+		}
+
+		return nil, fmt.Errorf("failed to Register: %w", err)
+	}
+
+	return user, nil
+}
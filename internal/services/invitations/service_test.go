@@ -0,0 +1,528 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationsService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Create success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultUserRole)).Build()
+		expired := NewFakeInvitation(t).CreatedBy(user).ExpiresAt(now.Add(-time.Hour)).Build()
+		used := NewFakeInvitation(t).CreatedBy(user).WithUses(1, 1).ExpiresAt(now.Add(time.Hour)).Build()
+		usable := NewFakeInvitation(t).CreatedBy(user).ExpiresAt(now.Add(time.Hour)).Build()
+		code := uuid.UUID("e8fa8cd3-2a1d-4a5c-8d46-4a5a4b6e3c49")
+
+		// Mocks
+		storageMock.On("GetAllCreatedBy", mock.Anything, user.ID()).
+			Return([]Invitation{*expired, *used, *usable}, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Twice()
+		tools.UUIDMock.On("New").Return(code).Once()
+		storageMock.On("Save", mock.Anything, &Invitation{
+			code:      string(code),
+			maxUses:   5,
+			uses:      0,
+			expiresAt: now.Add(48 * time.Hour),
+			createdAt: now,
+			createdBy: user.ID(),
+		}).Return(nil).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{
+			CreatedBy: user,
+			MaxUses:   5,
+			Lifetime:  48 * time.Hour,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, string(code), res.Code())
+	})
+
+	t.Run("Create with the quota exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{Quotas: map[perms.Role]int{perms.DefaultUserRole: 1}}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultUserRole)).Build()
+		usable := NewFakeInvitation(t).CreatedBy(user).ExpiresAt(now.Add(time.Hour)).Build()
+
+		// Mocks
+		storageMock.On("GetAllCreatedBy", mock.Anything, user.ID()).Return([]Invitation{*usable}, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{
+			CreatedBy: user,
+			MaxUses:   1,
+			Lifetime:  48 * time.Hour,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Create with a role without quota", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.Role("some-unknown-role"))).Build()
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{
+			CreatedBy: user,
+			MaxUses:   1,
+			Lifetime:  48 * time.Hour,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Create with a validation error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Run
+		res, err := svc.Create(ctx, &CreateCmd{
+			CreatedBy: users.NewFakeUser(t).Build(),
+			MaxUses:   0,
+			Lifetime:  48 * time.Hour,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+		assert.Nil(t, res)
+	})
+
+	t.Run("RemainingQuota with an unlimited role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		admin := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Run
+		res, err := svc.RemainingQuota(ctx, admin)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, Unlimited, res)
+	})
+
+	t.Run("GetByCode not found", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, "some-code").Return(nil, errNotFound).Once()
+
+		// Run
+		res, err := svc.GetByCode(ctx, "some-code")
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("DeleteAllCreatedBy", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Revoke by the creator", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(user).Build()
+
+		// Mocks
+		storageMock.On("Delete", mock.Anything, invitation.Code()).Return(nil).Once()
+
+		// Run
+		err := svc.Revoke(ctx, &RevokeCmd{User: user, Invitation: invitation})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Revoke by an admin", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		admin := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storageMock.On("Delete", mock.Anything, invitation.Code()).Return(nil).Once()
+
+		// Run
+		err := svc.Revoke(ctx, &RevokeCmd{User: admin, Invitation: invitation})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Revoke by someone else", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		err := svc.Revoke(ctx, &RevokeCmd{User: user, Invitation: invitation})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("Redeem success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		inviter := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+		newUser := users.NewFakeUser(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, inviter.ID()).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("IncrementUses", mock.Anything, invitation.Code(), now).Return(nil).Once()
+		usersMock.On("Register", mock.Anything, &users.RegisterCmd{
+			InvitedBy: inviter,
+			Username:  newUser.Username(),
+			Password:  secret.NewText("some-password"),
+		}).Return(newUser, nil).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: newUser.Username(),
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, newUser, res)
+	})
+
+	t.Run("Redeem with an unknown code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, "some-code").Return(nil, errNotFound).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     "some-code",
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidCode)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Redeem with an exhausted or expired code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		inviter := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, inviter.ID()).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("IncrementUses", mock.Anything, invitation.Code(), now).Return(errNotFound).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidCode)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Redeem with a code of a banned inviter", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		inviter := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, inviter.ID()).
+			Return(errs.Unauthorized(bans.ErrBanned, "you are banned")).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidCode)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Redeem with a code of a deleted inviter", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		inviter := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidCode)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Redeem gives the use back if the registration fails", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		inviter := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, inviter.ID()).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("IncrementUses", mock.Anything, invitation.Code(), now).Return(nil).Once()
+		usersMock.On("Register", mock.Anything, &users.RegisterCmd{
+			InvitedBy: inviter,
+			Username:  "some-username",
+			Password:  secret.NewText("some-password"),
+		}).Return(nil, errs.BadRequest(users.ErrUsernameTaken)).Once()
+		storageMock.On("DecrementUses", mock.Anything, invitation.Code()).Return(nil).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, users.ErrUsernameTaken)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Redeem with a DecrementUses error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		svc := newService(Config{}, tools, storageMock, usersMock, permsMock, bansMock)
+
+		// Data
+		now := time.Now()
+		inviter := users.NewFakeUser(t).Build()
+		invitation := NewFakeInvitation(t).CreatedBy(inviter).Build()
+
+		// Mocks
+		storageMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		usersMock.On("GetByID", mock.Anything, inviter.ID()).Return(inviter, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, inviter.ID()).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("IncrementUses", mock.Anything, invitation.Code(), now).Return(nil).Once()
+		usersMock.On("Register", mock.Anything, mock.Anything).Return(nil, errs.BadRequest(users.ErrUsernameTaken)).Once()
+		storageMock.On("DecrementUses", mock.Anything, invitation.Code()).Return(errors.New("some-error")).Once()
+
+		// Run
+		res, err := svc.Redeem(ctx, &RedeemCmd{
+			Code:     invitation.Code(),
+			Username: "some-username",
+			Password: secret.NewText("some-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		assert.Nil(t, res)
+	})
+}
@@ -0,0 +1,202 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "invitations"
+
+var errNotFound = errors.New("not found")
+
+var allFields = []string{"code", "max_uses", "uses", "expires_at", "created_at", "created_by"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) Save(ctx context.Context, invitation *Invitation) error {
+	_, err := sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(
+			invitation.code,
+			invitation.maxUses,
+			invitation.uses,
+			ptr.To(sqlstorage.SQLTime(invitation.expiresAt)),
+			ptr.To(sqlstorage.SQLTime(invitation.createdAt)),
+			invitation.createdBy).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetByCode(ctx context.Context, code string) (*Invitation, error) {
+	row := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"code": code}).
+		RunWith(s.db).
+		QueryRowContext(ctx)
+
+	return s.scanRow(row)
+}
+
+// GetAll returns all the invitations, the most recent first.
+func (s *sqlStorage) GetAll(ctx context.Context) ([]Invitation, error) {
+	return s.getAll(ctx, nil)
+}
+
+// GetAllCreatedBy returns all the invitations created by the given user,
+// the most recent first.
+func (s *sqlStorage) GetAllCreatedBy(ctx context.Context, userID uuid.UUID) ([]Invitation, error) {
+	return s.getAll(ctx, sq.Eq{"created_by": userID})
+}
+
+// IncrementUses consumes one use of the invitation if it is still usable at
+// the given time. It returns errNotFound otherwise.
+func (s *sqlStorage) IncrementUses(ctx context.Context, code string, now time.Time) error {
+	res, err := sq.
+		Update(tableName).
+		Set("uses", sq.Expr("uses + 1")).
+		Where(sq.Eq{"code": code}).
+		Where(sq.Expr("uses < max_uses")).
+		Where(sq.Expr("julianday(expires_at) > julianday(?)", ptr.To(sqlstorage.SQLTime(now)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the affected rows: %w", err)
+	}
+
+	if updated == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
+
+// DecrementUses gives back a use consumed with IncrementUses.
+func (s *sqlStorage) DecrementUses(ctx context.Context, code string) error {
+	_, err := sq.
+		Update(tableName).
+		Set("uses", sq.Expr("uses - 1")).
+		Where(sq.Eq{"code": code}).
+		Where(sq.Gt{"uses": 0}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) Delete(ctx context.Context, code string) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"code": code}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteAllCreatedBy(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"created_by": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) getAll(ctx context.Context, where any) ([]Invitation, error) {
+	query := sq.
+		Select(allFields...).
+		From(tableName).
+		OrderBy("created_at DESC")
+
+	if where != nil {
+		query = query.Where(where)
+	}
+
+	rows, err := query.
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Invitation{}
+
+	for rows.Next() {
+		invitation, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *invitation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) scanRow(row sq.RowScanner) (*Invitation, error) {
+	var res Invitation
+	var sqlExpiresAt sqlstorage.SQLTime
+	var sqlCreatedAt sqlstorage.SQLTime
+
+	err := row.Scan(
+		&res.code,
+		&res.maxUses,
+		&res.uses,
+		&sqlExpiresAt,
+		&sqlCreatedAt,
+		&res.createdBy,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	res.expiresAt = sqlExpiresAt.Time()
+	res.createdAt = sqlCreatedAt.Time()
+
+	return &res, nil
+}
@@ -0,0 +1,154 @@
+package invitations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, db sqlstorage.Querier) *users.User {
+		t.Helper()
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+
+		return users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+	}
+
+	t.Run("Save and GetByCode success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+
+		invitation := NewFakeInvitation(t).CreatedBy(user).WithUses(1, 5).Build()
+		invitation.createdAt = time.Now().UTC().Round(time.Millisecond)
+		invitation.expiresAt = invitation.createdAt.Add(time.Hour)
+
+		err := store.Save(ctx, invitation)
+		require.NoError(t, err)
+
+		res, err := store.GetByCode(ctx, invitation.code)
+		require.NoError(t, err)
+		require.Equal(t, invitation, res)
+	})
+
+	t.Run("GetByCode not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetByCode(ctx, "unknown")
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("GetAll and GetAllCreatedBy", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		other := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		older := NewFakeInvitation(t).CreatedBy(user).Build()
+		older.createdAt = now.Add(-time.Hour)
+		older.expiresAt = now.Add(time.Hour)
+		newer := NewFakeInvitation(t).CreatedBy(other).Build()
+		newer.createdAt = now
+		newer.expiresAt = now.Add(time.Hour)
+
+		require.NoError(t, store.Save(ctx, older))
+		require.NoError(t, store.Save(ctx, newer))
+
+		res, err := store.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []Invitation{*newer, *older}, res)
+
+		res, err = store.GetAllCreatedBy(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, []Invitation{*older}, res)
+	})
+
+	t.Run("IncrementUses and DecrementUses", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		now := time.Now()
+		invitation := NewFakeInvitation(t).CreatedBy(user).WithUses(0, 1).ExpiresAt(now.Add(time.Hour)).BuildAndStore(ctx, db)
+
+		err := store.IncrementUses(ctx, invitation.code, now)
+		require.NoError(t, err)
+
+		// All the uses are consumed.
+		err = store.IncrementUses(ctx, invitation.code, now)
+		require.ErrorIs(t, err, errNotFound)
+
+		err = store.DecrementUses(ctx, invitation.code)
+		require.NoError(t, err)
+
+		res, err := store.GetByCode(ctx, invitation.code)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Uses())
+
+		// Expired
+		err = store.IncrementUses(ctx, invitation.code, now.Add(2*time.Hour))
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		invitation := NewFakeInvitation(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		err := store.Delete(ctx, invitation.code)
+		require.NoError(t, err)
+
+		res, err := store.GetByCode(ctx, invitation.code)
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("DeleteAllCreatedBy success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		NewFakeInvitation(t).CreatedBy(user).BuildAndStore(ctx, db)
+		NewFakeInvitation(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		err := store.DeleteAllCreatedBy(ctx, user.ID())
+		require.NoError(t, err)
+
+		res, err := store.GetAllCreatedBy(ctx, user.ID())
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+}
@@ -0,0 +1,55 @@
+package loginattempts
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+const (
+	DefaultMaxAttempts     = 10
+	DefaultLockoutDuration = 30 * time.Minute
+	DefaultPurgeInterval   = time.Hour
+)
+
+// Config of the login attempts tracking.
+type Config struct {
+	// MaxAttempts is the number of consecutive failures locking an account.
+	// [DefaultMaxAttempts] is used when not set.
+	MaxAttempts int
+	// LockoutDuration is the time an account stays locked.
+	// [DefaultLockoutDuration] is used when not set.
+	LockoutDuration time.Duration
+	// PurgeInterval is the delay between two removals of the outdated
+	// counters and audit entries.
+	PurgeInterval time.Duration
+}
+
+type Service interface {
+	// Reserve refuses the attempt when it comes too early after the previous
+	// failures or when the account is locked. Each reserved attempt must end
+	// with RegisterFailure, RegisterSuccess or Release.
+	Reserve(ctx context.Context, cmd *AttemptCmd) error
+	RegisterFailure(ctx context.Context, cmd *AttemptCmd) error
+	RegisterSuccess(ctx context.Context, cmd *AttemptCmd) error
+	Release(ctx context.Context, cmd *AttemptCmd) error
+	// ThrottleReset counts a password reset request and refuses it once the
+	// e-mail or the IP address reached its quota.
+	ThrottleReset(ctx context.Context, cmd *ResetRequestCmd) error
+	GetLocked(ctx context.Context) ([]Counter, error)
+	GetRecentFailures(ctx context.Context, limit int) ([]Failure, error)
+	Unlock(ctx context.Context, username string) error
+	PurgeExpired(ctx context.Context) error
+}
+
+func Init(
+	cfg Config,
+	tools tools.Tools,
+	db sqlstorage.Querier,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(cfg, tools, storage)
+}
@@ -0,0 +1,122 @@
+package loginattempts
+
+import (
+	"time"
+
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// FreeAttempts is the number of consecutive failures allowed before
+	// delaying the next attempts.
+	FreeAttempts = 3
+
+	// BaseDelay is the delay imposed after the first failure beyond the
+	// FreeAttempts. It doubles with each new failure up to MaxDelay.
+	BaseDelay = time.Second
+	MaxDelay  = 5 * time.Minute
+
+	// ResetWindow is the time after which the failures are forgotten.
+	ResetWindow = 24 * time.Hour
+
+	// AuditRetention is the time the failed attempts are kept for the audit.
+	AuditRetention = 30 * 24 * time.Hour
+
+	// MaxResetRequests is the number of password reset requests accepted for
+	// an e-mail within the ResetRequestWindow.
+	MaxResetRequests = 3
+	// MaxResetRequestsByIP is the number of password reset requests accepted
+	// from an IP address within the ResetRequestWindow.
+	MaxResetRequestsByIP = 10
+	ResetRequestWindow   = time.Hour
+)
+
+// Kind is the kind of value tracked by a [Counter].
+type Kind string
+
+const (
+	ByIP       Kind = "ip"
+	ByUsername Kind = "username"
+	// ResetByIP and ResetByEmail count the password reset requests.
+	ResetByIP    Kind = "reset-ip"
+	ResetByEmail Kind = "reset-email"
+)
+
+// Counter tracks the consecutive failed logins for an IP address or for a
+// username. For the password resets, it counts the requests instead.
+type Counter struct {
+	lastFailureAt time.Time
+	lockedUntil   *time.Time
+	kind          Kind
+	key           string
+	failures      int
+}
+
+func (c Counter) Kind() Kind               { return c.kind }
+func (c Counter) Key() string              { return c.key }
+func (c Counter) Failures() int            { return c.failures }
+func (c Counter) LastFailureAt() time.Time { return c.lastFailureAt }
+func (c Counter) LockedUntil() *time.Time  { return c.lockedUntil }
+
+// RetryAt returns the time from which a new attempt is accepted.
+func (c Counter) RetryAt() time.Time {
+	return c.lastFailureAt.Add(Delay(c.failures))
+}
+
+func (c Counter) IsLocked(now time.Time) bool {
+	return c.lockedUntil != nil && now.Before(*c.lockedUntil)
+}
+
+// Delay returns the time to wait after the given number of consecutive
+// failures before a new attempt.
+func Delay(failures int) time.Duration {
+	if failures < FreeAttempts {
+		return 0
+	}
+
+	delay := BaseDelay
+	for i := FreeAttempts; i < failures && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, MaxDelay)
+}
+
+// Failure is the audit entry of a failed login.
+type Failure struct {
+	createdAt time.Time
+	username  string
+	ip        string
+	userAgent string
+}
+
+func (f Failure) Username() string     { return f.username }
+func (f Failure) IP() string           { return f.ip }
+func (f Failure) UserAgent() string    { return f.userAgent }
+func (f Failure) CreatedAt() time.Time { return f.createdAt }
+
+// AttemptCmd describes a login attempt.
+type AttemptCmd struct {
+	Username   string
+	RemoteAddr string
+	UserAgent  string
+}
+
+func (t AttemptCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.RemoteAddr, v.Required),
+	)
+}
+
+// ResetRequestCmd describes a password reset request.
+type ResetRequestCmd struct {
+	Email      string
+	RemoteAddr string
+}
+
+func (t ResetRequestCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Email, v.Required),
+		v.Field(&t.RemoteAddr, v.Required),
+	)
+}
@@ -0,0 +1,105 @@
+package loginattempts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeCounterBuilder struct {
+	t       testing.TB
+	counter *Counter
+}
+
+func NewFakeCounter(t testing.TB) *FakeCounterBuilder {
+	t.Helper()
+
+	return &FakeCounterBuilder{
+		t: t,
+		counter: &Counter{
+			kind:          ByUsername,
+			key:           gofakeit.Username(),
+			failures:      1,
+			lastFailureAt: gofakeit.DateRange(time.Now().Add(-time.Hour), time.Now()),
+			lockedUntil:   nil,
+		},
+	}
+}
+
+func (f *FakeCounterBuilder) ForIP(ip string) *FakeCounterBuilder {
+	f.counter.kind = ByIP
+	f.counter.key = ip
+
+	return f
+}
+
+func (f *FakeCounterBuilder) ForUsername(username string) *FakeCounterBuilder {
+	f.counter.kind = ByUsername
+	f.counter.key = username
+
+	return f
+}
+
+func (f *FakeCounterBuilder) WithFailures(failures int, lastFailureAt time.Time) *FakeCounterBuilder {
+	f.counter.failures = failures
+	f.counter.lastFailureAt = lastFailureAt
+
+	return f
+}
+
+func (f *FakeCounterBuilder) LockedUntil(lockedUntil time.Time) *FakeCounterBuilder {
+	f.counter.lockedUntil = ptr.To(lockedUntil)
+
+	return f
+}
+
+func (f *FakeCounterBuilder) Build() *Counter {
+	return f.counter
+}
+
+type FakeFailureBuilder struct {
+	t       testing.TB
+	failure *Failure
+}
+
+func NewFakeFailure(t testing.TB) *FakeFailureBuilder {
+	t.Helper()
+
+	return &FakeFailureBuilder{
+		t: t,
+		failure: &Failure{
+			username:  gofakeit.Username(),
+			ip:        gofakeit.IPv4Address(),
+			userAgent: gofakeit.UserAgent(),
+			createdAt: gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now()),
+		},
+	}
+}
+
+func (f *FakeFailureBuilder) CreatedAt(createdAt time.Time) *FakeFailureBuilder {
+	f.failure.createdAt = createdAt
+
+	return f
+}
+
+func (f *FakeFailureBuilder) Build() *Failure {
+	return f.failure
+}
+
+func (f *FakeFailureBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Failure {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	failure := f.Build()
+
+	err := storage.SaveFailure(ctx, failure)
+	require.NoError(f.t, err)
+
+	return failure
+}
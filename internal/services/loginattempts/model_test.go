@@ -0,0 +1,74 @@
+package loginattempts
+
+import (
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Counter_Getters(t *testing.T) {
+	c := NewFakeCounter(t).LockedUntil(time.Now()).Build()
+
+	assert.Equal(t, c.kind, c.Kind())
+	assert.Equal(t, c.key, c.Key())
+	assert.Equal(t, c.failures, c.Failures())
+	assert.Equal(t, c.lastFailureAt, c.LastFailureAt())
+	assert.Equal(t, c.lockedUntil, c.LockedUntil())
+}
+
+func Test_Counter_IsLocked(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, NewFakeCounter(t).Build().IsLocked(now))
+	assert.True(t, NewFakeCounter(t).LockedUntil(now.Add(time.Minute)).Build().IsLocked(now))
+	assert.False(t, NewFakeCounter(t).LockedUntil(now.Add(-time.Minute)).Build().IsLocked(now))
+}
+
+func Test_Counter_RetryAt(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, now, NewFakeCounter(t).WithFailures(FreeAttempts-1, now).Build().RetryAt())
+	assert.Equal(t, now.Add(BaseDelay), NewFakeCounter(t).WithFailures(FreeAttempts, now).Build().RetryAt())
+}
+
+func Test_Delay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{0, 0},
+		{FreeAttempts - 1, 0},
+		{FreeAttempts, time.Second},
+		{FreeAttempts + 1, 2 * time.Second},
+		{FreeAttempts + 2, 4 * time.Second},
+		{FreeAttempts + 8, 256 * time.Second},
+		{FreeAttempts + 9, MaxDelay},
+		{1000, MaxDelay},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Delay(test.failures), "failures: %d", test.failures)
+	}
+}
+
+func Test_Failure_Getters(t *testing.T) {
+	f := NewFakeFailure(t).Build()
+
+	assert.Equal(t, f.username, f.Username())
+	assert.Equal(t, f.ip, f.IP())
+	assert.Equal(t, f.userAgent, f.UserAgent())
+	assert.Equal(t, f.createdAt, f.CreatedAt())
+}
+
+func Test_AttemptCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(AttemptCmd))
+}
+
+func Test_AttemptCmd_Validate(t *testing.T) {
+	require.NoError(t, AttemptCmd{Username: "jane", RemoteAddr: "192.0.2.1:1234"}.Validate())
+	require.NoError(t, AttemptCmd{Username: "", RemoteAddr: "192.0.2.1"}.Validate())
+	require.Error(t, AttemptCmd{Username: "jane", RemoteAddr: ""}.Validate())
+}
@@ -0,0 +1,335 @@
+package loginattempts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+)
+
+var (
+	ErrTooManyAttempts = fmt.Errorf("too many attempts")
+	ErrLocked          = fmt.Errorf("account locked")
+)
+
+type storage interface {
+	IncrementFailures(ctx context.Context, kind Kind, key string, now time.Time, resetBefore time.Time) error
+	DecrementFailures(ctx context.Context, kind Kind, key string) error
+	GetCounter(ctx context.Context, kind Kind, key string) (*Counter, error)
+	Lock(ctx context.Context, kind Kind, key string, until time.Time) error
+	DeleteCounter(ctx context.Context, kind Kind, key string) error
+	GetLocked(ctx context.Context, kind Kind, now time.Time) ([]Counter, error)
+	RemoveStaleCounters(ctx context.Context, lastFailureBefore time.Time, now time.Time) error
+	SaveFailure(ctx context.Context, failure *Failure) error
+	GetRecentFailures(ctx context.Context, limit int) ([]Failure, error)
+	RemoveFailuresBefore(ctx context.Context, before time.Time) error
+}
+
+type service struct {
+	storage         storage
+	clock           clock.Clock
+	log             *slog.Logger
+	maxAttempts     int
+	lockoutDuration time.Duration
+
+	// lock serializes the reservations.
+	lock *sync.Mutex
+}
+
+func newService(cfg Config, tools tools.Tools, storage storage) *service {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	lockoutDuration := cfg.LockoutDuration
+	if lockoutDuration <= 0 {
+		lockoutDuration = DefaultLockoutDuration
+	}
+
+	return &service{
+		storage:         storage,
+		clock:           tools.Clock(),
+		log:             tools.Logger(),
+		maxAttempts:     maxAttempts,
+		lockoutDuration: lockoutDuration,
+		lock:            new(sync.Mutex),
+	}
+}
+
+// Reserve refuses the attempt when it comes too early after the previous
+// failures or when the account is locked. Otherwise the attempt is counted
+// as a failure until [Service.RegisterSuccess] or [Service.Release] is
+// called.
+//
+// The check and the reservation are done under a lock, before the password
+// is hashed, so the concurrent attempts can't all pass the check.
+func (s *service) Reserve(ctx context.Context, cmd *AttemptCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.clock.Now()
+	ip := ipFromAddr(cmd.RemoteAddr)
+
+	if cmd.Username != "" {
+		counter, err := s.getCounter(ctx, ByUsername, cmd.Username)
+		if err != nil {
+			return err
+		}
+
+		if counter != nil && counter.IsLocked(now) {
+			return errs.TooManyRequests(ErrLocked, "this account is locked after too many failed attempts, retry later")
+		}
+
+		if counter != nil && now.Before(counter.RetryAt()) {
+			return errs.TooManyRequests(ErrTooManyAttempts, "too many failed attempts, retry in %s", retryIn(now, counter))
+		}
+	}
+
+	counter, err := s.getCounter(ctx, ByIP, ip)
+	if err != nil {
+		return err
+	}
+
+	if counter != nil && now.Before(counter.RetryAt()) {
+		return errs.TooManyRequests(ErrTooManyAttempts, "too many failed attempts, retry in %s", retryIn(now, counter))
+	}
+
+	err = s.storage.IncrementFailures(ctx, ByIP, ip, now, now.Add(-ResetWindow))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to increment the ip counter: %w", err))
+	}
+
+	if cmd.Username == "" {
+		return nil
+	}
+
+	err = s.storage.IncrementFailures(ctx, ByUsername, cmd.Username, now, now.Add(-ResetWindow))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to increment the username counter: %w", err))
+	}
+
+	return nil
+}
+
+// RegisterFailure audits the failed attempt, already counted by
+// [Service.Reserve]. The account is locked once the username reaches the
+// maximum number of attempts.
+func (s *service) RegisterFailure(ctx context.Context, cmd *AttemptCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	now := s.clock.Now()
+	ip := ipFromAddr(cmd.RemoteAddr)
+
+	err = s.storage.SaveFailure(ctx, &Failure{
+		username:  cmd.Username,
+		ip:        ip,
+		userAgent: cmd.UserAgent,
+		createdAt: now,
+	})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to save the failure: %w", err))
+	}
+
+	if cmd.Username == "" {
+		return nil
+	}
+
+	counter, err := s.getCounter(ctx, ByUsername, cmd.Username)
+	if err != nil {
+		return err
+	}
+
+	if counter == nil || counter.failures < s.maxAttempts || counter.lockedUntil != nil {
+		return nil
+	}
+
+	err = s.storage.Lock(ctx, ByUsername, cmd.Username, now.Add(s.lockoutDuration))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to lock the account: %w", err))
+	}
+
+	s.log.WarnContext(ctx, "account locked after too many failed logins",
+		slog.String("username", cmd.Username),
+		slog.String("ip", ip),
+		slog.Int("failures", counter.failures))
+
+	return nil
+}
+
+// RegisterSuccess resets the counter of the username. For the IP address,
+// only the reservation is removed: a valid account must not allow to guess
+// the others.
+func (s *service) RegisterSuccess(ctx context.Context, cmd *AttemptCmd) error {
+	err := s.storage.DecrementFailures(ctx, ByIP, ipFromAddr(cmd.RemoteAddr))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to decrement the ip counter: %w", err))
+	}
+
+	if cmd.Username == "" {
+		return nil
+	}
+
+	err = s.storage.DeleteCounter(ctx, ByUsername, cmd.Username)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteCounter: %w", err))
+	}
+
+	return nil
+}
+
+// Release removes the reservation of an attempt which is neither a failure
+// nor a complete login, like a valid password waiting for the second factor.
+func (s *service) Release(ctx context.Context, cmd *AttemptCmd) error {
+	err := s.storage.DecrementFailures(ctx, ByIP, ipFromAddr(cmd.RemoteAddr))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to decrement the ip counter: %w", err))
+	}
+
+	if cmd.Username == "" {
+		return nil
+	}
+
+	err = s.storage.DecrementFailures(ctx, ByUsername, cmd.Username)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to decrement the username counter: %w", err))
+	}
+
+	return nil
+}
+
+// ThrottleReset refuses the request when the e-mail or the IP address already
+// made too many requests within the [ResetRequestWindow]. Otherwise the
+// request is counted for both.
+//
+// The e-mail is counted whatever an account uses it or not so the refusals
+// don't tell which addresses are registered.
+func (s *service) ThrottleReset(ctx context.Context, cmd *ResetRequestCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.clock.Now()
+	ip := ipFromAddr(cmd.RemoteAddr)
+
+	quotas := []struct {
+		kind Kind
+		key  string
+		max  int
+	}{
+		{ResetByEmail, cmd.Email, MaxResetRequests},
+		{ResetByIP, ip, MaxResetRequestsByIP},
+	}
+
+	for _, quota := range quotas {
+		counter, err := s.getCounter(ctx, quota.kind, quota.key)
+		if err != nil {
+			return err
+		}
+
+		if counter != nil && counter.failures >= quota.max && now.Before(counter.lastFailureAt.Add(ResetRequestWindow)) {
+			return errs.TooManyRequests(ErrTooManyAttempts, "too many password reset requests, retry later")
+		}
+	}
+
+	for _, quota := range quotas {
+		err = s.storage.IncrementFailures(ctx, quota.kind, quota.key, now, now.Add(-ResetRequestWindow))
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to increment the %s counter: %w", quota.kind, err))
+		}
+	}
+
+	return nil
+}
+
+func (s *service) GetLocked(ctx context.Context) ([]Counter, error) {
+	res, err := s.storage.GetLocked(ctx, ByUsername, s.clock.Now())
+	if err != nil {
+		return nil, errs.Internal(err)
+	}
+
+	return res, nil
+}
+
+func (s *service) GetRecentFailures(ctx context.Context, limit int) ([]Failure, error) {
+	res, err := s.storage.GetRecentFailures(ctx, limit)
+	if err != nil {
+		return nil, errs.Internal(err)
+	}
+
+	return res, nil
+}
+
+// Unlock removes the lock and the failures of the given username.
+func (s *service) Unlock(ctx context.Context, username string) error {
+	err := s.storage.DeleteCounter(ctx, ByUsername, username)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteCounter: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) PurgeExpired(ctx context.Context) error {
+	now := s.clock.Now()
+
+	err := s.storage.RemoveStaleCounters(ctx, now.Add(-ResetWindow), now)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveStaleCounters: %w", err))
+	}
+
+	err = s.storage.RemoveFailuresBefore(ctx, now.Add(-AuditRetention))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveFailuresBefore: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) getCounter(ctx context.Context, kind Kind, key string) (*Counter, error) {
+	counter, err := s.storage.GetCounter(ctx, kind, key)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetCounter: %w", err))
+	}
+
+	return counter, nil
+}
+
+// retryIn returns the remaining delay, rounded up to the second.
+func retryIn(now time.Time, counter *Counter) time.Duration {
+	return (counter.RetryAt().Sub(now) + time.Second - 1).Truncate(time.Second)
+}
+
+// ipFromAddr removes the port from the remote address. The address is
+// returned as is when it has no port, like after the RealIP middleware.
+func ipFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
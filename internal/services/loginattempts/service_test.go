@@ -0,0 +1,456 @@
+package loginattempts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptsService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cmd := &AttemptCmd{
+		Username:   "jane",
+		RemoteAddr: "192.0.2.1:1234",
+		UserAgent:  "firefox 4.4.4.4",
+	}
+
+	t.Run("Reserve success without any failure counts the attempt", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(nil, errNotFound).Once()
+		storageMock.On("GetCounter", mock.Anything, ByIP, "192.0.2.1").Return(nil, errNotFound).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ByIP, "192.0.2.1", now, now.Add(-ResetWindow)).Return(nil).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ByUsername, "jane", now, now.Add(-ResetWindow)).Return(nil).Once()
+
+		// Run
+		err := svc.Reserve(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Reserve success once the delay is over", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForUsername("jane").WithFailures(FreeAttempts+2, now.Add(-time.Minute)).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(counter, nil).Once()
+		storageMock.On("GetCounter", mock.Anything, ByIP, "192.0.2.1").Return(nil, errNotFound).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ByIP, "192.0.2.1", now, now.Add(-ResetWindow)).Return(nil).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ByUsername, "jane", now, now.Add(-ResetWindow)).Return(nil).Once()
+
+		// Run
+		err := svc.Reserve(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Reserve during the delay of the username", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForUsername("jane").WithFailures(FreeAttempts+2, now).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(counter, nil).Once()
+
+		// Run
+		err := svc.Reserve(ctx, cmd)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrTooManyRequests)
+		require.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+
+	t.Run("Reserve during the delay of the IP address", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForIP("192.0.2.1").WithFailures(FreeAttempts, now).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(nil, errNotFound).Once()
+		storageMock.On("GetCounter", mock.Anything, ByIP, "192.0.2.1").Return(counter, nil).Once()
+
+		// Run
+		err := svc.Reserve(ctx, cmd)
+
+		// Asserts
+		require.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+
+	t.Run("Reserve with a locked account", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).
+			ForUsername("jane").
+			WithFailures(DefaultMaxAttempts, now.Add(-time.Hour)).
+			LockedUntil(now.Add(time.Minute)).
+			Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(counter, nil).Once()
+
+		// Run
+		err := svc.Reserve(ctx, cmd)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrTooManyRequests)
+		require.ErrorIs(t, err, ErrLocked)
+	})
+
+	t.Run("RegisterFailure success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForUsername("jane").WithFailures(2, now).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("SaveFailure", mock.Anything, &Failure{
+			username:  "jane",
+			ip:        "192.0.2.1",
+			userAgent: "firefox 4.4.4.4",
+			createdAt: now,
+		}).Return(nil).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(counter, nil).Once()
+
+		// Run
+		err := svc.RegisterFailure(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("RegisterFailure locks the account after the max attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{MaxAttempts: 5, LockoutDuration: time.Hour}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForUsername("jane").WithFailures(5, now).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("SaveFailure", mock.Anything, mock.Anything).Return(nil).Once()
+		storageMock.On("GetCounter", mock.Anything, ByUsername, "jane").Return(counter, nil).Once()
+		storageMock.On("Lock", mock.Anything, ByUsername, "jane", now.Add(time.Hour)).Return(nil).Once()
+
+		// Run
+		err := svc.RegisterFailure(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("RegisterFailure without username only saves the failure", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("SaveFailure", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Run
+		err := svc.RegisterFailure(ctx, &AttemptCmd{RemoteAddr: "192.0.2.1"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("RegisterSuccess resets the username counter", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Mocks
+		storageMock.On("DecrementFailures", mock.Anything, ByIP, "192.0.2.1").Return(nil).Once()
+		storageMock.On("DeleteCounter", mock.Anything, ByUsername, "jane").Return(nil).Once()
+
+		// Run
+		err := svc.RegisterSuccess(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Release success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Mocks
+		storageMock.On("DecrementFailures", mock.Anything, ByIP, "192.0.2.1").Return(nil).Once()
+		storageMock.On("DecrementFailures", mock.Anything, ByUsername, "jane").Return(nil).Once()
+
+		// Run
+		err := svc.Release(ctx, cmd)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("ThrottleReset success counts the request", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).WithFailures(MaxResetRequests-1, now).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByEmail, "jane@example.com").Return(counter, nil).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByIP, "192.0.2.1").Return(nil, errNotFound).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ResetByEmail, "jane@example.com", now, now.Add(-ResetRequestWindow)).Return(nil).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ResetByIP, "192.0.2.1", now, now.Add(-ResetRequestWindow)).Return(nil).Once()
+
+		// Run
+		err := svc.ThrottleReset(ctx, &ResetRequestCmd{Email: "jane@example.com", RemoteAddr: "192.0.2.1:1234"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("ThrottleReset once the window is over", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).WithFailures(MaxResetRequests, now.Add(-ResetRequestWindow)).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByEmail, "jane@example.com").Return(counter, nil).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByIP, "192.0.2.1").Return(nil, errNotFound).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ResetByEmail, "jane@example.com", now, now.Add(-ResetRequestWindow)).Return(nil).Once()
+		storageMock.On("IncrementFailures", mock.Anything, ResetByIP, "192.0.2.1", now, now.Add(-ResetRequestWindow)).Return(nil).Once()
+
+		// Run
+		err := svc.ThrottleReset(ctx, &ResetRequestCmd{Email: "jane@example.com", RemoteAddr: "192.0.2.1:1234"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("ThrottleReset with too many requests for the email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).WithFailures(MaxResetRequests, now.Add(-time.Minute)).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByEmail, "jane@example.com").Return(counter, nil).Once()
+
+		// Run
+		err := svc.ThrottleReset(ctx, &ResetRequestCmd{Email: "jane@example.com", RemoteAddr: "192.0.2.1:1234"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrTooManyRequests)
+		require.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+
+	t.Run("ThrottleReset with too many requests from the IP address", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).ForIP("192.0.2.1").WithFailures(MaxResetRequestsByIP, now.Add(-time.Minute)).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByEmail, "jane@example.com").Return(nil, errNotFound).Once()
+		storageMock.On("GetCounter", mock.Anything, ResetByIP, "192.0.2.1").Return(counter, nil).Once()
+
+		// Run
+		err := svc.ThrottleReset(ctx, &ResetRequestCmd{Email: "jane@example.com", RemoteAddr: "192.0.2.1:1234"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrTooManyRequests)
+	})
+
+	t.Run("ThrottleReset with a validation error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Run
+		err := svc.ThrottleReset(ctx, &ResetRequestCmd{Email: "jane@example.com"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("GetLocked success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+		counter := NewFakeCounter(t).LockedUntil(now.Add(time.Hour)).Build()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("GetLocked", mock.Anything, ByUsername, now).Return([]Counter{*counter}, nil).Once()
+
+		// Run
+		res, err := svc.GetLocked(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []Counter{*counter}, res)
+	})
+
+	t.Run("GetRecentFailures success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		failure := NewFakeFailure(t).Build()
+
+		// Mocks
+		storageMock.On("GetRecentFailures", mock.Anything, 50).Return([]Failure{*failure}, nil).Once()
+
+		// Run
+		res, err := svc.GetRecentFailures(ctx, 50)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []Failure{*failure}, res)
+	})
+
+	t.Run("Unlock success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Mocks
+		storageMock.On("DeleteCounter", mock.Anything, ByUsername, "jane").Return(nil).Once()
+
+		// Run
+		err := svc.Unlock(ctx, "jane")
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("PurgeExpired success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		svc := newService(Config{}, tools, storageMock)
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveStaleCounters", mock.Anything, now.Add(-ResetWindow), now).Return(nil).Once()
+		storageMock.On("RemoveFailuresBefore", mock.Anything, now.Add(-AuditRetention)).Return(nil).Once()
+
+		// Run
+		err := svc.PurgeExpired(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+}
+
+func Test_ipFromAddr(t *testing.T) {
+	assert.Equal(t, "192.0.2.1", ipFromAddr("192.0.2.1:1234"))
+	assert.Equal(t, "192.0.2.1", ipFromAddr("192.0.2.1"))
+	assert.Equal(t, "2001:db8::1", ipFromAddr("[2001:db8::1]:1234"))
+	assert.Equal(t, "2001:db8::1", ipFromAddr("2001:db8::1"))
+}
@@ -0,0 +1,265 @@
+package loginattempts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+const (
+	countersTableName = "login_counters"
+	failuresTableName = "login_failures"
+)
+
+var errNotFound = errors.New("not found")
+
+var (
+	counterFields = []string{"kind", "key", "failures", "last_failure_at", "locked_until"}
+	failureFields = []string{"username", "ip", "user_agent", "created_at"}
+)
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+// IncrementFailures adds a failure to the counter in a single statement so
+// the concurrent attempts are all counted. The counter restarts from one
+// when its last failure is older than resetBefore or when its lock has
+// expired.
+func (s *sqlStorage) IncrementFailures(ctx context.Context, kind Kind, key string, now time.Time, resetBefore time.Time) error {
+	sqlNow := ptr.To(sqlstorage.SQLTime(now))
+	sqlResetBefore := ptr.To(sqlstorage.SQLTime(resetBefore))
+
+	_, err := sq.
+		Insert(countersTableName).
+		Columns(counterFields...).
+		Values(kind, key, 1, sqlNow, nil).
+		Suffix(`ON CONFLICT(kind, key) DO UPDATE SET
+			failures = CASE
+				WHEN julianday(last_failure_at) < julianday(?) THEN 1
+				WHEN locked_until IS NOT NULL AND julianday(locked_until) <= julianday(?) THEN 1
+				ELSE failures + 1
+			END,
+			locked_until = CASE
+				WHEN locked_until IS NOT NULL AND julianday(locked_until) <= julianday(?) THEN NULL
+				ELSE locked_until
+			END,
+			last_failure_at = ?`, sqlResetBefore, sqlNow, sqlNow, sqlNow).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// DecrementFailures removes a failure from the counter, if any.
+func (s *sqlStorage) DecrementFailures(ctx context.Context, kind Kind, key string) error {
+	_, err := sq.
+		Update(countersTableName).
+		Set("failures", sq.Expr("MAX(failures - 1, 0)")).
+		Where(sq.Eq{"kind": kind, "key": key}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetCounter(ctx context.Context, kind Kind, key string) (*Counter, error) {
+	row := sq.
+		Select(counterFields...).
+		From(countersTableName).
+		Where(sq.Eq{"kind": kind, "key": key}).
+		RunWith(s.db).
+		QueryRowContext(ctx)
+
+	return s.scanCounter(row)
+}
+
+func (s *sqlStorage) Lock(ctx context.Context, kind Kind, key string, until time.Time) error {
+	_, err := sq.
+		Update(countersTableName).
+		Set("locked_until", ptr.To(sqlstorage.SQLTime(until))).
+		Where(sq.Eq{"kind": kind, "key": key}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteCounter(ctx context.Context, kind Kind, key string) error {
+	_, err := sq.
+		Delete(countersTableName).
+		Where(sq.Eq{"kind": kind, "key": key}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// GetLocked returns the counters locked at the given time, sorted by key.
+func (s *sqlStorage) GetLocked(ctx context.Context, kind Kind, now time.Time) ([]Counter, error) {
+	rows, err := sq.
+		Select(counterFields...).
+		From(countersTableName).
+		Where(sq.Eq{"kind": kind}).
+		Where(sq.Expr("julianday(locked_until) > julianday(?)", ptr.To(sqlstorage.SQLTime(now)))).
+		OrderBy("key").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Counter{}
+
+	for rows.Next() {
+		counter, err := s.scanCounter(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *counter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+// RemoveStaleCounters removes the counters without failure since the given
+// time and without a running lock.
+func (s *sqlStorage) RemoveStaleCounters(ctx context.Context, lastFailureBefore time.Time, now time.Time) error {
+	_, err := sq.
+		Delete(countersTableName).
+		Where(sq.Expr("julianday(last_failure_at) < julianday(?)", ptr.To(sqlstorage.SQLTime(lastFailureBefore)))).
+		Where(sq.Or{
+			sq.Eq{"locked_until": nil},
+			sq.Expr("julianday(locked_until) <= julianday(?)", ptr.To(sqlstorage.SQLTime(now))),
+		}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) SaveFailure(ctx context.Context, failure *Failure) error {
+	_, err := sq.
+		Insert(failuresTableName).
+		Columns(failureFields...).
+		Values(
+			failure.username,
+			failure.ip,
+			failure.userAgent,
+			ptr.To(sqlstorage.SQLTime(failure.createdAt))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecentFailures returns the last failures, the most recent first.
+func (s *sqlStorage) GetRecentFailures(ctx context.Context, limit int) ([]Failure, error) {
+	rows, err := sq.
+		Select(failureFields...).
+		From(failuresTableName).
+		OrderBy("created_at DESC").
+		Limit(uint64(limit)).
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Failure{}
+
+	for rows.Next() {
+		var failure Failure
+		var sqlCreatedAt sqlstorage.SQLTime
+
+		err := rows.Scan(&failure.username, &failure.ip, &failure.userAgent, &sqlCreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		failure.createdAt = sqlCreatedAt.Time()
+		res = append(res, failure)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) RemoveFailuresBefore(ctx context.Context, before time.Time) error {
+	_, err := sq.
+		Delete(failuresTableName).
+		Where(sq.Expr("julianday(created_at) < julianday(?)", ptr.To(sqlstorage.SQLTime(before)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) scanCounter(row sq.RowScanner) (*Counter, error) {
+	var res Counter
+	var sqlLastFailureAt sqlstorage.SQLTime
+	var sqlLockedUntil *sqlstorage.SQLTime
+
+	err := row.Scan(
+		&res.kind,
+		&res.key,
+		&res.failures,
+		&sqlLastFailureAt,
+		&sqlLockedUntil,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	res.lastFailureAt = sqlLastFailureAt.Time()
+	if sqlLockedUntil != nil {
+		res.lockedUntil = ptr.To(sqlLockedUntil.Time())
+	}
+
+	return &res, nil
+}
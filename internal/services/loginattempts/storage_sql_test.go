@@ -0,0 +1,198 @@
+package loginattempts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptsSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	t.Run("IncrementFailures and GetCounter success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		err := store.IncrementFailures(ctx, ByUsername, "jane", now.Add(-time.Minute), now.Add(-ResetWindow))
+		require.NoError(t, err)
+		err = store.IncrementFailures(ctx, ByUsername, "jane", now, now.Add(-ResetWindow))
+		require.NoError(t, err)
+		err = store.IncrementFailures(ctx, ByIP, "jane", now, now.Add(-ResetWindow))
+		require.NoError(t, err)
+
+		res, err := store.GetCounter(ctx, ByUsername, "jane")
+		require.NoError(t, err)
+		assert.Equal(t, &Counter{
+			kind:          ByUsername,
+			key:           "jane",
+			failures:      2,
+			lastFailureAt: now,
+			lockedUntil:   nil,
+		}, res)
+	})
+
+	t.Run("DecrementFailures success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "192.0.2.1", now, now.Add(-ResetWindow)))
+
+		err := store.DecrementFailures(ctx, ByIP, "192.0.2.1")
+		require.NoError(t, err)
+		err = store.DecrementFailures(ctx, ByIP, "192.0.2.1")
+		require.NoError(t, err)
+
+		res, err := store.GetCounter(ctx, ByIP, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.failures)
+	})
+
+	t.Run("GetCounter not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetCounter(ctx, ByIP, "192.0.2.1")
+		require.ErrorIs(t, err, errNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("IncrementFailures restarts an old counter", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		old := now.Add(-2 * ResetWindow)
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "192.0.2.1", old, old.Add(-ResetWindow)))
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "192.0.2.1", old, old.Add(-ResetWindow)))
+
+		err := store.IncrementFailures(ctx, ByIP, "192.0.2.1", now, now.Add(-ResetWindow))
+		require.NoError(t, err)
+
+		res, err := store.GetCounter(ctx, ByIP, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.failures)
+	})
+
+	t.Run("IncrementFailures restarts a counter with an expired lock", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "jane", now.Add(-time.Hour), now.Add(-ResetWindow)))
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "jane", now.Add(-time.Hour), now.Add(-ResetWindow)))
+		require.NoError(t, store.Lock(ctx, ByUsername, "jane", now.Add(-time.Minute)))
+
+		err := store.IncrementFailures(ctx, ByUsername, "jane", now, now.Add(-ResetWindow))
+		require.NoError(t, err)
+
+		res, err := store.GetCounter(ctx, ByUsername, "jane")
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.failures)
+		assert.Nil(t, res.lockedUntil)
+	})
+
+	t.Run("Lock and GetLocked success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "jane", now, now.Add(-ResetWindow)))
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "john", now, now.Add(-ResetWindow)))
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "expired", now, now.Add(-ResetWindow)))
+		require.NoError(t, store.Lock(ctx, ByUsername, "jane", now.Add(time.Hour)))
+		require.NoError(t, store.Lock(ctx, ByUsername, "expired", now.Add(-time.Hour)))
+
+		res, err := store.GetLocked(ctx, ByUsername, now)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "jane", res[0].key)
+		assert.Equal(t, now.Add(time.Hour), *res[0].lockedUntil)
+	})
+
+	t.Run("DeleteCounter success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		require.NoError(t, store.IncrementFailures(ctx, ByUsername, "jane", now, now.Add(-ResetWindow)))
+
+		err := store.DeleteCounter(ctx, ByUsername, "jane")
+		require.NoError(t, err)
+
+		res, err := store.GetCounter(ctx, ByUsername, "jane")
+		require.ErrorIs(t, err, errNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("RemoveStaleCounters success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		old := now.Add(-2 * ResetWindow)
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "stale", old, old.Add(-ResetWindow)))
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "locked", old, old.Add(-ResetWindow)))
+		require.NoError(t, store.Lock(ctx, ByIP, "locked", now.Add(time.Hour)))
+		require.NoError(t, store.IncrementFailures(ctx, ByIP, "recent", now, now.Add(-ResetWindow)))
+
+		err := store.RemoveStaleCounters(ctx, now.Add(-ResetWindow), now)
+		require.NoError(t, err)
+
+		_, err = store.GetCounter(ctx, ByIP, "stale")
+		require.ErrorIs(t, err, errNotFound)
+		_, err = store.GetCounter(ctx, ByIP, "locked")
+		require.NoError(t, err)
+		_, err = store.GetCounter(ctx, ByIP, "recent")
+		require.NoError(t, err)
+	})
+
+	t.Run("SaveFailure and GetRecentFailures success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		older := NewFakeFailure(t).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		newer := NewFakeFailure(t).CreatedAt(now).BuildAndStore(ctx, db)
+		NewFakeFailure(t).CreatedAt(now.Add(-2*time.Hour)).BuildAndStore(ctx, db)
+
+		res, err := store.GetRecentFailures(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []Failure{*newer, *older}, res)
+	})
+
+	t.Run("RemoveFailuresBefore success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		recent := NewFakeFailure(t).CreatedAt(now).BuildAndStore(ctx, db)
+		NewFakeFailure(t).CreatedAt(now.Add(-2*AuditRetention)).BuildAndStore(ctx, db)
+
+		err := store.RemoveFailuresBefore(ctx, now.Add(-AuditRetention))
+		require.NoError(t, err)
+
+		res, err := store.GetRecentFailures(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []Failure{*recent}, res)
+	})
+}
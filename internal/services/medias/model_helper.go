@@ -34,6 +34,12 @@ func NewFakeFileMeta(t testing.TB) *FakeFileMetaBuilder {
 	}
 }
 
+func (f *FakeFileMetaBuilder) WithType(mediaType MediaType) *FakeFileMetaBuilder {
+	f.fileMeta.mediaType = mediaType
+
+	return f
+}
+
 func (f *FakeFileMetaBuilder) Build() *FileMeta {
 	return f.fileMeta
 }
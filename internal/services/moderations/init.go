@@ -5,11 +5,13 @@ import (
 	"database/sql"
 
 	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/tools"
 )
 
 type Service interface {
 	ModeratePost(ctx context.Context, cmd *PostModerationCmd) (*Moderation, error)
+	Reassign(ctx context.Context, from, to *users.User) error
 }
 
 func Init(tools tools.Tools, db *sql.DB, permsSvc perms.Service) Service {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/clock"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
@@ -15,6 +16,7 @@ import (
 type storage interface {
 	Save(ctx context.Context, m *Moderation) error
 	GetAll(ctx context.Context, cmd *sqlstorage.PaginateCmd) ([]Moderation, error)
+	UpdateCreator(ctx context.Context, from, to uuid.UUID) error
 }
 
 type service struct {
@@ -60,3 +62,13 @@ func (s *service) ModeratePost(ctx context.Context, cmd *PostModerationCmd) (*Mo
 
 	return &moderation, nil
 }
+
+// Reassign transfers all the moderations created by the user "from" to the user "to".
+func (s *service) Reassign(ctx context.Context, from, to *users.User) error {
+	err := s.storage.UpdateCreator(ctx, from.ID(), to.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateCreator: %w", err))
+	}
+
+	return nil
+}
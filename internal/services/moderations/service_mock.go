@@ -5,6 +5,7 @@ package moderations
 import (
 	context "context"
 
+	users "github.com/Peltoche/onlyfun/internal/services/users"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -43,6 +44,24 @@ func (_m *MockService) ModeratePost(ctx context.Context, cmd *PostModerationCmd)
 	return r0, r1
 }
 
+// Reassign provides a mock function with given fields: ctx, from, to
+func (_m *MockService) Reassign(ctx context.Context, from *users.User, to *users.User) error {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reassign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *users.User, *users.User) error); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
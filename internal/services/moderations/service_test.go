@@ -12,6 +12,7 @@ import (
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -125,4 +126,34 @@ func Test_Moderations_Service(t *testing.T) {
 		require.ErrorIs(t, err, errs.ErrInternal)
 		require.ErrorContains(t, err, "some-error")
 	})
+	t.Run("Reassign success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(nil).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reassign with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(assert.AnError).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
 }
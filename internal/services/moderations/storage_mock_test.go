@@ -7,6 +7,8 @@ import (
 
 	sqlstorage "github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
 // mockStorage is an autogenerated mock type for the storage type
@@ -62,6 +64,24 @@ func (_m *mockStorage) Save(ctx context.Context, m *Moderation) error {
 	return r0
 }
 
+// UpdateCreator provides a mock function with given fields: ctx, from, to
+func (_m *mockStorage) UpdateCreator(ctx context.Context, from uuid.UUID, to uuid.UUID) error {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCreator")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // newMockStorage creates a new instance of mockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockStorage(t interface {
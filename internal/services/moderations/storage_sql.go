@@ -8,6 +8,7 @@ import (
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
 const tableName = "moderations"
@@ -118,3 +119,16 @@ func (s *sqlStorage) scanRows(rows *sql.Rows) ([]Moderation, error) {
 
 	return moderations, nil
 }
+
+func (s *sqlStorage) UpdateCreator(ctx context.Context, from, to uuid.UUID) error {
+	_, err := sq.Update(tableName).
+		Set("created_by", to).
+		Where(sq.Eq{"created_by": from}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
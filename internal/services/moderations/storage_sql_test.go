@@ -50,4 +50,28 @@ func Test_Moderations_SqlStorage(t *testing.T) {
 		require.NoError(t, err)
 		require.NotEqual(t, uint64(0), post.ID())
 	})
+	t.Run("UpdateCreator success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		ghost := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		err := store.Save(ctx, NewFakeModeration(t).CreatedBy(user).WithPost(post).Build())
+		require.NoError(t, err)
+
+		// Run
+		err = store.UpdateCreator(ctx, user.ID(), ghost.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetAll(ctx, &sqlstorage.PaginateCmd{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, ghost.ID(), res[0].CreatedBy())
+	})
 }
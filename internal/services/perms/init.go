@@ -14,6 +14,12 @@ type WithRole interface {
 
 type Service interface {
 	IsAuthorized(withRole WithRole, askedPerm Permission) bool
+	RolesWith(perm Permission) []Role
+	GetRoles() []Role
+	GetPermissions(role Role) []Permission
+	CreateRole(ctx context.Context, cmd *CreateRoleCmd) error
+	UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error
+	DeleteRole(ctx context.Context, cmd *DeleteRoleCmd) error
 }
 
 func Init(ctx context.Context, db sqlstorage.Querier, tools tools.Tools) (Service, error) {
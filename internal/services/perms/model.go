@@ -1,10 +1,22 @@
 package perms
 
+import (
+	"regexp"
+
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
 type Permission string
 
 const (
-	UploadPost Permission = "posts.upload"
-	Moderation Permission = "moderation"
+	UploadPost          Permission = "posts.upload"
+	VotePost            Permission = "posts.vote"
+	WriteComment        Permission = "comments.write"
+	ModerateComment     Permission = "comments.moderate"
+	Moderation          Permission = "moderation"
+	ManageSections      Permission = "sections.manage"
+	ManageRegistrations Permission = "registrations.manage"
+	ManageUsers         Permission = "users.manage"
 )
 
 type Role string
@@ -16,7 +28,82 @@ const (
 )
 
 var DefaultRoles = map[Role][]Permission{
-	DefaultAdminRole:     {UploadPost, Moderation},
-	DefaultModeratorRole: {UploadPost, Moderation},
-	DefaultUserRole:      {UploadPost},
+	DefaultAdminRole:     {UploadPost, VotePost, WriteComment, ModerateComment, Moderation, ManageSections, ManageRegistrations, ManageUsers},
+	DefaultModeratorRole: {UploadPost, VotePost, WriteComment, ModerateComment, Moderation},
+	DefaultUserRole:      {UploadPost, VotePost, WriteComment},
+}
+
+// AllPermissions lists all the known permissions, in the order they are
+// displayed to the admins.
+var AllPermissions = []Permission{
+	UploadPost,
+	VotePost,
+	WriteComment,
+	ModerateComment,
+	Moderation,
+	ManageSections,
+	ManageRegistrations,
+	ManageUsers,
+}
+
+// IsDefault returns true for the roles created at the first boot. Those
+// roles are referenced by the code so they can't be renamed or deleted.
+func (r Role) IsDefault() bool {
+	_, ok := DefaultRoles[r]
+
+	return ok
+}
+
+var roleNameRegexp = regexp.MustCompile("^[a-z0-9_-]+$")
+
+type CreateRoleCmd struct {
+	User        WithRole
+	Role        Role
+	Permissions []Permission
+}
+
+func (t CreateRoleCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Role, v.Required, v.Length(2, 30), v.Match(roleNameRegexp)),
+		v.Field(&t.Permissions, v.Each(v.In(knownPermissions()...))),
+	)
+}
+
+type UpdateRoleCmd struct {
+	User WithRole
+	Role Role
+	// NewName renames the role if it differs from Role.
+	NewName     Role
+	Permissions []Permission
+}
+
+func (t UpdateRoleCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Role, v.Required),
+		v.Field(&t.NewName, v.Required, v.Length(2, 30), v.Match(roleNameRegexp)),
+		v.Field(&t.Permissions, v.Each(v.In(knownPermissions()...))),
+	)
+}
+
+type DeleteRoleCmd struct {
+	User WithRole
+	Role Role
+}
+
+func (t DeleteRoleCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Role, v.Required),
+	)
+}
+
+func knownPermissions() []any {
+	res := make([]any, len(AllPermissions))
+	for i, perm := range AllPermissions {
+		res[i] = perm
+	}
+
+	return res
 }
@@ -0,0 +1,64 @@
+package perms
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateRoleCmd(t *testing.T) {
+	t.Parallel()
+
+	admin := &resourceWithRole{ptr.To(DefaultAdminRole)}
+
+	tests := []struct {
+		Name     string
+		Cmd      CreateRoleCmd
+		ErrorMsg string
+	}{
+		{
+			Name:     "valid",
+			Cmd:      CreateRoleCmd{User: admin, Role: "editor-2", Permissions: []Permission{UploadPost}},
+			ErrorMsg: "",
+		},
+		{
+			Name:     "without permissions",
+			Cmd:      CreateRoleCmd{User: admin, Role: "guest", Permissions: []Permission{}},
+			ErrorMsg: "",
+		},
+		{
+			Name:     "with an invalid name",
+			Cmd:      CreateRoleCmd{User: admin, Role: "Some Role", Permissions: []Permission{UploadPost}},
+			ErrorMsg: "Role: must be in a valid format.",
+		},
+		{
+			Name:     "with a too short name",
+			Cmd:      CreateRoleCmd{User: admin, Role: "a", Permissions: []Permission{UploadPost}},
+			ErrorMsg: "Role: the length must be between 2 and 30.",
+		},
+		{
+			Name:     "with an unknown permission",
+			Cmd:      CreateRoleCmd{User: admin, Role: "editor", Permissions: []Permission{"unknown"}},
+			ErrorMsg: "Permissions: (0: must be a valid value.).",
+		},
+		{
+			Name:     "without user",
+			Cmd:      CreateRoleCmd{User: nil, Role: "editor", Permissions: []Permission{UploadPost}},
+			ErrorMsg: "User: cannot be blank.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.Cmd.Validate()
+			if test.ErrorMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.ErrorMsg)
+			}
+		})
+	}
+}
@@ -2,21 +2,33 @@ package perms
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
-var ErrInvalidRoleName = fmt.Errorf("invalid role name")
+var (
+	ErrInvalidRoleName = fmt.Errorf("invalid role name")
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrRoleExists      = errors.New("role already exists")
+	ErrRoleInUse       = errors.New("role still used by some users")
+	ErrDefaultRole     = errors.New("default roles can't be renamed or deleted")
+	ErrAdminLockout    = errors.New("the admin role must keep the users management")
+)
 
 type storage interface {
 	Save(ctx context.Context, roles *Role, perms []Permission) error
 	GetAll(ctx context.Context) (map[Role][]Permission, error)
 	GetPermissions(ctx context.Context, roles *Role) ([]Permission, error)
+	Update(ctx context.Context, role *Role, newName *Role, perms []Permission) error
+	Delete(ctx context.Context, role *Role) error
 }
 
 type service struct {
@@ -93,6 +105,174 @@ func (s *service) IsAuthorized(withRole WithRole, askedPerm Permission) bool {
 	return false
 }
 
+// RolesWith returns all the roles having the given permission, sorted by
+// name.
+func (s *service) RolesWith(perm Permission) []Role {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	res := []Role{}
+	for role, permissions := range s.permsByRole {
+		if slices.Contains(permissions, perm) {
+			res = append(res, role)
+		}
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+// GetRoles returns all the existing roles, sorted by name.
+func (s *service) GetRoles() []Role {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	res := make([]Role, 0, len(s.permsByRole))
+	for role := range s.permsByRole {
+		res = append(res, role)
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+// GetPermissions returns the permissions of the given role, nil if the role
+// doesn't exist.
+func (s *service) GetPermissions(role Role) []Permission {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return slices.Clone(s.permsByRole[role])
+}
+
+// CreateRole adds a new role with the given permissions.
+func (s *service) CreateRole(ctx context.Context, cmd *CreateRoleCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.IsAuthorized(cmd.User, ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("the user doesn't have the authorization %q", ManageUsers))
+	}
+
+	permissions := normalizePermissions(cmd.Permissions)
+
+	// The lock is kept during the storage call so the cache always reflects
+	// the storage.
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.permsByRole[cmd.Role]; ok {
+		return errs.BadRequest(ErrRoleExists, "role already exists")
+	}
+
+	err = s.storage.Save(ctx, &cmd.Role, permissions)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Save: %w", err))
+	}
+
+	s.permsByRole[cmd.Role] = permissions
+
+	return nil
+}
+
+// UpdateRole renames the role and replaces its permissions.
+//
+// The default roles can't be renamed. The users and the two-factor
+// requirement of the role are renamed along with it. The roles named in the
+// configuration, like the invitation quotas or the OpenID Connect group
+// mappings, are not: they must be changed by hand.
+func (s *service) UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.IsAuthorized(cmd.User, ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("the user doesn't have the authorization %q", ManageUsers))
+	}
+
+	if cmd.NewName != cmd.Role && cmd.Role.IsDefault() {
+		return errs.BadRequest(ErrDefaultRole, "default roles can't be renamed")
+	}
+
+	permissions := normalizePermissions(cmd.Permissions)
+
+	if cmd.Role == DefaultAdminRole && !slices.Contains(permissions, ManageUsers) {
+		return errs.BadRequest(ErrAdminLockout, "the admin role must keep the %q permission", ManageUsers)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.permsByRole[cmd.Role]; !ok {
+		return errs.NotFound(ErrRoleNotFound, "role %q not found", cmd.Role)
+	}
+
+	if _, ok := s.permsByRole[cmd.NewName]; ok && cmd.NewName != cmd.Role {
+		return errs.BadRequest(ErrRoleExists, "role already exists")
+	}
+
+	err = s.storage.Update(ctx, &cmd.Role, &cmd.NewName, permissions)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Update: %w", err))
+	}
+
+	delete(s.permsByRole, cmd.Role)
+	s.permsByRole[cmd.NewName] = permissions
+
+	return nil
+}
+
+// DeleteRole removes a role. The default roles and the roles still used by
+// some users can't be deleted. Its two-factor requirement is removed with it.
+func (s *service) DeleteRole(ctx context.Context, cmd *DeleteRoleCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.IsAuthorized(cmd.User, ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("the user doesn't have the authorization %q", ManageUsers))
+	}
+
+	if cmd.Role.IsDefault() {
+		return errs.BadRequest(ErrDefaultRole, "default roles can't be deleted")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err = s.storage.Delete(ctx, &cmd.Role)
+	switch {
+	case err == nil:
+		delete(s.permsByRole, cmd.Role)
+		return nil
+	case errors.Is(err, errNotFound):
+		return errs.NotFound(ErrRoleNotFound, "role %q not found", cmd.Role)
+	case errors.Is(err, errRoleInUse):
+		return errs.BadRequest(ErrRoleInUse, "the role is still used by some users, it can't be deleted")
+	default:
+		return errs.Internal(fmt.Errorf("failed to Delete: %w", err))
+	}
+}
+
+// normalizePermissions sorts the permissions in the [AllPermissions] order
+// and removes the duplicates.
+func normalizePermissions(permissions []Permission) []Permission {
+	res := []Permission{}
+	for _, perm := range AllPermissions {
+		if slices.Contains(permissions, perm) {
+			res = append(res, perm)
+		}
+	}
+
+	return res
+}
+
 func (s *service) createDefaultRoles(ctx context.Context) error {
 	for role, permissions := range DefaultRoles {
 		err := s.storage.Save(ctx, &role, permissions)
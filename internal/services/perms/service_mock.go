@@ -2,13 +2,93 @@
 
 package perms
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockService is an autogenerated mock type for the Service type
 type MockService struct {
 	mock.Mock
 }
 
+// CreateRole provides a mock function with given fields: ctx, cmd
+func (_m *MockService) CreateRole(ctx context.Context, cmd *CreateRoleCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *CreateRoleCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteRole provides a mock function with given fields: ctx, cmd
+func (_m *MockService) DeleteRole(ctx context.Context, cmd *DeleteRoleCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *DeleteRoleCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPermissions provides a mock function with given fields: role
+func (_m *MockService) GetPermissions(role Role) []Permission {
+	ret := _m.Called(role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPermissions")
+	}
+
+	var r0 []Permission
+	if rf, ok := ret.Get(0).(func(Role) []Permission); ok {
+		r0 = rf(role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Permission)
+		}
+	}
+
+	return r0
+}
+
+// GetRoles provides a mock function with given fields:
+func (_m *MockService) GetRoles() []Role {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRoles")
+	}
+
+	var r0 []Role
+	if rf, ok := ret.Get(0).(func() []Role); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Role)
+		}
+	}
+
+	return r0
+}
+
 // IsAuthorized provides a mock function with given fields: withRole, askedPerm
 func (_m *MockService) IsAuthorized(withRole WithRole, askedPerm Permission) bool {
 	ret := _m.Called(withRole, askedPerm)
@@ -27,6 +107,44 @@ func (_m *MockService) IsAuthorized(withRole WithRole, askedPerm Permission) boo
 	return r0
 }
 
+// RolesWith provides a mock function with given fields: perm
+func (_m *MockService) RolesWith(perm Permission) []Role {
+	ret := _m.Called(perm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RolesWith")
+	}
+
+	var r0 []Role
+	if rf, ok := ret.Get(0).(func(Permission) []Role); ok {
+		r0 = rf(perm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Role)
+		}
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: ctx, cmd
+func (_m *MockService) UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UpdateRoleCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
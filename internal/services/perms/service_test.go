@@ -3,9 +3,11 @@ package perms
 import (
 	"context"
 	"fmt"
+	"maps"
 	"testing"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
 	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -59,6 +61,37 @@ func Test_Perms_Service(t *testing.T) {
 		require.False(t, res)
 	})
 
+	t.Run("RolesWith success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+
+		storage.On("GetAll", ctx).Return(DefaultRoles, nil).Once()
+
+		err := svc.bootstrap(ctx)
+		require.NoError(t, err)
+
+		res := svc.RolesWith(Moderation)
+		require.Equal(t, []Role{DefaultAdminRole, DefaultModeratorRole}, res)
+
+		res = svc.RolesWith(Permission("unknown"))
+		require.Empty(t, res)
+	})
+
+	t.Run("GetRoles success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+
+		storage.On("GetAll", ctx).Return(DefaultRoles, nil).Once()
+
+		err := svc.bootstrap(ctx)
+		require.NoError(t, err)
+
+		res := svc.GetRoles()
+		require.Equal(t, []Role{DefaultAdminRole, DefaultModeratorRole, DefaultUserRole}, res)
+	})
+
 	t.Run("boostrap and createDefaultRoles success", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
@@ -87,4 +120,396 @@ func Test_Perms_Service(t *testing.T) {
 		err := svc.createDefaultRoles(ctx)
 		require.ErrorContains(t, err, "some-error")
 	})
+	admin := &resourceWithRole{ptr.To(DefaultAdminRole)}
+	user := &resourceWithRole{ptr.To(DefaultUserRole)}
+
+	t.Run("GetPermissions success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		res := svc.GetPermissions(DefaultModeratorRole)
+		require.Equal(t, DefaultRoles[DefaultModeratorRole], res)
+
+		res = svc.GetPermissions(Role("unknown"))
+		require.Empty(t, res)
+	})
+
+	t.Run("CreateRole success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Mocks
+		storage.On("Save", ctx, ptr.To(Role("editor")), []Permission{UploadPost, ManageSections}).Return(nil).Once()
+
+		// Run
+		err := svc.CreateRole(ctx, &CreateRoleCmd{
+			User:        admin,
+			Role:        Role("editor"),
+			Permissions: []Permission{ManageSections, UploadPost, ManageSections},
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, []Permission{UploadPost, ManageSections}, svc.GetPermissions(Role("editor")))
+	})
+
+	t.Run("CreateRole with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.CreateRole(ctx, &CreateRoleCmd{
+			User:        admin,
+			Role:        Role("editor"),
+			Permissions: []Permission{Permission("unknown")},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("CreateRole without the ManageUsers permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.CreateRole(ctx, &CreateRoleCmd{
+			User:        user,
+			Role:        Role("editor"),
+			Permissions: []Permission{UploadPost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("CreateRole with an existing role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.CreateRole(ctx, &CreateRoleCmd{
+			User:        admin,
+			Role:        DefaultModeratorRole,
+			Permissions: []Permission{UploadPost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrRoleExists)
+	})
+
+	t.Run("CreateRole with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Mocks
+		storage.On("Save", ctx, ptr.To(Role("editor")), []Permission{UploadPost}).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := svc.CreateRole(ctx, &CreateRoleCmd{
+			User:        admin,
+			Role:        Role("editor"),
+			Permissions: []Permission{UploadPost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, svc.GetPermissions(Role("editor")))
+	})
+
+	t.Run("UpdateRole success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+		svc.permsByRole[Role("editor")] = []Permission{UploadPost}
+
+		// Mocks
+		storage.On("Update", ctx, ptr.To(Role("editor")), ptr.To(Role("writer")), []Permission{UploadPost, WriteComment}).Return(nil).Once()
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        Role("editor"),
+			NewName:     Role("writer"),
+			Permissions: []Permission{WriteComment, UploadPost},
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		require.Nil(t, svc.GetPermissions(Role("editor")))
+		require.Equal(t, []Permission{UploadPost, WriteComment}, svc.GetPermissions(Role("writer")))
+	})
+
+	t.Run("UpdateRole the permissions of a default role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Mocks
+		storage.On("Update", ctx, ptr.To(DefaultUserRole), ptr.To(DefaultUserRole), []Permission{VotePost}).Return(nil).Once()
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        DefaultUserRole,
+			NewName:     DefaultUserRole,
+			Permissions: []Permission{VotePost},
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		require.False(t, svc.IsAuthorized(user, UploadPost))
+		require.True(t, svc.IsAuthorized(user, VotePost))
+	})
+
+	t.Run("UpdateRole with a rename of a default role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        DefaultUserRole,
+			NewName:     Role("member"),
+			Permissions: DefaultRoles[DefaultUserRole],
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrDefaultRole)
+	})
+
+	t.Run("UpdateRole removing ManageUsers from the admin role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        DefaultAdminRole,
+			NewName:     DefaultAdminRole,
+			Permissions: []Permission{Moderation},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrAdminLockout)
+	})
+
+	t.Run("UpdateRole without the ManageUsers permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        user,
+			Role:        DefaultUserRole,
+			NewName:     DefaultUserRole,
+			Permissions: AllPermissions,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("UpdateRole with an unknown role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        Role("unknown"),
+			NewName:     Role("unknown"),
+			Permissions: []Permission{UploadPost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrRoleNotFound)
+	})
+
+	t.Run("UpdateRole with a rename to an existing role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+		svc.permsByRole[Role("editor")] = []Permission{UploadPost}
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        Role("editor"),
+			NewName:     DefaultModeratorRole,
+			Permissions: []Permission{UploadPost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrRoleExists)
+	})
+
+	t.Run("UpdateRole with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Mocks
+		storage.On("Update", ctx, ptr.To(DefaultUserRole), ptr.To(DefaultUserRole), []Permission{VotePost}).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := svc.UpdateRole(ctx, &UpdateRoleCmd{
+			User:        admin,
+			Role:        DefaultUserRole,
+			NewName:     DefaultUserRole,
+			Permissions: []Permission{VotePost},
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Equal(t, DefaultRoles[DefaultUserRole], svc.GetPermissions(DefaultUserRole))
+	})
+
+	t.Run("DeleteRole success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+		svc.permsByRole[Role("editor")] = []Permission{UploadPost}
+
+		// Mocks
+		storage.On("Delete", ctx, ptr.To(Role("editor"))).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: admin,
+			Role: Role("editor"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, []Role{DefaultAdminRole, DefaultModeratorRole, DefaultUserRole}, svc.GetRoles())
+	})
+
+	t.Run("DeleteRole with a default role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: admin,
+			Role: DefaultModeratorRole,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrDefaultRole)
+	})
+
+	t.Run("DeleteRole without the ManageUsers permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: user,
+			Role: Role("editor"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("DeleteRole with a role in use", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+		svc.permsByRole[Role("editor")] = []Permission{UploadPost}
+
+		// Mocks
+		storage.On("Delete", ctx, ptr.To(Role("editor"))).Return(errRoleInUse).Once()
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: admin,
+			Role: Role("editor"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrRoleInUse)
+		require.Equal(t, []Permission{UploadPost}, svc.GetPermissions(Role("editor")))
+	})
+
+	t.Run("DeleteRole with an unknown role", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+
+		// Mocks
+		storage.On("Delete", ctx, ptr.To(Role("unknown"))).Return(errNotFound).Once()
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: admin,
+			Role: Role("unknown"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrRoleNotFound)
+	})
+
+	t.Run("DeleteRole with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		svc := newService(tools, storage)
+		svc.permsByRole = maps.Clone(DefaultRoles)
+		svc.permsByRole[Role("editor")] = []Permission{UploadPost}
+
+		// Mocks
+		storage.On("Delete", ctx, ptr.To(Role("editor"))).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := svc.DeleteRole(ctx, &DeleteRoleCmd{
+			User: admin,
+			Role: Role("editor"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
 }
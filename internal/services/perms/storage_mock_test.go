@@ -13,6 +13,24 @@ type mockStorage struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: ctx, role
+func (_m *mockStorage) Delete(ctx context.Context, role *Role) error {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Role) error); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetAll provides a mock function with given fields: ctx
 func (_m *mockStorage) GetAll(ctx context.Context) (map[Role][]Permission, error) {
 	ret := _m.Called(ctx)
@@ -91,6 +109,24 @@ func (_m *mockStorage) Save(ctx context.Context, roles *Role, perms []Permission
 	return r0
 }
 
+// Update provides a mock function with given fields: ctx, role, newName, perms
+func (_m *mockStorage) Update(ctx context.Context, role *Role, newName *Role, perms []Permission) error {
+	ret := _m.Called(ctx, role, newName, perms)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Role, *Role, []Permission) error); ok {
+		r0 = rf(ctx, role, newName, perms)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // newMockStorage creates a new instance of mockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockStorage(t interface {
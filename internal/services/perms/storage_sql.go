@@ -9,6 +9,7 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/mattn/go-sqlite3"
 )
 
 const (
@@ -16,7 +17,10 @@ const (
 	permSeparator = ","
 )
 
-var errNotFound = errors.New("not found")
+var (
+	errNotFound  = errors.New("not found")
+	errRoleInUse = errors.New("role in use")
+)
 
 var allFields = []string{"role", "permissions"}
 
@@ -29,22 +33,10 @@ func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
 }
 
 func (s *sqlStorage) Save(ctx context.Context, role *Role, perms []Permission) error {
-	var rawPerms strings.Builder
-	rawPerms.Grow(len(perms) * 15)
-
-	if len(perms) > 0 {
-		rawPerms.WriteString(string(perms[0]))
-
-		for _, p := range perms[1:] {
-			rawPerms.WriteString(permSeparator)
-			rawPerms.WriteString(string(p))
-		}
-	}
-
 	_, err := sq.
 		Insert(tableName).
 		Columns(allFields...).
-		Values(role, rawPerms.String()).
+		Values(role, formatPermissions(perms)).
 		RunWith(s.db).
 		ExecContext(ctx)
 	if err != nil {
@@ -76,12 +68,7 @@ func (s *sqlStorage) GetAll(ctx context.Context) (map[Role][]Permission, error)
 			return nil, fmt.Errorf("failed to scan a row: %w", err)
 		}
 
-		var permissions []Permission
-		for _, permStr := range strings.Split(rawPerms, permSeparator) {
-			permissions = append(permissions, Permission(permStr))
-		}
-
-		res[role] = permissions
+		res[role] = parsePermissions(rawPerms)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -108,10 +95,101 @@ func (s *sqlStorage) GetPermissions(ctx context.Context, role *Role) ([]Permissi
 		return nil, fmt.Errorf("sql error: %w", err)
 	}
 
-	var permissions []Permission
+	return parsePermissions(rawPerms), nil
+}
+
+// Update replaces the name and the permissions of the given role. The users
+// and the two-factor requirement of the role follow the new name.
+func (s *sqlStorage) Update(ctx context.Context, role *Role, newName *Role, perms []Permission) error {
+	res, err := sq.
+		Update(tableName).
+		SetMap(map[string]any{
+			"role":        newName,
+			"permissions": formatPermissions(perms),
+		}).
+		Where(sq.Eq{"role": role}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return checkAffected(res)
+}
+
+// Delete removes the given role. It returns errRoleInUse if some users still
+// have it.
+func (s *sqlStorage) Delete(ctx context.Context, role *Role) error {
+	res, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"role": role}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if isForeignKeyErr(err) {
+		return errRoleInUse
+	}
+
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return checkAffected(res)
+}
+
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
+
+// isForeignKeyErr returns true if the users table refused the change because
+// some users still reference the role.
+//
+// The "ON DELETE RESTRICT" action is reported with the trigger code instead
+// of the foreign key one.
+func isForeignKeyErr(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey ||
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintTrigger
+}
+
+func formatPermissions(perms []Permission) string {
+	var rawPerms strings.Builder
+	rawPerms.Grow(len(perms) * 15)
+
+	for i, p := range perms {
+		if i > 0 {
+			rawPerms.WriteString(permSeparator)
+		}
+
+		rawPerms.WriteString(string(p))
+	}
+
+	return rawPerms.String()
+}
+
+// parsePermissions is the reverse of formatPermissions. A role without any
+// permission gives an empty list.
+func parsePermissions(rawPerms string) []Permission {
+	permissions := []Permission{}
+	if rawPerms == "" {
+		return permissions
+	}
+
 	for _, permStr := range strings.Split(rawPerms, permSeparator) {
 		permissions = append(permissions, Permission(permStr))
 	}
 
-	return permissions, nil
+	return permissions
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,4 +87,250 @@ func TestRoleSqlStorage(t *testing.T) {
 			*role: permissions,
 		}, res)
 	})
+	t.Run("GetAll with a role without permissions", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).
+			WithName("guest").
+			WithPermissions().
+			BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetAll(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, map[Role][]Permission{
+			*role: {},
+		}, res)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).
+			WithName("editor").
+			WithPermissions(UploadPost).
+			BuildAndStore(ctx, db)
+
+		newName := Role("writer")
+
+		// Run
+		err := store.Update(ctx, role, &newName, []Permission{UploadPost, WriteComment})
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, map[Role][]Permission{
+			newName: {UploadPost, WriteComment},
+		}, res)
+	})
+
+	t.Run("Update not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role := Role("some-invalid-id")
+
+		// Run
+		err := store.Update(ctx, &role, &role, []Permission{UploadPost})
+
+		// Asserts
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("Update with a rename of a role in use", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+		storeUserWithRole(t, db, role)
+
+		newName := Role("writer")
+
+		// Run
+		err := store.Update(ctx, role, &newName, []Permission{UploadPost})
+
+		// Asserts
+		require.NoError(t, err)
+
+		var userRole Role
+		err = sq.
+			Select("role").
+			From("users").
+			Where(sq.Eq{"id": "some-user-id"}).
+			RunWith(db).
+			ScanContext(ctx, &userRole)
+		require.NoError(t, err)
+		assert.Equal(t, newName, userRole)
+	})
+
+	t.Run("Update the permissions of a role in use", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+		storeUserWithRole(t, db, role)
+
+		// Run
+		err := store.Update(ctx, role, role, []Permission{VotePost})
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetPermissions(ctx, role)
+		require.NoError(t, err)
+		require.Equal(t, []Permission{VotePost}, res)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+
+		// Run
+		err := store.Delete(ctx, role)
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetPermissions(ctx, role)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("Update renames the two-factor requirement", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+		storeTwoFactorRequiredRole(t, db, role)
+
+		newName := Role("writer")
+
+		// Run
+		err := store.Update(ctx, role, &newName, []Permission{UploadPost})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []Role{newName}, getTwoFactorRequiredRoles(t, db))
+	})
+
+	t.Run("Delete removes the two-factor requirement", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+		storeTwoFactorRequiredRole(t, db, role)
+
+		// Run
+		err := store.Delete(ctx, role)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Empty(t, getTwoFactorRequiredRoles(t, db))
+	})
+
+	t.Run("Delete not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role := Role("some-invalid-id")
+
+		// Run
+		err := store.Delete(ctx, &role)
+
+		// Asserts
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("Delete a role in use", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := NewFakePermissions(t).WithName("editor").BuildAndStore(ctx, db)
+		storeUserWithRole(t, db, role)
+
+		// Run
+		err := store.Delete(ctx, role)
+
+		// Asserts
+		require.ErrorIs(t, err, errRoleInUse)
+	})
+}
+
+// storeUserWithRole inserts a minimal user row. The users package can't be
+// imported here as it depends on this one.
+func storeUserWithRole(t *testing.T, db sqlstorage.Querier, role *Role) {
+	t.Helper()
+
+	avatar := medias.NewFakeFileMeta(t).BuildAndStore(context.Background(), db)
+
+	_, err := sq.
+		Insert("users").
+		Columns("id", "username", "password", "role", "status", "password_changed_at", "avatar", "created_at", "created_by").
+		Values("some-user-id", "some-username", "some-password", role, "active", "2024-01-01", avatar.ID(), "2024-01-01", "some-user-id").
+		RunWith(db).
+		Exec()
+	require.NoError(t, err)
+}
+
+// storeTwoFactorRequiredRole makes the two-factor authentication mandatory
+// for the role. The twofactor package can't be imported here as it depends
+// on this one.
+func storeTwoFactorRequiredRole(t *testing.T, db sqlstorage.Querier, role *Role) {
+	t.Helper()
+
+	_, err := sq.
+		Insert("two_factor_required_roles").
+		Columns("role", "created_at").
+		Values(role, "2024-01-01").
+		RunWith(db).
+		Exec()
+	require.NoError(t, err)
+}
+
+func getTwoFactorRequiredRoles(t *testing.T, db sqlstorage.Querier) []Role {
+	t.Helper()
+
+	rows, err := sq.
+		Select("role").
+		From("two_factor_required_roles").
+		RunWith(db).
+		Query()
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := []Role{}
+	for rows.Next() {
+		var role Role
+		require.NoError(t, rows.Scan(&role))
+		res = append(res, role)
+	}
+
+	require.NoError(t, rows.Err())
+
+	return res
 }
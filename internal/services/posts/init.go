@@ -3,6 +3,7 @@ package posts
 import (
 	"context"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/users"
@@ -15,12 +16,18 @@ type Service interface {
 	GetLatestPost(ctx context.Context) (*Post, error)
 	GetByID(ctx context.Context, postID uint) (*Post, error)
 	SetPostStatus(ctx context.Context, post *Post, status Status) error
-	GetPosts(ctx context.Context, start uint, nbPosts uint) ([]Post, error)
+	GetFeed(ctx context.Context, cmd *GetFeedCmd) ([]Post, *FeedCursor, error)
+	GetFeedVersion(ctx context.Context, cmd *GetFeedVersionCmd) (*FeedVersion, error)
+	RefreshRanks(ctx context.Context) error
 	GetNextPostToModerate(ctx context.Context) (*Post, error)
 	CountPostsWaitingModeration(ctx context.Context) (int, error)
 	GetUserStats(ctx context.Context, user *users.User) (map[Status]int, error)
+	GetUserPosts(ctx context.Context, cmd *GetUserPostsCmd) ([]Post, error)
 	SuscribeToNewPost() <-chan Post
 	ValidatePost(ctx context.Context, cmd *ValidatePostcmd) error
+	SetTags(ctx context.Context, cmd *SetTagsCmd) error
+	AddVotes(ctx context.Context, post *Post, upvotes int, downvotes int) error
+	Reassign(ctx context.Context, from, to *users.User) error
 }
 
 func Init(
@@ -28,8 +35,9 @@ func Init(
 	db sqlstorage.Querier,
 	mediasSvc medias.Service,
 	permsSvc perms.Service,
+	bansSvc bans.Service,
 ) Service {
 	storage := newSqlStorage(db)
 
-	return newService(tools, storage, mediasSvc, permsSvc)
+	return newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 }
@@ -1,10 +1,18 @@
 package posts
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	v "github.com/go-ozzo/ozzo-validation"
 )
@@ -17,6 +25,55 @@ const (
 
 type Status string
 
+const (
+	Hot      Feed = "hot"
+	Trending Feed = "trending"
+	Fresh    Feed = "fresh"
+)
+
+// Feed is a way to rank the listed posts:
+//   - Hot: score with a time decay, the older the post is the higher the score
+//     must be to stay at the top.
+//   - Trending: votes received during the last 24 hours.
+//   - Fresh: the most recent posts first.
+//
+// The Hot and Trending ranks are computed every [RanksRefreshInterval], so a
+// new post can take some time to appear in those feeds.
+type Feed string
+
+var Feeds = []Feed{Hot, Trending, Fresh}
+
+const MaxTags = 5
+
+// TagRegexp is the format of a normalized tag: lowercase letters and digits
+// separated by single dashes.
+var TagRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+var tagRules = []v.Rule{v.Required, v.Length(2, 30), v.Match(TagRegexp)}
+
+// ParseTags splits a comma separated list of tags typed by a user and
+// normalizes them.
+func ParseTags(input string) []string {
+	return NormalizeTags(strings.Split(input, ","))
+}
+
+// NormalizeTags lowercases the tags, replaces the spaces with dashes and
+// removes the empty and duplicated entries.
+func NormalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" || slices.Contains(res, tag) {
+			continue
+		}
+
+		res = append(res, tag)
+	}
+
+	return res
+}
+
 type Post struct {
 	id        uint
 	status    Status
@@ -24,6 +81,10 @@ type Post struct {
 	fileID    uuid.UUID
 	createdAt time.Time
 	createdBy uuid.UUID
+	upvotes   int
+	downvotes int
+	tags      []string
+	listedAt  *time.Time
 }
 
 func (p Post) ID() uint             { return p.id }
@@ -32,11 +93,20 @@ func (p Post) Title() string        { return p.title }
 func (p Post) FileID() uuid.UUID    { return p.fileID }
 func (p Post) CreatedAt() time.Time { return p.createdAt }
 func (p Post) CreatedBy() uuid.UUID { return p.createdBy }
+func (p Post) Upvotes() int         { return p.upvotes }
+func (p Post) Downvotes() int       { return p.downvotes }
+func (p Post) Score() int           { return p.upvotes - p.downvotes }
+func (p Post) Tags() []string       { return p.tags }
+
+// ListedAt returns the date of the last listing of the post by the
+// moderation, nil if it has never been listed.
+func (p Post) ListedAt() *time.Time { return p.listedAt }
 
 type CreateCmd struct {
 	Title     string
 	Media     io.Reader
 	CreatedBy *users.User
+	Tags      []string
 }
 
 func (t CreateCmd) Validate() error {
@@ -44,6 +114,21 @@ func (t CreateCmd) Validate() error {
 		v.Field(&t.Title, v.Required, v.Length(3, 280)),
 		v.Field(&t.CreatedBy, v.Required),
 		v.Field(&t.Media, v.Required),
+		v.Field(&t.Tags, v.Length(0, MaxTags), v.Each(tagRules...)),
+	)
+}
+
+type SetTagsCmd struct {
+	User *users.User
+	Post *Post
+	Tags []string
+}
+
+func (t SetTagsCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Post, v.Required),
+		v.Field(&t.Tags, v.Length(0, MaxTags), v.Each(tagRules...)),
 	)
 }
 
@@ -51,3 +136,114 @@ type ValidatePostcmd struct {
 	User *users.User
 	Post *Post
 }
+
+// FeedCursor is the position of the last post seen inside a feed.
+//
+// The Hot and Trending ranks are read from the ranks saved at the At date,
+// and the Fresh feed ignores the posts created after it. This way the posts
+// order stays the same between two pages.
+type FeedCursor struct {
+	At     time.Time
+	Rank   float64
+	PostID uint
+}
+
+var (
+	// ErrInvalidCursor is returned when an encoded cursor can't be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrExpiredCursor is returned when the ranks used by a cursor have been
+	// removed, the feed must be restarted from the first page.
+	ErrExpiredCursor = errors.New("expired cursor")
+)
+
+type feedCursorJSON struct {
+	At     time.Time `json:"a"`
+	Rank   float64   `json:"r"`
+	PostID uint      `json:"p"`
+}
+
+// Encode returns the cursor as an opaque and url safe string. It is the only
+// representation of the cursor given to the clients.
+func (c FeedCursor) Encode() string {
+	// The marshaling can't fail, all the fields are basic types.
+	raw, _ := json.Marshal(feedCursorJSON(c))
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeFeedCursor parses a cursor generated by FeedCursor.Encode.
+func DecodeFeedCursor(encoded string) (*FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var res feedCursorJSON
+	err = json.Unmarshal(raw, &res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	if res.At.IsZero() || res.PostID == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return ptr.To(FeedCursor(res)), nil
+}
+
+// GetFeedCmd retrieves a feed page. An empty Tag retrieves the posts of
+// all the tags and a nil Author the posts of all the users.
+type GetFeedCmd struct {
+	Feed   Feed
+	Tag    string
+	Author *users.User
+	Cursor *FeedCursor
+	Limit  uint
+}
+
+func (t GetFeedCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Feed, v.Required, v.In(Hot, Trending, Fresh)),
+		v.Field(&t.Tag, v.Length(2, 30), v.Match(TagRegexp)),
+		v.Field(&t.Limit, v.Required),
+	)
+}
+
+// GetFeedVersionCmd retrieves the version of the feed of the given Tag and
+// Author, with the same zero values as [GetFeedCmd].
+type GetFeedVersionCmd struct {
+	Tag    string
+	Author *users.User
+}
+
+func (t GetFeedVersionCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Tag, v.Length(2, 30), v.Match(TagRegexp)),
+	)
+}
+
+// FeedVersion identifies the listed posts of a feed without loading them: it
+// changes each time a post is listed or leaves the feed.
+type FeedVersion struct {
+	Count        int
+	LastPostID   uint
+	LastListedAt time.Time
+}
+
+// GetUserPostsCmd retrieves a page of the posts of a user. A zero BeforeID
+// retrieves the first page, otherwise it must be the id of the last post of
+// the previous page.
+type GetUserPostsCmd struct {
+	User     *users.User
+	Status   Status
+	BeforeID uint
+	Limit    uint
+}
+
+func (t GetUserPostsCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Status, v.Required, v.In(Uploaded, Listed, Moderated)),
+		v.Field(&t.Limit, v.Required),
+	)
+}
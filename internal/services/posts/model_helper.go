@@ -33,6 +33,7 @@ func NewFakePost(t testing.TB) *FakePostBuilder {
 			fileID:    uuidProvider.New(),
 			createdAt: createdAt,
 			createdBy: uuidProvider.New(),
+			tags:      []string{},
 		},
 	}
 }
@@ -43,18 +44,49 @@ func (f *FakePostBuilder) WithMedia(media *medias.FileMeta) *FakePostBuilder {
 	return f
 }
 
+func (f *FakePostBuilder) WithTitle(title string) *FakePostBuilder {
+	f.post.title = title
+
+	return f
+}
+
 func (f *FakePostBuilder) WithStatus(status Status) *FakePostBuilder {
 	f.post.status = status
 
 	return f
 }
 
+func (f *FakePostBuilder) WithVotes(upvotes int, downvotes int) *FakePostBuilder {
+	f.post.upvotes = upvotes
+	f.post.downvotes = downvotes
+
+	return f
+}
+
+func (f *FakePostBuilder) WithTags(tags ...string) *FakePostBuilder {
+	f.post.tags = tags
+
+	return f
+}
+
+func (f *FakePostBuilder) CreatedAt(at time.Time) *FakePostBuilder {
+	f.post.createdAt = at
+
+	return f
+}
+
 func (f *FakePostBuilder) CreatedBy(user *users.User) *FakePostBuilder {
 	f.post.createdBy = user.ID()
 
 	return f
 }
 
+func (f *FakePostBuilder) ListedAt(at time.Time) *FakePostBuilder {
+	f.post.listedAt = &at
+
+	return f
+}
+
 func (f *FakePostBuilder) Build() *Post {
 	return f.post
 }
@@ -2,7 +2,9 @@ package posts
 
 import (
 	"bytes"
+	"encoding/base64"
 	"testing"
+	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -19,6 +21,26 @@ func Test_Post_Getters(t *testing.T) {
 	assert.Equal(t, p.fileID, p.FileID())
 	assert.Equal(t, p.createdAt, p.CreatedAt())
 	assert.Equal(t, p.createdBy, p.CreatedBy())
+	assert.Equal(t, p.upvotes, p.Upvotes())
+	assert.Equal(t, p.downvotes, p.Downvotes())
+	assert.Equal(t, p.tags, p.Tags())
+}
+
+func Test_ParseTags(t *testing.T) {
+	assert.Equal(t, []string{"cats", "funny-animals"}, ParseTags("Cats, funny animals,,"))
+	assert.Empty(t, ParseTags(""))
+}
+
+func Test_NormalizeTags(t *testing.T) {
+	res := NormalizeTags([]string{"Cats", "  funny   Animals ", "cats", "", "  "})
+
+	assert.Equal(t, []string{"cats", "funny-animals"}, res)
+}
+
+func Test_Post_Score(t *testing.T) {
+	p := NewFakePost(t).WithVotes(12, 5).Build()
+
+	assert.Equal(t, 7, p.Score())
 }
 
 func Test_CreateCmd_is_validatable(t *testing.T) {
@@ -37,3 +59,98 @@ func Test_CreateCmd_Validate_success(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func Test_SetTagsCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(SetTagsCmd))
+}
+
+func Test_SetTagsCmd_Validate_success(t *testing.T) {
+	err := SetTagsCmd{
+		User: users.NewFakeUser(t).Build(),
+		Post: NewFakePost(t).Build(),
+		Tags: []string{"cats", "funny-animals"},
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_SetTagsCmd_Validate_with_an_invalid_tag(t *testing.T) {
+	err := SetTagsCmd{
+		User: users.NewFakeUser(t).Build(),
+		Post: NewFakePost(t).Build(),
+		Tags: []string{"Not A Tag!"},
+	}.Validate()
+
+	require.Error(t, err)
+}
+
+func Test_GetFeedCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(GetFeedCmd))
+}
+
+func Test_GetFeedCmd_Validate_success(t *testing.T) {
+	err := GetFeedCmd{
+		Feed:   Trending,
+		Cursor: nil,
+		Limit:  10,
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_GetFeedCmd_Validate_with_an_unknown_feed(t *testing.T) {
+	err := GetFeedCmd{
+		Feed:   Feed("unknown"),
+		Cursor: nil,
+		Limit:  10,
+	}.Validate()
+
+	require.EqualError(t, err, "Feed: must be a valid value.")
+}
+
+func Test_FeedCursor_Encode_and_Decode(t *testing.T) {
+	cursor := FeedCursor{
+		At:     time.Date(2024, 8, 1, 10, 0, 0, 123456789, time.UTC),
+		Rank:   0.1234567890123,
+		PostID: 42,
+	}
+
+	res, err := DecodeFeedCursor(cursor.Encode())
+
+	require.NoError(t, err)
+	assert.Equal(t, &cursor, res)
+}
+
+func Test_DecodeFeedCursor_with_invalid_inputs(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":"2024-08-01T10:00:00Z","r":1}`)),
+	} {
+		res, err := DecodeFeedCursor(input)
+
+		assert.Nil(t, res, input)
+		require.ErrorIs(t, err, ErrInvalidCursor, input)
+	}
+}
+
+func Test_GetUserPostsCmd_Validate_success(t *testing.T) {
+	err := GetUserPostsCmd{
+		User:   users.NewFakeUser(t).Build(),
+		Status: Listed,
+		Limit:  10,
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_GetUserPostsCmd_Validate_with_an_unknown_status(t *testing.T) {
+	err := GetUserPostsCmd{
+		User:   users.NewFakeUser(t).Build(),
+		Status: Status("unknown"),
+		Limit:  10,
+	}.Validate()
+
+	require.EqualError(t, err, "Status: must be a valid value.")
+}
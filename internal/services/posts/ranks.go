@@ -0,0 +1,33 @@
+package posts
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/periodic"
+	"go.uber.org/fx"
+)
+
+const (
+	// RanksRefreshInterval is the delay between two computations of the Hot
+	// and Trending ranks.
+	RanksRefreshInterval = 5 * time.Minute
+	// RanksRetention is how long the ranks are kept, it limits how long a
+	// feed can be scrolled with the same cursor.
+	RanksRetention = time.Hour
+	// RanksMaxRows is the number of ranks kept per feed. The most recent
+	// ranks are always kept, whatever their number.
+	RanksMaxRows = 100_000
+)
+
+// RunRanksJob computes periodically the ranks of the posts for as long as
+// the application is running.
+func RunRanksJob(lc fx.Lifecycle, svc Service, tools tools.Tools) {
+	periodic.Register(lc, tools.Logger(), periodic.Job{
+		Name:     "posts-ranks",
+		Interval: RanksRefreshInterval,
+		// The saved ranks are outdated after a restart.
+		RunAtStart: true,
+		Run:        svc.RefreshRanks,
+	})
+}
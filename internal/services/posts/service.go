@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/clock"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
@@ -26,28 +30,40 @@ type storage interface {
 	Save(ctx context.Context, post *Post) error
 	GetLatestPostWithStatus(ctx context.Context, status Status) (*Post, error)
 	GetOldestPostWithStatus(ctx context.Context, status Status) (*Post, error)
-	GetListedPosts(ctx context.Context, start uint, limit uint) ([]Post, error)
+	GetFeedPosts(ctx context.Context, feed Feed, filter *feedFilter, cursor *FeedCursor, limit uint) ([]Post, *FeedCursor, error)
+	GetFeedVersion(ctx context.Context, filter *feedFilter) (*FeedVersion, error)
+	SaveRanks(ctx context.Context, feed Feed, at time.Time) error
+	GetLastRankedAt(ctx context.Context, feed Feed) (time.Time, error)
+	HasRanks(ctx context.Context, feed Feed, at time.Time) (bool, error)
+	RemoveRanksBefore(ctx context.Context, before time.Time) error
+	RemoveRanksBeyond(ctx context.Context, feed Feed, maxRows int) error
 	GetByID(ctx context.Context, postID uint) (*Post, error)
 	CountPostsWithStatus(ctx context.Context, status Status) (int, error)
 	CountUserPostsByStatus(ctx context.Context, userID uuid.UUID, status Status) (int, error)
+	GetUserPostsByStatus(ctx context.Context, userID uuid.UUID, status Status, beforeID uint, limit uint) ([]Post, error)
 	Update(ctx context.Context, post *Post) error
+	UpdateTags(ctx context.Context, post *Post) error
+	AddVotes(ctx context.Context, postID uint, upvotes int, downvotes int) error
+	UpdateCreator(ctx context.Context, from, to uuid.UUID) error
 }
 
 type service struct {
 	storage      storage
 	mediasSvc    medias.Service
 	permsSvc     perms.Service
+	bansSvc      bans.Service
 	clock        clock.Clock
 	uuid         uuid.Service
 	newPostChans []chan Post
 	l            *sync.Mutex
 }
 
-func newService(tools tools.Tools, posts storage, mediasSvc medias.Service, permsSvc perms.Service) *service {
+func newService(tools tools.Tools, posts storage, mediasSvc medias.Service, permsSvc perms.Service, bansSvc bans.Service) *service {
 	svc := &service{
 		storage:      posts,
 		mediasSvc:    mediasSvc,
 		permsSvc:     permsSvc,
+		bansSvc:      bansSvc,
 		clock:        tools.Clock(),
 		uuid:         tools.UUID(),
 		newPostChans: make([]chan Post, 0),
@@ -63,6 +79,9 @@ func (s *service) SetPostStatus(ctx context.Context, post *Post, status Status)
 	}
 
 	post.status = status
+	if status == Listed {
+		post.listedAt = ptr.To(s.clock.Now())
+	}
 
 	err := s.storage.Update(ctx, post)
 	if err != nil {
@@ -101,6 +120,7 @@ func (s *service) ValidatePost(ctx context.Context, cmd *ValidatePostcmd) error
 	}
 
 	cmd.Post.status = Listed
+	cmd.Post.listedAt = ptr.To(s.clock.Now())
 
 	err := s.storage.Update(ctx, cmd.Post)
 	if err != nil {
@@ -110,12 +130,49 @@ func (s *service) ValidatePost(ctx context.Context, cmd *ValidatePostcmd) error
 	return nil
 }
 
+// SetTags replaces the post tags. Only the moderators are allowed to edit
+// the tags.
+func (s *service) SetTags(ctx context.Context, cmd *SetTagsCmd) error {
+	cmd.Tags = NormalizeTags(cmd.Tags)
+
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.Moderation) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.Moderation))
+	}
+
+	if slices.Equal(cmd.Post.tags, cmd.Tags) {
+		return nil
+	}
+
+	cmd.Post.tags = cmd.Tags
+
+	err = s.storage.UpdateTags(ctx, cmd.Post)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateTags for post %d: %w", cmd.Post.id, err))
+	}
+
+	return nil
+}
+
 func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Post, error) {
+	cmd.Tags = NormalizeTags(cmd.Tags)
+
 	err := cmd.Validate()
 	if err != nil {
 		return nil, errs.Validation(err)
 	}
 
+	// The banned users are logged out but they can still have a request in
+	// flight.
+	err = s.bansSvc.EnsureNotBanned(ctx, cmd.CreatedBy.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to EnsureNotBanned: %w", err)
+	}
+
 	meta, err := s.mediasSvc.Upload(ctx, medias.Post, cmd.Media)
 	if err != nil {
 		return nil, errs.Internal(fmt.Errorf("failed to upload the media: %w", err))
@@ -133,6 +190,7 @@ func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Post, error) {
 		fileID:    meta.ID(),
 		createdBy: cmd.CreatedBy.ID(),
 		createdAt: s.clock.Now(),
+		tags:      cmd.Tags,
 	}
 
 	err = s.storage.Save(ctx, &post)
@@ -179,6 +237,26 @@ func (s *service) GetUserStats(ctx context.Context, user *users.User) (map[Statu
 	return stats, nil
 }
 
+// GetUserPosts returns a page of the user posts with the given status, the
+// most recent first.
+func (s *service) GetUserPosts(ctx context.Context, cmd *GetUserPostsCmd) ([]Post, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	if cmd.Limit > maxPostBatchSize {
+		return nil, errs.Validation(ErrToMuchPostsAsked)
+	}
+
+	res, err := s.storage.GetUserPostsByStatus(ctx, cmd.User.ID(), cmd.Status, cmd.BeforeID, cmd.Limit)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetUserPostsByStatus: %w", err))
+	}
+
+	return res, nil
+}
+
 func (s *service) GetLatestPost(ctx context.Context) (*Post, error) {
 	res, err := s.storage.GetLatestPostWithStatus(ctx, Listed)
 	if errors.Is(err, errNotFound) {
@@ -201,15 +279,144 @@ func (s *service) CountPostsWaitingModeration(ctx context.Context) (int, error)
 	return res, nil
 }
 
-func (s *service) GetPosts(ctx context.Context, start uint, nbPosts uint) ([]Post, error) {
-	if nbPosts > maxPostBatchSize {
-		return nil, errs.Validation(ErrToMuchPostsAsked)
+// GetFeed returns a page of the listed posts ranked for the given feed.
+//
+// A nil cursor returns the first page. The returned cursor must be used in
+// order to fetch the next page, it is nil once the end of the feed is reached.
+func (s *service) GetFeed(ctx context.Context, cmd *GetFeedCmd) ([]Post, *FeedCursor, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, nil, errs.Validation(err)
+	}
+
+	if cmd.Limit > maxPostBatchSize {
+		return nil, nil, errs.Validation(ErrToMuchPostsAsked)
+	}
+
+	filter := feedFilter{tag: cmd.Tag}
+	if cmd.Author != nil {
+		filter.createdBy = cmd.Author.ID()
+	}
+
+	cursor := cmd.Cursor
+	switch {
+	case cursor == nil && cmd.Feed == Fresh:
+		cursor = &FeedCursor{At: s.clock.Now()}
+	case cursor == nil:
+		at, err := s.storage.GetLastRankedAt(ctx, cmd.Feed)
+		if errors.Is(err, errNotFound) {
+			// The ranks are only saved by RunRanksJob. Until the first ones,
+			// the posts are served in the Fresh order, on a single page.
+			res, _, err := s.storage.GetFeedPosts(ctx, Fresh, &filter, &FeedCursor{At: s.clock.Now()}, cmd.Limit)
+			if err != nil {
+				return nil, nil, errs.Internal(fmt.Errorf("failed to GetFeedPosts: %w", err))
+			}
+
+			return res, nil, nil
+		}
+
+		if err != nil {
+			return nil, nil, errs.Internal(fmt.Errorf("failed to GetLastRankedAt: %w", err))
+		}
+
+		cursor = &FeedCursor{At: at}
+	case cmd.Feed != Fresh:
+		// The ranks used by the previous pages are removed after
+		// [RanksRetention] or earlier once the feed has [RanksMaxRows] ranks.
+		exists, err := s.storage.HasRanks(ctx, cmd.Feed, cursor.At)
+		if err != nil {
+			return nil, nil, errs.Internal(fmt.Errorf("failed to HasRanks: %w", err))
+		}
+
+		if !exists {
+			return nil, nil, errs.Validation(ErrExpiredCursor)
+		}
 	}
 
-	res, err := s.storage.GetListedPosts(ctx, start, nbPosts)
+	res, next, err := s.storage.GetFeedPosts(ctx, cmd.Feed, &filter, cursor, cmd.Limit)
 	if err != nil {
-		return nil, errs.Internal(fmt.Errorf("failed to GetAll: %w", err))
+		return nil, nil, errs.Internal(fmt.Errorf("failed to GetFeedPosts: %w", err))
+	}
+
+	if uint(len(res)) < cmd.Limit {
+		next = nil
+	}
+
+	return res, next, nil
+}
+
+// GetFeedVersion returns the version of a feed. It is far cheaper than
+// GetFeed and allows to detect if a feed has changed.
+func (s *service) GetFeedVersion(ctx context.Context, cmd *GetFeedVersionCmd) (*FeedVersion, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	filter := feedFilter{tag: cmd.Tag}
+	if cmd.Author != nil {
+		filter.createdBy = cmd.Author.ID()
+	}
+
+	res, err := s.storage.GetFeedVersion(ctx, &filter)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetFeedVersion: %w", err))
 	}
 
 	return res, nil
 }
+
+// RefreshRanks saves the current rank of the listed posts for the ranked
+// feeds and removes the ranks older than [RanksRetention]. The oldest ranks
+// are also removed once a feed has more than [RanksMaxRows] ranks.
+func (s *service) RefreshRanks(ctx context.Context) error {
+	now := s.clock.Now()
+
+	for _, feed := range []Feed{Hot, Trending} {
+		err := s.storage.SaveRanks(ctx, feed, now)
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to SaveRanks for %q: %w", feed, err))
+		}
+
+		err = s.storage.RemoveRanksBeyond(ctx, feed, RanksMaxRows)
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to RemoveRanksBeyond for %q: %w", feed, err))
+		}
+	}
+
+	err := s.storage.RemoveRanksBefore(ctx, now.Add(-RanksRetention))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveRanksBefore: %w", err))
+	}
+
+	return nil
+}
+
+// AddVotes increments the vote counters of the given post by the given deltas.
+//
+// The deltas can be negative in order to retract some votes.
+func (s *service) AddVotes(ctx context.Context, post *Post, upvotes int, downvotes int) error {
+	if upvotes == 0 && downvotes == 0 {
+		return nil
+	}
+
+	err := s.storage.AddVotes(ctx, post.id, upvotes, downvotes)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to AddVotes to the post %d: %w", post.id, err))
+	}
+
+	post.upvotes += upvotes
+	post.downvotes += downvotes
+
+	return nil
+}
+
+// Reassign transfers all the posts created by the user "from" to the user "to".
+func (s *service) Reassign(ctx context.Context, from, to *users.User) error {
+	err := s.storage.UpdateCreator(ctx, from.ID(), to.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateCreator: %w", err))
+	}
+
+	return nil
+}
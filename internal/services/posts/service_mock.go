@@ -14,6 +14,24 @@ type MockService struct {
 	mock.Mock
 }
 
+// AddVotes provides a mock function with given fields: ctx, post, upvotes, downvotes
+func (_m *MockService) AddVotes(ctx context.Context, post *Post, upvotes int, downvotes int) error {
+	ret := _m.Called(ctx, post, upvotes, downvotes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddVotes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Post, int, int) error); ok {
+		r0 = rf(ctx, post, upvotes, downvotes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CountPostsWaitingModeration provides a mock function with given fields: ctx
 func (_m *MockService) CountPostsWaitingModeration(ctx context.Context) (int, error) {
 	ret := _m.Called(ctx)
@@ -102,6 +120,75 @@ func (_m *MockService) GetByID(ctx context.Context, postID uint) (*Post, error)
 	return r0, r1
 }
 
+// GetFeed provides a mock function with given fields: ctx, cmd
+func (_m *MockService) GetFeed(ctx context.Context, cmd *GetFeedCmd) ([]Post, *FeedCursor, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFeed")
+	}
+
+	var r0 []Post
+	var r1 *FeedCursor
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *GetFeedCmd) ([]Post, *FeedCursor, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *GetFeedCmd) []Post); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *GetFeedCmd) *FeedCursor); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*FeedCursor)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *GetFeedCmd) error); ok {
+		r2 = rf(ctx, cmd)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetFeedVersion provides a mock function with given fields: ctx, cmd
+func (_m *MockService) GetFeedVersion(ctx context.Context, cmd *GetFeedVersionCmd) (*FeedVersion, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFeedVersion")
+	}
+
+	var r0 *FeedVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *GetFeedVersionCmd) (*FeedVersion, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *GetFeedVersionCmd) *FeedVersion); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*FeedVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *GetFeedVersionCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLatestPost provides a mock function with given fields: ctx
 func (_m *MockService) GetLatestPost(ctx context.Context) (*Post, error) {
 	ret := _m.Called(ctx)
@@ -162,29 +249,29 @@ func (_m *MockService) GetNextPostToModerate(ctx context.Context) (*Post, error)
 	return r0, r1
 }
 
-// GetPosts provides a mock function with given fields: ctx, start, nbPosts
-func (_m *MockService) GetPosts(ctx context.Context, start uint, nbPosts uint) ([]Post, error) {
-	ret := _m.Called(ctx, start, nbPosts)
+// GetUserPosts provides a mock function with given fields: ctx, cmd
+func (_m *MockService) GetUserPosts(ctx context.Context, cmd *GetUserPostsCmd) ([]Post, error) {
+	ret := _m.Called(ctx, cmd)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPosts")
+		panic("no return value specified for GetUserPosts")
 	}
 
 	var r0 []Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) ([]Post, error)); ok {
-		return rf(ctx, start, nbPosts)
+	if rf, ok := ret.Get(0).(func(context.Context, *GetUserPostsCmd) ([]Post, error)); ok {
+		return rf(ctx, cmd)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []Post); ok {
-		r0 = rf(ctx, start, nbPosts)
+	if rf, ok := ret.Get(0).(func(context.Context, *GetUserPostsCmd) []Post); ok {
+		r0 = rf(ctx, cmd)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
-		r1 = rf(ctx, start, nbPosts)
+	if rf, ok := ret.Get(1).(func(context.Context, *GetUserPostsCmd) error); ok {
+		r1 = rf(ctx, cmd)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -222,6 +309,42 @@ func (_m *MockService) GetUserStats(ctx context.Context, user *users.User) (map[
 	return r0, r1
 }
 
+// Reassign provides a mock function with given fields: ctx, from, to
+func (_m *MockService) Reassign(ctx context.Context, from *users.User, to *users.User) error {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reassign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *users.User, *users.User) error); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RefreshRanks provides a mock function with given fields: ctx
+func (_m *MockService) RefreshRanks(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshRanks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetPostStatus provides a mock function with given fields: ctx, post, status
 func (_m *MockService) SetPostStatus(ctx context.Context, post *Post, status Status) error {
 	ret := _m.Called(ctx, post, status)
@@ -240,6 +363,24 @@ func (_m *MockService) SetPostStatus(ctx context.Context, post *Post, status Sta
 	return r0
 }
 
+// SetTags provides a mock function with given fields: ctx, cmd
+func (_m *MockService) SetTags(ctx context.Context, cmd *SetTagsCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTags")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *SetTagsCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SuscribeToNewPost provides a mock function with given fields:
 func (_m *MockService) SuscribeToNewPost() <-chan Post {
 	ret := _m.Called()
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,7 +26,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		mediaContent := strings.NewReader("some-content")
 
@@ -34,6 +38,7 @@ func Test_Posts_Service(t *testing.T) {
 		postWithoutID := post
 		postWithoutID.id = 0
 
+		bansSvc.On("EnsureNotBanned", ctx, user.ID()).Return(nil).Once()
 		mediasSvc.On("Upload", ctx, medias.Post, mediaContent).Return(fileMeta, nil).Once()
 		tools.ClockMock.On("Now").Return(post.CreatedAt).Once()
 		storage.On("Save", ctx, postWithoutID).Return(nil).Once()
@@ -47,12 +52,66 @@ func Test_Posts_Service(t *testing.T) {
 		require.Equal(t, post, res)
 	})
 
+	t.Run("Create with some tags", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		mediaContent := strings.NewReader("some-content")
+
+		fileMeta := medias.NewFakeFileMeta(t).Build()
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).CreatedBy(user).WithMedia(fileMeta).WithTags("cats", "funny-animals").Build()
+
+		postWithoutID := post
+		postWithoutID.id = 0
+
+		bansSvc.On("EnsureNotBanned", ctx, user.ID()).Return(nil).Once()
+		mediasSvc.On("Upload", ctx, medias.Post, mediaContent).Return(fileMeta, nil).Once()
+		tools.ClockMock.On("Now").Return(post.CreatedAt).Once()
+		storage.On("Save", ctx, postWithoutID).Return(nil).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			Title:     post.title,
+			Media:     mediaContent,
+			CreatedBy: user,
+			Tags:      []string{"Cats", " Funny Animals ", "cats", ""},
+		})
+		require.NoError(t, err)
+		require.Equal(t, post, res)
+	})
+
+	t.Run("Create with an invalid tag", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		mediaContent := strings.NewReader("some-content")
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			Title:     "some title",
+			Media:     mediaContent,
+			CreatedBy: user,
+			Tags:      []string{"not/valid"},
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+	})
+
 	t.Run("Create with a validation error", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		user := users.NewFakeUser(t).Build()
 		mediaContent := strings.NewReader("some-content")
@@ -66,16 +125,41 @@ func Test_Posts_Service(t *testing.T) {
 		require.Nil(t, res)
 	})
 
+	t.Run("Create with a banned user", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		mediaContent := strings.NewReader("some-content")
+		user := users.NewFakeUser(t).Build()
+
+		bansSvc.On("EnsureNotBanned", ctx, user.ID()).Return(errs.Unauthorized(bans.ErrBanned)).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			Title:     "Some title",
+			Media:     mediaContent,
+			CreatedBy: user,
+		})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, bans.ErrBanned)
+		require.Nil(t, res)
+	})
+
 	t.Run("Create with a media.Upload error", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		mediaContent := strings.NewReader("some-content")
 		user := users.NewFakeUser(t).Build()
 
+		bansSvc.On("EnsureNotBanned", ctx, user.ID()).Return(nil).Once()
 		mediasSvc.On("Upload", ctx, medias.Post, mediaContent).Return(nil, fmt.Errorf("some-error")).Once()
 
 		res, err := svc.Create(ctx, &CreateCmd{
@@ -93,7 +177,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		mediaContent := strings.NewReader("some-content")
 
@@ -104,6 +189,7 @@ func Test_Posts_Service(t *testing.T) {
 		postWithoutID := post
 		postWithoutID.id = 0
 
+		bansSvc.On("EnsureNotBanned", ctx, user.ID()).Return(nil).Once()
 		mediasSvc.On("Upload", ctx, medias.Post, mediaContent).Return(fileMeta, nil).Once()
 		tools.ClockMock.On("Now").Return(post.CreatedAt).Once()
 		storage.On("Save", ctx, postWithoutID).Return(fmt.Errorf("some-error")).Once()
@@ -123,7 +209,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).Build()
 
@@ -139,7 +226,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetByID", ctx, uint(32)).Return(nil, errNotFound).Once()
 
@@ -153,7 +241,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetByID", ctx, uint(32)).Return(nil, fmt.Errorf("some-error")).Once()
 
@@ -168,7 +257,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).Build()
 
@@ -184,7 +274,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetOldestPostWithStatus", ctx, Uploaded).Return(nil, errNotFound).Once()
 
@@ -198,7 +289,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetOldestPostWithStatus", ctx, Uploaded).Return(nil, fmt.Errorf("some-error")).Once()
 
@@ -213,7 +305,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		user := users.NewFakeUser(t).Build()
 
@@ -235,7 +328,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		user := users.NewFakeUser(t).Build()
 
@@ -248,12 +342,78 @@ func Test_Posts_Service(t *testing.T) {
 		require.Nil(t, res)
 	})
 
+	t.Run("GetUserPosts success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).Build()
+
+		storage.On("GetUserPostsByStatus", ctx, user.ID(), Listed, uint(42), uint(10)).Return([]Post{*post}, nil).Once()
+
+		res, err := svc.GetUserPosts(ctx, &GetUserPostsCmd{
+			User:     user,
+			Status:   Listed,
+			BeforeID: 42,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post}, res)
+	})
+
+	t.Run("GetUserPosts with too much posts asked", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		res, err := svc.GetUserPosts(ctx, &GetUserPostsCmd{
+			User:   user,
+			Status: Listed,
+			Limit:  maxPostBatchSize + 1,
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.ErrorIs(t, err, ErrToMuchPostsAsked)
+		require.Nil(t, res)
+	})
+
+	t.Run("GetUserPosts with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		storage.On("GetUserPostsByStatus", ctx, user.ID(), Listed, uint(0), uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, err := svc.GetUserPosts(ctx, &GetUserPostsCmd{
+			User:   user,
+			Status: Listed,
+			Limit:  10,
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
 	t.Run("GetLatestPost success", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).Build()
 
@@ -269,7 +429,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetLatestPostWithStatus", ctx, Listed).Return(nil, errNotFound).Once()
 
@@ -283,7 +444,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("GetLatestPostWithStatus", ctx, Listed).Return(nil, fmt.Errorf("some-error")).Once()
 
@@ -298,7 +460,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("CountPostsWithStatus", ctx, Uploaded).Return(32, nil).Once()
 
@@ -312,7 +475,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		storage.On("CountPostsWithStatus", ctx, Uploaded).Return(0, fmt.Errorf("some-error")).Once()
 
@@ -322,46 +486,369 @@ func Test_Posts_Service(t *testing.T) {
 		require.Equal(t, 0, res)
 	})
 
-	t.Run("GetPosts success", func(t *testing.T) {
+	t.Run("GetFeed success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		posts := make([]Post, 3)
+		for i := range 3 {
+			posts[i] = *NewFakePost(t).Build()
+		}
+		next := &FeedCursor{At: now, Rank: 1.4, PostID: posts[2].id}
+
+		storage.On("GetLastRankedAt", ctx, Hot).Return(now, nil).Once()
+		storage.On("GetFeedPosts", ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, uint(3)).Return(posts, next, nil).Once()
+
+		res, resNext, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Hot, Cursor: nil, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, posts, res)
+		require.Equal(t, next, resNext)
+	})
+
+	t.Run("GetFeed without any ranks saved falls back to the fresh order", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		posts := make([]Post, 3)
+		for i := range 3 {
+			posts[i] = *NewFakePost(t).Build()
+		}
+
+		storage.On("GetLastRankedAt", ctx, Hot).Return(time.Time{}, errNotFound).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("GetFeedPosts", ctx, Fresh, &feedFilter{}, &FeedCursor{At: now}, uint(3)).
+			Return(posts, &FeedCursor{At: now, PostID: posts[2].id}, nil).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Hot, Cursor: nil, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, posts, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with an expired cursor", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		cursor := &FeedCursor{At: now.Add(-time.Hour), Rank: 1.2, PostID: 12}
+
+		storage.On("HasRanks", ctx, Hot, cursor.At).Return(false, nil).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Hot, Cursor: cursor, Limit: 3})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.ErrorIs(t, err, ErrExpiredCursor)
+		require.Nil(t, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with a ranked cursor", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
+		now := time.Now()
 		posts := make([]Post, 3)
 		for i := range 3 {
 			posts[i] = *NewFakePost(t).Build()
 		}
+		cursor := &FeedCursor{At: now.Add(-time.Hour), Rank: 1.2, PostID: 12}
+		next := &FeedCursor{At: cursor.At, Rank: 0.4, PostID: posts[2].id}
+
+		storage.On("HasRanks", ctx, Hot, cursor.At).Return(true, nil).Once()
+		storage.On("GetFeedPosts", ctx, Hot, &feedFilter{}, cursor, uint(3)).Return(posts, next, nil).Once()
+
+		res, resNext, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Hot, Cursor: cursor, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, posts, res)
+		require.Equal(t, next, resNext)
+	})
 
-		storage.On("GetListedPosts", ctx, uint(200), uint(3)).Return(posts, nil).Once()
+	t.Run("GetFeed with a cursor", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
-		res, err := svc.GetPosts(ctx, 200, 3)
+		cursor := &FeedCursor{At: time.Now(), Rank: 12, PostID: 12}
+		posts := []Post{*NewFakePost(t).Build()}
+
+		storage.On("GetFeedPosts", ctx, Fresh, &feedFilter{}, cursor, uint(3)).
+			Return(posts, &FeedCursor{At: cursor.At, Rank: 11, PostID: 11}, nil).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Fresh, Cursor: cursor, Limit: 3})
 		require.NoError(t, err)
 		require.Equal(t, posts, res)
+		require.Nil(t, next) // Less posts than asked, this is the end of the feed.
+	})
+
+	t.Run("GetFeed with a tag", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		posts := []Post{*NewFakePost(t).WithTags("cats").Build()}
+
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("GetFeedPosts", ctx, Fresh, &feedFilter{tag: "cats"}, &FeedCursor{At: now}, uint(3)).
+			Return(posts, &FeedCursor{At: now, Rank: 11, PostID: 11}, nil).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Fresh, Tag: "cats", Cursor: nil, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, posts, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with an author", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		posts := []Post{*NewFakePost(t).CreatedBy(user).Build()}
+
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("GetFeedPosts", ctx, Fresh, &feedFilter{createdBy: user.ID()}, &FeedCursor{At: now}, uint(3)).
+			Return(posts, &FeedCursor{At: now, Rank: 11, PostID: 11}, nil).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Fresh, Author: user, Cursor: nil, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, posts, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Feed("unknown"), Cursor: nil, Limit: 3})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with too much posts asked", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Hot, Cursor: nil, Limit: maxPostBatchSize + 1})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.ErrorIs(t, err, ErrToMuchPostsAsked)
+		require.Nil(t, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeed with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+
+		storage.On("GetLastRankedAt", ctx, Trending).Return(now, nil).Once()
+		storage.On("GetFeedPosts", ctx, Trending, &feedFilter{}, &FeedCursor{At: now}, uint(3)).Return(nil, nil, fmt.Errorf("some-error")).Once()
+
+		res, next, err := svc.GetFeed(ctx, &GetFeedCmd{Feed: Trending, Cursor: nil, Limit: 3})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+		require.Nil(t, next)
+	})
+
+	t.Run("GetFeedVersion success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		author := users.NewFakeUser(t).Build()
+		version := &FeedVersion{Count: 2, LastPostID: 12, LastListedAt: time.Now()}
+
+		storage.On("GetFeedVersion", ctx, &feedFilter{tag: "cats", createdBy: author.ID()}).Return(version, nil).Once()
+
+		res, err := svc.GetFeedVersion(ctx, &GetFeedVersionCmd{Tag: "cats", Author: author})
+		require.NoError(t, err)
+		require.Equal(t, version, res)
 	})
 
-	t.Run("GetPosts with a storage error", func(t *testing.T) {
+	t.Run("GetFeedVersion with a storage error", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
-		storage.On("GetListedPosts", ctx, uint(200), uint(3)).Return(nil, fmt.Errorf("some-error")).Once()
+		storage.On("GetFeedVersion", ctx, &feedFilter{}).Return(nil, fmt.Errorf("some-error")).Once()
 
-		res, err := svc.GetPosts(ctx, 200, 3)
+		res, err := svc.GetFeedVersion(ctx, &GetFeedVersionCmd{})
 		require.ErrorIs(t, err, errs.ErrInternal)
 		require.ErrorContains(t, err, "some-error")
 		require.Nil(t, res)
 	})
 
+	t.Run("RefreshRanks success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("SaveRanks", ctx, Hot, now).Return(nil).Once()
+		storage.On("RemoveRanksBeyond", ctx, Hot, RanksMaxRows).Return(nil).Once()
+		storage.On("SaveRanks", ctx, Trending, now).Return(nil).Once()
+		storage.On("RemoveRanksBeyond", ctx, Trending, RanksMaxRows).Return(nil).Once()
+		storage.On("RemoveRanksBefore", ctx, now.Add(-RanksRetention)).Return(nil).Once()
+
+		err := svc.RefreshRanks(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("SetTags success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).WithTags("cats").Build()
+
+		expected := *post
+		expected.tags = []string{"dogs", "funny-animals"}
+
+		permsSvc.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		storage.On("UpdateTags", ctx, &expected).Return(nil).Once()
+
+		err := svc.SetTags(ctx, &SetTagsCmd{
+			User: user,
+			Post: post,
+			Tags: []string{"Dogs", "funny animals"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"dogs", "funny-animals"}, post.Tags())
+	})
+
+	t.Run("SetTags with the same tags", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).WithTags("cats").Build()
+
+		permsSvc.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+
+		err := svc.SetTags(ctx, &SetTagsCmd{User: user, Post: post, Tags: []string{"cats"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("SetTags with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).Build()
+
+		err := svc.SetTags(ctx, &SetTagsCmd{User: user, Post: post, Tags: []string{"a", "b", "c", "d", "e", "f"}})
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("SetTags without the moderation permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.Moderation).Return(false).Once()
+
+		err := svc.SetTags(ctx, &SetTagsCmd{User: user, Post: post, Tags: []string{"cats"}})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.Empty(t, post.Tags())
+	})
+
+	t.Run("SetTags with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := NewFakePost(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		storage.On("UpdateTags", ctx, post).Return(fmt.Errorf("some-error")).Once()
+
+		err := svc.SetTags(ctx, &SetTagsCmd{User: user, Post: post, Tags: []string{"cats"}})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
 	t.Run("SetPostStatus success", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).WithStatus(Uploaded).Build()
 		postWithNewStatus := *post
@@ -373,12 +860,35 @@ func Test_Posts_Service(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("SetPostStatus to Listed saves the listing date", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		now := time.Now()
+		post := NewFakePost(t).WithStatus(Uploaded).Build()
+		postWithNewStatus := *post
+		postWithNewStatus.status = Listed
+		postWithNewStatus.listedAt = &now
+
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Update", ctx, &postWithNewStatus).Return(nil).Once()
+
+		err := svc.SetPostStatus(ctx, post, Listed)
+		require.NoError(t, err)
+		require.Equal(t, &now, post.ListedAt())
+	})
+
 	t.Run("SetPostStatus with the same status", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).WithStatus(Uploaded).Build()
 
@@ -393,7 +903,8 @@ func Test_Posts_Service(t *testing.T) {
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
 		permsSvc := perms.NewMockService(t)
-		svc := newService(tools, storage, mediasSvc, permsSvc)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
 
 		post := NewFakePost(t).WithStatus(Uploaded).Build()
 		postWithNewStatus := *post
@@ -405,4 +916,90 @@ func Test_Posts_Service(t *testing.T) {
 		require.ErrorIs(t, err, errs.ErrInternal)
 		require.ErrorContains(t, err, "some-error")
 	})
+
+	t.Run("AddVotes success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		post := NewFakePost(t).WithStatus(Listed).WithVotes(10, 2).Build()
+
+		storage.On("AddVotes", ctx, post.ID(), 1, -1).Return(nil).Once()
+
+		err := svc.AddVotes(ctx, post, 1, -1)
+		require.NoError(t, err)
+		require.Equal(t, 11, post.Upvotes())
+		require.Equal(t, 1, post.Downvotes())
+	})
+
+	t.Run("AddVotes without any change", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		post := NewFakePost(t).WithStatus(Listed).WithVotes(10, 2).Build()
+
+		// Nothing to change so do nothing
+
+		err := svc.AddVotes(ctx, post, 0, 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("AddVotes with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		post := NewFakePost(t).WithStatus(Listed).WithVotes(10, 2).Build()
+
+		storage.On("AddVotes", ctx, post.ID(), 1, 0).Return(fmt.Errorf("some-error")).Once()
+
+		err := svc.AddVotes(ctx, post, 1, 0)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Equal(t, 10, post.Upvotes())
+	})
+	t.Run("Reassign success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(nil).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reassign with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		bansSvc := bans.NewMockService(t)
+		svc := newService(tools, storage, mediasSvc, permsSvc, bansSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(assert.AnError).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
 }
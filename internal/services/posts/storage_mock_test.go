@@ -4,9 +4,11 @@ package posts
 
 import (
 	context "context"
+	time "time"
 
-	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
 // mockStorage is an autogenerated mock type for the storage type
@@ -14,6 +16,24 @@ type mockStorage struct {
 	mock.Mock
 }
 
+// AddVotes provides a mock function with given fields: ctx, postID, upvotes, downvotes
+func (_m *mockStorage) AddVotes(ctx context.Context, postID uint, upvotes int, downvotes int) error {
+	ret := _m.Called(ctx, postID, upvotes, downvotes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddVotes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, int, int) error); ok {
+		r0 = rf(ctx, postID, upvotes, downvotes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CountPostsWithStatus provides a mock function with given fields: ctx, status
 func (_m *mockStorage) CountPostsWithStatus(ctx context.Context, status Status) (int, error) {
 	ret := _m.Called(ctx, status)
@@ -100,29 +120,68 @@ func (_m *mockStorage) GetByID(ctx context.Context, postID uint) (*Post, error)
 	return r0, r1
 }
 
-// GetLatestPostWithStatus provides a mock function with given fields: ctx, status
-func (_m *mockStorage) GetLatestPostWithStatus(ctx context.Context, status Status) (*Post, error) {
-	ret := _m.Called(ctx, status)
+// GetFeedPosts provides a mock function with given fields: ctx, feed, filter, cursor, limit
+func (_m *mockStorage) GetFeedPosts(ctx context.Context, feed Feed, filter *feedFilter, cursor *FeedCursor, limit uint) ([]Post, *FeedCursor, error) {
+	ret := _m.Called(ctx, feed, filter, cursor, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLatestPostWithStatus")
+		panic("no return value specified for GetFeedPosts")
 	}
 
-	var r0 *Post
+	var r0 []Post
+	var r1 *FeedCursor
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, *feedFilter, *FeedCursor, uint) ([]Post, *FeedCursor, error)); ok {
+		return rf(ctx, feed, filter, cursor, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, *feedFilter, *FeedCursor, uint) []Post); ok {
+		r0 = rf(ctx, feed, filter, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, Feed, *feedFilter, *FeedCursor, uint) *FeedCursor); ok {
+		r1 = rf(ctx, feed, filter, cursor, limit)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*FeedCursor)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, Feed, *feedFilter, *FeedCursor, uint) error); ok {
+		r2 = rf(ctx, feed, filter, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetFeedVersion provides a mock function with given fields: ctx, filter
+func (_m *mockStorage) GetFeedVersion(ctx context.Context, filter *feedFilter) (*FeedVersion, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFeedVersion")
+	}
+
+	var r0 *FeedVersion
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, Status) (*Post, error)); ok {
-		return rf(ctx, status)
+	if rf, ok := ret.Get(0).(func(context.Context, *feedFilter) (*FeedVersion, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, Status) *Post); ok {
-		r0 = rf(ctx, status)
+	if rf, ok := ret.Get(0).(func(context.Context, *feedFilter) *FeedVersion); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*Post)
+			r0 = ret.Get(0).(*FeedVersion)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, Status) error); ok {
-		r1 = rf(ctx, status)
+	if rf, ok := ret.Get(1).(func(context.Context, *feedFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -130,29 +189,57 @@ func (_m *mockStorage) GetLatestPostWithStatus(ctx context.Context, status Statu
 	return r0, r1
 }
 
-// GetListedPosts provides a mock function with given fields: ctx, start, limit
-func (_m *mockStorage) GetListedPosts(ctx context.Context, start uint, limit uint) ([]Post, error) {
-	ret := _m.Called(ctx, start, limit)
+// GetLastRankedAt provides a mock function with given fields: ctx, feed
+func (_m *mockStorage) GetLastRankedAt(ctx context.Context, feed Feed) (time.Time, error) {
+	ret := _m.Called(ctx, feed)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetListedPosts")
+		panic("no return value specified for GetLastRankedAt")
 	}
 
-	var r0 []Post
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, Feed) (time.Time, error)); ok {
+		return rf(ctx, feed)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, Feed) time.Time); ok {
+		r0 = rf(ctx, feed)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, Feed) error); ok {
+		r1 = rf(ctx, feed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestPostWithStatus provides a mock function with given fields: ctx, status
+func (_m *mockStorage) GetLatestPostWithStatus(ctx context.Context, status Status) (*Post, error) {
+	ret := _m.Called(ctx, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLatestPostWithStatus")
+	}
+
+	var r0 *Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) ([]Post, error)); ok {
-		return rf(ctx, start, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, Status) (*Post, error)); ok {
+		return rf(ctx, status)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []Post); ok {
-		r0 = rf(ctx, start, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, Status) *Post); ok {
+		r0 = rf(ctx, status)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]Post)
+			r0 = ret.Get(0).(*Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
-		r1 = rf(ctx, start, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, Status) error); ok {
+		r1 = rf(ctx, status)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -190,6 +277,100 @@ func (_m *mockStorage) GetOldestPostWithStatus(ctx context.Context, status Statu
 	return r0, r1
 }
 
+// GetUserPostsByStatus provides a mock function with given fields: ctx, userID, status, beforeID, limit
+func (_m *mockStorage) GetUserPostsByStatus(ctx context.Context, userID uuid.UUID, status Status, beforeID uint, limit uint) ([]Post, error) {
+	ret := _m.Called(ctx, userID, status, beforeID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserPostsByStatus")
+	}
+
+	var r0 []Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, Status, uint, uint) ([]Post, error)); ok {
+		return rf(ctx, userID, status, beforeID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, Status, uint, uint) []Post); ok {
+		r0 = rf(ctx, userID, status, beforeID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, Status, uint, uint) error); ok {
+		r1 = rf(ctx, userID, status, beforeID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasRanks provides a mock function with given fields: ctx, feed, at
+func (_m *mockStorage) HasRanks(ctx context.Context, feed Feed, at time.Time) (bool, error) {
+	ret := _m.Called(ctx, feed, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasRanks")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, time.Time) (bool, error)); ok {
+		return rf(ctx, feed, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, time.Time) bool); ok {
+		r0 = rf(ctx, feed, at)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, Feed, time.Time) error); ok {
+		r1 = rf(ctx, feed, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveRanksBefore provides a mock function with given fields: ctx, before
+func (_m *mockStorage) RemoveRanksBefore(ctx context.Context, before time.Time) error {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveRanksBefore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveRanksBeyond provides a mock function with given fields: ctx, feed, maxRows
+func (_m *mockStorage) RemoveRanksBeyond(ctx context.Context, feed Feed, maxRows int) error {
+	ret := _m.Called(ctx, feed, maxRows)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveRanksBeyond")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, int) error); ok {
+		r0 = rf(ctx, feed, maxRows)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: ctx, post
 func (_m *mockStorage) Save(ctx context.Context, post *Post) error {
 	ret := _m.Called(ctx, post)
@@ -208,6 +389,24 @@ func (_m *mockStorage) Save(ctx context.Context, post *Post) error {
 	return r0
 }
 
+// SaveRanks provides a mock function with given fields: ctx, feed, at
+func (_m *mockStorage) SaveRanks(ctx context.Context, feed Feed, at time.Time) error {
+	ret := _m.Called(ctx, feed, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRanks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Feed, time.Time) error); ok {
+		r0 = rf(ctx, feed, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, post
 func (_m *mockStorage) Update(ctx context.Context, post *Post) error {
 	ret := _m.Called(ctx, post)
@@ -226,6 +425,42 @@ func (_m *mockStorage) Update(ctx context.Context, post *Post) error {
 	return r0
 }
 
+// UpdateCreator provides a mock function with given fields: ctx, from, to
+func (_m *mockStorage) UpdateCreator(ctx context.Context, from uuid.UUID, to uuid.UUID) error {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCreator")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTags provides a mock function with given fields: ctx, post
+func (_m *mockStorage) UpdateTags(ctx context.Context, post *Post) error {
+	ret := _m.Called(ctx, post)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTags")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Post) error); ok {
+		r0 = rf(ctx, post)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // newMockStorage creates a new instance of mockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockStorage(t interface {
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Peltoche/onlyfun/internal/tools/ptr"
@@ -12,11 +14,20 @@ import (
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
-const tableName = "posts"
+const (
+	tableName      = "posts"
+	tagsTableName  = "post_tags"
+	ranksTableName = "feed_ranks"
+	tagSeparator   = ","
+)
 
 var errNotFound = errors.New("not found")
 
-var allFields = []string{"id", "status", "title", "file_id", "created_at", "created_by"}
+var allFields = []string{"id", "status", "title", "file_id", "created_at", "created_by", "upvotes", "downvotes", "tags", "listed_at"}
+
+// postFields are the allFields prefixed by the table name, for the queries
+// with some joins.
+var postFields = prefixFields(tableName, allFields)
 
 type sqlStorage struct {
 	db sqlstorage.Querier
@@ -37,7 +48,11 @@ func (s *sqlStorage) Save(ctx context.Context, p *Post) error {
 			p.title,
 			p.fileID,
 			ptr.To(sqlstorage.SQLTime(p.createdAt)),
-			p.createdBy).
+			p.createdBy,
+			p.upvotes,
+			p.downvotes,
+			strings.Join(p.tags, tagSeparator),
+			listedAtValue(p)).
 		Suffix("RETURNING \"id\"").
 		RunWith(s.db).
 		ScanContext(ctx, &id)
@@ -47,12 +62,20 @@ func (s *sqlStorage) Save(ctx context.Context, p *Post) error {
 
 	p.id = id
 
+	// XXX:MULTI-WRITE
+	err = s.saveTagsIndex(ctx, p)
+	if err != nil {
+		return fmt.Errorf("failed to save the tags index: %w", err)
+	}
+
 	return nil
 }
 
 func (s *sqlStorage) GetByID(ctx context.Context, postID uint) (*Post, error) {
 	var res Post
 	var sqlCreatedAt sqlstorage.SQLTime
+	var rawTags string
+	var sqlListedAt *sqlstorage.SQLTime
 
 	err := sq.Select(allFields...).
 		From(tableName).
@@ -64,7 +87,11 @@ func (s *sqlStorage) GetByID(ctx context.Context, postID uint) (*Post, error) {
 			&res.title,
 			&res.fileID,
 			&sqlCreatedAt,
-			&res.createdBy)
+			&res.createdBy,
+			&res.upvotes,
+			&res.downvotes,
+			&rawTags,
+			&sqlListedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errNotFound
 	}
@@ -74,29 +101,301 @@ func (s *sqlStorage) GetByID(ctx context.Context, postID uint) (*Post, error) {
 	}
 
 	res.createdAt = sqlCreatedAt.Time()
+	res.tags = splitTags(rawTags)
+	if sqlListedAt != nil {
+		res.listedAt = ptr.To(sqlListedAt.Time())
+	}
 
 	return &res, nil
 }
 
-func (s *sqlStorage) GetListedPosts(ctx context.Context, start uint, limit uint) ([]Post, error) {
-	rows, err := sq.
-		Select(allFields...).
-		From(tableName).
-		Where(
-			sq.And{
-				sq.LtOrEq{"id": start},
-				sq.Eq{"status": Listed},
-			},
-		).
-		OrderBy("id DESC").
+// feedFilter restricts a feed to some posts. The zero values keep all the
+// posts.
+type feedFilter struct {
+	tag       string
+	createdBy uuid.UUID
+}
+
+// GetFeedPosts returns the listed posts ranked for the given feed, starting
+// right after the cursor position. The returned cursor points to the last
+// returned post.
+//
+// The Hot and Trending posts are read from the ranks saved at the cursor date
+// by SaveRanks, this way the votes received between two pages don't change
+// the order.
+func (s *sqlStorage) GetFeedPosts(ctx context.Context, feed Feed, filter *feedFilter, cursor *FeedCursor, limit uint) ([]Post, *FeedCursor, error) {
+	var query sq.SelectBuilder
+
+	switch feed {
+	case Hot, Trending:
+		// Use the idx_feed_ranks_feed_ranked_at_rank_post_id index.
+		query = sq.
+			Select(postFields...).
+			Column(ranksTableName+".rank").
+			From(ranksTableName).
+			Join(tableName+" ON "+tableName+".id = "+ranksTableName+".post_id").
+			Where(sq.Eq{
+				ranksTableName + ".feed":      feed,
+				ranksTableName + ".ranked_at": ptr.To(sqlstorage.SQLTime(cursor.At)),
+			}).
+			OrderBy(ranksTableName+".rank DESC", ranksTableName+".post_id DESC")
+
+		if cursor.PostID != 0 {
+			query = query.Where(sq.Or{
+				sq.Lt{ranksTableName + ".rank": cursor.Rank},
+				sq.And{
+					sq.Eq{ranksTableName + ".rank": cursor.Rank},
+					sq.Lt{ranksTableName + ".post_id": cursor.PostID},
+				},
+			})
+		}
+	default:
+		// The ids are incremental, ordering by id gives the chronological
+		// order. Use the idx_posts_status_id index.
+		query = sq.
+			Select(postFields...).
+			Column("CAST(" + tableName + ".id AS REAL)").
+			From(tableName).
+			Where(sq.Expr("julianday("+tableName+".created_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(cursor.At)))).
+			OrderBy(tableName + ".id DESC")
+
+		if cursor.PostID != 0 {
+			query = query.Where(sq.Lt{tableName + ".id": cursor.PostID})
+		}
+	}
+
+	query = query.Where(sq.Eq{tableName + ".status": Listed})
+
+	if filter.tag != "" {
+		query = query.
+			Join(tagsTableName + " ON " + tagsTableName + ".post_id = " + tableName + ".id").
+			Where(sq.Eq{tagsTableName + ".tag": filter.tag})
+	}
+
+	if filter.createdBy != "" {
+		query = query.Where(sq.Eq{tableName + ".created_by": filter.createdBy})
+	}
+
+	rows, err := query.
 		Limit(uint64(limit)).
 		RunWith(s.db).
 		QueryContext(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	next := FeedCursor{At: cursor.At}
+
+	for rows.Next() {
+		var res Post
+		var sqlCreatedAt sqlstorage.SQLTime
+		var rawTags string
+		var sqlListedAt *sqlstorage.SQLTime
+
+		err := rows.Scan(&res.id,
+			&res.status,
+			&res.title,
+			&res.fileID,
+			&sqlCreatedAt,
+			&res.createdBy,
+			&res.upvotes,
+			&res.downvotes,
+			&rawTags,
+			&sqlListedAt,
+			&next.Rank)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan a row: %w", err)
+		}
+
+		res.createdAt = sqlCreatedAt.Time()
+		res.tags = splitTags(rawTags)
+		if sqlListedAt != nil {
+			res.listedAt = ptr.To(sqlListedAt.Time())
+		}
+		next.PostID = res.id
+
+		posts = append(posts, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return posts, &next, nil
+}
+
+// GetFeedVersion returns the number of listed posts matching the filter, the
+// highest id and the most recent listing date among them.
+func (s *sqlStorage) GetFeedVersion(ctx context.Context, filter *feedFilter) (*FeedVersion, error) {
+	var res FeedVersion
+	var lastPostID *uint
+	var lastListedAt *sqlstorage.SQLTime
+
+	query := sq.
+		Select("COUNT(*)", "MAX("+tableName+".id)", "MAX("+tableName+".listed_at)").
+		From(tableName).
+		Where(sq.Eq{tableName + ".status": Listed})
+
+	if filter.tag != "" {
+		query = query.
+			Join(tagsTableName + " ON " + tagsTableName + ".post_id = " + tableName + ".id").
+			Where(sq.Eq{tagsTableName + ".tag": filter.tag})
+	}
+
+	if filter.createdBy != "" {
+		query = query.Where(sq.Eq{tableName + ".created_by": filter.createdBy})
+	}
+
+	err := query.
+		RunWith(s.db).
+		ScanContext(ctx, &res.Count, &lastPostID, &lastListedAt)
 	if err != nil {
 		return nil, fmt.Errorf("sql error: %w", err)
 	}
 
-	return s.scanRows(rows)
+	if lastPostID != nil {
+		res.LastPostID = *lastPostID
+	}
+
+	if lastListedAt != nil {
+		res.LastListedAt = lastListedAt.Time()
+	}
+
+	return &res, nil
+}
+
+// SaveRanks computes the rank of all the listed posts inside the given feed
+// at the given date and saves them for GetFeedPosts.
+func (s *sqlStorage) SaveRanks(ctx context.Context, feed Feed, at time.Time) error {
+	sqlAt := ptr.To(sqlstorage.SQLTime(at))
+
+	query := sq.
+		Select().
+		Column("?", feed).
+		Column("?", sqlAt).
+		Column("id").
+		From(tableName).
+		Where(sq.Eq{"status": Listed}).
+		Where(sq.Expr("julianday(created_at) <= julianday(?)", sqlAt))
+
+	switch feed {
+	case Hot:
+		// The score is divided by the square of the post age in hours. The
+		// age starts at 2 hours in order to not over promote the new posts.
+		// A negative score is multiplied instead, so that the downvoted posts
+		// keep sinking with the time instead of getting back to zero.
+		age := "((julianday(?) - julianday(created_at)) * 24 + 2)"
+
+		query = query.Column(fmt.Sprintf(`CASE WHEN upvotes >= downvotes
+			THEN CAST(upvotes - downvotes AS REAL) / (%[1]s * %[1]s)
+			ELSE CAST(upvotes - downvotes AS REAL) * (%[1]s * %[1]s) END`, age), sqlAt, sqlAt, sqlAt, sqlAt)
+	case Trending:
+		// The votes are retrieved from the votes table because the posts
+		// table only contains the all time counters.
+		query = query.
+			Column("CAST(COALESCE(trends.score, 0) AS REAL)").
+			LeftJoin(`(SELECT post_id, SUM(value) AS score FROM votes
+			WHERE julianday(voted_at) > julianday(?) - 1
+			AND julianday(voted_at) <= julianday(?)
+			GROUP BY post_id) AS trends ON trends.post_id = posts.id`, sqlAt, sqlAt)
+	default:
+		return fmt.Errorf("the %q feed has no rank", feed)
+	}
+
+	_, err := sq.
+		Insert(ranksTableName).
+		Columns("feed", "ranked_at", "post_id", "rank").
+		Select(query).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// GetLastRankedAt returns the date of the last ranks saved for the given
+// feed.
+func (s *sqlStorage) GetLastRankedAt(ctx context.Context, feed Feed) (time.Time, error) {
+	var res *sqlstorage.SQLTime
+
+	err := sq.
+		Select("MAX(ranked_at)").
+		From(ranksTableName).
+		Where(sq.Eq{"feed": feed}).
+		RunWith(s.db).
+		ScanContext(ctx, &res)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("sql error: %w", err)
+	}
+
+	if res == nil {
+		return time.Time{}, errNotFound
+	}
+
+	return res.Time(), nil
+}
+
+// HasRanks returns true if the ranks of the feed saved at the given date are
+// still there.
+func (s *sqlStorage) HasRanks(ctx context.Context, feed Feed, at time.Time) (bool, error) {
+	var res bool
+
+	err := sq.
+		Select("COUNT(*) > 0").
+		From(ranksTableName).
+		Where(sq.Eq{"feed": feed}).
+		Where(sq.Expr("julianday(ranked_at) = julianday(?)", ptr.To(sqlstorage.SQLTime(at)))).
+		Limit(1).
+		RunWith(s.db).
+		ScanContext(ctx, &res)
+	if err != nil {
+		return false, fmt.Errorf("sql error: %w", err)
+	}
+
+	return res, nil
+}
+
+// RemoveRanksBefore removes the ranks saved before the given date.
+func (s *sqlStorage) RemoveRanksBefore(ctx context.Context, before time.Time) error {
+	_, err := sq.
+		Delete(ranksTableName).
+		Where(sq.Expr("julianday(ranked_at) < julianday(?)", ptr.To(sqlstorage.SQLTime(before)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveRanksBeyond removes the oldest ranks of the feed once it has more than
+// maxRows ranks. The ranks saved at the same date are removed together and
+// the most recent ones are always kept.
+func (s *sqlStorage) RemoveRanksBeyond(ctx context.Context, feed Feed, maxRows int) error {
+	_, err := sq.
+		Delete(ranksTableName).
+		Where(sq.Eq{"feed": feed}).
+		Where(`ranked_at NOT IN (
+			SELECT ranked_at FROM (
+				SELECT ranked_at, SUM(COUNT(*)) OVER (ORDER BY julianday(ranked_at) DESC) AS total,
+					ROW_NUMBER() OVER (ORDER BY julianday(ranked_at) DESC) AS position
+				FROM feed_ranks
+				WHERE feed = ?
+				GROUP BY ranked_at
+			) WHERE total <= ? OR position = 1
+		)`, feed, maxRows).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
 }
 
 func (s *sqlStorage) CountPostsWithStatus(ctx context.Context, status Status) (int, error) {
@@ -107,6 +406,47 @@ func (s *sqlStorage) CountUserPostsByStatus(ctx context.Context, userID uuid.UUI
 	return s.countByKeys(ctx, sq.Eq{"created_by": userID, "status": status})
 }
 
+// GetUserPostsByStatus returns the posts of the given user with the given
+// status, the most recent first. Only the posts with an id lower than
+// beforeID are returned, 0 returns the most recent posts.
+func (s *sqlStorage) GetUserPostsByStatus(ctx context.Context, userID uuid.UUID, status Status, beforeID uint, limit uint) ([]Post, error) {
+	// Use the idx_posts_created_by_status index.
+	query := sq.Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"created_by": userID, "status": status})
+
+	if beforeID != 0 {
+		query = query.Where(sq.Lt{"id": beforeID})
+	}
+
+	rows, err := query.
+		OrderBy("id DESC").
+		Limit(uint64(limit)).
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Post{}
+
+	for rows.Next() {
+		post, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
 func (s *sqlStorage) GetOldestPostWithStatus(ctx context.Context, status Status) (*Post, error) {
 	row := sq.Select(allFields...).
 		From(tableName).
@@ -134,6 +474,8 @@ func (s *sqlStorage) GetLatestPostWithStatus(ctx context.Context, status Status)
 func (s *sqlStorage) scanRow(row sq.RowScanner) (*Post, error) {
 	var res Post
 	var sqlCreatedAt sqlstorage.SQLTime
+	var rawTags string
+	var sqlListedAt *sqlstorage.SQLTime
 
 	err := row.Scan(
 		&res.id,
@@ -142,6 +484,10 @@ func (s *sqlStorage) scanRow(row sq.RowScanner) (*Post, error) {
 		&res.fileID,
 		&sqlCreatedAt,
 		&res.createdBy,
+		&res.upvotes,
+		&res.downvotes,
+		&rawTags,
+		&sqlListedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errNotFound
@@ -152,6 +498,10 @@ func (s *sqlStorage) scanRow(row sq.RowScanner) (*Post, error) {
 	}
 
 	res.createdAt = sqlCreatedAt.Time()
+	res.tags = splitTags(rawTags)
+	if sqlListedAt != nil {
+		res.listedAt = ptr.To(sqlListedAt.Time())
+	}
 
 	return &res, nil
 }
@@ -159,8 +509,9 @@ func (s *sqlStorage) scanRow(row sq.RowScanner) (*Post, error) {
 func (s *sqlStorage) Update(ctx context.Context, post *Post) error {
 	_, err := sq.Update(tableName).
 		SetMap(map[string]any{
-			"status":  post.status,
-			"file_id": post.fileID,
+			"status":    post.status,
+			"file_id":   post.fileID,
+			"listed_at": listedAtValue(post),
 		}).
 		Where(sq.Eq{"id": post.id}).
 		RunWith(s.db).
@@ -172,6 +523,67 @@ func (s *sqlStorage) Update(ctx context.Context, post *Post) error {
 	return nil
 }
 
+// UpdateTags replaces the post tags.
+func (s *sqlStorage) UpdateTags(ctx context.Context, post *Post) error {
+	_, err := sq.Update(tableName).
+		Set("tags", strings.Join(post.tags, tagSeparator)).
+		Where(sq.Eq{"id": post.id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	_, err = sq.Delete(tagsTableName).
+		Where(sq.Eq{"post_id": post.id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clean the tags index: %w", err)
+	}
+
+	// XXX:MULTI-WRITE
+	err = s.saveTagsIndex(ctx, post)
+	if err != nil {
+		return fmt.Errorf("failed to save the tags index: %w", err)
+	}
+
+	return nil
+}
+
+// saveTagsIndex fills the post_tags table used to filter the feeds by tag.
+func (s *sqlStorage) saveTagsIndex(ctx context.Context, post *Post) error {
+	if len(post.tags) == 0 {
+		return nil
+	}
+
+	query := sq.Insert(tagsTableName).Columns("post_id", "tag")
+	for _, tag := range post.tags {
+		query = query.Values(post.id, tag)
+	}
+
+	_, err := query.RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) AddVotes(ctx context.Context, postID uint, upvotes int, downvotes int) error {
+	_, err := sq.Update(tableName).
+		Set("upvotes", sq.Expr("upvotes + ?", upvotes)).
+		Set("downvotes", sq.Expr("downvotes + ?", downvotes)).
+		Where(sq.Eq{"id": postID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
 func (s *sqlStorage) countByKeys(ctx context.Context, wheres ...any) (int, error) {
 	var count int
 
@@ -192,31 +604,40 @@ func (s *sqlStorage) countByKeys(ctx context.Context, wheres ...any) (int, error
 	return count, nil
 }
 
-func (s *sqlStorage) scanRows(rows *sql.Rows) ([]Post, error) {
-	posts := []Post{}
+func prefixFields(table string, fields []string) []string {
+	res := make([]string, len(fields))
+	for i, field := range fields {
+		res[i] = table + "." + field
+	}
 
-	for rows.Next() {
-		var res Post
-		var sqlCreatedAt sqlstorage.SQLTime
+	return res
+}
 
-		err := rows.Scan(&res.id,
-			&res.status,
-			&res.title,
-			&res.fileID,
-			&sqlCreatedAt,
-			&res.createdBy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan a row: %w", err)
-		}
+func listedAtValue(post *Post) *sqlstorage.SQLTime {
+	if post.listedAt == nil {
+		return nil
+	}
 
-		res.createdAt = sqlCreatedAt.Time()
+	return ptr.To(sqlstorage.SQLTime(*post.listedAt))
+}
 
-		posts = append(posts, res)
+func splitTags(rawTags string) []string {
+	if rawTags == "" {
+		return []string{}
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("scan error: %w", err)
+	return strings.Split(rawTags, tagSeparator)
+}
+
+func (s *sqlStorage) UpdateCreator(ctx context.Context, from, to uuid.UUID) error {
+	_, err := sq.Update(tableName).
+		Set("created_by", to).
+		Where(sq.Eq{"created_by": from}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
 	}
 
-	return posts, nil
+	return nil
 }
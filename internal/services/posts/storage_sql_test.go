@@ -3,10 +3,12 @@ package posts
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	"github.com/stretchr/testify/require"
 )
@@ -16,18 +18,19 @@ func TestPostSqlStorage(t *testing.T) {
 
 	ctx := context.Background()
 
-	t.Run("GetListedPosts with nothing", func(t *testing.T) {
+	t.Run("GetFeedPosts with nothing", func(t *testing.T) {
 		t.Parallel()
 
 		db := sqlstorage.NewTestStorage(t)
 		store := newSqlStorage(db)
 
 		// Run
-		res, err := store.GetListedPosts(ctx, 0, 10)
+		res, next, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{}, &FeedCursor{At: time.Now()}, 10)
 
 		// Asserts
 		require.NoError(t, err)
 		require.Empty(t, res)
+		require.Equal(t, &FeedCursor{At: next.At}, next)
 	})
 
 	t.Run("GetLatestPostWithStatus with nothing", func(t *testing.T) {
@@ -111,79 +114,550 @@ func TestPostSqlStorage(t *testing.T) {
 		require.Equal(t, post, res)
 	})
 
-	t.Run("GetListedPosts success", func(t *testing.T) {
+	t.Run("GetFeedPosts Fresh success", func(t *testing.T) {
 		t.Parallel()
 
 		db := sqlstorage.NewTestStorage(t)
 		store := newSqlStorage(db)
 
-		nbUsers := 25
+		nbPosts := 25
+		now := time.Now().UTC().Round(time.Millisecond)
 		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
 		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
 		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
-		posts := make(map[uint]Post, nbUsers)
+		posts := make(map[uint]Post, nbPosts)
 
-		for i := 0; i < nbUsers; i++ {
-			res := NewFakePost(t).CreatedBy(user).WithStatus(Listed).BuildAndStore(ctx, db)
-			t.Logf("%02d -> %s\n", res.id, res.title)
+		for i := 0; i < nbPosts; i++ {
+			res := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
 			posts[res.id] = *res
 		}
 
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Uploaded).BuildAndStore(ctx, db)
+
 		// Test 1
-		res, err := store.GetListedPosts(ctx, 24, 5)
+		res, next, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{}, &FeedCursor{At: now}, 5)
 		require.NoError(t, err)
 		require.EqualValues(t, []Post{
+			posts[25],
 			posts[24],
 			posts[23],
 			posts[22],
 			posts[21],
-			posts[20],
 		}, res)
+		require.Equal(t, &FeedCursor{At: now, Rank: 21, PostID: 21}, next)
 
 		// Test 2
-		res2, err2 := store.GetListedPosts(ctx, 4, 10)
-		require.NoError(t, err2)
+		res2, next2, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{}, &FeedCursor{At: now, Rank: 5, PostID: 5}, 10)
+		require.NoError(t, err)
 		require.EqualValues(t, []Post{
 			posts[4],
 			posts[3],
 			posts[2],
 			posts[1],
 		}, res2)
+		require.Equal(t, &FeedCursor{At: now, Rank: 1, PostID: 1}, next2)
 	})
 
-	t.Run("GetLatestPostWithStatus and GetListedPosts success", func(t *testing.T) {
+	t.Run("GetFeedPosts ignores the posts created after the cursor date", func(t *testing.T) {
 		t.Parallel()
 
 		db := sqlstorage.NewTestStorage(t)
 		store := newSqlStorage(db)
 
-		nbUsers := 25
+		now := time.Now().UTC().Round(time.Millisecond)
 		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
 		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
 		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
-		posts := make(map[uint]Post, nbUsers)
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(time.Hour)).BuildAndStore(ctx, db)
 
-		for i := 0; i < nbUsers; i++ {
-			res := NewFakePost(t).CreatedBy(user).WithStatus(Listed).BuildAndStore(ctx, db)
-			t.Logf("%02d -> %s\n", res.id, res.title)
-			posts[res.id] = *res
-		}
+		res, _, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post}, res)
+	})
+
+	t.Run("GetFeedPosts Hot success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		// Rank: 100 / (48 + 2)² = 0.04
+		oldPopular := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(110, 10).CreatedAt(now.Add(-48*time.Hour)).BuildAndStore(ctx, db)
+		// Rank: 10 / (1 + 2)² = 1.11
+		recentPopular := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(10, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		// Rank: 0
+		recent := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
+		// Rank: -5 / (2 + 2)² = -0.31
+		disliked := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(0, 5).CreatedAt(now.Add(-2*time.Hour)).BuildAndStore(ctx, db)
+
+		err := store.SaveRanks(ctx, Hot, now)
+		require.NoError(t, err)
 
 		// Test 1
-		latest, err := store.GetLatestPostWithStatus(ctx, Listed)
+		res, next, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 2)
 		require.NoError(t, err)
+		require.EqualValues(t, []Post{*recentPopular, *oldPopular}, res)
+		require.Equal(t, oldPopular.id, next.PostID)
+		require.InDelta(t, 0.04, next.Rank, 0.001)
 
-		res, err := store.GetListedPosts(ctx, latest.id, 5)
+		// Test 2
+		res2, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, next, 10)
 		require.NoError(t, err)
-		require.EqualValues(t, []Post{
-			posts[25],
-			posts[24],
-			posts[23],
-			posts[22],
-			posts[21],
+		require.EqualValues(t, []Post{*recent, *disliked}, res2)
+	})
+
+	t.Run("GetFeedPosts pages are stable when new posts are listed between two fetches", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(30, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post2 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(20, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post3 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(10, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post4 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		res, next, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post1, *post2}, res)
+
+		// A new post would have been ranked first.
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(100, 0).CreatedAt(now.Add(time.Second)).BuildAndStore(ctx, db)
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(time.Minute)))
+
+		// The cursor goes through the clients as an opaque string.
+		cursor, err := DecodeFeedCursor(next.Encode())
+		require.NoError(t, err)
+
+		res2, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, cursor, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post3, *post4}, res2)
+	})
+
+	t.Run("GetFeedPosts pages are stable when some votes are cast between two fetches", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(30, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post2 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(20, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post3 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(10, 0).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		res, next, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 1)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post1}, res)
+
+		// post3 would be ranked first and post1 would be seen twice.
+		require.NoError(t, store.AddVotes(ctx, post3.id, 100, 0))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(time.Minute)))
+
+		res2, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, next, 10)
+		require.NoError(t, err)
+		require.Len(t, res2, 2)
+		require.Equal(t, post2.id, res2[0].id)
+		require.Equal(t, post3.id, res2[1].id)
+	})
+
+	t.Run("GetFeedPosts Trending success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user1 := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		user2 := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user1).WithStatus(Listed).WithVotes(2, 0).CreatedAt(now.Add(-72*time.Hour)).BuildAndStore(ctx, db)
+		post2 := NewFakePost(t).CreatedBy(user1).WithStatus(Listed).WithVotes(1, 0).CreatedAt(now.Add(-72*time.Hour)).BuildAndStore(ctx, db)
+		post3 := NewFakePost(t).CreatedBy(user1).WithStatus(Listed).WithVotes(1, 0).CreatedAt(now.Add(-72*time.Hour)).BuildAndStore(ctx, db)
+
+		// post1 has the more votes but they are too old.
+		insertVote(t, db, post1, user1, 1, now.Add(-48*time.Hour))
+		insertVote(t, db, post1, user2, 1, now.Add(-48*time.Hour))
+		insertVote(t, db, post2, user1, 1, now.Add(-time.Hour))
+		// Votes after the ranking date are ignored.
+		insertVote(t, db, post3, user1, 1, now.Add(time.Hour))
+
+		err := store.SaveRanks(ctx, Trending, now)
+		require.NoError(t, err)
+
+		res, next, err := store.GetFeedPosts(ctx, Trending, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post2, *post3, *post1}, res)
+		require.Equal(t, &FeedCursor{At: now, Rank: 0, PostID: post1.id}, next)
+	})
+
+	t.Run("GetFeedPosts with a tag", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("cats").CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("dogs").CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		post3 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("dogs", "cats").CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Uploaded).WithTags("cats").CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		res, next, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{tag: "cats"}, &FeedCursor{At: now}, 1)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post3}, res)
+
+		res2, _, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{tag: "cats"}, next, 10)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post1}, res2)
+	})
+
+	t.Run("GetFeedVersion success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("cats").ListedAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
+		post2 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("cats").ListedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("dogs").ListedAt(now).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Uploaded).WithTags("cats").BuildAndStore(ctx, db)
+
+		res, err := store.GetFeedVersion(ctx, &feedFilter{tag: "cats"})
+		require.NoError(t, err)
+		require.Equal(t, &FeedVersion{
+			Count:        2,
+			LastPostID:   post2.ID(),
+			LastListedAt: now.Add(-time.Minute),
 		}, res)
 	})
 
+	t.Run("GetFeedVersion with nothing", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetFeedVersion(ctx, &feedFilter{})
+		require.NoError(t, err)
+		require.Equal(t, &FeedVersion{}, res)
+	})
+
+	t.Run("GetFeedPosts with an author", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user1 := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		user2 := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user1).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user2).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user1).WithStatus(Uploaded).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		res, _, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{createdBy: user1.ID()}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post1}, res)
+	})
+
+	t.Run("GetFeedPosts Hot ignores the moderated posts", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		moderated := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		moderated.status = Moderated
+		require.NoError(t, store.Update(ctx, moderated))
+
+		res, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post}, res)
+	})
+
+	t.Run("GetFeedPosts Hot sinks the downvoted posts with the time", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		upvoted := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(1, 0).CreatedAt(now.Add(-48*time.Hour)).BuildAndStore(ctx, db)
+		neutral := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(2, 2).CreatedAt(now.Add(-48*time.Hour)).BuildAndStore(ctx, db)
+		newDownvoted := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(0, 1).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+		oldDownvoted := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(0, 5).CreatedAt(now.Add(-48*time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		res, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*upvoted, *neutral, *newDownvoted, *oldDownvoted}, res)
+	})
+
+	t.Run("SaveRanks with the Fresh feed", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		err := store.SaveRanks(ctx, Fresh, time.Now())
+		require.Error(t, err)
+	})
+
+	t.Run("GetLastRankedAt success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		_, err := store.GetLastRankedAt(ctx, Hot)
+		require.ErrorIs(t, err, errNotFound)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-time.Minute)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+		require.NoError(t, store.SaveRanks(ctx, Trending, now.Add(time.Minute)))
+
+		res, err := store.GetLastRankedAt(ctx, Hot)
+		require.NoError(t, err)
+		require.Equal(t, now, res)
+	})
+
+	t.Run("HasRanks success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-time.Minute)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+		require.NoError(t, store.RemoveRanksBeyond(ctx, Hot, 1))
+
+		res, err := store.HasRanks(ctx, Hot, now)
+		require.NoError(t, err)
+		require.True(t, res)
+
+		// Removed by the size cap even if it is still inside the retention.
+		res, err = store.HasRanks(ctx, Hot, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.False(t, res)
+
+		res, err = store.HasRanks(ctx, Trending, now)
+		require.NoError(t, err)
+		require.False(t, res)
+	})
+
+	t.Run("RemoveRanksBefore success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-2*time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-time.Hour)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		err := store.RemoveRanksBefore(ctx, now.Add(-time.Minute))
+		require.NoError(t, err)
+
+		res, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now.Add(-time.Hour)}, 10)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		res, _, err = store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, []Post{*post}, res)
+	})
+
+	t.Run("RemoveRanksBeyond success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post1 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-3*time.Hour)).BuildAndStore(ctx, db)
+		post2 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-3*time.Hour)).BuildAndStore(ctx, db)
+
+		// Two ranks per date.
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-2*time.Minute)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-time.Minute)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+		require.NoError(t, store.SaveRanks(ctx, Trending, now.Add(-2*time.Minute)))
+
+		err := store.RemoveRanksBeyond(ctx, Hot, 5)
+		require.NoError(t, err)
+
+		res, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now.Add(-2 * time.Minute)}, 10)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		for _, at := range []time.Time{now.Add(-time.Minute), now} {
+			res, _, err = store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: at}, 10)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []Post{*post1, *post2}, res)
+		}
+
+		// The other feeds are untouched.
+		res, _, err = store.GetFeedPosts(ctx, Trending, &feedFilter{}, &FeedCursor{At: now.Add(-2 * time.Minute)}, 10)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+	})
+
+	t.Run("RemoveRanksBeyond keeps the most recent ranks", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now().UTC().Round(time.Millisecond)
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-3*time.Hour)).BuildAndStore(ctx, db)
+		NewFakePost(t).CreatedBy(user).WithStatus(Listed).CreatedAt(now.Add(-3*time.Hour)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.SaveRanks(ctx, Hot, now.Add(-time.Minute)))
+		require.NoError(t, store.SaveRanks(ctx, Hot, now))
+
+		err := store.RemoveRanksBeyond(ctx, Hot, 1)
+		require.NoError(t, err)
+
+		res, _, err := store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now.Add(-time.Minute)}, 10)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		res, _, err = store.GetFeedPosts(ctx, Hot, &feedFilter{}, &FeedCursor{At: now}, 10)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Contains(t, res, *post)
+	})
+
+	t.Run("GetUserPostsByStatus success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		otherUser := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post1 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(user).WithStatus(Uploaded).BuildAndStore(ctx, db)
+		post3 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).BuildAndStore(ctx, db)
+		_ = NewFakePost(t).CreatedBy(otherUser).WithStatus(Listed).BuildAndStore(ctx, db)
+		post5 := NewFakePost(t).CreatedBy(user).WithStatus(Listed).BuildAndStore(ctx, db)
+
+		// Test 1
+		res, err := store.GetUserPostsByStatus(ctx, user.ID(), Listed, 0, 2)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post5, *post3}, res)
+
+		// Test 2
+		res2, err := store.GetUserPostsByStatus(ctx, user.ID(), Listed, post3.id, 2)
+		require.NoError(t, err)
+		require.Equal(t, []Post{*post1}, res2)
+	})
+
+	t.Run("UpdateTags success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithTags("cats").BuildAndStore(ctx, db)
+
+		post.tags = []string{"dogs"}
+		err := store.UpdateTags(ctx, post)
+		require.NoError(t, err)
+
+		res, err := store.GetByID(ctx, post.id)
+		require.NoError(t, err)
+		require.Equal(t, []string{"dogs"}, res.Tags())
+
+		// The tags index must be updated too.
+		resCats, _, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{tag: "cats"}, &FeedCursor{At: time.Now()}, 10)
+		require.NoError(t, err)
+		require.Empty(t, resCats)
+
+		resDogs, _, err := store.GetFeedPosts(ctx, Fresh, &feedFilter{tag: "dogs"}, &FeedCursor{At: time.Now()}, 10)
+		require.NoError(t, err)
+		require.Len(t, resDogs, 1)
+	})
+
 	t.Run("CountPostsWithStatus success", func(t *testing.T) {
 		t.Parallel()
 
@@ -266,4 +740,56 @@ func TestPostSqlStorage(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, nbListedPosts, res)
 	})
+
+	t.Run("AddVotes success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := NewFakePost(t).CreatedBy(user).WithStatus(Listed).WithVotes(3, 1).BuildAndStore(ctx, db)
+
+		err := store.AddVotes(ctx, post.ID(), 2, -1)
+		require.NoError(t, err)
+
+		res, err := store.GetByID(ctx, post.ID())
+		require.NoError(t, err)
+		require.Equal(t, 5, res.Upvotes())
+		require.Equal(t, 0, res.Downvotes())
+	})
+	t.Run("UpdateCreator success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		ghost := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		// Run
+		err := store.UpdateCreator(ctx, user.ID(), ghost.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetByID(ctx, post.ID())
+		require.NoError(t, err)
+		require.Equal(t, ghost.ID(), res.CreatedBy())
+	})
+}
+
+func insertVote(t *testing.T, db sqlstorage.Querier, post *Post, user *users.User, value int, votedAt time.Time) {
+	t.Helper()
+
+	// The votes fake builder can't be used here because the votes package
+	// imports this package.
+	_, err := db.ExecContext(context.Background(),
+		"INSERT INTO votes (post_id, user_id, value, voted_at) VALUES (?, ?, ?, ?)",
+		post.id, user.ID(), value, ptr.To(sqlstorage.SQLTime(votedAt)))
+	require.NoError(t, err)
 }
@@ -0,0 +1,60 @@
+package recovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+const DefaultPurgeInterval = time.Hour
+
+type Config struct {
+	// BaseURL is the public url of the server, used to build the links sent
+	// by e-mail. It must never be taken from the requests: an attacker could
+	// change it to receive the tokens.
+	BaseURL string
+	// PurgeInterval is the delay between two removals of the expired tokens.
+	PurgeInterval time.Duration
+}
+
+type Service interface {
+	// RequestEmail sends a verification link to the new e-mail of a user.
+	RequestEmail(ctx context.Context, cmd *RequestEmailCmd) error
+	VerifyEmail(ctx context.Context, token secret.Text) error
+	// RequestReset registers a [SendResetTask] for the given e-mail. Nothing
+	// tells if an account uses it.
+	RequestReset(ctx context.Context, cmd *RequestResetCmd) error
+	// SendReset sends a reset link if an active user has the given e-mail.
+	SendReset(ctx context.Context, email string) error
+	CheckResetToken(ctx context.Context, token secret.Text) error
+	// ResetPassword replaces the password and logs the user out of all its
+	// devices.
+	ResetPassword(ctx context.Context, cmd *ResetPasswordCmd) error
+	// ForceReset invalidates the password of the user and sends a reset link
+	// to its e-mail. The link is returned instead when the user has no e-mail.
+	ForceReset(ctx context.Context, user *users.User) (secret.Text, error)
+	// DeleteAll invalidates all the links sent to the user.
+	DeleteAll(ctx context.Context, user *users.User) error
+	PurgeExpired(ctx context.Context) error
+}
+
+func Init(
+	cfg Config,
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	users users.Service,
+	webSessions websessions.Service,
+	mailer mailer.Mailer,
+	tasks taskrunner.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(cfg, tools, storage, users, webSessions, mailer, tasks)
+}
@@ -0,0 +1,118 @@
+package recovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+const (
+	// ResetTokenLifetime is the time a user has to reset its password.
+	ResetTokenLifetime = time.Hour
+	// VerifyTokenLifetime is the time a user has to verify its e-mail.
+	VerifyTokenLifetime = 24 * time.Hour
+)
+
+// SendResetTaskName is the name of the [SendResetTask].
+const SendResetTaskName = "password-reset-send"
+
+// Kind is the action allowed by a [Token].
+type Kind string
+
+const (
+	VerifyEmail   Kind = "verify-email"
+	ResetPassword Kind = "reset-password"
+)
+
+// Token is a single-use secret sent by e-mail. Only its hash is saved, the
+// raw token is only known by the receiver of the e-mail.
+type Token struct {
+	createdAt time.Time
+	expiresAt time.Time
+	hash      string
+	kind      Kind
+	userID    uuid.UUID
+	// email is the address the token have been sent to.
+	email string
+}
+
+func (t Token) Kind() Kind           { return t.kind }
+func (t Token) UserID() uuid.UUID    { return t.userID }
+func (t Token) Email() string        { return t.email }
+func (t Token) CreatedAt() time.Time { return t.createdAt }
+func (t Token) ExpiresAt() time.Time { return t.expiresAt }
+
+func (t Token) IsExpired(now time.Time) bool {
+	return !now.Before(t.expiresAt)
+}
+
+// RequestEmailCmd asks to set the e-mail of a user. The e-mail is only
+// saved once verified.
+type RequestEmailCmd struct {
+	User  *users.User
+	Email string
+}
+
+func (t RequestEmailCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Email, v.Required, v.Length(1, 254), is.Email),
+	)
+}
+
+type RequestResetCmd struct {
+	Email string
+}
+
+func (t RequestResetCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Email, v.Required, is.Email),
+	)
+}
+
+// SendResetTask is registered by [Service.RequestReset]. Its runner calls
+// [Service.SendReset] out of the request so the response doesn't depend on
+// the e-mail being registered or not.
+type SendResetTask struct {
+	Email string `json:"email"`
+}
+
+func (t *SendResetTask) Name() string  { return SendResetTaskName }
+func (t *SendResetTask) Priority() int { return 1 }
+
+func (t *SendResetTask) Validate() error {
+	return v.ValidateStruct(t,
+		v.Field(&t.Email, v.Required, is.Email),
+	)
+}
+
+func (t *SendResetTask) Args() json.RawMessage {
+	res, _ := json.Marshal(t)
+
+	return res
+}
+
+type ResetPasswordCmd struct {
+	Token       secret.Text
+	NewPassword secret.Text
+}
+
+func (t ResetPasswordCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Token, v.Required),
+		v.Field(&t.NewPassword, v.Required, v.Length(users.SecretMinLength, users.SecretMaxLength)),
+	)
+}
+
+func hashToken(token secret.Text) string {
+	sum := sha256.Sum256([]byte(token.Raw()))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeTokenBuilder struct {
+	t     testing.TB
+	token *Token
+}
+
+func NewFakeToken(t testing.TB) *FakeTokenBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Minute*30), time.Now())
+
+	return &FakeTokenBuilder{
+		t: t,
+		token: &Token{
+			hash:      hashToken(secret.NewText(gofakeit.UUID())),
+			kind:      ResetPassword,
+			userID:    uuidProvider.New(),
+			email:     gofakeit.Email(),
+			createdAt: createdAt,
+			expiresAt: createdAt.Add(ResetTokenLifetime),
+		},
+	}
+}
+
+// WithRawToken sets the token sent by e-mail.
+func (f *FakeTokenBuilder) WithRawToken(raw string) *FakeTokenBuilder {
+	f.token.hash = hashToken(secret.NewText(raw))
+
+	return f
+}
+
+func (f *FakeTokenBuilder) WithKind(kind Kind) *FakeTokenBuilder {
+	f.token.kind = kind
+
+	return f
+}
+
+func (f *FakeTokenBuilder) WithUser(user *users.User) *FakeTokenBuilder {
+	f.token.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeTokenBuilder) WithEmail(email string) *FakeTokenBuilder {
+	f.token.email = email
+
+	return f
+}
+
+func (f *FakeTokenBuilder) ExpiresAt(expiresAt time.Time) *FakeTokenBuilder {
+	f.token.expiresAt = expiresAt
+
+	return f
+}
+
+func (f *FakeTokenBuilder) Build() *Token {
+	return f.token
+}
+
+func (f *FakeTokenBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Token {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	token := f.Build()
+
+	err := storage.Save(ctx, token)
+	require.NoError(f.t, err)
+
+	return token
+}
@@ -0,0 +1,53 @@
+package recovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Token_Getters(t *testing.T) {
+	token := NewFakeToken(t).Build()
+
+	assert.Equal(t, token.kind, token.Kind())
+	assert.Equal(t, token.userID, token.UserID())
+	assert.Equal(t, token.email, token.Email())
+	assert.Equal(t, token.createdAt, token.CreatedAt())
+	assert.Equal(t, token.expiresAt, token.ExpiresAt())
+}
+
+func Test_Token_IsExpired(t *testing.T) {
+	now := time.Now()
+	token := NewFakeToken(t).ExpiresAt(now).Build()
+
+	assert.False(t, token.IsExpired(now.Add(-time.Second)))
+	assert.True(t, token.IsExpired(now))
+}
+
+func Test_RequestEmailCmd_Validate(t *testing.T) {
+	err := RequestEmailCmd{
+		User:  users.NewFakeUser(t).Build(),
+		Email: "not-an-email",
+	}.Validate()
+
+	require.EqualError(t, err, "Email: must be a valid email address.")
+}
+
+func Test_ResetPasswordCmd_Validate(t *testing.T) {
+	err := ResetPasswordCmd{
+		Token:       secret.NewText("some-token"),
+		NewPassword: secret.NewText("short"),
+	}.Validate()
+
+	require.EqualError(t, err, "NewPassword: the length must be between 8 and 200.")
+}
+
+func Test_hashToken(t *testing.T) {
+	assert.Equal(t, hashToken(secret.NewText("some-token")), hashToken(secret.NewText("some-token")))
+	assert.NotEqual(t, hashToken(secret.NewText("some-token")), hashToken(secret.NewText("other-token")))
+	assert.NotContains(t, hashToken(secret.NewText("some-token")), "some-token")
+}
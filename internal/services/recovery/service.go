@@ -0,0 +1,364 @@
+package recovery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type storage interface {
+	Save(ctx context.Context, token *Token) error
+	GetByHash(ctx context.Context, hash string) (*Token, error)
+	Delete(ctx context.Context, hash string) (bool, error)
+	DeleteAllForUser(ctx context.Context, userID uuid.UUID, kind Kind) error
+	RemoveExpired(ctx context.Context, now time.Time) error
+}
+
+type service struct {
+	storage     storage
+	users       users.Service
+	webSessions websessions.Service
+	mailer      mailer.Mailer
+	tasks       taskrunner.Service
+	clock       clock.Clock
+	log         *slog.Logger
+	baseURL     string
+}
+
+func newService(
+	cfg Config,
+	tools tools.Tools,
+	storage storage,
+	users users.Service,
+	webSessions websessions.Service,
+	mailer mailer.Mailer,
+	tasks taskrunner.Service,
+) *service {
+	return &service{
+		storage:     storage,
+		users:       users,
+		webSessions: webSessions,
+		mailer:      mailer,
+		tasks:       tasks,
+		clock:       tools.Clock(),
+		log:         tools.Logger(),
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+	}
+}
+
+func (s *service) RequestEmail(ctx context.Context, cmd *RequestEmailCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	email := users.NormalizeEmail(cmd.Email)
+
+	// Only the last requested e-mail can be verified.
+	err = s.storage.DeleteAllForUser(ctx, cmd.User.ID(), VerifyEmail)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteAllForUser: %w", err))
+	}
+
+	token, err := s.createToken(ctx, VerifyEmail, cmd.User.ID(), email, VerifyTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Email{
+		To:      email,
+		Subject: "Confirm your e-mail address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Open the following link to confirm this e-mail address for your OnlyFun account:\n\n"+
+			"%s/settings/email/verify?token=%s\n\n"+
+			"The link expires in %s. If you didn't ask for it, you can ignore this e-mail.\n",
+			cmd.User.Username(), s.baseURL, token.Raw(), VerifyTokenLifetime),
+	})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to send the verification email: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) VerifyEmail(ctx context.Context, rawToken secret.Text) error {
+	token, err := s.consume(ctx, VerifyEmail, rawToken)
+	if err != nil {
+		return err
+	}
+
+	err = s.users.UpdateEmail(ctx, &users.UpdateEmailCmd{
+		UserID: token.userID,
+		Email:  token.email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to UpdateEmail: %w", err)
+	}
+
+	return nil
+}
+
+// RequestReset only registers a task: looking for the user and sending the
+// e-mail in the request would make the known addresses slower to answer.
+func (s *service) RequestReset(ctx context.Context, cmd *RequestResetCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	err = s.tasks.RegisterTask(ctx, &SendResetTask{Email: users.NormalizeEmail(cmd.Email)})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to register the task: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) SendReset(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, errs.ErrNotFound) {
+		s.log.DebugContext(ctx, "password reset asked for an unknown email")
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to GetByEmail: %w", err)
+	}
+
+	if user.Status() != users.Active {
+		s.log.DebugContext(ctx, "password reset asked for an inactive user", slog.String("user", string(user.ID())))
+		return nil
+	}
+
+	// Only the last sent link can be used.
+	err = s.storage.DeleteAllForUser(ctx, user.ID(), ResetPassword)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteAllForUser: %w", err))
+	}
+
+	token, err := s.createToken(ctx, ResetPassword, user.ID(), user.Email(), ResetTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Email{
+		To:      user.Email(),
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Open the following link to choose a new password for your OnlyFun account:\n\n"+
+			"%s/password-reset?token=%s\n\n"+
+			"The link expires in %s. If you didn't ask for it, you can ignore this e-mail: "+
+			"your password stays unchanged.\n",
+			user.Username(), s.baseURL, token.Raw(), ResetTokenLifetime),
+	})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to send the reset email: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) CheckResetToken(ctx context.Context, rawToken secret.Text) error {
+	_, err := s.getValid(ctx, ResetPassword, rawToken)
+
+	return err
+}
+
+func (s *service) ResetPassword(ctx context.Context, cmd *ResetPasswordCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	token, err := s.consume(ctx, ResetPassword, cmd.Token)
+	if err != nil {
+		return err
+	}
+
+	err = s.users.UpdateUserPassword(ctx, &users.UpdatePasswordCmd{
+		UserID:      token.userID,
+		NewPassword: cmd.NewPassword,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to UpdateUserPassword: %w", err)
+	}
+
+	// The sessions opened with the old password could belong to the one who
+	// stole it.
+	err = s.webSessions.DeleteAll(ctx, token.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete the websessions: %w", err)
+	}
+
+	return nil
+}
+
+// ForceReset replaces the password of the user by a random one, logs it
+// out of all its devices and creates a reset link. The link is sent to the
+// user e-mail if any. It is only returned, to be handed over, when the user
+// has no e-mail: nobody else than the user must be able to use it.
+func (s *service) ForceReset(ctx context.Context, user *users.User) (secret.Text, error) {
+	password, err := newRandomText()
+	if err != nil {
+		return secret.Text{}, errs.Internal(fmt.Errorf("failed to generate the password: %w", err))
+	}
+
+	err = s.users.UpdateUserPassword(ctx, &users.UpdatePasswordCmd{
+		UserID:      user.ID(),
+		NewPassword: password,
+	})
+	if err != nil {
+		return secret.Text{}, fmt.Errorf("failed to UpdateUserPassword: %w", err)
+	}
+
+	err = s.webSessions.DeleteAll(ctx, user.ID())
+	if err != nil {
+		return secret.Text{}, fmt.Errorf("failed to delete the websessions: %w", err)
+	}
+
+	err = s.storage.DeleteAllForUser(ctx, user.ID(), ResetPassword)
+	if err != nil {
+		return secret.Text{}, errs.Internal(fmt.Errorf("failed to DeleteAllForUser: %w", err))
+	}
+
+	token, err := s.createToken(ctx, ResetPassword, user.ID(), user.Email(), ResetTokenLifetime)
+	if err != nil {
+		return secret.Text{}, err
+	}
+
+	link := secret.NewText(fmt.Sprintf("%s/password-reset?token=%s", s.baseURL, token.Raw()))
+
+	if user.Email() == "" {
+		return link, nil
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Email{
+		To:      user.Email(),
+		Subject: "Your password has been reset",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"An administrator has reset the password of your OnlyFun account. "+
+			"Open the following link to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s.\n",
+			user.Username(), link.Raw(), ResetTokenLifetime),
+	})
+	if err != nil {
+		return secret.Text{}, errs.Internal(fmt.Errorf("failed to send the reset email: %w", err))
+	}
+
+	return secret.Text{}, nil
+}
+
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	for _, kind := range []Kind{VerifyEmail, ResetPassword} {
+		err := s.storage.DeleteAllForUser(ctx, user.ID(), kind)
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to DeleteAllForUser %q: %w", kind, err))
+		}
+	}
+
+	return nil
+}
+
+func (s *service) PurgeExpired(ctx context.Context) error {
+	err := s.storage.RemoveExpired(ctx, s.clock.Now())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveExpired: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) createToken(ctx context.Context, kind Kind, userID uuid.UUID, email string, lifetime time.Duration) (secret.Text, error) {
+	rawToken, err := newRandomText()
+	if err != nil {
+		return secret.Text{}, errs.Internal(fmt.Errorf("failed to generate the token: %w", err))
+	}
+
+	now := s.clock.Now()
+
+	err = s.storage.Save(ctx, &Token{
+		hash:      hashToken(rawToken),
+		kind:      kind,
+		userID:    userID,
+		email:     email,
+		createdAt: now,
+		expiresAt: now.Add(lifetime),
+	})
+	if err != nil {
+		return secret.Text{}, errs.Internal(fmt.Errorf("failed to save the token: %w", err))
+	}
+
+	return rawToken, nil
+}
+
+// getValid returns the token of the given kind if it exists and has not
+// expired.
+func (s *service) getValid(ctx context.Context, kind Kind, rawToken secret.Text) (*Token, error) {
+	if rawToken.Raw() == "" {
+		return nil, errs.BadRequest(ErrInvalidToken, "invalid or expired link")
+	}
+
+	token, err := s.storage.GetByHash(ctx, hashToken(rawToken))
+	if errors.Is(err, errNotFound) {
+		return nil, errs.BadRequest(ErrInvalidToken, "invalid or expired link")
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByHash: %w", err))
+	}
+
+	if token.kind != kind || token.IsExpired(s.clock.Now()) {
+		return nil, errs.BadRequest(ErrInvalidToken, "invalid or expired link")
+	}
+
+	return token, nil
+}
+
+// consume returns a valid token and removes it. A token can be consumed
+// only once, even with concurrent calls.
+func (s *service) consume(ctx context.Context, kind Kind, rawToken secret.Text) (*Token, error) {
+	token, err := s.getValid(ctx, kind, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.storage.Delete(ctx, token.hash)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Delete: %w", err))
+	}
+
+	if !deleted {
+		return nil, errs.BadRequest(ErrInvalidToken, "invalid or expired link")
+	}
+
+	return token, nil
+}
+
+func newRandomText() (secret.Text, error) {
+	raw := make([]byte, 32)
+
+	_, err := rand.Read(raw)
+	if err != nil {
+		return secret.Text{}, err
+	}
+
+	return secret.NewText(base64.RawURLEncoding.EncodeToString(raw)), nil
+}
@@ -0,0 +1,609 @@
+package recovery
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/mailer"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var linkRegexp = regexp.MustCompile(`https://onlyfun\.example\.com/\S+`)
+
+// tokenFromEmail extracts the token from the link sent by e-mail.
+func tokenFromEmail(t *testing.T, email *mailer.Email) secret.Text {
+	t.Helper()
+
+	link, err := url.Parse(linkRegexp.FindString(email.Body))
+	require.NoError(t, err)
+
+	return secret.NewText(link.Query().Get("token"))
+}
+
+func TestRecoveryService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := Config{BaseURL: "https://onlyfun.example.com/"}
+
+	t.Run("RequestEmail success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		now := time.Now()
+		var saved *Token
+		var sent *mailer.Email
+
+		// Mocks
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), VerifyEmail).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*Token)
+		}).Return(nil).Once()
+		mailerMock.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(1).(*mailer.Email)
+		}).Return(nil).Once()
+
+		// Run
+		err := svc.RequestEmail(ctx, &RequestEmailCmd{User: user, Email: "Jane@Example.com"})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, VerifyEmail, saved.kind)
+		assert.Equal(t, user.ID(), saved.userID)
+		assert.Equal(t, "jane@example.com", saved.email)
+		assert.Equal(t, now.Add(VerifyTokenLifetime), saved.expiresAt)
+		assert.Equal(t, "jane@example.com", sent.To)
+		assert.Contains(t, sent.Body, "https://onlyfun.example.com/settings/email/verify?token=")
+		assert.Equal(t, saved.hash, hashToken(tokenFromEmail(t, sent)))
+	})
+
+	t.Run("RequestEmail with an invalid email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Run
+		err := svc.RequestEmail(ctx, &RequestEmailCmd{User: users.NewFakeUser(t).Build(), Email: "not-an-email"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("VerifyEmail success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		token := NewFakeToken(t).
+			WithRawToken("some-token").
+			WithKind(VerifyEmail).
+			WithUser(user).
+			WithEmail("jane@example.com").
+			Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, hashToken(secret.NewText("some-token"))).Return(token, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+		storageMock.On("Delete", mock.Anything, token.hash).Return(true, nil).Once()
+		usersMock.On("UpdateEmail", mock.Anything, &users.UpdateEmailCmd{
+			UserID: user.ID(),
+			Email:  "jane@example.com",
+		}).Return(nil).Once()
+
+		// Run
+		err := svc.VerifyEmail(ctx, secret.NewText("some-token"))
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("VerifyEmail with a reset token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		token := NewFakeToken(t).WithRawToken("some-token").WithKind(ResetPassword).Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, token.hash).Return(token, nil).Once()
+
+		// Run
+		err := svc.VerifyEmail(ctx, secret.NewText("some-token"))
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("VerifyEmail with a token already used", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		token := NewFakeToken(t).WithRawToken("some-token").WithKind(VerifyEmail).Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, token.hash).Return(token, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+		storageMock.On("Delete", mock.Anything, token.hash).Return(false, nil).Once()
+
+		// Run
+		err := svc.VerifyEmail(ctx, secret.NewText("some-token"))
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("RequestReset success registers a task", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Mocks
+		tasksMock.On("RegisterTask", mock.Anything, &SendResetTask{Email: "jane@example.com"}).Return(nil).Once()
+
+		// Run
+		err := svc.RequestReset(ctx, &RequestResetCmd{Email: "Jane@Example.com"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("RequestReset with an invalid email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Run
+		err := svc.RequestReset(ctx, &RequestResetCmd{Email: "not-an-email"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("SendReset success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithEmail("jane@example.com").Build()
+		now := time.Now()
+		var saved *Token
+		var sent *mailer.Email
+
+		// Mocks
+		usersMock.On("GetByEmail", mock.Anything, "jane@example.com").Return(user, nil).Once()
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), ResetPassword).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*Token)
+		}).Return(nil).Once()
+		mailerMock.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(1).(*mailer.Email)
+		}).Return(nil).Once()
+
+		// Run
+		err := svc.SendReset(ctx, "jane@example.com")
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, ResetPassword, saved.kind)
+		assert.Equal(t, now.Add(ResetTokenLifetime), saved.expiresAt)
+		assert.Equal(t, "jane@example.com", sent.To)
+		assert.Contains(t, sent.Body, "https://onlyfun.example.com/password-reset?token=")
+		assert.Equal(t, saved.hash, hashToken(tokenFromEmail(t, sent)))
+	})
+
+	t.Run("SendReset with an unknown email does nothing", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Mocks
+		usersMock.On("GetByEmail", mock.Anything, "jane@example.com").Return(nil, errs.NotFound(errs.ErrNotFound)).Once()
+
+		// Run
+		err := svc.SendReset(ctx, "jane@example.com")
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("SendReset with a pending user does nothing", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithEmail("jane@example.com").WithStatus(users.Pending).Build()
+
+		// Mocks
+		usersMock.On("GetByEmail", mock.Anything, "jane@example.com").Return(user, nil).Once()
+
+		// Run
+		err := svc.SendReset(ctx, "jane@example.com")
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("CheckResetToken success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		token := NewFakeToken(t).WithRawToken("some-token").Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, token.hash).Return(token, nil).Once()
+		tools.ClockMock.On("Now").Return(token.createdAt).Once()
+
+		// Run
+		err := svc.CheckResetToken(ctx, secret.NewText("some-token"))
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("CheckResetToken with an expired token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		token := NewFakeToken(t).WithRawToken("some-token").Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, token.hash).Return(token, nil).Once()
+		tools.ClockMock.On("Now").Return(token.expiresAt).Once()
+
+		// Run
+		err := svc.CheckResetToken(ctx, secret.NewText("some-token"))
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("CheckResetToken with an empty token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Run
+		err := svc.CheckResetToken(ctx, secret.NewText(""))
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("ResetPassword success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		token := NewFakeToken(t).WithRawToken("some-token").WithUser(user).Build()
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, token.hash).Return(token, nil).Once()
+		tools.ClockMock.On("Now").Return(token.createdAt).Once()
+		storageMock.On("Delete", mock.Anything, token.hash).Return(true, nil).Once()
+		usersMock.On("UpdateUserPassword", mock.Anything, &users.UpdatePasswordCmd{
+			UserID:      user.ID(),
+			NewPassword: secret.NewText("some-new-password"),
+		}).Return(nil).Once()
+		webSessionsMock.On("DeleteAll", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.ResetPassword(ctx, &ResetPasswordCmd{
+			Token:       secret.NewText("some-token"),
+			NewPassword: secret.NewText("some-new-password"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("ResetPassword with an unknown token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Mocks
+		storageMock.On("GetByHash", mock.Anything, hashToken(secret.NewText("some-token"))).Return(nil, errNotFound).Once()
+
+		// Run
+		err := svc.ResetPassword(ctx, &ResetPasswordCmd{
+			Token:       secret.NewText("some-token"),
+			NewPassword: secret.NewText("some-new-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("ResetPassword with a password too short", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Run
+		err := svc.ResetPassword(ctx, &ResetPasswordCmd{
+			Token:       secret.NewText("some-token"),
+			NewPassword: secret.NewText("short"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("ForceReset with an email only sends the link", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithEmail("jane@example.com").Build()
+		now := time.Now()
+		var saved *Token
+		var sent *mailer.Email
+
+		// Mocks
+		usersMock.On("UpdateUserPassword", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			cmd := args.Get(1).(*users.UpdatePasswordCmd)
+			assert.Equal(t, user.ID(), cmd.UserID)
+			assert.NotEmpty(t, cmd.NewPassword.Raw())
+		}).Return(nil).Once()
+		webSessionsMock.On("DeleteAll", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), ResetPassword).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*Token)
+		}).Return(nil).Once()
+		mailerMock.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(1).(*mailer.Email)
+		}).Return(nil).Once()
+
+		// Run
+		link, err := svc.ForceReset(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, ResetPassword, saved.kind)
+		assert.Empty(t, link.Raw())
+		assert.Equal(t, "jane@example.com", sent.To)
+		assert.Contains(t, sent.Body, "https://onlyfun.example.com/password-reset?token=")
+		assert.Equal(t, saved.hash, hashToken(tokenFromEmail(t, sent)))
+	})
+
+	t.Run("ForceReset without an email only returns the link", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithEmail("").Build()
+		now := time.Now()
+
+		// Mocks
+		usersMock.On("UpdateUserPassword", mock.Anything, mock.Anything).Return(nil).Once()
+		webSessionsMock.On("DeleteAll", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), ResetPassword).Return(nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Run
+		link, err := svc.ForceReset(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(link.Raw(), "https://onlyfun.example.com/password-reset?token="))
+	})
+
+	t.Run("ForceReset with an UpdateUserPassword error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		usersMock.On("UpdateUserPassword", mock.Anything, mock.Anything).Return(errs.Internal(assert.AnError)).Once()
+
+		// Run
+		link, err := svc.ForceReset(ctx, user)
+
+		// Asserts
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, link.Raw())
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), VerifyEmail).Return(nil).Once()
+		storageMock.On("DeleteAllForUser", mock.Anything, user.ID(), ResetPassword).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("PurgeExpired success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		mailerMock := mailer.NewMock(t)
+		tasksMock := taskrunner.NewMockService(t)
+		svc := newService(cfg, tools, storageMock, usersMock, webSessionsMock, mailerMock, tasksMock)
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveExpired", mock.Anything, now).Return(nil).Once()
+
+		// Run
+		err := svc.PurgeExpired(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+}
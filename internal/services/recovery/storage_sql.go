@@ -0,0 +1,124 @@
+package recovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "recovery_tokens"
+
+var errNotFound = errors.New("not found")
+
+var allFields = []string{"hash", "kind", "user_id", "email", "created_at", "expires_at"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) Save(ctx context.Context, token *Token) error {
+	_, err := sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(token.hash,
+			token.kind,
+			token.userID,
+			token.email,
+			ptr.To(sqlstorage.SQLTime(token.createdAt)),
+			ptr.To(sqlstorage.SQLTime(token.expiresAt))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetByHash(ctx context.Context, hash string) (*Token, error) {
+	res := Token{}
+
+	var sqlCreatedAt sqlstorage.SQLTime
+	var sqlExpiresAt sqlstorage.SQLTime
+	err := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"hash": hash}).
+		RunWith(s.db).
+		ScanContext(ctx,
+			&res.hash,
+			&res.kind,
+			&res.userID,
+			&res.email,
+			&sqlCreatedAt,
+			&sqlExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+
+	res.createdAt = sqlCreatedAt.Time()
+	res.expiresAt = sqlExpiresAt.Time()
+
+	return &res, nil
+}
+
+// Delete removes the token and reports if it was still there. Only one of
+// the concurrent calls can succeed, making the tokens single-use.
+func (s *sqlStorage) Delete(ctx context.Context, hash string) (bool, error) {
+	res, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"hash": hash}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("sql error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the affected rows: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (s *sqlStorage) DeleteAllForUser(ctx context.Context, userID uuid.UUID, kind Kind) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"user_id": userID, "kind": kind}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) RemoveExpired(ctx context.Context, now time.Time) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Expr("julianday(expires_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(now)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package recovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverySqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, db sqlstorage.Querier) *users.User {
+		t.Helper()
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+
+		return users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+	}
+
+	t.Run("Save and GetByHash success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		token := NewFakeToken(t).WithUser(user).Build()
+
+		err := store.Save(ctx, token)
+		require.NoError(t, err)
+
+		res, err := store.GetByHash(ctx, token.hash)
+		require.NoError(t, err)
+		assert.Equal(t, token, res)
+	})
+
+	t.Run("GetByHash not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetByHash(ctx, "some-unknown-hash")
+		require.ErrorIs(t, err, errNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Delete succeeds only once", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		token := NewFakeToken(t).WithUser(user).BuildAndStore(ctx, db)
+
+		deleted, err := store.Delete(ctx, token.hash)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = store.Delete(ctx, token.hash)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	t.Run("DeleteAllForUser only removes the given kind", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		reset := NewFakeToken(t).WithUser(user).WithKind(ResetPassword).BuildAndStore(ctx, db)
+		verify := NewFakeToken(t).WithUser(user).WithKind(VerifyEmail).BuildAndStore(ctx, db)
+
+		err := store.DeleteAllForUser(ctx, user.ID(), ResetPassword)
+		require.NoError(t, err)
+
+		_, err = store.GetByHash(ctx, reset.hash)
+		require.ErrorIs(t, err, errNotFound)
+
+		res, err := store.GetByHash(ctx, verify.hash)
+		require.NoError(t, err)
+		assert.Equal(t, verify, res)
+	})
+
+	t.Run("RemoveExpired success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now()
+		user := newUser(t, db)
+		expired := NewFakeToken(t).WithUser(user).ExpiresAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
+		valid := NewFakeToken(t).WithUser(user).ExpiresAt(now.Add(time.Minute)).BuildAndStore(ctx, db)
+
+		err := store.RemoveExpired(ctx, now)
+		require.NoError(t, err)
+
+		_, err = store.GetByHash(ctx, expired.hash)
+		require.ErrorIs(t, err, errNotFound)
+
+		_, err = store.GetByHash(ctx, valid.hash)
+		require.NoError(t, err)
+	})
+}
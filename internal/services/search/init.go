@@ -0,0 +1,21 @@
+package search
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+type Service interface {
+	SearchPosts(ctx context.Context, cmd *SearchCmd) ([]posts.Post, bool, error)
+}
+
+func Init(
+	db sqlstorage.Querier,
+	postsSvc posts.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(storage, postsSvc)
+}
@@ -0,0 +1,20 @@
+package search
+
+import (
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const maxResults = 100
+
+type SearchCmd struct {
+	Query  string
+	Offset uint
+	Limit  uint
+}
+
+func (t SearchCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Query, v.Required, v.Length(1, 200)),
+		v.Field(&t.Limit, v.Required, v.Max(uint(maxResults))),
+	)
+}
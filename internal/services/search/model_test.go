@@ -0,0 +1,32 @@
+package search
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SearchCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(SearchCmd))
+}
+
+func Test_SearchCmd_Validate_success(t *testing.T) {
+	err := SearchCmd{
+		Query:  "funny cat",
+		Offset: 20,
+		Limit:  20,
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_SearchCmd_Validate_with_too_much_results(t *testing.T) {
+	err := SearchCmd{
+		Query: "funny cat",
+		Limit: maxResults + 1,
+	}.Validate()
+
+	require.Error(t, err)
+}
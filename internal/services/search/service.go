@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+)
+
+type storage interface {
+	SearchPosts(ctx context.Context, match string, offset uint, limit uint) ([]uint, error)
+}
+
+type service struct {
+	storage  storage
+	postsSvc posts.Service
+}
+
+func newService(storage storage, postsSvc posts.Service) *service {
+	return &service{
+		storage:  storage,
+		postsSvc: postsSvc,
+	}
+}
+
+// SearchPosts returns the listed posts matching the query, the most relevant
+// first. The boolean is true if there is more results after this page.
+//
+// The titles, the tags and the published comments are searched.
+func (s *service) SearchPosts(ctx context.Context, cmd *SearchCmd) ([]posts.Post, bool, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, false, errs.Validation(err)
+	}
+
+	match := toMatchQuery(cmd.Query)
+	if match == "" {
+		return []posts.Post{}, false, nil
+	}
+
+	// Ask for an extra result in order to know if there is a next page.
+	postIDs, err := s.storage.SearchPosts(ctx, match, cmd.Offset, cmd.Limit+1)
+	if err != nil {
+		return nil, false, errs.Internal(fmt.Errorf("failed to SearchPosts: %w", err))
+	}
+
+	hasMore := uint(len(postIDs)) > cmd.Limit
+	if hasMore {
+		postIDs = postIDs[:cmd.Limit]
+	}
+
+	res := make([]posts.Post, 0, len(postIDs))
+	for _, postID := range postIDs {
+		post, err := s.postsSvc.GetByID(ctx, postID)
+		if err != nil {
+			return nil, false, errs.Internal(fmt.Errorf("failed to GetByID the post %d: %w", postID, err))
+		}
+
+		res = append(res, *post)
+	}
+
+	return res, hasMore, nil
+}
+
+// toMatchQuery converts a user query into a MATCH expression. The special
+// characters are removed in order to avoid any syntax error and each word is
+// used as a prefix: "funny cat" becomes "funny* cat*".
+func toMatchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + "*"
+	}
+
+	return strings.Join(words, " ")
+}
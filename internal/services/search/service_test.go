@@ -0,0 +1,108 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Search_Service(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("SearchPosts success", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		post1 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		post2 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		storage.On("SearchPosts", ctx, "funny* cat*", uint(0), uint(3)).Return([]uint{post1.ID(), post2.ID()}, nil).Once()
+		postsSvc.On("GetByID", ctx, post1.ID()).Return(post1, nil).Once()
+		postsSvc.On("GetByID", ctx, post2.ID()).Return(post2, nil).Once()
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: "Funny cat!", Offset: 0, Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, []posts.Post{*post1, *post2}, res)
+		require.False(t, hasMore)
+	})
+
+	t.Run("SearchPosts with more results", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		post1 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		storage.On("SearchPosts", ctx, "cat*", uint(10), uint(2)).Return([]uint{post1.ID(), 42}, nil).Once()
+		postsSvc.On("GetByID", ctx, post1.ID()).Return(post1, nil).Once()
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: "cat", Offset: 10, Limit: 1})
+		require.NoError(t, err)
+		require.Equal(t, []posts.Post{*post1}, res)
+		require.True(t, hasMore)
+	})
+
+	t.Run("SearchPosts without any word", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: `"*-`, Limit: 2})
+		require.NoError(t, err)
+		require.Empty(t, res)
+		require.False(t, hasMore)
+	})
+
+	t.Run("SearchPosts with a validation error", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: "", Limit: 2})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+		require.False(t, hasMore)
+	})
+
+	t.Run("SearchPosts with a storage error", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		storage.On("SearchPosts", ctx, "cat*", uint(0), uint(3)).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: "cat", Limit: 2})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+		require.False(t, hasMore)
+	})
+
+	t.Run("SearchPosts with a GetByID error", func(t *testing.T) {
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		svc := newService(storage, postsSvc)
+
+		storage.On("SearchPosts", ctx, "cat*", uint(0), uint(3)).Return([]uint{12}, nil).Once()
+		postsSvc.On("GetByID", ctx, uint(12)).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, hasMore, err := svc.SearchPosts(ctx, &SearchCmd{Query: "cat", Limit: 2})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+		require.False(t, hasMore)
+	})
+}
+
+func Test_toMatchQuery(t *testing.T) {
+	assert.Equal(t, "funny* cat*", toMatchQuery(`Funny "CAT"`))
+	assert.Equal(t, "don* t*", toMatchQuery("don't"))
+	assert.Empty(t, toMatchQuery(" *- "))
+}
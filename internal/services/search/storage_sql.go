@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+const tableName = "posts_search"
+
+// rankExpr ranks the matches with the weights of the title, the tags and
+// the comments columns. The best matches have the lowest bm25 score.
+const rankExpr = "bm25(posts_search, 3.0, 2.0, 1.0)"
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+// SearchPosts returns the ids of the listed posts matching the fts expression,
+// ordered by relevance.
+func (s *sqlStorage) SearchPosts(ctx context.Context, match string, offset uint, limit uint) ([]uint, error) {
+	rows, err := sq.
+		Select(tableName+".rowid").
+		From(tableName).
+		Join("posts ON posts.id = "+tableName+".rowid").
+		Where(sq.Expr(tableName+" MATCH ?", match)).
+		Where(sq.Eq{"posts.status": posts.Listed}).
+		OrderBy(rankExpr, tableName+".rowid DESC").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []uint{}
+
+	for rows.Next() {
+		var postID uint
+
+		err := rows.Scan(&postID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan a row: %w", err)
+		}
+
+		res = append(res, postID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
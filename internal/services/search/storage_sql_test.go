@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("SearchPosts with nothing", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.SearchPosts(ctx, "cat*", 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+
+	t.Run("SearchPosts success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		inTitle := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("My cat is funny").BuildAndStore(ctx, db)
+		inTags := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("Look at this").WithTags("cats").BuildAndStore(ctx, db)
+		inComment := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("Some title").BuildAndStore(ctx, db)
+		_ = comments.NewFakeComment(t).WithPost(inComment).CreatedBy(user).WithContent("This is my cat").BuildAndStore(ctx, db)
+
+		// Not listed
+		notListed := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Uploaded).WithTitle("Not listed").BuildAndStore(ctx, db)
+		_ = comments.NewFakeComment(t).WithPost(notListed).CreatedBy(user).WithContent("Another cat").BuildAndStore(ctx, db)
+		// Not matching
+		_ = posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("Some dog").BuildAndStore(ctx, db)
+		// Moderated comment
+		moderated := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("Some bird").BuildAndStore(ctx, db)
+		_ = comments.NewFakeComment(t).WithPost(moderated).CreatedBy(user).WithContent("I love cats").
+			WithStatus(comments.Moderated).BuildAndStore(ctx, db)
+
+		// The title has a bigger weight than the tags which have a bigger
+		// weight than the comments.
+		res, err := store.SearchPosts(ctx, "cat*", 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []uint{inTitle.ID(), inTags.ID(), inComment.ID()}, res)
+
+		// Pagination
+		res, err = store.SearchPosts(ctx, "cat*", 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint{inTags.ID()}, res)
+	})
+
+	t.Run("SearchPosts follows the tags update", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		post := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).WithTitle("Some title").BuildAndStore(ctx, db)
+
+		_, err := db.ExecContext(ctx, "UPDATE posts SET tags = 'dogs' WHERE id = ?", post.ID())
+		require.NoError(t, err)
+
+		res, err := store.SearchPosts(ctx, "dog*", 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []uint{post.ID()}, res)
+	})
+}
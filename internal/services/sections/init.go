@@ -0,0 +1,28 @@
+package sections
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+type Service interface {
+	Create(ctx context.Context, cmd *CreateCmd) (*Section, error)
+	GetByName(ctx context.Context, name string) (*Section, error)
+	GetAll(ctx context.Context) ([]Section, error)
+	Delete(ctx context.Context, cmd *DeleteCmd) error
+	Reassign(ctx context.Context, from, to *users.User) error
+}
+
+func Init(
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	permsSvc perms.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(tools, storage, permsSvc)
+}
@@ -0,0 +1,50 @@
+package sections
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+// Section is a tag curated by the admins. The sections are proposed to the
+// users when they submit a post and are listed on the home page.
+type Section struct {
+	name        string
+	description string
+	createdAt   time.Time
+	createdBy   uuid.UUID
+}
+
+func (s Section) Name() string         { return s.name }
+func (s Section) Description() string  { return s.description }
+func (s Section) CreatedAt() time.Time { return s.createdAt }
+func (s Section) CreatedBy() uuid.UUID { return s.createdBy }
+
+type CreateCmd struct {
+	User        *users.User
+	Name        string
+	Description string
+}
+
+func (t CreateCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Name, v.Required, v.Length(2, 30), v.Match(posts.TagRegexp)),
+		v.Field(&t.Description, v.Length(0, 200)),
+	)
+}
+
+type DeleteCmd struct {
+	User    *users.User
+	Section *Section
+}
+
+func (t DeleteCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Section, v.Required),
+	)
+}
@@ -0,0 +1,65 @@
+package sections
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeSectionBuilder struct {
+	t       testing.TB
+	section *Section
+}
+
+func NewFakeSection(t testing.TB) *FakeSectionBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*1000), time.Now())
+
+	return &FakeSectionBuilder{
+		t: t,
+		section: &Section{
+			name:        strings.ToLower(gofakeit.LetterN(10)),
+			description: gofakeit.LoremIpsumSentence(8),
+			createdAt:   createdAt,
+			createdBy:   uuidProvider.New(),
+		},
+	}
+}
+
+func (f *FakeSectionBuilder) WithName(name string) *FakeSectionBuilder {
+	f.section.name = name
+
+	return f
+}
+
+func (f *FakeSectionBuilder) CreatedBy(user *users.User) *FakeSectionBuilder {
+	f.section.createdBy = user.ID()
+
+	return f
+}
+
+func (f *FakeSectionBuilder) Build() *Section {
+	return f.section
+}
+
+func (f *FakeSectionBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Section {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	section := f.Build()
+
+	err := storage.Save(ctx, section)
+	require.NoError(f.t, err)
+
+	return section
+}
@@ -0,0 +1,46 @@
+package sections
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Section_Getters(t *testing.T) {
+	s := NewFakeSection(t).Build()
+
+	assert.Equal(t, s.name, s.Name())
+	assert.Equal(t, s.description, s.Description())
+	assert.Equal(t, s.createdAt, s.CreatedAt())
+	assert.Equal(t, s.createdBy, s.CreatedBy())
+}
+
+func Test_CreateCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(CreateCmd))
+}
+
+func Test_CreateCmd_Validate_success(t *testing.T) {
+	err := CreateCmd{
+		User:        users.NewFakeUser(t).Build(),
+		Name:        "funny-animals",
+		Description: "Cats, dogs and all the others.",
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_CreateCmd_Validate_with_an_invalid_name(t *testing.T) {
+	err := CreateCmd{
+		User: users.NewFakeUser(t).Build(),
+		Name: "Funny Animals",
+	}.Validate()
+
+	require.Error(t, err)
+}
+
+func Test_DeleteCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(DeleteCmd))
+}
@@ -0,0 +1,130 @@
+package sections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var ErrAlreadyExists = errors.New("section already exists")
+
+type storage interface {
+	Save(ctx context.Context, section *Section) error
+	GetByName(ctx context.Context, name string) (*Section, error)
+	GetAll(ctx context.Context) ([]Section, error)
+	Delete(ctx context.Context, name string) error
+	UpdateCreator(ctx context.Context, from, to uuid.UUID) error
+}
+
+type service struct {
+	storage  storage
+	permsSvc perms.Service
+	clock    clock.Clock
+}
+
+func newService(tools tools.Tools, storage storage, permsSvc perms.Service) *service {
+	return &service{
+		storage:  storage,
+		permsSvc: permsSvc,
+		clock:    tools.Clock(),
+	}
+}
+
+// Create adds a new section. The section name is normalized like a post tag.
+func (s *service) Create(ctx context.Context, cmd *CreateCmd) (*Section, error) {
+	if tags := posts.NormalizeTags([]string{cmd.Name}); len(tags) == 1 {
+		cmd.Name = tags[0]
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.ManageSections) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.ManageSections))
+	}
+
+	existing, err := s.storage.GetByName(ctx, cmd.Name)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByName: %w", err))
+	}
+
+	if existing != nil {
+		return nil, errs.BadRequest(ErrAlreadyExists, "section already exists")
+	}
+
+	section := Section{
+		name:        cmd.Name,
+		description: cmd.Description,
+		createdAt:   s.clock.Now(),
+		createdBy:   cmd.User.ID(),
+	}
+
+	err = s.storage.Save(ctx, &section)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to Save the section: %w", err))
+	}
+
+	return &section, nil
+}
+
+func (s *service) GetByName(ctx context.Context, name string) (*Section, error) {
+	res, err := s.storage.GetByName(ctx, name)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(err)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByName: %w", err))
+	}
+
+	return res, nil
+}
+
+// GetAll returns all the sections ordered by name.
+func (s *service) GetAll(ctx context.Context) ([]Section, error) {
+	res, err := s.storage.GetAll(ctx)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetAll: %w", err))
+	}
+
+	return res, nil
+}
+
+// Delete removes the section. The posts keep their tag.
+func (s *service) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.ManageSections) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.ManageSections))
+	}
+
+	err = s.storage.Delete(ctx, cmd.Section.name)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Delete the section %q: %w", cmd.Section.name, err))
+	}
+
+	return nil
+}
+
+// Reassign transfers all the sections created by the user "from" to the user "to".
+func (s *service) Reassign(ctx context.Context, from, to *users.User) error {
+	err := s.storage.UpdateCreator(ctx, from.ID(), to.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateCreator: %w", err))
+	}
+
+	return nil
+}
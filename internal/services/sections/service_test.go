@@ -0,0 +1,253 @@
+package sections
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sections_Service(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Create success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+
+		expected := &Section{
+			name:        "funny-animals",
+			description: "Cats and dogs.",
+			createdAt:   now,
+			createdBy:   user.ID(),
+		}
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(true).Once()
+		storage.On("GetByName", ctx, "funny-animals").Return(nil, errNotFound).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Save", ctx, expected).Return(nil).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User:        user,
+			Name:        "Funny Animals",
+			Description: "Cats and dogs.",
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("Create with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		res, err := svc.Create(ctx, &CreateCmd{
+			User: users.NewFakeUser(t).Build(),
+			Name: "not/valid",
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(false).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{User: user, Name: "cats"})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with an already existing section", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		section := NewFakeSection(t).WithName("cats").Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(true).Once()
+		storage.On("GetByName", ctx, "cats").Return(section, nil).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{User: user, Name: "cats"})
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+		require.Nil(t, res)
+	})
+
+	t.Run("Create with a Save error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(true).Once()
+		storage.On("GetByName", ctx, "cats").Return(nil, errNotFound).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Save", ctx, &Section{name: "cats", createdAt: now, createdBy: user.ID()}).
+			Return(fmt.Errorf("some-error")).Once()
+
+		res, err := svc.Create(ctx, &CreateCmd{User: user, Name: "cats"})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("GetByName success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		section := NewFakeSection(t).Build()
+
+		storage.On("GetByName", ctx, section.name).Return(section, nil).Once()
+
+		res, err := svc.GetByName(ctx, section.name)
+		require.NoError(t, err)
+		require.Equal(t, section, res)
+	})
+
+	t.Run("GetByName not found", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		storage.On("GetByName", ctx, "cats").Return(nil, errNotFound).Once()
+
+		res, err := svc.GetByName(ctx, "cats")
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		sections := []Section{*NewFakeSection(t).Build()}
+
+		storage.On("GetAll", ctx).Return(sections, nil).Once()
+
+		res, err := svc.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, sections, res)
+	})
+
+	t.Run("GetAll with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		storage.On("GetAll", ctx).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, err := svc.GetAll(ctx)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		section := NewFakeSection(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(true).Once()
+		storage.On("Delete", ctx, section.name).Return(nil).Once()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Section: section})
+		require.NoError(t, err)
+	})
+
+	t.Run("Delete without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		section := NewFakeSection(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(false).Once()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Section: section})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("Delete with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		section := NewFakeSection(t).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.ManageSections).Return(true).Once()
+		storage.On("Delete", ctx, section.name).Return(fmt.Errorf("some-error")).Once()
+
+		err := svc.Delete(ctx, &DeleteCmd{User: user, Section: section})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+	t.Run("Reassign success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(nil).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reassign with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		ghost := users.NewFakeUser(t).Build()
+
+		storage.On("UpdateCreator", ctx, user.ID(), ghost.ID()).Return(assert.AnError).Once()
+
+		err := svc.Reassign(ctx, user, ghost)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
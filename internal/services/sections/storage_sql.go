@@ -0,0 +1,135 @@
+package sections
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "sections"
+
+var errNotFound = errors.New("not found")
+
+var allFields = []string{"name", "description", "created_at", "created_by"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) Save(ctx context.Context, section *Section) error {
+	_, err := sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(
+			section.name,
+			section.description,
+			ptr.To(sqlstorage.SQLTime(section.createdAt)),
+			section.createdBy).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetByName(ctx context.Context, name string) (*Section, error) {
+	row := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Eq{"name": name}).
+		RunWith(s.db).
+		QueryRowContext(ctx)
+
+	return s.scanRow(row)
+}
+
+func (s *sqlStorage) GetAll(ctx context.Context) ([]Section, error) {
+	rows, err := sq.
+		Select(allFields...).
+		From(tableName).
+		OrderBy("name").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []Section{}
+
+	for rows.Next() {
+		section, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *section)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) Delete(ctx context.Context, name string) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"name": name}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) scanRow(row sq.RowScanner) (*Section, error) {
+	var res Section
+	var sqlCreatedAt sqlstorage.SQLTime
+
+	err := row.Scan(
+		&res.name,
+		&res.description,
+		&sqlCreatedAt,
+		&res.createdBy,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	res.createdAt = sqlCreatedAt.Time()
+
+	return &res, nil
+}
+
+func (s *sqlStorage) UpdateCreator(ctx context.Context, from, to uuid.UUID) error {
+	_, err := sq.Update(tableName).
+		Set("created_by", to).
+		Where(sq.Eq{"created_by": from}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
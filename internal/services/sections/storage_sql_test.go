@@ -0,0 +1,112 @@
+package sections
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSectionSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Save and GetByName success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		section := NewFakeSection(t).CreatedBy(user).Build()
+		section.createdAt = time.Now().UTC().Round(time.Millisecond)
+
+		err := store.Save(ctx, section)
+		require.NoError(t, err)
+
+		res, err := store.GetByName(ctx, section.name)
+		require.NoError(t, err)
+		require.Equal(t, section, res)
+	})
+
+	t.Run("GetByName not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		res, err := store.GetByName(ctx, "unknown")
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		dogs := NewFakeSection(t).WithName("dogs").CreatedBy(user).BuildAndStore(ctx, db)
+		cats := NewFakeSection(t).WithName("cats").CreatedBy(user).BuildAndStore(ctx, db)
+
+		res, err := store.GetAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, cats.name, res[0].Name())
+		require.Equal(t, dogs.name, res[1].Name())
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		section := NewFakeSection(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		err := store.Delete(ctx, section.name)
+		require.NoError(t, err)
+
+		res, err := store.GetByName(ctx, section.name)
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+	t.Run("UpdateCreator success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		ghost := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		section := NewFakeSection(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		// Run
+		err := store.UpdateCreator(ctx, user.ID(), ghost.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := store.GetByName(ctx, section.Name())
+		require.NoError(t, err)
+		require.Equal(t, ghost.ID(), res.CreatedBy())
+	})
+}
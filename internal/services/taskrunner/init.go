@@ -17,7 +17,8 @@ type Task interface {
 
 type Service interface {
 	RegisterTask(ctx context.Context, task Task) error
-	Run(ctx context.Context) error
+	// Run executes all the queued tasks with the runner matching their name.
+	Run(ctx context.Context, runners []TaskRunner) error
 }
 
 type TaskRunner interface {
@@ -25,8 +26,10 @@ type TaskRunner interface {
 	Name() string
 }
 
-func Init(runners []TaskRunner, tools tools.Tools, db sqlstorage.Querier) Service {
+// Init doesn't take the runners: they depend on the services registering the
+// tasks, see [RunWorker].
+func Init(tools tools.Tools, db sqlstorage.Querier) Service {
 	storage := newSqlStorage(db)
 
-	return newService(tools, storage, runners)
+	return newService(tools, storage)
 }
@@ -28,22 +28,14 @@ type storage interface {
 
 type service struct {
 	storage storage
-	runners map[string]TaskRunner
 	uuid    uuid.Service
 	clock   clock.Clock
 	log     *slog.Logger
 }
 
-func newService(tools tools.Tools, storage storage, runners []TaskRunner) *service {
-	runnerMap := make(map[string]TaskRunner, len(runners))
-
-	for _, runner := range runners {
-		runnerMap[runner.Name()] = runner
-	}
-
+func newService(tools tools.Tools, storage storage) *service {
 	return &service{
 		storage: storage,
-		runners: runnerMap,
 		uuid:    tools.UUID(),
 		clock:   tools.Clock(),
 		log:     tools.Logger(),
@@ -71,7 +63,12 @@ func (s *service) RegisterTask(ctx context.Context, task Task) error {
 	return nil
 }
 
-func (s *service) Run(ctx context.Context) error {
+func (s *service) Run(ctx context.Context, runners []TaskRunner) error {
+	runnerMap := make(map[string]TaskRunner, len(runners))
+	for _, runner := range runners {
+		runnerMap[runner.Name()] = runner
+	}
+
 	for {
 		task, err := s.storage.GetNext(ctx)
 		if errors.Is(err, errNotFound) {
@@ -85,7 +82,7 @@ func (s *service) Run(ctx context.Context) error {
 
 		logger := s.log.With(slog.Any("task", task))
 
-		runner, ok := s.runners[task.Name]
+		runner, ok := runnerMap[task.Name]
 		if !ok {
 			logger.Error(fmt.Sprintf("unhandled task name: %s", task.Name))
 
@@ -31,17 +31,17 @@ func (_m *MockService) RegisterTask(ctx context.Context, task Task) error {
 	return r0
 }
 
-// Run provides a mock function with given fields: ctx
-func (_m *MockService) Run(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// Run provides a mock function with given fields: ctx, runners
+func (_m *MockService) Run(ctx context.Context, runners []TaskRunner) error {
+	ret := _m.Called(ctx, runners)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Run")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, []TaskRunner) error); ok {
+		r0 = rf(ctx, runners)
 	} else {
 		r0 = ret.Error(0)
 	}
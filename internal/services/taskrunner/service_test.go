@@ -26,7 +26,7 @@ func TestTasksService(t *testing.T) {
 		task := newFakeTask(t).WithTaksName("some-task").Build()
 
 		taskRunner.On("Name").Return("some-task").Once()
-		svc := newService(tools, storage, []TaskRunner{taskRunner})
+		svc := newService(tools, storage)
 
 		// First loop
 		storage.On("GetNext", mock.Anything).Return(task, nil).Once()
@@ -36,7 +36,7 @@ func TestTasksService(t *testing.T) {
 		// Second loop
 		storage.On("GetNext", mock.Anything).Return(nil, errNotFound).Once()
 
-		err := svc.Run(context.Background())
+		err := svc.Run(context.Background(), []TaskRunner{taskRunner})
 		require.NoError(t, err)
 	})
 
@@ -46,11 +46,11 @@ func TestTasksService(t *testing.T) {
 		taskRunner := newMockTaskRunner(t)
 
 		taskRunner.On("Name").Return("some-task").Once()
-		svc := newService(tools, storage, []TaskRunner{taskRunner})
+		svc := newService(tools, storage)
 
 		storage.On("GetNext", mock.Anything).Return(nil, fmt.Errorf("some-error")).Once()
 
-		err := svc.Run(context.Background())
+		err := svc.Run(context.Background(), []TaskRunner{taskRunner})
 		require.ErrorContains(t, err, "some-error")
 	})
 
@@ -62,7 +62,7 @@ func TestTasksService(t *testing.T) {
 		task := newFakeTask(t).WithTaksName("some-task").Build()
 
 		taskRunner.On("Name").Return("some-task").Once()
-		svc := newService(tools, storage, []TaskRunner{taskRunner})
+		svc := newService(tools, storage)
 
 		// First loop
 		storage.On("GetNext", mock.Anything).Return(task, nil).Once()
@@ -83,7 +83,7 @@ func TestTasksService(t *testing.T) {
 		// Second loop
 		storage.On("GetNext", mock.Anything).Return(nil, errNotFound).Once()
 
-		err := svc.Run(context.Background())
+		err := svc.Run(context.Background(), []TaskRunner{taskRunner})
 		require.NoError(t, err)
 	})
 
@@ -96,7 +96,7 @@ func TestTasksService(t *testing.T) {
 		task := newFakeTask(t).WithTaksName("some-task").WithRetries(defaultMaxRetries).Build()
 
 		taskRunner.On("Name").Return("some-task").Once()
-		svc := newService(tools, storage, []TaskRunner{taskRunner})
+		svc := newService(tools, storage)
 
 		// First loop
 		storage.On("GetNext", mock.Anything).Return(task, nil).Once()
@@ -112,7 +112,7 @@ func TestTasksService(t *testing.T) {
 		// Second loop
 		storage.On("GetNext", mock.Anything).Return(nil, errNotFound).Once()
 
-		err := svc.Run(context.Background())
+		err := svc.Run(context.Background(), []TaskRunner{taskRunner})
 		require.NoError(t, err)
 	})
 
@@ -125,7 +125,7 @@ func TestTasksService(t *testing.T) {
 		task := newFakeTask(t).WithTaksName("some-task").WithRetries(defaultMaxRetries).Build()
 
 		taskRunner.On("Name").Return("some-task").Once()
-		svc := newService(tools, storage, []TaskRunner{taskRunner})
+		svc := newService(tools, storage)
 
 		// First loop
 		storage.On("GetNext", mock.Anything).Return(task, nil).Once()
@@ -140,14 +140,14 @@ func TestTasksService(t *testing.T) {
 
 		// No second loop
 
-		err := svc.Run(context.Background())
+		err := svc.Run(context.Background(), []TaskRunner{taskRunner})
 		require.ErrorContains(t, err, "some-error")
 	})
 
 	t.Run("RegisterTask success", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
-		svc := newService(tools, storage, []TaskRunner{})
+		svc := newService(tools, storage)
 
 		task := taskStub{
 			name:          "test",
@@ -179,7 +179,7 @@ func TestTasksService(t *testing.T) {
 	t.Run("RegisterTask with a validation error", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
-		svc := newService(tools, storage, []TaskRunner{})
+		svc := newService(tools, storage)
 
 		task := taskStub{
 			name:          "test",
@@ -196,7 +196,7 @@ func TestTasksService(t *testing.T) {
 	t.Run("RegisterTask with a storage error", func(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
-		svc := newService(tools, storage, []TaskRunner{})
+		svc := newService(tools, storage)
 
 		task := taskStub{
 			name:          "test",
@@ -0,0 +1,25 @@
+package taskrunner
+
+import (
+	"context"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/periodic"
+	"go.uber.org/fx"
+)
+
+// RunInterval is the delay between two runs of the queued tasks.
+const RunInterval = 10 * time.Second
+
+// RunWorker executes periodically the queued tasks for as long as the
+// application is running.
+func RunWorker(lc fx.Lifecycle, svc Service, runners []TaskRunner, tools tools.Tools) {
+	periodic.Register(lc, tools.Logger(), periodic.Job{
+		Name:     "taskrunner-worker",
+		Interval: RunInterval,
+		Run: func(ctx context.Context) error {
+			return svc.Run(ctx, runners)
+		},
+	})
+}
@@ -0,0 +1,37 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+type Service interface {
+	IsEnabled(ctx context.Context, user *users.User) (bool, error)
+	IsRequired(ctx context.Context, user *users.User) (bool, error)
+	NeedsChallenge(ctx context.Context, user *users.User) (bool, error)
+	Enroll(ctx context.Context, user *users.User) (*Enrollment, error)
+	Confirm(ctx context.Context, cmd *ConfirmCmd) ([]string, error)
+	Verify(ctx context.Context, cmd *VerifyCmd) error
+	RemainingRecoveryCodes(ctx context.Context, user *users.User) (int, error)
+	Disable(ctx context.Context, cmd *DisableCmd) error
+	GetRequiredRoles(ctx context.Context) ([]perms.Role, error)
+	SetRequired(ctx context.Context, cmd *SetRequiredCmd) error
+	CreateChallenge(ctx context.Context, cmd *CreateChallengeCmd) (*Challenge, error)
+	GetChallenge(ctx context.Context, token secret.Text) (*Challenge, error)
+	RegisterChallengeFailure(ctx context.Context, challenge *Challenge) error
+	DeleteChallenge(ctx context.Context, challenge *Challenge) error
+	// DeleteAll removes the secret, the recovery codes and the challenges of
+	// the user, whatever its role requires.
+	DeleteAll(ctx context.Context, user *users.User) error
+}
+
+func Init(tools tools.Tools, db sqlstorage.Querier, permsSvc perms.Service) Service {
+	storage := newSqlStorage(db)
+
+	return newService(tools, storage, permsSvc)
+}
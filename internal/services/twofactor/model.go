@@ -0,0 +1,137 @@
+package twofactor
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// Issuer is the name displayed by the authenticator applications.
+	Issuer = "OnlyFun"
+
+	// Period is the validity duration of a TOTP code, as recommended by
+	// the RFC 6238.
+	Period = 30 * time.Second
+
+	// Skew is the number of periods accepted before and after the current
+	// one, to compensate the clock drift of the devices.
+	Skew = 1
+
+	RecoveryCodesCount = 10
+
+	// RecoveryCodeSize is the number of random bytes of a recovery code. The
+	// codes are only hashed with sha256 so they must be long enough to resist
+	// an offline brute force of a leaked hash.
+	RecoveryCodeSize = 10
+
+	ChallengeLifetime    = 5 * time.Minute
+	ChallengeMaxAttempts = 5
+)
+
+// Secret is the TOTP shared secret of a user. It is pending until the user
+// proves they configured their authenticator by giving a first valid code.
+type Secret struct {
+	enabledAt *time.Time
+	createdAt time.Time
+	userID    uuid.UUID
+	secret    secret.Text
+	// lastStep is the last time step used, a code can't be used twice.
+	lastStep int64
+}
+
+func (s Secret) UserID() uuid.UUID     { return s.userID }
+func (s Secret) CreatedAt() time.Time  { return s.createdAt }
+func (s Secret) EnabledAt() *time.Time { return s.enabledAt }
+func (s Secret) IsEnabled() bool       { return s.enabledAt != nil }
+
+// Enrollment contains everything needed to configure an authenticator
+// application.
+type Enrollment struct {
+	// URL is the "otpauth://" url encoded inside the QR code.
+	URL string
+	// Secret is the base32 secret for a manual configuration.
+	Secret string
+	// QRCode is a PNG image of the URL.
+	QRCode []byte
+}
+
+// Challenge is the second step of a login: the password has been checked
+// and the user must now give a TOTP code. No web session exists until the
+// challenge is solved.
+type Challenge struct {
+	expiresAt time.Time
+	token     secret.Text
+	userID    uuid.UUID
+	attempts  int
+	remember  bool
+}
+
+func (c Challenge) Token() secret.Text   { return c.token }
+func (c Challenge) UserID() uuid.UUID    { return c.userID }
+func (c Challenge) Attempts() int        { return c.attempts }
+func (c Challenge) Remember() bool       { return c.remember }
+func (c Challenge) ExpiresAt() time.Time { return c.expiresAt }
+
+type ConfirmCmd struct {
+	User *users.User
+	Code string
+}
+
+func (t ConfirmCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Code, v.Required),
+	)
+}
+
+// VerifyCmd checks a TOTP code or a recovery code.
+type VerifyCmd struct {
+	User *users.User
+	Code string
+}
+
+func (t VerifyCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Code, v.Required),
+	)
+}
+
+type DisableCmd struct {
+	User *users.User
+	Code string
+}
+
+func (t DisableCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Code, v.Required),
+	)
+}
+
+type SetRequiredCmd struct {
+	Role     perms.Role
+	Required bool
+}
+
+func (t SetRequiredCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Role, v.Required),
+	)
+}
+
+type CreateChallengeCmd struct {
+	User     *users.User
+	Remember bool
+}
+
+func (t CreateChallengeCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+	)
+}
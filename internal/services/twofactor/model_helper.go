@@ -0,0 +1,135 @@
+package twofactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeSecretBuilder struct {
+	t      testing.TB
+	secret *Secret
+}
+
+// NewFakeSecret builds an enabled secret.
+func NewFakeSecret(t testing.TB) *FakeSecretBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now())
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: Issuer, AccountName: gofakeit.Username()})
+	require.NoError(t, err)
+
+	return &FakeSecretBuilder{
+		t: t,
+		secret: &Secret{
+			userID:    uuidProvider.New(),
+			secret:    secret.NewText(key.Secret()),
+			lastStep:  0,
+			enabledAt: ptr.To(createdAt),
+			createdAt: createdAt,
+		},
+	}
+}
+
+func (f *FakeSecretBuilder) WithUser(user *users.User) *FakeSecretBuilder {
+	f.secret.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeSecretBuilder) Pending() *FakeSecretBuilder {
+	f.secret.enabledAt = nil
+
+	return f
+}
+
+func (f *FakeSecretBuilder) Build() *Secret {
+	return f.secret
+}
+
+func (f *FakeSecretBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Secret {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	secret := f.Build()
+
+	err := storage.SaveSecret(ctx, secret)
+	require.NoError(f.t, err)
+
+	return secret
+}
+
+type FakeChallengeBuilder struct {
+	t         testing.TB
+	challenge *Challenge
+}
+
+func NewFakeChallenge(t testing.TB) *FakeChallengeBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+
+	return &FakeChallengeBuilder{
+		t: t,
+		challenge: &Challenge{
+			token:     secret.NewText(string(uuidProvider.New())),
+			userID:    uuidProvider.New(),
+			remember:  false,
+			attempts:  0,
+			expiresAt: time.Now().Add(ChallengeLifetime),
+		},
+	}
+}
+
+func (f *FakeChallengeBuilder) WithUser(user *users.User) *FakeChallengeBuilder {
+	f.challenge.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeChallengeBuilder) WithRemember() *FakeChallengeBuilder {
+	f.challenge.remember = true
+
+	return f
+}
+
+func (f *FakeChallengeBuilder) WithAttempts(attempts int) *FakeChallengeBuilder {
+	f.challenge.attempts = attempts
+
+	return f
+}
+
+func (f *FakeChallengeBuilder) ExpiresAt(expiresAt time.Time) *FakeChallengeBuilder {
+	f.challenge.expiresAt = expiresAt
+
+	return f
+}
+
+func (f *FakeChallengeBuilder) Build() *Challenge {
+	return f.challenge
+}
+
+func (f *FakeChallengeBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Challenge {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	challenge := f.Build()
+
+	err := storage.SaveChallenge(ctx, challenge)
+	require.NoError(f.t, err)
+
+	return challenge
+}
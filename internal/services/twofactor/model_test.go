@@ -0,0 +1,56 @@
+package twofactor
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Secret_Getters(t *testing.T) {
+	s := NewFakeSecret(t).Build()
+
+	assert.Equal(t, s.userID, s.UserID())
+	assert.Equal(t, s.enabledAt, s.EnabledAt())
+	assert.Equal(t, s.createdAt, s.CreatedAt())
+	assert.True(t, s.IsEnabled())
+	assert.False(t, NewFakeSecret(t).Pending().Build().IsEnabled())
+}
+
+func Test_Challenge_Getters(t *testing.T) {
+	c := NewFakeChallenge(t).WithRemember().WithAttempts(2).Build()
+
+	assert.Equal(t, c.token, c.Token())
+	assert.Equal(t, c.userID, c.UserID())
+	assert.Equal(t, 2, c.Attempts())
+	assert.True(t, c.Remember())
+	assert.Equal(t, c.expiresAt, c.ExpiresAt())
+}
+
+func Test_ConfirmCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(ConfirmCmd))
+}
+
+func Test_VerifyCmd_Validate(t *testing.T) {
+	user := users.NewFakeUser(t).Build()
+
+	require.NoError(t, VerifyCmd{User: user, Code: "123456"}.Validate())
+	require.Error(t, VerifyCmd{User: user, Code: ""}.Validate())
+	require.Error(t, VerifyCmd{User: nil, Code: "123456"}.Validate())
+}
+
+func Test_DisableCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(DisableCmd))
+}
+
+func Test_SetRequiredCmd_Validate(t *testing.T) {
+	require.NoError(t, SetRequiredCmd{Role: perms.DefaultModeratorRole, Required: true}.Validate())
+	require.Error(t, SetRequiredCmd{Role: "", Required: true}.Validate())
+}
+
+func Test_CreateChallengeCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(CreateChallengeCmd))
+}
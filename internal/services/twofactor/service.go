@@ -0,0 +1,521 @@
+package twofactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const qrCodeSize = 200
+
+var (
+	ErrAlreadyEnabled    = errors.New("two-factor authentication already enabled")
+	ErrNotEnabled        = errors.New("two-factor authentication not enabled")
+	ErrNotEnrolled       = errors.New("two-factor authentication enrollment not started")
+	ErrRequired          = errors.New("two-factor authentication required for this role")
+	ErrRoleNotEligible   = errors.New("role without the moderation permission")
+	ErrInvalidCode       = errors.New("invalid two-factor code")
+	ErrChallengeNotFound = errors.New("login challenge not found")
+)
+
+type storage interface {
+	SaveSecret(ctx context.Context, secret *Secret) error
+	GetSecret(ctx context.Context, userID uuid.UUID) (*Secret, error)
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteSecret(ctx context.Context, userID uuid.UUID) error
+	SaveRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	GetRequiredRoles(ctx context.Context) ([]perms.Role, error)
+	AddRequiredRole(ctx context.Context, role perms.Role, now time.Time) error
+	RemoveRequiredRole(ctx context.Context, role perms.Role) error
+	SaveChallenge(ctx context.Context, challenge *Challenge) error
+	GetChallenge(ctx context.Context, token secret.Text) (*Challenge, error)
+	IncrementChallengeAttempts(ctx context.Context, token secret.Text) error
+	DeleteChallenge(ctx context.Context, token secret.Text) error
+	DeleteChallengesForUser(ctx context.Context, userID uuid.UUID) error
+	RemoveExpiredChallenges(ctx context.Context, now time.Time) error
+}
+
+type service struct {
+	storage  storage
+	permsSvc perms.Service
+	clock    clock.Clock
+	uuid     uuid.Service
+}
+
+func newService(tools tools.Tools, storage storage, permsSvc perms.Service) *service {
+	return &service{
+		storage:  storage,
+		permsSvc: permsSvc,
+		clock:    tools.Clock(),
+		uuid:     tools.UUID(),
+	}
+}
+
+// IsEnabled returns true if the user confirmed its enrollment.
+func (s *service) IsEnabled(ctx context.Context, user *users.User) (bool, error) {
+	res, err := s.storage.GetSecret(ctx, user.ID())
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errs.Internal(fmt.Errorf("failed to GetSecret: %w", err))
+	}
+
+	return res.IsEnabled(), nil
+}
+
+// IsRequired returns true if the role of the user requires the two-factor
+// authentication. Only the roles with the [perms.Moderation] permission can
+// require it.
+func (s *service) IsRequired(ctx context.Context, user *users.User) (bool, error) {
+	if user.Role() == nil || !s.permsSvc.IsAuthorized(user, perms.Moderation) {
+		return false, nil
+	}
+
+	roles, err := s.storage.GetRequiredRoles(ctx)
+	if err != nil {
+		return false, errs.Internal(fmt.Errorf("failed to GetRequiredRoles: %w", err))
+	}
+
+	return slices.Contains(roles, *user.Role()), nil
+}
+
+// NeedsChallenge returns true if the user must go through the second login
+// step, either to give a code or to enroll.
+func (s *service) NeedsChallenge(ctx context.Context, user *users.User) (bool, error) {
+	enabled, err := s.IsEnabled(ctx, user)
+	if err != nil {
+		return false, err
+	}
+
+	if enabled {
+		return true, nil
+	}
+
+	return s.IsRequired(ctx, user)
+}
+
+// Enroll generates a new secret for the user. The secret stays pending until
+// it is confirmed with [Service.Confirm]. Calling Enroll again with a pending
+// secret returns the same secret.
+func (s *service) Enroll(ctx context.Context, user *users.User) (*Enrollment, error) {
+	existing, err := s.storage.GetSecret(ctx, user.ID())
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, errs.Internal(fmt.Errorf("failed to GetSecret: %w", err))
+	}
+
+	if existing != nil && existing.IsEnabled() {
+		return nil, errs.BadRequest(ErrAlreadyEnabled)
+	}
+
+	opts := totp.GenerateOpts{
+		Issuer:      Issuer,
+		AccountName: user.Username(),
+		Period:      uint(Period.Seconds()),
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	}
+
+	if existing != nil {
+		opts.Secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(existing.secret.Raw())
+		if err != nil {
+			return nil, errs.Internal(fmt.Errorf("failed to decode the secret: %w", err))
+		}
+	}
+
+	key, err := totp.Generate(opts)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to generate the key: %w", err))
+	}
+
+	if existing == nil {
+		err = s.storage.SaveSecret(ctx, &Secret{
+			userID:    user.ID(),
+			secret:    secret.NewText(key.Secret()),
+			lastStep:  0,
+			enabledAt: nil,
+			createdAt: s.clock.Now(),
+		})
+		if err != nil {
+			return nil, errs.Internal(fmt.Errorf("failed to SaveSecret: %w", err))
+		}
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to generate the QR code: %w", err))
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to encode the QR code: %w", err))
+	}
+
+	return &Enrollment{
+		URL:    key.URL(),
+		Secret: key.Secret(),
+		QRCode: buf.Bytes(),
+	}, nil
+}
+
+// Confirm enables the pending secret if the code is valid and returns a new
+// set of recovery codes. Those codes are only stored hashed and can't be
+// retrieved later.
+func (s *service) Confirm(ctx context.Context, cmd *ConfirmCmd) ([]string, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	existing, err := s.storage.GetSecret(ctx, cmd.User.ID())
+	if errors.Is(err, errNotFound) {
+		return nil, errs.BadRequest(ErrNotEnrolled)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetSecret: %w", err))
+	}
+
+	if existing.IsEnabled() {
+		return nil, errs.BadRequest(ErrAlreadyEnabled)
+	}
+
+	step, err := s.verifyTOTP(ctx, existing, cmd.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.lastStep = step
+	existing.enabledAt = ptr.To(s.clock.Now())
+
+	err = s.storage.SaveSecret(ctx, existing)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to SaveSecret: %w", err))
+	}
+
+	return s.generateRecoveryCodes(ctx, cmd.User)
+}
+
+// Verify checks a TOTP code or a recovery code. A recovery code is consumed
+// and a TOTP code can't be used twice.
+func (s *service) Verify(ctx context.Context, cmd *VerifyCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	existing, err := s.storage.GetSecret(ctx, cmd.User.ID())
+	if err != nil && !errors.Is(err, errNotFound) {
+		return errs.Internal(fmt.Errorf("failed to GetSecret: %w", err))
+	}
+
+	if existing == nil || !existing.IsEnabled() {
+		return errs.BadRequest(ErrNotEnabled)
+	}
+
+	code := normalizeCode(cmd.Code)
+	if len(code) == int(otp.DigitsSix) {
+		_, err = s.verifyTOTP(ctx, existing, code)
+
+		return err
+	}
+
+	err = s.storage.UseRecoveryCode(ctx, cmd.User.ID(), hashCode(code))
+	if errors.Is(err, errNotFound) {
+		return errs.Unauthorized(ErrInvalidCode, "invalid code")
+	}
+
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UseRecoveryCode: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) RemainingRecoveryCodes(ctx context.Context, user *users.User) (int, error) {
+	res, err := s.storage.CountRecoveryCodes(ctx, user.ID())
+	if err != nil {
+		return 0, errs.Internal(fmt.Errorf("failed to CountRecoveryCodes: %w", err))
+	}
+
+	return res, nil
+}
+
+// Disable removes the secret and the recovery codes of the user. A valid code
+// is required and a user can't disable it if its role requires it.
+func (s *service) Disable(ctx context.Context, cmd *DisableCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	required, err := s.IsRequired(ctx, cmd.User)
+	if err != nil {
+		return err
+	}
+
+	if required {
+		return errs.BadRequest(ErrRequired, "your role requires the two-factor authentication")
+	}
+
+	err = s.Verify(ctx, &VerifyCmd{User: cmd.User, Code: cmd.Code})
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.DeleteRecoveryCodes(ctx, cmd.User.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteRecoveryCodes: %w", err))
+	}
+
+	err = s.storage.DeleteSecret(ctx, cmd.User.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteSecret: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	err := s.storage.DeleteChallengesForUser(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteChallengesForUser: %w", err))
+	}
+
+	err = s.storage.DeleteRecoveryCodes(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteRecoveryCodes: %w", err))
+	}
+
+	err = s.storage.DeleteSecret(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteSecret: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) GetRequiredRoles(ctx context.Context) ([]perms.Role, error) {
+	res, err := s.storage.GetRequiredRoles(ctx)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetRequiredRoles: %w", err))
+	}
+
+	return res, nil
+}
+
+// SetRequired makes the two-factor authentication mandatory, or not, for
+// all the users of a role. Only the roles with the [perms.Moderation]
+// permission are accepted.
+func (s *service) SetRequired(ctx context.Context, cmd *SetRequiredCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !slices.Contains(s.permsSvc.RolesWith(perms.Moderation), cmd.Role) {
+		return errs.BadRequest(ErrRoleNotEligible, "the role %q doesn't have the moderation permission", cmd.Role)
+	}
+
+	if cmd.Required {
+		err = s.storage.AddRequiredRole(ctx, cmd.Role, s.clock.Now())
+	} else {
+		err = s.storage.RemoveRequiredRole(ctx, cmd.Role)
+	}
+
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to update the required roles: %w", err))
+	}
+
+	return nil
+}
+
+// CreateChallenge starts the second step of a login. The expired challenges
+// are removed at the same time.
+func (s *service) CreateChallenge(ctx context.Context, cmd *CreateChallengeCmd) (*Challenge, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	now := s.clock.Now()
+
+	err = s.storage.RemoveExpiredChallenges(ctx, now)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to RemoveExpiredChallenges: %w", err))
+	}
+
+	challenge := Challenge{
+		token:     secret.NewText(string(s.uuid.New())),
+		userID:    cmd.User.ID(),
+		remember:  cmd.Remember,
+		attempts:  0,
+		expiresAt: now.Add(ChallengeLifetime),
+	}
+
+	err = s.storage.SaveChallenge(ctx, &challenge)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to SaveChallenge: %w", err))
+	}
+
+	return &challenge, nil
+}
+
+func (s *service) GetChallenge(ctx context.Context, token secret.Text) (*Challenge, error) {
+	res, err := s.storage.GetChallenge(ctx, token)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(ErrChallengeNotFound)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetChallenge: %w", err))
+	}
+
+	if !s.clock.Now().Before(res.expiresAt) {
+		return nil, errs.NotFound(ErrChallengeNotFound, "login challenge expired")
+	}
+
+	return res, nil
+}
+
+// RegisterChallengeFailure counts a failed attempt. The challenge is deleted
+// after [ChallengeMaxAttempts] failures and the login must be restarted.
+func (s *service) RegisterChallengeFailure(ctx context.Context, challenge *Challenge) error {
+	if challenge.attempts+1 >= ChallengeMaxAttempts {
+		return s.DeleteChallenge(ctx, challenge)
+	}
+
+	err := s.storage.IncrementChallengeAttempts(ctx, challenge.token)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to IncrementChallengeAttempts: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) DeleteChallenge(ctx context.Context, challenge *Challenge) error {
+	err := s.storage.DeleteChallenge(ctx, challenge.token)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to DeleteChallenge: %w", err))
+	}
+
+	return nil
+}
+
+// verifyTOTP checks the code against the time steps around now and marks
+// the matching step as used.
+func (s *service) verifyTOTP(ctx context.Context, existing *Secret, code string) (int64, error) {
+	opts := totp.ValidateOpts{
+		Period:    uint(Period.Seconds()),
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	code = normalizeCode(code)
+	current := s.clock.Now().Unix() / int64(opts.Period)
+
+	for step := current - Skew; step <= current+Skew; step++ {
+		expected, err := totp.GenerateCodeCustom(existing.secret.Raw(), time.Unix(step*int64(opts.Period), 0), opts)
+		if err != nil {
+			return 0, errs.Internal(fmt.Errorf("failed to generate a code: %w", err))
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		err = s.storage.UseStep(ctx, existing.userID, step)
+		if errors.Is(err, errNotFound) {
+			// The code have already been used.
+			return 0, errs.Unauthorized(ErrInvalidCode, "invalid code")
+		}
+
+		if err != nil {
+			return 0, errs.Internal(fmt.Errorf("failed to UseStep: %w", err))
+		}
+
+		return step, nil
+	}
+
+	return 0, errs.Unauthorized(ErrInvalidCode, "invalid code")
+}
+
+func (s *service) generateRecoveryCodes(ctx context.Context, user *users.User) ([]string, error) {
+	err := s.storage.DeleteRecoveryCodes(ctx, user.ID())
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to DeleteRecoveryCodes: %w", err))
+	}
+
+	now := s.clock.Now()
+	res := make([]string, RecoveryCodesCount)
+
+	for i := range res {
+		raw := make([]byte, RecoveryCodeSize)
+
+		_, err = rand.Read(raw)
+		if err != nil {
+			return nil, errs.Internal(fmt.Errorf("failed to generate a recovery code: %w", err))
+		}
+
+		code := hex.EncodeToString(raw)
+
+		err = s.storage.SaveRecoveryCode(ctx, user.ID(), hashCode(code), now)
+		if err != nil {
+			return nil, errs.Internal(fmt.Errorf("failed to SaveRecoveryCode: %w", err))
+		}
+
+		res[i] = formatRecoveryCode(code)
+	}
+
+	return res, nil
+}
+
+// formatRecoveryCode splits the code into groups of five characters to ease
+// its copy.
+func formatRecoveryCode(code string) string {
+	groups := make([]string, 0, len(code)/5+1)
+
+	for len(code) > 5 {
+		groups = append(groups, code[:5])
+		code = code[5:]
+	}
+
+	return strings.Join(append(groups, code), "-")
+}
+
+// normalizeCode removes the separators and the spaces users could type.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeCode(code)))
+
+	return hex.EncodeToString(sum[:])
+}
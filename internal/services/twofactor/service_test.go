@@ -0,0 +1,575 @@
+package twofactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("NeedsChallenge with an enabled secret", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+
+		// Run
+		res, err := svc.NeedsChallenge(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("NeedsChallenge with a required role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultModeratorRole)).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		storageMock.On("GetRequiredRoles", mock.Anything).
+			Return([]perms.Role{perms.DefaultModeratorRole}, nil).Once()
+
+		// Run
+		res, err := svc.NeedsChallenge(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("NeedsChallenge without moderation permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultUserRole)).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(false).Once()
+
+		// Run
+		res, err := svc.NeedsChallenge(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.False(t, res)
+	})
+
+	t.Run("Enroll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("SaveSecret", mock.Anything, mock.MatchedBy(func(s *Secret) bool {
+			return s.userID == user.ID() && !s.IsEnabled() && s.createdAt == now
+		})).Return(nil).Once()
+
+		// Run
+		res, err := svc.Enroll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Contains(t, res.URL, "otpauth://totp/")
+		assert.NotEmpty(t, res.Secret)
+		assert.NotEmpty(t, res.QRCode)
+	})
+
+	t.Run("Enroll with a pending secret returns the same secret", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		pending := NewFakeSecret(t).WithUser(user).Pending().Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(pending, nil).Once()
+
+		// Run
+		res, err := svc.Enroll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, pending.secret.Raw(), res.Secret)
+	})
+
+	t.Run("Enroll with an enabled secret", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+
+		// Run
+		res, err := svc.Enroll(ctx, user)
+
+		// Asserts
+		require.ErrorIs(t, err, ErrAlreadyEnabled)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Confirm success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		pending := NewFakeSecret(t).WithUser(user).Pending().Build()
+		code, err := totp.GenerateCode(pending.secret.Raw(), now)
+		require.NoError(t, err)
+		step := now.Unix() / int64(Period.Seconds())
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(pending, nil).Once()
+		tools.ClockMock.On("Now").Return(now)
+		storageMock.On("UseStep", mock.Anything, user.ID(), step).Return(nil).Once()
+		storageMock.On("SaveSecret", mock.Anything, mock.MatchedBy(func(s *Secret) bool {
+			return s.IsEnabled() && s.lastStep == step
+		})).Return(nil).Once()
+		storageMock.On("DeleteRecoveryCodes", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("SaveRecoveryCode", mock.Anything, user.ID(), mock.Anything, now).
+			Return(nil).Times(RecoveryCodesCount)
+
+		// Run
+		res, err := svc.Confirm(ctx, &ConfirmCmd{User: user, Code: code})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Len(t, res, RecoveryCodesCount)
+		for _, code := range res {
+			assert.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}-[0-9a-f]{5}-[0-9a-f]{5}$`, code)
+		}
+	})
+
+	t.Run("Confirm with an invalid code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		pending := NewFakeSecret(t).WithUser(user).Pending().Build()
+		// A code from far in the past.
+		code, err := totp.GenerateCode(pending.secret.Raw(), now.Add(-time.Hour))
+		require.NoError(t, err)
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(pending, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := svc.Confirm(ctx, &ConfirmCmd{User: user, Code: code})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidCode)
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Verify with a replayed code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+		code, err := totp.GenerateCode(existing.secret.Raw(), now)
+		require.NoError(t, err)
+		step := now.Unix() / int64(Period.Seconds())
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("UseStep", mock.Anything, user.ID(), step).Return(errNotFound).Once()
+
+		// Run
+		err = svc.Verify(ctx, &VerifyCmd{User: user, Code: code})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("Verify with a recovery code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+		storageMock.On("UseRecoveryCode", mock.Anything, user.ID(), hashCode("abcde12345abcde12345")).Return(nil).Once()
+
+		// Run
+		err := svc.Verify(ctx, &VerifyCmd{User: user, Code: "ABCDE-12345-ABCDE-12345"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Verify with an unknown recovery code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+		storageMock.On("UseRecoveryCode", mock.Anything, user.ID(), mock.Anything).Return(errNotFound).Once()
+
+		// Run
+		err := svc.Verify(ctx, &VerifyCmd{User: user, Code: "abcde-12345-abcde-12345"})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidCode)
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("Verify without 2FA enabled", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+
+		// Run
+		err := svc.Verify(ctx, &VerifyCmd{User: user, Code: "123456"})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrNotEnabled)
+	})
+
+	t.Run("Disable success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultUserRole)).Build()
+		existing := NewFakeSecret(t).WithUser(user).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(false).Once()
+		storageMock.On("GetSecret", mock.Anything, user.ID()).Return(existing, nil).Once()
+		storageMock.On("UseRecoveryCode", mock.Anything, user.ID(), hashCode("abcde12345abcde12345")).Return(nil).Once()
+		storageMock.On("DeleteRecoveryCodes", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("DeleteSecret", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.Disable(ctx, &DisableCmd{User: user, Code: "abcde-12345-abcde-12345"})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultModeratorRole)).Build()
+
+		// Mocks
+		storageMock.On("DeleteChallengesForUser", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("DeleteRecoveryCodes", mock.Anything, user.ID()).Return(nil).Once()
+		storageMock.On("DeleteSecret", mock.Anything, user.ID()).Return(nil).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteAll with a storage error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("DeleteChallengesForUser", mock.Anything, user.ID()).Return(assert.AnError).Once()
+
+		// Run
+		err := svc.DeleteAll(ctx, user)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Disable with a required role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultModeratorRole)).Build()
+
+		// Mocks
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		storageMock.On("GetRequiredRoles", mock.Anything).
+			Return([]perms.Role{perms.DefaultModeratorRole}, nil).Once()
+
+		// Run
+		err := svc.Disable(ctx, &DisableCmd{User: user, Code: "123456"})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrRequired)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+	})
+
+	t.Run("SetRequired success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+
+		// Mocks
+		permsMock.On("RolesWith", perms.Moderation).
+			Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultModeratorRole}).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("AddRequiredRole", mock.Anything, perms.DefaultModeratorRole, now).Return(nil).Once()
+
+		// Run
+		err := svc.SetRequired(ctx, &SetRequiredCmd{Role: perms.DefaultModeratorRole, Required: true})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("SetRequired with a role without moderation", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Mocks
+		permsMock.On("RolesWith", perms.Moderation).
+			Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultModeratorRole}).Once()
+
+		// Run
+		err := svc.SetRequired(ctx, &SetRequiredCmd{Role: perms.DefaultUserRole, Required: true})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrRoleNotEligible)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+	})
+
+	t.Run("CreateChallenge success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		token := uuid.UUID("4d5c7f0e-0a55-4f3b-9b8a-6c1a36f4f6c1")
+		expected := &Challenge{
+			token:     secret.NewText(string(token)),
+			userID:    user.ID(),
+			remember:  true,
+			attempts:  0,
+			expiresAt: now.Add(ChallengeLifetime),
+		}
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveExpiredChallenges", mock.Anything, now).Return(nil).Once()
+		tools.UUIDMock.On("New").Return(token).Once()
+		storageMock.On("SaveChallenge", mock.Anything, expected).Return(nil).Once()
+
+		// Run
+		res, err := svc.CreateChallenge(ctx, &CreateChallengeCmd{User: user, Remember: true})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("GetChallenge with an expired challenge", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		now := time.Now()
+		challenge := NewFakeChallenge(t).ExpiresAt(now.Add(-time.Second)).Build()
+
+		// Mocks
+		storageMock.On("GetChallenge", mock.Anything, challenge.token).Return(challenge, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := svc.GetChallenge(ctx, challenge.token)
+
+		// Asserts
+		require.ErrorIs(t, err, ErrChallengeNotFound)
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("RegisterChallengeFailure increments the attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		challenge := NewFakeChallenge(t).WithAttempts(1).Build()
+
+		// Mocks
+		storageMock.On("IncrementChallengeAttempts", mock.Anything, challenge.token).Return(nil).Once()
+
+		// Run
+		err := svc.RegisterChallengeFailure(ctx, challenge)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("RegisterChallengeFailure deletes after the max attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		permsMock := perms.NewMockService(t)
+		svc := newService(tools, storageMock, permsMock)
+
+		// Data
+		challenge := NewFakeChallenge(t).WithAttempts(ChallengeMaxAttempts - 1).Build()
+
+		// Mocks
+		storageMock.On("DeleteChallenge", mock.Anything, challenge.token).Return(nil).Once()
+
+		// Run
+		err := svc.RegisterChallengeFailure(ctx, challenge)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,364 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const (
+	secretsTableName       = "two_factor_secrets"
+	recoveryCodesTableName = "two_factor_recovery_codes"
+	requiredRolesTableName = "two_factor_required_roles"
+	challengesTableName    = "two_factor_challenges"
+)
+
+var errNotFound = errors.New("not found")
+
+var (
+	secretFields    = []string{"user_id", "secret", "last_step", "enabled_at", "created_at"}
+	challengeFields = []string{"token", "user_id", "remember", "attempts", "expires_at"}
+)
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+// SaveSecret saves the secret, replacing any previous secret of the user.
+func (s *sqlStorage) SaveSecret(ctx context.Context, secret *Secret) error {
+	var enabledAt *sqlstorage.SQLTime
+	if secret.enabledAt != nil {
+		enabledAt = ptr.To(sqlstorage.SQLTime(*secret.enabledAt))
+	}
+
+	_, err := sq.
+		Insert(secretsTableName).
+		Options("OR REPLACE").
+		Columns(secretFields...).
+		Values(
+			secret.userID,
+			secret.secret,
+			secret.lastStep,
+			enabledAt,
+			ptr.To(sqlstorage.SQLTime(secret.createdAt))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetSecret(ctx context.Context, userID uuid.UUID) (*Secret, error) {
+	var res Secret
+	var sqlEnabledAt *sqlstorage.SQLTime
+	var sqlCreatedAt sqlstorage.SQLTime
+
+	err := sq.
+		Select(secretFields...).
+		From(secretsTableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ScanContext(ctx,
+			&res.userID,
+			&res.secret,
+			&res.lastStep,
+			&sqlEnabledAt,
+			&sqlCreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+
+	if sqlEnabledAt != nil {
+		res.enabledAt = ptr.To(sqlEnabledAt.Time())
+	}
+	res.createdAt = sqlCreatedAt.Time()
+
+	return &res, nil
+}
+
+// UseStep registers the time step of a valid code. It returns errNotFound if
+// this step or a later one have already been used.
+func (s *sqlStorage) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	res, err := sq.
+		Update(secretsTableName).
+		Set("last_step", step).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Lt{"last_step": step}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the affected rows: %w", err)
+	}
+
+	if updated == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteSecret(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(secretsTableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) SaveRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error {
+	_, err := sq.
+		Insert(recoveryCodesTableName).
+		Columns("hash", "user_id", "created_at").
+		Values(hash, userID, ptr.To(sqlstorage.SQLTime(now))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var res int
+
+	err := sq.
+		Select("count(*)").
+		From(recoveryCodesTableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ScanContext(ctx, &res)
+	if err != nil {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	return res, nil
+}
+
+// UseRecoveryCode deletes the recovery code. It returns errNotFound if the
+// user doesn't have this code.
+func (s *sqlStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	res, err := sq.
+		Delete(recoveryCodesTableName).
+		Where(sq.Eq{"user_id": userID, "hash": hash}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the affected rows: %w", err)
+	}
+
+	if deleted == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(recoveryCodesTableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetRequiredRoles(ctx context.Context) ([]perms.Role, error) {
+	rows, err := sq.
+		Select("role").
+		From(requiredRolesTableName).
+		OrderBy("role").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []perms.Role{}
+
+	for rows.Next() {
+		var role perms.Role
+
+		err = rows.Scan(&role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		res = append(res, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) AddRequiredRole(ctx context.Context, role perms.Role, now time.Time) error {
+	_, err := sq.
+		Insert(requiredRolesTableName).
+		Options("OR IGNORE").
+		Columns("role", "created_at").
+		Values(role, ptr.To(sqlstorage.SQLTime(now))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) RemoveRequiredRole(ctx context.Context, role perms.Role) error {
+	_, err := sq.
+		Delete(requiredRolesTableName).
+		Where(sq.Eq{"role": role}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) SaveChallenge(ctx context.Context, challenge *Challenge) error {
+	_, err := sq.
+		Insert(challengesTableName).
+		Columns(challengeFields...).
+		Values(
+			challenge.token,
+			challenge.userID,
+			challenge.remember,
+			challenge.attempts,
+			ptr.To(sqlstorage.SQLTime(challenge.expiresAt))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) GetChallenge(ctx context.Context, token secret.Text) (*Challenge, error) {
+	var res Challenge
+	var sqlExpiresAt sqlstorage.SQLTime
+
+	err := sq.
+		Select(challengeFields...).
+		From(challengesTableName).
+		Where(sq.Eq{"token": token}).
+		RunWith(s.db).
+		ScanContext(ctx,
+			&res.token,
+			&res.userID,
+			&res.remember,
+			&res.attempts,
+			&sqlExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+
+	res.expiresAt = sqlExpiresAt.Time()
+
+	return &res, nil
+}
+
+func (s *sqlStorage) IncrementChallengeAttempts(ctx context.Context, token secret.Text) error {
+	_, err := sq.
+		Update(challengesTableName).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(sq.Eq{"token": token}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteChallenge(ctx context.Context, token secret.Text) error {
+	_, err := sq.
+		Delete(challengesTableName).
+		Where(sq.Eq{"token": token}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) DeleteChallengesForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := sq.
+		Delete(challengesTableName).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveExpiredChallenges deletes all the challenges expired at the given
+// time.
+func (s *sqlStorage) RemoveExpiredChallenges(ctx context.Context, now time.Time) error {
+	_, err := sq.
+		Delete(challengesTableName).
+		Where(sq.Expr("julianday(expires_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(now)))).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package twofactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, db sqlstorage.Querier) *users.User {
+		t.Helper()
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+
+		return users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+	}
+
+	t.Run("SaveSecret and GetSecret success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+
+		secret := NewFakeSecret(t).WithUser(user).Pending().Build()
+		secret.createdAt = time.Now().UTC().Round(time.Millisecond)
+
+		err := store.SaveSecret(ctx, secret)
+		require.NoError(t, err)
+
+		res, err := store.GetSecret(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, secret, res)
+
+		// Enable it by replacing the pending secret.
+		secret.enabledAt = ptr.To(secret.createdAt.Add(time.Minute))
+		err = store.SaveSecret(ctx, secret)
+		require.NoError(t, err)
+
+		res, err = store.GetSecret(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, secret, res)
+	})
+
+	t.Run("GetSecret not found", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+
+		res, err := store.GetSecret(ctx, user.ID())
+		require.ErrorIs(t, err, errNotFound)
+		require.Nil(t, res)
+	})
+
+	t.Run("UseStep refuses an already used step", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		NewFakeSecret(t).WithUser(user).BuildAndStore(ctx, db)
+
+		err := store.UseStep(ctx, user.ID(), 42)
+		require.NoError(t, err)
+
+		err = store.UseStep(ctx, user.ID(), 42)
+		require.ErrorIs(t, err, errNotFound)
+
+		err = store.UseStep(ctx, user.ID(), 41)
+		require.ErrorIs(t, err, errNotFound)
+
+		err = store.UseStep(ctx, user.ID(), 43)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteSecret success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		NewFakeSecret(t).WithUser(user).BuildAndStore(ctx, db)
+
+		err := store.DeleteSecret(ctx, user.ID())
+		require.NoError(t, err)
+
+		_, err = store.GetSecret(ctx, user.ID())
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("RecoveryCodes lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		now := time.Now()
+
+		require.NoError(t, store.SaveRecoveryCode(ctx, user.ID(), "hash-1", now))
+		require.NoError(t, store.SaveRecoveryCode(ctx, user.ID(), "hash-2", now))
+
+		count, err := store.CountRecoveryCodes(ctx, user.ID())
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		err = store.UseRecoveryCode(ctx, user.ID(), "hash-1")
+		require.NoError(t, err)
+
+		err = store.UseRecoveryCode(ctx, user.ID(), "hash-1")
+		require.ErrorIs(t, err, errNotFound)
+
+		err = store.DeleteRecoveryCodes(ctx, user.ID())
+		require.NoError(t, err)
+
+		count, err = store.CountRecoveryCodes(ctx, user.ID())
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("RequiredRoles lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		now := time.Now()
+
+		perms.NewFakePermissions(t).WithName(string(perms.DefaultModeratorRole)).BuildAndStore(ctx, db)
+		perms.NewFakePermissions(t).WithName(string(perms.DefaultAdminRole)).BuildAndStore(ctx, db)
+
+		require.NoError(t, store.AddRequiredRole(ctx, perms.DefaultModeratorRole, now))
+		require.NoError(t, store.AddRequiredRole(ctx, perms.DefaultAdminRole, now))
+		// Adding twice is a no-op.
+		require.NoError(t, store.AddRequiredRole(ctx, perms.DefaultAdminRole, now))
+
+		res, err := store.GetRequiredRoles(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []perms.Role{perms.DefaultAdminRole, perms.DefaultModeratorRole}, res)
+
+		require.NoError(t, store.RemoveRequiredRole(ctx, perms.DefaultAdminRole))
+
+		res, err = store.GetRequiredRoles(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []perms.Role{perms.DefaultModeratorRole}, res)
+	})
+
+	t.Run("Challenges lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+
+		challenge := NewFakeChallenge(t).WithUser(user).WithRemember().Build()
+		challenge.expiresAt = time.Now().UTC().Round(time.Millisecond).Add(time.Minute)
+
+		err := store.SaveChallenge(ctx, challenge)
+		require.NoError(t, err)
+
+		res, err := store.GetChallenge(ctx, challenge.token)
+		require.NoError(t, err)
+		require.Equal(t, challenge, res)
+
+		err = store.IncrementChallengeAttempts(ctx, challenge.token)
+		require.NoError(t, err)
+
+		res, err = store.GetChallenge(ctx, challenge.token)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Attempts())
+
+		err = store.DeleteChallenge(ctx, challenge.token)
+		require.NoError(t, err)
+
+		_, err = store.GetChallenge(ctx, challenge.token)
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("RemoveExpiredChallenges success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		now := time.Now()
+
+		expired := NewFakeChallenge(t).WithUser(user).ExpiresAt(now.Add(-time.Minute)).BuildAndStore(ctx, db)
+		valid := NewFakeChallenge(t).WithUser(user).ExpiresAt(now.Add(time.Minute)).BuildAndStore(ctx, db)
+
+		err := store.RemoveExpiredChallenges(ctx, now)
+		require.NoError(t, err)
+
+		_, err = store.GetChallenge(ctx, expired.token)
+		require.ErrorIs(t, err, errNotFound)
+
+		_, err = store.GetChallenge(ctx, valid.token)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteChallengesForUser success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := newUser(t, db)
+		challenge := NewFakeChallenge(t).WithUser(user).BuildAndStore(ctx, db)
+
+		err := store.DeleteChallengesForUser(ctx, user.ID())
+		require.NoError(t, err)
+
+		_, err = store.GetChallenge(ctx, challenge.token)
+		require.ErrorIs(t, err, errNotFound)
+	})
+}
@@ -0,0 +1,110 @@
+package users
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // Register the gif decoder for the uploaded avatars.
+	_ "image/jpeg" // Register the jpeg decoder for the uploaded avatars.
+	"io"
+
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/o1egl/govatar"
+)
+
+const (
+	// AvatarSize is the width and the height of the uploaded avatars, in
+	// pixels.
+	AvatarSize = 256
+
+	maxAvatarSizeBytes = 5 * 1024 * 1024 // 5MiB
+
+	// maxAvatarPixels protects against the decompression bombs: the size
+	// is checked before decoding the image.
+	maxAvatarPixels = 4096 * 4096
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+func (s AvatarStyle) gender() govatar.Gender {
+	if s == FemaleAvatar {
+		return govatar.FEMALE
+	}
+
+	return govatar.MALE
+}
+
+// defaultAvatar returns the avatar given at the account creation.
+func defaultAvatar(username string) (image.Image, error) {
+	return govatar.GenerateForUsername(govatar.MALE, username)
+}
+
+// decodeAvatar reads an uploaded png, jpeg or gif image.
+func decodeAvatar(r io.Reader) (image.Image, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxAvatarSizeBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the image: %w", err)
+	}
+
+	if len(content) > maxAvatarSizeBytes {
+		return nil, errs.BadRequest(ErrInvalidImage, "the image must be smaller than 5MiB")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, errs.BadRequest(fmt.Errorf("%w: %w", ErrInvalidImage, err), "only the png, jpeg and gif images are supported")
+	}
+
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, errs.BadRequest(ErrInvalidImage, "the image must be smaller than 4096x4096 pixels")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, errs.BadRequest(fmt.Errorf("%w: %w", ErrInvalidImage, err), "corrupted image")
+	}
+
+	return img, nil
+}
+
+// squareResize crops the centered square of the image and scales it to
+// size x size pixels. Each pixel is the average of the source pixels it
+// covers.
+func squareResize(img image.Image, size int) *image.RGBA64 {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA64(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		sy0 := y0 + y*side/size
+		sy1 := max(y0+(y+1)*side/size, sy0+1)
+
+		for x := 0; x < size; x++ {
+			sx0 := x0 + x*side/size
+			sx1 := max(x0+(x+1)*side/size, sx0+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
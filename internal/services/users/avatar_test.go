@@ -0,0 +1,90 @@
+package users
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPNG(t *testing.T, width, height int) *bytes.Buffer {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	require.NoError(t, err)
+
+	return &buf
+}
+
+func Test_Avatar(t *testing.T) {
+	t.Run("decodeAvatar success", func(t *testing.T) {
+		t.Parallel()
+
+		img, err := decodeAvatar(newTestPNG(t, 40, 20))
+
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+	})
+
+	t.Run("decodeAvatar with an invalid image", func(t *testing.T) {
+		t.Parallel()
+
+		img, err := decodeAvatar(strings.NewReader("not an image"))
+
+		require.Nil(t, img)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidImage)
+	})
+
+	t.Run("decodeAvatar with a too big file", func(t *testing.T) {
+		t.Parallel()
+
+		img, err := decodeAvatar(bytes.NewReader(make([]byte, maxAvatarSizeBytes+1)))
+
+		require.Nil(t, img)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidImage)
+	})
+
+	t.Run("squareResize crops the center and scales down", func(t *testing.T) {
+		t.Parallel()
+
+		img := image.NewRGBA(image.Rect(0, 0, 30, 10))
+		// Only the centered 10x10 square is white.
+		for x := 10; x < 20; x++ {
+			for y := 0; y < 10; y++ {
+				img.Set(x, y, color.White)
+			}
+		}
+
+		res := squareResize(img, 5)
+
+		require.Equal(t, image.Rect(0, 0, 5, 5), res.Bounds())
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				require.Equal(t, color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}, res.RGBA64At(x, y))
+			}
+		}
+	})
+
+	t.Run("squareResize scales up", func(t *testing.T) {
+		t.Parallel()
+
+		res := squareResize(image.NewRGBA(image.Rect(0, 0, 2, 2)), AvatarSize)
+
+		require.Equal(t, image.Rect(0, 0, AvatarSize, AvatarSize), res.Bounds())
+	})
+}
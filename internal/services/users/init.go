@@ -4,30 +4,74 @@ import (
 	"context"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
+// Config of the users service.
+type Config struct {
+	// Registration controls the self-service registration. An empty value
+	// is handled as [RegistrationClosed].
+	Registration RegistrationMode
+}
+
+// SessionRevoker logs a user out of all its devices. It is implemented by
+// websessions.Service which can't be imported here: it depends on this
+// package.
+type SessionRevoker interface {
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+}
+
 type Service interface {
 	Create(ctx context.Context, user *CreateCmd) (*User, error)
 	Bootstrap(ctx context.Context, cmd *BootstrapCmd) (*User, error)
+	Register(ctx context.Context, cmd *RegisterCmd) (*User, error)
+	CreateExternal(ctx context.Context, cmd *CreateExternalCmd) (*User, error)
+	UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error
+	// UpdateExternalRole applies the role given by an identity provider, no
+	// admin is involved.
+	UpdateExternalRole(ctx context.Context, cmd *UpdateExternalRoleCmd) error
+	RegistrationMode() RegistrationMode
+	Approve(ctx context.Context, userID uuid.UUID) error
+	Reject(ctx context.Context, userID uuid.UUID) error
 	GetByID(ctx context.Context, userID uuid.UUID) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	UpdateEmail(ctx context.Context, cmd *UpdateEmailCmd) error
 	Authenticate(ctx context.Context, username string, password secret.Text) (*User, error)
 	GetAll(ctx context.Context, paginateCmd *sqlstorage.PaginateCmd) ([]User, error)
-	AddToDeletion(ctx context.Context, userID uuid.UUID) error
+	Search(ctx context.Context, cmd *SearchCmd) ([]User, error)
+	// AddToDeletion logs the user out and schedules the removal of its
+	// account with a [DeleteTask].
+	AddToDeletion(ctx context.Context, cmd *AddToDeletionCmd) error
+	// HardDelete removes a user marked for deletion along with its avatar.
+	// Nothing must reference it anymore.
 	HardDelete(ctx context.Context, userID uuid.UUID) error
+	// GetGhost returns the account owning the content of the deleted users.
+	// It is created on the first call.
+	GetGhost(ctx context.Context) (*User, error)
 	GetAllWithStatus(ctx context.Context, status Status, cmd *sqlstorage.PaginateCmd) ([]User, error)
 	UpdateUserPassword(ctx context.Context, cmd *UpdatePasswordCmd) error
+	UploadAvatar(ctx context.Context, cmd *UploadAvatarCmd) error
+	GenerateAvatar(ctx context.Context, cmd *GenerateAvatarCmd) error
+	// ResetAvatar lets the moderators remove an offensive avatar.
+	ResetAvatar(ctx context.Context, cmd *ResetAvatarCmd) error
 }
 
 func Init(
+	cfg Config,
 	tools tools.Tools,
 	medias medias.Service,
 	db sqlstorage.Querier,
+	roles perms.Service,
+	sessions SessionRevoker,
+	tasks taskrunner.Service,
 ) Service {
 	store := newSqlStorage(db)
 
-	return newService(tools, store, medias)
+	return newService(cfg, tools, store, medias, roles, sessions, tasks)
 }
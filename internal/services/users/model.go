@@ -1,7 +1,10 @@
 package users
 
 import (
+	"encoding/json"
+	"io"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/perms"
@@ -18,19 +21,48 @@ const (
 
 var UsernameRegexp = regexp.MustCompile("^[0-9a-zA-Z-]+$")
 
+// GhostUsername is the username of the account receiving the content of the
+// deleted users. It doesn't match [UsernameRegexp] so nobody can register
+// it.
+const GhostUsername = "[deleted]"
+
+// DeleteTaskName is the name of the [DeleteTask].
+const DeleteTaskName = "user-delete"
+
 type Status string
 
 const (
 	Active   Status = "active"
+	Pending  Status = "pending"
 	Deleting Status = "deleting"
+	// Ghost is the status of the account owning the content of the deleted
+	// users. It can't log in and is hidden from the lookups by username.
+	Ghost Status = "ghost"
 )
 
+// RegistrationMode defines who is allowed to create an account through the
+// self-service registration.
+type RegistrationMode string
+
+const (
+	// RegistrationOpen lets anyone create an active account.
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationApproval creates the accounts with the [Pending] status
+	// until an admin approves them.
+	RegistrationApproval RegistrationMode = "approval"
+	// RegistrationClosed disables the self-service registration.
+	RegistrationClosed RegistrationMode = "closed"
+)
+
+var RegistrationModes = []RegistrationMode{RegistrationOpen, RegistrationApproval, RegistrationClosed}
+
 // User representation
 type User struct {
 	createdAt         time.Time
 	passwordChangedAt time.Time
 	id                uuid.UUID
 	username          string
+	email             string // Empty until a verified e-mail is set.
 	password          secret.Text
 	role              *perms.Role
 	status            Status
@@ -40,6 +72,7 @@ type User struct {
 
 func (u User) ID() uuid.UUID                { return u.id }
 func (u User) Username() string             { return u.username }
+func (u User) Email() string                { return u.email }
 func (u User) Role() *perms.Role            { return u.role }
 func (u User) Status() Status               { return u.status }
 func (u User) PasswordChangedAt() time.Time { return u.passwordChangedAt }
@@ -88,3 +121,178 @@ func (t BootstrapCmd) Validate() error {
 		v.Field(&t.Password, v.Required, v.Length(SecretMinLength, SecretMaxLength)),
 	)
 }
+
+// RegisterCmd represents a self-service account creation.
+type RegisterCmd struct {
+	// InvitedBy is set when the account is created with an invitation. It
+	// becomes the creator of the account.
+	InvitedBy *User
+	Username  string
+	Password  secret.Text
+}
+
+func (t RegisterCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Username, v.Required, v.Length(1, 20), v.Match(UsernameRegexp)),
+		v.Field(&t.Password, v.Required, v.Length(SecretMinLength, SecretMaxLength)),
+	)
+}
+
+// CreateExternalCmd represents the creation of an account authenticated by
+// an external identity provider. Such an account has no usable password.
+type CreateExternalCmd struct {
+	Role     *perms.Role
+	Username string
+}
+
+func (t CreateExternalCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Role, v.Required),
+		v.Field(&t.Username, v.Required, v.Length(1, 20), v.Match(UsernameRegexp)),
+	)
+}
+
+type UpdateRoleCmd struct {
+	Admin  *User
+	UserID uuid.UUID
+	Role   perms.Role
+}
+
+func (t UpdateRoleCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Admin, v.Required),
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+		v.Field(&t.Role, v.Required),
+	)
+}
+
+// UpdateExternalRoleCmd replaces the role of a user with the one given by an
+// external identity provider.
+type UpdateExternalRoleCmd struct {
+	UserID uuid.UUID
+	Role   perms.Role
+}
+
+func (t UpdateExternalRoleCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+		v.Field(&t.Role, v.Required),
+	)
+}
+
+type AddToDeletionCmd struct {
+	Admin  *User
+	UserID uuid.UUID
+}
+
+func (t AddToDeletionCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Admin, v.Required),
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+	)
+}
+
+// SearchCmd looks for the users with a username containing Query. The page
+// starts after the username After.
+type SearchCmd struct {
+	Query string
+	After string
+	Limit int
+}
+
+func (t SearchCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Query, v.Length(0, 20)),
+		v.Field(&t.Limit, v.Required, v.Min(1), v.Max(100)),
+	)
+}
+
+// UpdateEmailCmd replaces the e-mail of a user. The e-mail must have been
+// verified before. An empty e-mail removes it.
+type UpdateEmailCmd struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+func (t UpdateEmailCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+		v.Field(&t.Email, v.Length(1, 254), is.Email),
+	)
+}
+
+// AvatarStyle is the style of the generated avatars.
+type AvatarStyle string
+
+const (
+	MaleAvatar   AvatarStyle = "male"
+	FemaleAvatar AvatarStyle = "female"
+)
+
+// UploadAvatarCmd replaces the avatar of a user by an image. The image is
+// cropped to a square and resized to [AvatarSize].
+type UploadAvatarCmd struct {
+	UserID  uuid.UUID
+	Content io.Reader
+}
+
+func (t UploadAvatarCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+		v.Field(&t.Content, v.Required),
+	)
+}
+
+// GenerateAvatarCmd replaces the avatar of a user by a new random one.
+type GenerateAvatarCmd struct {
+	UserID uuid.UUID
+	Style  AvatarStyle
+}
+
+func (t GenerateAvatarCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+		v.Field(&t.Style, v.Required, v.In(MaleAvatar, FemaleAvatar)),
+	)
+}
+
+// ResetAvatarCmd replaces an offensive avatar by the one generated at the
+// account creation.
+type ResetAvatarCmd struct {
+	Moderator *User
+	UserID    uuid.UUID
+}
+
+func (t ResetAvatarCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.Moderator, v.Required),
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+	)
+}
+
+// NormalizeEmail returns the form of the e-mail saved and compared.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// DeleteTask is registered by [Service.AddToDeletion]. Its runner removes
+// or anonymizes everything referencing the user before calling
+// [Service.HardDelete].
+type DeleteTask struct {
+	UserID uuid.UUID `json:"user-id"`
+}
+
+func (t *DeleteTask) Name() string  { return DeleteTaskName }
+func (t *DeleteTask) Priority() int { return 2 }
+
+func (t *DeleteTask) Validate() error {
+	return v.ValidateStruct(t,
+		v.Field(&t.UserID, v.Required, is.UUIDv4),
+	)
+}
+
+func (t *DeleteTask) Args() json.RawMessage {
+	res, _ := json.Marshal(t)
+
+	return res
+}
@@ -52,6 +52,12 @@ func (f *FakeUserBuilder) WithUsername(username string) *FakeUserBuilder {
 	return f
 }
 
+func (f *FakeUserBuilder) WithEmail(email string) *FakeUserBuilder {
+	f.user.email = email
+
+	return f
+}
+
 func (f *FakeUserBuilder) WithPassword(password string) *FakeUserBuilder {
 	f.user.password = secret.NewText(password)
 
@@ -17,6 +17,7 @@ func Test_User_Getters(t *testing.T) {
 	assert.Equal(t, u.id, u.ID())
 	assert.Equal(t, u.role, u.Role())
 	assert.Equal(t, u.username, u.Username())
+	assert.Equal(t, u.email, u.Email())
 	assert.Equal(t, u.createdAt, u.CreatedAt())
 	assert.Equal(t, u.avatar, u.Avatar())
 	assert.Equal(t, u.passwordChangedAt, u.PasswordChangedAt())
@@ -50,6 +51,33 @@ func Test_UpdatePasswordCmd(t *testing.T) {
 	require.EqualError(t, err, "UserID: must be a valid UUID v4.")
 }
 
+func Test_UpdateEmailCmd(t *testing.T) {
+	err := UpdateEmailCmd{
+		UserID: uuid.UUID("ab5d5d9c-5d8d-4c3c-9a3e-2a4c8e8a2b61"),
+		Email:  "not-an-email",
+	}.Validate()
+
+	require.EqualError(t, err, "Email: must be a valid email address.")
+}
+
+func Test_GenerateAvatarCmd(t *testing.T) {
+	require.NoError(t, GenerateAvatarCmd{
+		UserID: uuid.UUID("ab5d5d9c-5d8d-4c3c-9a3e-2a4c8e8a2b61"),
+		Style:  FemaleAvatar,
+	}.Validate())
+
+	err := GenerateAvatarCmd{
+		UserID: uuid.UUID("ab5d5d9c-5d8d-4c3c-9a3e-2a4c8e8a2b61"),
+		Style:  AvatarStyle("robot"),
+	}.Validate()
+
+	require.EqualError(t, err, "Style: must be a valid value.")
+}
+
+func Test_NormalizeEmail(t *testing.T) {
+	assert.Equal(t, "jane@example.com", NormalizeEmail(" Jane@Example.COM "))
+}
+
 func Test_BootstrapCmd_is_validatable(t *testing.T) {
 	assert.Implements(t, (*v.Validatable)(nil), new(BootstrapCmd))
 }
@@ -62,3 +90,30 @@ func Test_BootstrapCmd_Validate_success(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func Test_RegisterCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*v.Validatable)(nil), new(RegisterCmd))
+}
+
+func Test_RegisterCmd_Validate(t *testing.T) {
+	require.NoError(t, RegisterCmd{
+		Username: "some-username",
+		Password: secret.NewText("myLittleSecret"),
+	}.Validate())
+
+	require.Error(t, RegisterCmd{
+		Username: "some username",
+		Password: secret.NewText("myLittleSecret"),
+	}.Validate())
+}
+
+func Test_DeleteTask(t *testing.T) {
+	task := DeleteTask{UserID: uuid.UUID("f4d3b7a6-6c2e-4d5b-9d0e-8d1f3a2b1c0d")}
+
+	assert.Equal(t, DeleteTaskName, task.Name())
+	assert.NotZero(t, task.Priority())
+	require.NoError(t, task.Validate())
+	assert.JSONEq(t, `{"user-id": "f4d3b7a6-6c2e-4d5b-9d0e-8d1f3a2b1c0d"}`, string(task.Args()))
+
+	require.Error(t, (&DeleteTask{}).Validate())
+}
@@ -5,12 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
 	"image/png"
-
-	"github.com/o1egl/govatar"
+	"log/slog"
+	"slices"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/clock"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
@@ -19,45 +21,78 @@ import (
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/o1egl/govatar"
 )
 
 var (
-	ErrAlreadyExists     = fmt.Errorf("user already exists")
-	ErrUsernameTaken     = fmt.Errorf("username taken")
-	ErrInvalidUsername   = fmt.Errorf("invalid username")
-	ErrInvalidPassword   = fmt.Errorf("invalid password")
-	ErrLastAdmin         = fmt.Errorf("can't remove the last admin")
-	ErrInvalidStatus     = fmt.Errorf("invalid status")
-	ErrUnauthorizedSpace = fmt.Errorf("unauthorized space")
+	ErrAlreadyExists      = fmt.Errorf("user already exists")
+	ErrUsernameTaken      = fmt.Errorf("username taken")
+	ErrEmailTaken         = fmt.Errorf("email taken")
+	ErrInvalidUsername    = fmt.Errorf("invalid username")
+	ErrInvalidPassword    = fmt.Errorf("invalid password")
+	ErrLastAdmin          = fmt.Errorf("can't remove the last admin")
+	ErrInvalidStatus      = fmt.Errorf("invalid status")
+	ErrUnauthorizedSpace  = fmt.Errorf("unauthorized space")
+	ErrRegistrationClosed = fmt.Errorf("registration closed")
+	ErrPendingApproval    = fmt.Errorf("pending approval")
+	ErrGhost              = fmt.Errorf("ghost account")
+	ErrUnknownRole        = fmt.Errorf("unknown role")
 )
 
 // storage encapsulates the logic to access user from the data source.
 type storage interface {
 	Save(ctx context.Context, user *User) error
 	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, userID uuid.UUID) (*User, error)
 	GetAll(ctx context.Context, cmd *sqlstorage.PaginateCmd) ([]User, error)
+	Search(ctx context.Context, query string, cmd *sqlstorage.PaginateCmd) ([]User, error)
 	HardDelete(ctx context.Context, userID uuid.UUID) error
+	CountWithRoles(ctx context.Context, roles []perms.Role, status Status) (int, error)
+	CountWithAvatar(ctx context.Context, avatarID uuid.UUID) (int, error)
 	Patch(ctx context.Context, userID uuid.UUID, fields map[string]any) error
 }
 
 // services handling all the logic.
 type services struct {
-	medias   medias.Service
-	storage  storage
-	clock    clock.Clock
-	uuid     uuid.Service
-	password password.Password
+	registration RegistrationMode
+	medias       medias.Service
+	roles        perms.Service
+	sessions     SessionRevoker
+	tasks        taskrunner.Service
+	storage      storage
+	clock        clock.Clock
+	uuid         uuid.Service
+	password     password.Password
+	log          *slog.Logger
 }
 
 // newService create a new user services.
-func newService(tools tools.Tools, storage storage, medias medias.Service) *services {
+func newService(
+	cfg Config,
+	tools tools.Tools,
+	storage storage,
+	medias medias.Service,
+	roles perms.Service,
+	sessions SessionRevoker,
+	tasks taskrunner.Service,
+) *services {
+	registration := cfg.Registration
+	if registration == "" {
+		registration = RegistrationClosed
+	}
+
 	return &services{
-		medias:   medias,
-		storage:  storage,
-		clock:    tools.Clock(),
-		uuid:     tools.UUID(),
-		password: tools.Password(),
+		registration: registration,
+		medias:       medias,
+		roles:        roles,
+		sessions:     sessions,
+		tasks:        tasks,
+		storage:      storage,
+		clock:        tools.Clock(),
+		uuid:         tools.UUID(),
+		password:     tools.Password(),
+		log:          tools.Logger(),
 	}
 }
 
@@ -68,7 +103,7 @@ func (s *services) Bootstrap(ctx context.Context, cmd *BootstrapCmd) (*User, err
 	}
 
 	newUserID := s.uuid.New()
-	return s.createUser(ctx, newUserID, ptr.To(perms.DefaultAdminRole), cmd.Username, cmd.Password, newUserID)
+	return s.createUser(ctx, newUserID, ptr.To(perms.DefaultAdminRole), Active, cmd.Username, cmd.Password, newUserID)
 }
 
 // Create will create and register a new user.
@@ -88,13 +123,217 @@ func (s *services) Create(ctx context.Context, cmd *CreateCmd) (*User, error) {
 	}
 
 	newUserID := s.uuid.New()
-	return s.createUser(ctx, newUserID, cmd.Role, cmd.Username, cmd.Password, cmd.CreatedBy.id)
+	return s.createUser(ctx, newUserID, cmd.Role, Active, cmd.Username, cmd.Password, cmd.CreatedBy.id)
+}
+
+// Register creates an account for a visitor, following the configured
+// [RegistrationMode]. The new user is its own creator unless it has been
+// invited: an invited account is active whatever the registration mode.
+func (s *services) Register(ctx context.Context, cmd *RegisterCmd) (*User, error) {
+	var status Status
+	switch {
+	case cmd.InvitedBy != nil, s.registration == RegistrationOpen:
+		status = Active
+	case s.registration == RegistrationApproval:
+		status = Pending
+	default:
+		return nil, errs.Unauthorized(ErrRegistrationClosed, "registration is closed")
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	userWithSameUsername, err := s.storage.GetByUsername(ctx, cmd.Username)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByUsername: %w", err))
+	}
+
+	if userWithSameUsername != nil {
+		return nil, errs.BadRequest(ErrUsernameTaken, "username already taken")
+	}
+
+	newUserID := s.uuid.New()
+	createdBy := newUserID
+	if cmd.InvitedBy != nil {
+		createdBy = cmd.InvitedBy.id
+	}
+
+	return s.createUser(ctx, newUserID, ptr.To(perms.DefaultUserRole), status, cmd.Username, cmd.Password, createdBy)
+}
+
+// CreateExternal creates an active account for a user authenticated by an
+// external identity provider. The account is its own creator and gets a
+// random password: it can only log in through its provider until the password
+// is reset.
+func (s *services) CreateExternal(ctx context.Context, cmd *CreateExternalCmd) (*User, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	userWithSameUsername, err := s.storage.GetByUsername(ctx, cmd.Username)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByUsername: %w", err))
+	}
+
+	if userWithSameUsername != nil {
+		return nil, errs.BadRequest(ErrUsernameTaken, "username already taken")
+	}
+
+	newUserID := s.uuid.New()
+	password := secret.NewText(string(s.uuid.New()))
+
+	return s.createUser(ctx, newUserID, cmd.Role, Active, cmd.Username, password, newUserID)
+}
+
+// UpdateRole replaces the role of a user.
+func (s *services) UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.roles.IsAuthorized(cmd.Admin, perms.ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.Admin.ID(), perms.ManageUsers))
+	}
+
+	return s.updateRole(ctx, cmd.UserID, cmd.Role)
+}
+
+func (s *services) UpdateExternalRole(ctx context.Context, cmd *UpdateExternalRoleCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	return s.updateRole(ctx, cmd.UserID, cmd.Role)
+}
+
+func (s *services) updateRole(ctx context.Context, userID uuid.UUID, role perms.Role) error {
+	if !slices.Contains(s.roles.GetRoles(), role) {
+		return errs.Validation(fmt.Errorf("%w: %q", ErrUnknownRole, role))
+	}
+
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	if user.status == Ghost {
+		return errs.BadRequest(ErrGhost, "this account can't be modified")
+	}
+
+	if !slices.Contains(s.roles.RolesWith(perms.ManageUsers), role) {
+		lastAdmin, err := s.isLastAdmin(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		if lastAdmin {
+			return errs.Unauthorized(ErrLastAdmin, "the last admin can't lose its role")
+		}
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"role": role})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to patch the user: %w", err))
+	}
+
+	return nil
+}
+
+// UpdateEmail replaces the e-mail of a user. An e-mail can't be shared by
+// several users.
+func (s *services) UpdateEmail(ctx context.Context, cmd *UpdateEmailCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	user, err := s.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	email := NormalizeEmail(cmd.Email)
+
+	var value any
+	if email != "" {
+		userWithSameEmail, err := s.storage.GetByEmail(ctx, email)
+		if err != nil && !errors.Is(err, errNotFound) {
+			return errs.Internal(fmt.Errorf("failed to GetByEmail: %w", err))
+		}
+
+		if userWithSameEmail != nil && userWithSameEmail.id != user.id {
+			return errs.BadRequest(ErrEmailTaken, "email already used by another account")
+		}
+
+		value = email
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"email": value})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to patch the user: %w", err))
+	}
+
+	return nil
+}
+
+func (s *services) RegistrationMode() RegistrationMode {
+	return s.registration
+}
+
+// Approve activates an account waiting for approval.
+func (s *services) Approve(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	if user.status != Pending {
+		return errs.BadRequest(ErrInvalidStatus, "the user is not waiting for approval")
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"status": Active})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to patch the user: %w", err))
+	}
+
+	return nil
+}
+
+// Reject removes an account waiting for approval along with its avatar. A
+// pending account has never been able to log in so nothing references it yet.
+func (s *services) Reject(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	if user.status != Pending {
+		return errs.BadRequest(ErrInvalidStatus, "the user is not waiting for approval")
+	}
+
+	err = s.storage.HardDelete(ctx, user.id)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to HardDelete: %w", err))
+	}
+
+	err = s.deleteAvatarIfUnused(ctx, user.avatar)
+	if err != nil {
+		return fmt.Errorf("failed to delete the avatar: %w", err)
+	}
+
+	return nil
 }
 
 func (s *services) createUser(
 	ctx context.Context,
 	newUserID uuid.UUID,
 	role *perms.Role,
+	status Status,
 	username string,
 	password secret.Text,
 	createdBy uuid.UUID,
@@ -106,20 +345,14 @@ func (s *services) createUser(
 
 	now := s.clock.Now()
 
-	avatarImg, err := govatar.GenerateForUsername(govatar.MALE, username)
+	avatarImg, err := defaultAvatar(username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate the avatar: %w", err)
 	}
 
-	var avatarBuf bytes.Buffer
-	err = png.Encode(&avatarBuf, avatarImg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode the image into png: %w", err)
-	}
-
-	avatar, err := s.medias.Upload(ctx, medias.Avatar, &avatarBuf)
+	avatar, err := s.saveAvatar(ctx, avatarImg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save the avatar: %w", err)
+		return nil, err
 	}
 
 	user := User{
@@ -127,7 +360,7 @@ func (s *services) createUser(
 		role:              role,
 		username:          username,
 		password:          hashedPassword,
-		status:            Active,
+		status:            status,
 		passwordChangedAt: now,
 		avatar:            avatar.ID(),
 		createdAt:         now,
@@ -199,9 +432,44 @@ func (s *services) Authenticate(ctx context.Context, username string, userPasswo
 		return nil, errs.BadRequest(ErrInvalidPassword)
 	}
 
+	if user.status == Pending {
+		return nil, errs.Unauthorized(ErrPendingApproval, "your account is waiting for an admin approval")
+	}
+
+	if user.status == Deleting || user.status == Ghost {
+		return nil, errs.BadRequest(ErrInvalidUsername)
+	}
+
+	if s.password.NeedsRehash(user.password) {
+		// The login must not fail because of the migration, it will be
+		// retried on the next login.
+		err = s.rehashPassword(ctx, user, userPassword)
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to rehash the password", slog.String("user", string(user.id)), slog.String("error", err.Error()))
+		}
+	}
+
 	return user, nil
 }
 
+// rehashPassword replaces a hash made with weaker parameters or imported from
+// another system. It isn't a password change so the sessions are kept.
+func (s *services) rehashPassword(ctx context.Context, user *User, userPassword secret.Text) error {
+	hash, err := s.password.Encrypt(ctx, userPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash the password: %w", err)
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"password": hash})
+	if err != nil {
+		return fmt.Errorf("failed to Patch: %w", err)
+	}
+
+	user.password = hash
+
+	return nil
+}
+
 func (s *services) GetByID(ctx context.Context, userID uuid.UUID) (*User, error) {
 	res, err := s.storage.GetByID(ctx, userID)
 	if errors.Is(err, errNotFound) {
@@ -215,6 +483,38 @@ func (s *services) GetByID(ctx context.Context, userID uuid.UUID) (*User, error)
 	return res, nil
 }
 
+// GetByUsername returns the user with the given username. The ghost account
+// is never returned: it has no profile and can't be managed.
+func (s *services) GetByUsername(ctx context.Context, username string) (*User, error) {
+	res, err := s.storage.GetByUsername(ctx, username)
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(err)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(err)
+	}
+
+	if res.status == Ghost {
+		return nil, errs.NotFound(errNotFound)
+	}
+
+	return res, nil
+}
+
+func (s *services) GetByEmail(ctx context.Context, email string) (*User, error) {
+	res, err := s.storage.GetByEmail(ctx, NormalizeEmail(email))
+	if errors.Is(err, errNotFound) {
+		return nil, errs.NotFound(err)
+	}
+
+	if err != nil {
+		return nil, errs.Internal(err)
+	}
+
+	return res, nil
+}
+
 func (s *services) GetAll(ctx context.Context, paginateCmd *sqlstorage.PaginateCmd) ([]User, error) {
 	res, err := s.storage.GetAll(ctx, paginateCmd)
 	if err != nil {
@@ -224,36 +524,91 @@ func (s *services) GetAll(ctx context.Context, paginateCmd *sqlstorage.PaginateC
 	return res, nil
 }
 
-func (s *services) AddToDeletion(ctx context.Context, userID uuid.UUID) error {
-	_, err := s.GetByID(ctx, userID)
-	if errors.Is(err, errNotFound) {
-		return errs.NotFound(err)
+// Search returns a page of the users with a username containing the query,
+// sorted by username.
+func (s *services) Search(ctx context.Context, cmd *SearchCmd) ([]User, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
 	}
 
+	res, err := s.storage.Search(ctx, cmd.Query, &sqlstorage.PaginateCmd{
+		StartAfter: map[string]string{"username": cmd.After},
+		Limit:      cmd.Limit,
+	})
 	if err != nil {
-		return errs.Internal(fmt.Errorf("failed to GetByID: %w", err))
+		return nil, errs.Internal(fmt.Errorf("failed to Search: %w", err))
 	}
 
-	// TODO: Should check if the user is not the last one with the user creation
-	// if user.IsAdmin() {
-	// 	users, err := s.GetAll(ctx, nil)
-	// 	if err != nil {
-	// 		return errs.Internal(fmt.Errorf("failed to GetAll: %w", err))
-	// 	}
-	//
-	// 	if isTheLastAdmin(users) {
-	// 		return errs.Unauthorized(ErrLastAdmin, "you are the last admin, you account can't be removed")
-	// 	}
-	// }
+	return res, nil
+}
 
-	err = s.storage.HardDelete(ctx, userID)
+func (s *services) AddToDeletion(ctx context.Context, cmd *AddToDeletionCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.roles.IsAuthorized(cmd.Admin, perms.ManageUsers) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.Admin.ID(), perms.ManageUsers))
+	}
+
+	user, err := s.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	if user.status == Deleting {
+		return nil
+	}
+
+	if user.status == Ghost {
+		return errs.BadRequest(ErrGhost, "this account can't be removed")
+	}
+
+	lastAdmin, err := s.isLastAdmin(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if lastAdmin {
+		return errs.Unauthorized(ErrLastAdmin, "the last admin can't be removed")
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"status": Deleting})
 	if err != nil {
 		return errs.Internal(fmt.Errorf("failed to Patch the user: %w", err))
 	}
 
+	// XXX:MULTI-WRITE
+	err = s.sessions.DeleteAll(ctx, user.id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke the sessions: %w", err)
+	}
+
+	err = s.tasks.RegisterTask(ctx, &DeleteTask{UserID: user.id})
+	if err != nil {
+		return fmt.Errorf("failed to register the deletion task: %w", err)
+	}
+
 	return nil
 }
 
+// isLastAdmin returns true if the user is the only active one able to
+// manage the users.
+func (s *services) isLastAdmin(ctx context.Context, user *User) (bool, error) {
+	if user.status != Active || !s.roles.IsAuthorized(user, perms.ManageUsers) {
+		return false, nil
+	}
+
+	nbAdmins, err := s.storage.CountWithRoles(ctx, s.roles.RolesWith(perms.ManageUsers), Active)
+	if err != nil {
+		return false, errs.Internal(fmt.Errorf("failed to CountWithRoles: %w", err))
+	}
+
+	return nbAdmins <= 1, nil
+}
+
 func (s *services) HardDelete(ctx context.Context, userID uuid.UUID) error {
 	res, err := s.storage.GetByID(ctx, userID)
 	if errors.Is(err, errNotFound) {
@@ -272,5 +627,156 @@ func (s *services) HardDelete(ctx context.Context, userID uuid.UUID) error {
 		return errs.Internal(fmt.Errorf("failed to HardDelete: %w", err))
 	}
 
+	err = s.deleteAvatarIfUnused(ctx, res.avatar)
+	if err != nil {
+		return fmt.Errorf("failed to delete the avatar: %w", err)
+	}
+
+	return nil
+}
+
+func (s *services) GetGhost(ctx context.Context) (*User, error) {
+	ghost, err := s.storage.GetByUsername(ctx, GhostUsername)
+	if err == nil {
+		return ghost, nil
+	}
+
+	if !errors.Is(err, errNotFound) {
+		return nil, errs.Internal(fmt.Errorf("failed to GetByUsername: %w", err))
+	}
+
+	// Nobody knows the password and the status prevents any login. The role
+	// is only set because it is required.
+	newUserID := s.uuid.New()
+	return s.createUser(ctx, newUserID, ptr.To(perms.DefaultUserRole), Ghost, GhostUsername, secret.NewText(string(s.uuid.New())), newUserID)
+}
+
+func (s *services) UploadAvatar(ctx context.Context, cmd *UploadAvatarCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	user, err := s.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	img, err := decodeAvatar(cmd.Content)
+	if err != nil {
+		return fmt.Errorf("failed to decode the avatar: %w", err)
+	}
+
+	return s.replaceAvatar(ctx, user, squareResize(img, AvatarSize))
+}
+
+func (s *services) GenerateAvatar(ctx context.Context, cmd *GenerateAvatarCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	user, err := s.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	img, err := govatar.Generate(cmd.Style.gender())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to generate the avatar: %w", err))
+	}
+
+	return s.replaceAvatar(ctx, user, img)
+}
+
+func (s *services) ResetAvatar(ctx context.Context, cmd *ResetAvatarCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.roles.IsAuthorized(cmd.Moderator, perms.Moderation) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.Moderator.ID(), perms.Moderation))
+	}
+
+	user, err := s.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	img, err := defaultAvatar(user.username)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to generate the avatar: %w", err))
+	}
+
+	return s.replaceAvatar(ctx, user, img)
+}
+
+// saveAvatar encodes the avatar into png and uploads it.
+func (s *services) saveAvatar(ctx context.Context, img image.Image) (*medias.FileMeta, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to encode the image into png: %w", err))
+	}
+
+	avatar, err := s.medias.Upload(ctx, medias.Avatar, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save the avatar: %w", err)
+	}
+
+	return avatar, nil
+}
+
+func (s *services) replaceAvatar(ctx context.Context, user *User, img image.Image) error {
+	avatar, err := s.saveAvatar(ctx, img)
+	if err != nil {
+		return err
+	}
+
+	if avatar.ID() == user.avatar {
+		return nil
+	}
+
+	err = s.storage.Patch(ctx, user.id, map[string]any{"avatar": avatar.ID()})
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to patch the user: %w", err))
+	}
+
+	// XXX:MULTI-WRITE
+	err = s.deleteAvatarIfUnused(ctx, user.avatar)
+	if err != nil {
+		return fmt.Errorf("failed to delete the previous avatar: %w", err)
+	}
+
 	return nil
 }
+
+// deleteAvatarIfUnused removes an avatar once no user references it. The
+// medias are deduplicated by checksum so several users can share the same
+// avatar and a media uploaded for a post is never removed.
+func (s *services) deleteAvatarIfUnused(ctx context.Context, avatarID uuid.UUID) error {
+	meta, err := s.medias.GetMetadata(ctx, avatarID)
+	if errors.Is(err, medias.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to GetMetadata: %w", err))
+	}
+
+	if meta.Type() != medias.Avatar {
+		return nil
+	}
+
+	nbUsers, err := s.storage.CountWithAvatar(ctx, avatarID)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to CountWithAvatar: %w", err))
+	}
+
+	if nbUsers > 0 {
+		return nil
+	}
+
+	return s.medias.Delete(ctx, avatarID)
+}
@@ -18,14 +18,32 @@ type MockService struct {
 	mock.Mock
 }
 
-// AddToDeletion provides a mock function with given fields: ctx, userID
-func (_m *MockService) AddToDeletion(ctx context.Context, userID uuid.UUID) error {
-	ret := _m.Called(ctx, userID)
+// AddToDeletion provides a mock function with given fields: ctx, cmd
+func (_m *MockService) AddToDeletion(ctx context.Context, cmd *AddToDeletionCmd) error {
+	ret := _m.Called(ctx, cmd)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddToDeletion")
 	}
 
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *AddToDeletionCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Approve provides a mock function with given fields: ctx, userID
+func (_m *MockService) Approve(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Approve")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
 		r0 = rf(ctx, userID)
@@ -126,6 +144,54 @@ func (_m *MockService) Create(ctx context.Context, user *CreateCmd) (*User, erro
 	return r0, r1
 }
 
+// CreateExternal provides a mock function with given fields: ctx, cmd
+func (_m *MockService) CreateExternal(ctx context.Context, cmd *CreateExternalCmd) (*User, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateExternal")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *CreateExternalCmd) (*User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *CreateExternalCmd) *User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *CreateExternalCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateAvatar provides a mock function with given fields: ctx, cmd
+func (_m *MockService) GenerateAvatar(ctx context.Context, cmd *GenerateAvatarCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateAvatar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *GenerateAvatarCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetAll provides a mock function with given fields: ctx, paginateCmd
 func (_m *MockService) GetAll(ctx context.Context, paginateCmd *sqlstorage.PaginateCmd) ([]User, error) {
 	ret := _m.Called(ctx, paginateCmd)
@@ -186,6 +252,36 @@ func (_m *MockService) GetAllWithStatus(ctx context.Context, status Status, cmd
 	return r0, r1
 }
 
+// GetByEmail provides a mock function with given fields: ctx, email
+func (_m *MockService) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByEmail")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: ctx, userID
 func (_m *MockService) GetByID(ctx context.Context, userID uuid.UUID) (*User, error) {
 	ret := _m.Called(ctx, userID)
@@ -216,6 +312,66 @@ func (_m *MockService) GetByID(ctx context.Context, userID uuid.UUID) (*User, er
 	return r0, r1
 }
 
+// GetByUsername provides a mock function with given fields: ctx, username
+func (_m *MockService) GetByUsername(ctx context.Context, username string) (*User, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByUsername")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*User, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *User); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGhost provides a mock function with given fields: ctx
+func (_m *MockService) GetGhost(ctx context.Context) (*User, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGhost")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*User, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HardDelete provides a mock function with given fields: ctx, userID
 func (_m *MockService) HardDelete(ctx context.Context, userID uuid.UUID) error {
 	ret := _m.Called(ctx, userID)
@@ -234,6 +390,174 @@ func (_m *MockService) HardDelete(ctx context.Context, userID uuid.UUID) error {
 	return r0
 }
 
+// Register provides a mock function with given fields: ctx, cmd
+func (_m *MockService) Register(ctx context.Context, cmd *RegisterCmd) (*User, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *RegisterCmd) (*User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *RegisterCmd) *User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *RegisterCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegistrationMode provides a mock function with given fields:
+func (_m *MockService) RegistrationMode() RegistrationMode {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegistrationMode")
+	}
+
+	var r0 RegistrationMode
+	if rf, ok := ret.Get(0).(func() RegistrationMode); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(RegistrationMode)
+	}
+
+	return r0
+}
+
+// Reject provides a mock function with given fields: ctx, userID
+func (_m *MockService) Reject(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reject")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetAvatar provides a mock function with given fields: ctx, cmd
+func (_m *MockService) ResetAvatar(ctx context.Context, cmd *ResetAvatarCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetAvatar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ResetAvatarCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, cmd
+func (_m *MockService) Search(ctx context.Context, cmd *SearchCmd) ([]User, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *SearchCmd) ([]User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *SearchCmd) []User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *SearchCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateEmail provides a mock function with given fields: ctx, cmd
+func (_m *MockService) UpdateEmail(ctx context.Context, cmd *UpdateEmailCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UpdateEmailCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateExternalRole provides a mock function with given fields: ctx, cmd
+func (_m *MockService) UpdateExternalRole(ctx context.Context, cmd *UpdateExternalRoleCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateExternalRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UpdateExternalRoleCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: ctx, cmd
+func (_m *MockService) UpdateRole(ctx context.Context, cmd *UpdateRoleCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UpdateRoleCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateUserPassword provides a mock function with given fields: ctx, cmd
 func (_m *MockService) UpdateUserPassword(ctx context.Context, cmd *UpdatePasswordCmd) error {
 	ret := _m.Called(ctx, cmd)
@@ -252,6 +576,24 @@ func (_m *MockService) UpdateUserPassword(ctx context.Context, cmd *UpdatePasswo
 	return r0
 }
 
+// UploadAvatar provides a mock function with given fields: ctx, cmd
+func (_m *MockService) UploadAvatar(ctx context.Context, cmd *UploadAvatarCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadAvatar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UploadAvatarCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
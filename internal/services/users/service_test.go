@@ -3,15 +3,19 @@ package users
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -25,7 +29,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		mediasSvc := medias.NewMockService(t)
-		services := newService(tools, storage, mediasSvc)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
 
 		// Data
 		role, _ := perms.NewFakePermissions(t).Build()
@@ -68,7 +75,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		role, _ := perms.NewFakePermissions(t).Build()
@@ -96,7 +106,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		role, _ := perms.NewFakePermissions(t).Build()
@@ -123,7 +136,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		user := NewFakeUser(t).Build()
@@ -131,6 +147,7 @@ func Test_Users_Service(t *testing.T) {
 		// Mocks
 		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
 		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-password")).Return(true, nil).Once()
+		tools.PasswordMock.On("NeedsRehash", user.password).Return(false).Once()
 
 		// Run
 		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
@@ -140,12 +157,75 @@ func Test_Users_Service(t *testing.T) {
 		assert.Equal(t, user, res)
 	})
 
+	t.Run("Authenticate rehashes a weak password", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+		oldHash := user.password
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, oldHash, secret.NewText("some-password")).Return(true, nil).Once()
+		tools.PasswordMock.On("NeedsRehash", oldHash).Return(true).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("some-password")).
+			Return(secret.NewText("some-new-hash"), nil).Once()
+		storage.On("Patch", ctx, user.ID(), map[string]any{"password": secret.NewText("some-new-hash")}).Return(nil).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, secret.NewText("some-new-hash"), res.password)
+	})
+
+	t.Run("Authenticate with a rehash error", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+		oldHash := user.password
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, oldHash, secret.NewText("some-password")).Return(true, nil).Once()
+		tools.PasswordMock.On("NeedsRehash", oldHash).Return(true).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("some-password")).
+			Return(secret.NewText("some-new-hash"), nil).Once()
+		storage.On("Patch", ctx, user.ID(), map[string]any{"password": secret.NewText("some-new-hash")}).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+
+		// Asserts
+		require.NoError(t, err, "the login must not fail because of the migration")
+		assert.Equal(t, oldHash, res.password)
+	})
+
 	t.Run("Authenticate with an invalid username", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 
@@ -156,198 +236,1415 @@ func Test_Users_Service(t *testing.T) {
 		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-secret"))
 
 		// Asserts
-		require.ErrorIs(t, err, errs.ErrBadRequest)
-		require.ErrorIs(t, err, ErrInvalidUsername)
-		assert.Nil(t, res)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidUsername)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Authenticate with an invalid password", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-invalid-password")).Return(false, nil).Once()
+
+		// Invalid password here
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-invalid-password"))
+		require.ErrorIs(t, err, ErrInvalidPassword)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Authenticate an unhandled password error", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-password")).Return(false, fmt.Errorf("some-error")).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		assert.Nil(t, res)
+	})
+
+	t.Run("Authenticate with a user waiting for approval", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithStatus(Pending).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-password")).Return(true, nil).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrPendingApproval)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Authenticate with a user marked for deletion", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithStatus(Deleting).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-password")).Return(true, nil).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidUsername)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Authenticate with the ghost", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		ghost := NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, GhostUsername).Return(ghost, nil).Once()
+		tools.PasswordMock.On("Compare", ctx, ghost.password, secret.NewText("some-password")).Return(true, nil).Once()
+
+		// Run
+		res, err := services.Authenticate(ctx, GhostUsername, secret.NewText("some-password"))
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidUsername)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Register success with the open mode", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationOpen}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		avatar := medias.NewFakeFileMeta(t).Build()
+		newUser := NewFakeUser(t).
+			WithAvatar(avatar).
+			WithRole(ptr.To(perms.DefaultUserRole)).
+			Build()
+		newUser.createdBy = newUser.id
+
+		// Mocks
+		storage.On("GetByUsername", ctx, newUser.username).Return(nil, errNotFound).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+		tools.UUIDMock.On("New").Return(newUser.id).Once()
+		tools.ClockMock.On("Now").Return(newUser.createdAt).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("my-super-password")).
+			Return(newUser.password, nil).Once()
+		storage.On("Save", ctx, newUser).Return(nil).Once()
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: newUser.username,
+			Password: secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, newUser, res)
+		assert.Equal(t, Active, res.Status())
+	})
+
+	t.Run("Register with the approval mode creates a pending user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationApproval}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		avatar := medias.NewFakeFileMeta(t).Build()
+		newUser := NewFakeUser(t).
+			WithAvatar(avatar).
+			WithRole(ptr.To(perms.DefaultUserRole)).
+			WithStatus(Pending).
+			Build()
+		newUser.createdBy = newUser.id
+
+		// Mocks
+		storage.On("GetByUsername", ctx, newUser.username).Return(nil, errNotFound).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+		tools.UUIDMock.On("New").Return(newUser.id).Once()
+		tools.ClockMock.On("Now").Return(newUser.createdAt).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("my-super-password")).
+			Return(newUser.password, nil).Once()
+		storage.On("Save", ctx, newUser).Return(nil).Once()
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: newUser.username,
+			Password: secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, Pending, res.Status())
+	})
+
+	t.Run("Register with the closed mode", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationClosed}, tools, storage, medias, roles, sessions, tasks)
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrRegistrationClosed)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Register is closed by default", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, ErrRegistrationClosed)
+		assert.Nil(t, res)
+		assert.Equal(t, RegistrationClosed, services.RegistrationMode())
+	})
+
+	t.Run("Register with an invitation while the registration is closed", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationClosed}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		inviter := NewFakeUser(t).Build()
+		avatar := medias.NewFakeFileMeta(t).Build()
+		newUser := NewFakeUser(t).
+			WithAvatar(avatar).
+			WithRole(ptr.To(perms.DefaultUserRole)).
+			CreatedBy(inviter).
+			Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, newUser.username).Return(nil, errNotFound).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+		tools.UUIDMock.On("New").Return(newUser.id).Once()
+		tools.ClockMock.On("Now").Return(newUser.createdAt).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("my-super-password")).
+			Return(newUser.password, nil).Once()
+		storage.On("Save", ctx, newUser).Return(nil).Once()
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			InvitedBy: inviter,
+			Username:  newUser.username,
+			Password:  secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, Active, res.Status())
+		assert.Equal(t, inviter.ID(), res.CreatedBy())
+	})
+
+	t.Run("Register with a taken username", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationOpen}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, user.username).Return(user, nil).Once()
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: user.username,
+			Password: secret.NewText("my-super-password"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrUsernameTaken)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Register with an invalid password", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{Registration: RegistrationOpen}, tools, storage, medias, roles, sessions, tasks)
+
+		// Run
+		res, err := services.Register(ctx, &RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("short"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+		assert.Nil(t, res)
+	})
+
+	t.Run("CreateExternal success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		avatar := medias.NewFakeFileMeta(t).Build()
+		newUser := NewFakeUser(t).
+			WithAvatar(avatar).
+			WithRole(ptr.To(perms.DefaultUserRole)).
+			Build()
+		newUser.createdBy = newUser.id
+
+		// Mocks
+		storage.On("GetByUsername", ctx, newUser.username).Return(nil, errNotFound).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+		tools.UUIDMock.On("New").Return(newUser.id).Once()
+		tools.UUIDMock.On("New").Return(uuid.UUID("b2a7a9b8-5f5c-4a7e-9a3f-0c1d2e3f4a5b")).Once()
+		tools.ClockMock.On("Now").Return(newUser.createdAt).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("b2a7a9b8-5f5c-4a7e-9a3f-0c1d2e3f4a5b")).
+			Return(newUser.password, nil).Once()
+		storage.On("Save", ctx, newUser).Return(nil).Once()
+
+		// Run
+		res, err := services.CreateExternal(ctx, &CreateExternalCmd{
+			Role:     ptr.To(perms.DefaultUserRole),
+			Username: newUser.username,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, newUser, res)
+		assert.Equal(t, Active, res.Status())
+	})
+
+	t.Run("CreateExternal with a taken username", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, user.username).Return(user, nil).Once()
+
+		// Run
+		res, err := services.CreateExternal(ctx, &CreateExternalCmd{
+			Role:     ptr.To(perms.DefaultUserRole),
+			Username: user.username,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrUsernameTaken)
+		assert.Nil(t, res)
+	})
+
+	t.Run("UpdateRole success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"role": perms.DefaultAdminRole}).Return(nil).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+			Role:   perms.DefaultAdminRole,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UpdateRole with an unknown user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+			Role:   perms.DefaultAdminRole,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("UpdateRole of the ghost", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		ghost := NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		storage.On("GetByID", mock.Anything, ghost.ID()).Return(ghost, nil).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: ghost.ID(),
+			Role:   perms.DefaultAdminRole,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrGhost)
+	})
+
+	t.Run("UpdateRole with an unknown role", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+			Role:   perms.Role("unknown"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.ErrorIs(t, err, ErrUnknownRole)
+	})
+
+	t.Run("UpdateRole of the last admin", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Twice()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		storage.On("CountWithRoles", mock.Anything, []perms.Role{perms.DefaultAdminRole}, Active).Return(1, nil).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+			Role:   perms.DefaultUserRole,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrLastAdmin)
+	})
+
+	t.Run("UpdateRole of an admin with another admin", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Twice()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		storage.On("CountWithRoles", mock.Anything, []perms.Role{perms.DefaultAdminRole}, Active).Return(2, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"role": perms.DefaultUserRole}).Return(nil).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+			Role:   perms.DefaultUserRole,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UpdateRole without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		notAdmin := NewFakeUser(t).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", notAdmin, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		err := services.UpdateRole(ctx, &UpdateRoleCmd{
+			Admin:  notAdmin,
+			UserID: user.ID(),
+			Role:   perms.DefaultAdminRole,
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("UpdateExternalRole success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultModeratorRole}).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Once()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"role": perms.DefaultModeratorRole}).Return(nil).Once()
+
+		// Run
+		err := services.UpdateExternalRole(ctx, &UpdateExternalRoleCmd{
+			UserID: user.ID(),
+			Role:   perms.DefaultModeratorRole,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Search success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("Search", mock.Anything, "jo", &sqlstorage.PaginateCmd{
+			StartAfter: map[string]string{"username": "alice"},
+			Limit:      20,
+		}).Return([]User{*user}, nil).Once()
+
+		// Run
+		res, err := services.Search(ctx, &SearchCmd{Query: "jo", After: "alice", Limit: 20})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []User{*user}, res)
+	})
+
+	t.Run("Search with an invalid cmd", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Run
+		res, err := services.Search(ctx, &SearchCmd{Query: "jo", Limit: 0})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+		assert.Nil(t, res)
+	})
+
+	t.Run("UpdateEmail success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		storage.On("GetByEmail", mock.Anything, "jane@example.com").Return(nil, errNotFound).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"email": "jane@example.com"}).Return(nil).Once()
+
+		// Run
+		err := services.UpdateEmail(ctx, &UpdateEmailCmd{
+			UserID: user.ID(),
+			Email:  "Jane@Example.com",
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UpdateEmail with an empty email removes it", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithEmail("jane@example.com").Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"email": nil}).Return(nil).Once()
+
+		// Run
+		err := services.UpdateEmail(ctx, &UpdateEmailCmd{
+			UserID: user.ID(),
+			Email:  "",
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UpdateEmail with an email used by another user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+		otherUser := NewFakeUser(t).WithEmail("jane@example.com").Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		storage.On("GetByEmail", mock.Anything, "jane@example.com").Return(otherUser, nil).Once()
+
+		// Run
+		err := services.UpdateEmail(ctx, &UpdateEmailCmd{
+			UserID: user.ID(),
+			Email:  "jane@example.com",
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrEmailTaken)
+	})
+
+	t.Run("GetByEmail success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithEmail("jane@example.com").Build()
+
+		// Mocks
+		storage.On("GetByEmail", mock.Anything, "jane@example.com").Return(user, nil).Once()
+
+		// Run
+		res, err := services.GetByEmail(ctx, " JANE@example.com")
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("GetByEmail not found", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Mocks
+		storage.On("GetByEmail", mock.Anything, "jane@example.com").Return(nil, errNotFound).Once()
+
+		// Run
+		res, err := services.GetByEmail(ctx, "jane@example.com")
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Approve success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithStatus(Pending).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"status": Active}).Return(nil).Once()
+
+		// Run
+		err := services.Approve(ctx, user.ID())
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Approve an active user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithStatus(Active).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+
+		// Run
+		err := services.Approve(ctx, user.ID())
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidStatus)
+	})
+
+	t.Run("Reject success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		avatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithStatus(Pending).WithAvatar(avatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		storage.On("HardDelete", mock.Anything, user.ID()).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, avatar.ID()).Return(avatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, avatar.ID()).Return(0, nil).Once()
+		mediasSvc.On("Delete", mock.Anything, avatar.ID()).Return(nil).Once()
+
+		// Run
+		err := services.Reject(ctx, user.ID())
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("Reject an active user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).WithStatus(Active).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+
+		// Run
+		err := services.Reject(ctx, user.ID())
+
+		// Asserts
+		require.ErrorIs(t, err, ErrInvalidStatus)
+	})
+
+	t.Run("GetByID success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+
+		// Run
+		res, err := services.GetByID(ctx, user.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("GetByUsername success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, user.Username()).Return(user, nil).Once()
+
+		// Run
+		res, err := services.GetByUsername(ctx, user.Username())
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("GetByUsername not found", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Mocks
+		storage.On("GetByUsername", ctx, "some-username").Return(nil, errNotFound).Once()
+
+		// Run
+		res, err := services.GetByUsername(ctx, "some-username")
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("GetByUsername with the ghost", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		ghost := NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).Build()
+
+		// Mocks
+		storage.On("GetByUsername", ctx, GhostUsername).Return(ghost, nil).Once()
+
+		// Run
+		res, err := services.GetByUsername(ctx, GhostUsername)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, res)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetAll", ctx, &sqlstorage.PaginateCmd{Limit: 10}).Return([]User{*user}, nil).Once()
+
+		// Run
+		res, err := services.GetAll(ctx, &sqlstorage.PaginateCmd{Limit: 10})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []User{*user}, res)
+	})
+
+	t.Run("GetAllWithStatus success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetAll", ctx, &sqlstorage.PaginateCmd{Limit: 10}).Return([]User{*user}, nil).Once()
+
+		// Run
+		res, err := services.GetAllWithStatus(ctx, Active, &sqlstorage.PaginateCmd{Limit: 10})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []User{*user}, res)
+	})
+
+	t.Run("AddToDeletion success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+		storage.On("Patch", ctx, user.ID(), map[string]any{"status": Deleting}).Return(nil).Once()
+		sessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		tasks.On("RegisterTask", ctx, &DeleteTask{UserID: user.ID()}).Return(nil).Once()
+
+		// Run
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("AddToDeletion an admin with another admin success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Once()
+		storage.On("CountWithRoles", ctx, []perms.Role{perms.DefaultAdminRole}, Active).Return(2, nil).Once()
+		storage.On("Patch", ctx, user.ID(), map[string]any{"status": Deleting}).Return(nil).Once()
+		sessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		tasks.On("RegisterTask", ctx, &DeleteTask{UserID: user.ID()}).Return(nil).Once()
+
+		// Run
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("AddToDeletion the last admin failed", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		roles.On("RolesWith", perms.ManageUsers).Return([]perms.Role{perms.DefaultAdminRole}).Once()
+		storage.On("CountWithRoles", ctx, []perms.Role{perms.DefaultAdminRole}, Active).Return(1, nil).Once()
+
+		// Run
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.ErrorIs(t, err, ErrLastAdmin)
+	})
+
+	t.Run("AddToDeletion a user already deleting", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		medias := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
+
+		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithStatus(Deleting).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+
+		// Run
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
+
+		// Asserts
+		require.NoError(t, err)
 	})
 
-	t.Run("Authenticate with an invalid password", func(t *testing.T) {
+	t.Run("AddToDeletion the ghost", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
-		user := NewFakeUser(t).Build()
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		user := NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).Build()
 
 		// Mocks
-		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
-		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-invalid-password")).Return(false, nil).Once()
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
 
-		// Invalid password here
-		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-invalid-password"))
-		require.ErrorIs(t, err, ErrInvalidPassword)
-		assert.Nil(t, res)
+		// Run
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrGhost)
 	})
 
-	t.Run("Authenticate an unhandled password error", func(t *testing.T) {
+	t.Run("AddToDeletion with a RegisterTask error", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
 		user := NewFakeUser(t).Build()
 
 		// Mocks
-		storage.On("GetByUsername", ctx, "Donald-Duck").Return(user, nil).Once()
-		tools.PasswordMock.On("Compare", ctx, user.password, secret.NewText("some-password")).Return(false, fmt.Errorf("some-error")).Once()
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		roles.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+		storage.On("Patch", ctx, user.ID(), map[string]any{"status": Deleting}).Return(nil).Once()
+		sessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		tasks.On("RegisterTask", ctx, &DeleteTask{UserID: user.ID()}).Return(errs.Internal(fmt.Errorf("some-error"))).Once()
 
 		// Run
-		res, err := services.Authenticate(ctx, "Donald-Duck", secret.NewText("some-password"))
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
 
 		// Asserts
 		require.ErrorIs(t, err, errs.ErrInternal)
 		require.ErrorContains(t, err, "some-error")
-		assert.Nil(t, res)
 	})
 
-	t.Run("GetByID success", func(t *testing.T) {
+	t.Run("AddToDeletion with a user not found", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
+		admin := NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
 		user := NewFakeUser(t).Build()
 
 		// Mocks
-		storage.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		roles.On("IsAuthorized", admin, perms.ManageUsers).Return(true).Once()
+		storage.On("GetByID", ctx, user.ID()).Return(nil, errNotFound).Once()
 
 		// Run
-		res, err := services.GetByID(ctx, user.ID())
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  admin,
+			UserID: user.ID(),
+		})
 
 		// Asserts
-		require.NoError(t, err)
-		assert.Equal(t, user, res)
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, errNotFound)
 	})
 
-	t.Run("GetAll success", func(t *testing.T) {
+	t.Run("AddToDeletion without the users.manage permission", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
+		notAdmin := NewFakeUser(t).Build()
 		user := NewFakeUser(t).Build()
 
 		// Mocks
-		storage.On("GetAll", ctx, &sqlstorage.PaginateCmd{Limit: 10}).Return([]User{*user}, nil).Once()
+		roles.On("IsAuthorized", notAdmin, perms.ManageUsers).Return(false).Once()
 
 		// Run
-		res, err := services.GetAll(ctx, &sqlstorage.PaginateCmd{Limit: 10})
+		err := services.AddToDeletion(ctx, &AddToDeletionCmd{
+			Admin:  notAdmin,
+			UserID: user.ID(),
+		})
 
 		// Asserts
-		require.NoError(t, err)
-		assert.Equal(t, []User{*user}, res)
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
 	})
 
-	t.Run("GetAllWithStatus success", func(t *testing.T) {
+	t.Run("HardDelete success", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
-		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
 
 		// Data
-		user := NewFakeUser(t).Build()
+		avatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		someSoftDeletedUser := NewFakeUser(t).WithStatus(Deleting).WithAvatar(avatar).Build()
 
 		// Mocks
-		storage.On("GetAll", ctx, &sqlstorage.PaginateCmd{Limit: 10}).Return([]User{*user}, nil).Once()
+		storage.On("GetByID", mock.Anything, someSoftDeletedUser.ID()).Return(someSoftDeletedUser, nil).Once()
+		storage.On("HardDelete", mock.Anything, someSoftDeletedUser.ID()).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, avatar.ID()).Return(avatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, avatar.ID()).Return(0, nil).Once()
+		mediasSvc.On("Delete", mock.Anything, avatar.ID()).Return(nil).Once()
 
 		// Run
-		res, err := services.GetAllWithStatus(ctx, Active, &sqlstorage.PaginateCmd{Limit: 10})
+		err := services.HardDelete(ctx, someSoftDeletedUser.ID())
 
 		// Asserts
 		require.NoError(t, err)
-		assert.Equal(t, []User{*user}, res)
 	})
 
-	// t.Run("AddToDeletion an admin user success", func(t *testing.T) {
-	// 	t.Parallel()
-	// 	tools := tools.NewMock(t)
-	// 	store := newMockStorage(t)
-	// 	services := newService(tools, store)
-	//
-	// 	// Data
-	// 	user := NewFakeUser(t).WithAdminRole().Build()
-	// 	anAnotherAdmin := NewFakeUser(t).WithAdminRole().Build()
-	//
-	// 	// Mocks
-	// 	store.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
-	// 	store.On("GetAll", ctx, (*sqlstorage.PaginateCmd)(nil)).
-	// 		Return([]User{*user, *anAnotherAdmin}, nil).Once() // We check that the deleted user is not the last admin.
-	// 	store.On("HardDelete", mock.Anything, user.ID()).Return(nil).Once()
-	//
-	// 	// Run
-	// 	err := services.AddToDeletion(ctx, user.ID())
-	//
-	// 	// Asserts
-	// 	require.NoError(t, err)
-	// })
-
-	t.Run("AddToDeletion with a user not found", func(t *testing.T) {
+	t.Run("GetGhost with an existing ghost", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
-		user := NewFakeUser(t).Build()
+		ghost := NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).Build()
 
 		// Mocks
-		storage.On("GetByID", ctx, user.ID()).Return(nil, errNotFound).Once()
+		storage.On("GetByUsername", ctx, GhostUsername).Return(ghost, nil).Once()
 
 		// Run
-		err := services.AddToDeletion(ctx, user.ID())
+		res, err := services.GetGhost(ctx)
 
 		// Asserts
-		require.ErrorIs(t, err, errs.ErrNotFound)
-		require.ErrorIs(t, err, errNotFound)
+		require.NoError(t, err)
+		assert.Equal(t, ghost, res)
 	})
 
-	// t.Run("AddToDeletion the last admin failed", func(t *testing.T) {
-	// 	t.Parallel()
-	// 	tools := tools.NewMock(t)
-	// 	store := newMockStorage(t)
-	// 	services := newService(tools, store)
-	//
-	// 	// Data
-	// 	user := NewFakeUser(t).WithAdminRole().Build()
-	// 	anAnotherUser := NewFakeUser(t).Build()
-	//
-	// 	// Mocks
-	// 	store.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
-	// 	store.On("GetAll", ctx, (*sqlstorage.PaginateCmd)(nil)).Return([]User{*user, *anAnotherUser}, nil).Once() // This is the last admin
-	//
-	// 	err := services.AddToDeletion(ctx, user.ID())
-	// 	require.EqualError(t, err, "unauthorized: can't remove the last admin")
-	// })
-	//
-	t.Run("HardDelete success", func(t *testing.T) {
+	t.Run("GetGhost creates the ghost", func(t *testing.T) {
 		t.Parallel()
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
-		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
 
 		// Data
-		someSoftDeletedUser := NewFakeUser(t).WithStatus(Deleting).Build()
+		avatar := medias.NewFakeFileMeta(t).Build()
+		ghost := NewFakeUser(t).
+			WithUsername(GhostUsername).
+			WithRole(ptr.To(perms.DefaultUserRole)).
+			WithStatus(Ghost).
+			WithAvatar(avatar).
+			Build()
+		ghost.createdBy = ghost.id
 
 		// Mocks
-		storage.On("GetByID", mock.Anything, someSoftDeletedUser.ID()).Return(someSoftDeletedUser, nil).Once()
-		storage.On("HardDelete", mock.Anything, someSoftDeletedUser.ID()).Return(nil).Once()
+		storage.On("GetByUsername", ctx, GhostUsername).Return(nil, errNotFound).Once()
+		tools.UUIDMock.On("New").Return(ghost.id).Once()
+		tools.UUIDMock.On("New").Return(uuid.UUID("some-random-password")).Once()
+		tools.PasswordMock.On("Encrypt", ctx, secret.NewText("some-random-password")).Return(ghost.password, nil).Once()
+		tools.ClockMock.On("Now").Return(ghost.createdAt).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+		storage.On("Save", ctx, mock.MatchedBy(func(u *User) bool {
+			return u.username == GhostUsername && u.id == ghost.id && u.status == Ghost
+		})).Return(nil).Once()
 
 		// Run
-		err := services.HardDelete(ctx, someSoftDeletedUser.ID())
+		res, err := services.GetGhost(ctx)
 
 		// Asserts
 		require.NoError(t, err)
+		assert.Equal(t, GhostUsername, res.Username())
+		assert.Equal(t, ghost.id, res.ID())
 	})
 
 	t.Run("HardDelete an non existing user", func(t *testing.T) {
@@ -355,7 +1652,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		someSoftDeletedUser := NewFakeUser(t).WithStatus(Deleting).Build()
@@ -375,7 +1675,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		someStillActifUser := NewFakeUser(t).WithStatus(Active).Build()
@@ -395,7 +1698,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		user := NewFakeUser(t).Build()
@@ -426,7 +1732,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		user := NewFakeUser(t).Build()
@@ -450,7 +1759,10 @@ func Test_Users_Service(t *testing.T) {
 		tools := tools.NewMock(t)
 		storage := newMockStorage(t)
 		medias := medias.NewMockService(t)
-		services := newService(tools, storage, medias)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, medias, roles, sessions, tasks)
 
 		// Data
 		user := NewFakeUser(t).Build()
@@ -479,4 +1791,329 @@ func Test_Users_Service(t *testing.T) {
 		require.ErrorIs(t, err, errs.ErrInternal)
 		require.ErrorContains(t, err, "some-error")
 	})
+	t.Run("UploadAvatar success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		oldAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(oldAvatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, oldAvatar.ID()).Return(oldAvatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, oldAvatar.ID()).Return(0, nil).Once()
+		mediasSvc.On("Delete", mock.Anything, oldAvatar.ID()).Return(nil).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 300, 200),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UploadAvatar with an invalid image", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: strings.NewReader("not an image"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrInvalidImage)
+	})
+
+	t.Run("UploadAvatar with a user not found", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(nil, errNotFound).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 10, 10),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("UploadAvatar with the same image", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		avatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(avatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		// The medias are deduplicated: the same content gives the same file.
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(avatar, nil).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 10, 10),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UploadAvatar keeps an avatar used by another user", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		oldAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(oldAvatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, oldAvatar.ID()).Return(oldAvatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, oldAvatar.ID()).Return(1, nil).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 10, 10),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UploadAvatar keeps a media which is not an avatar", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		oldAvatar := medias.NewFakeFileMeta(t).WithType(medias.Post).Build()
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(oldAvatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, oldAvatar.ID()).Return(oldAvatar, nil).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 10, 10),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("UploadAvatar with a Patch error", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := services.UploadAvatar(ctx, &UploadAvatarCmd{
+			UserID:  user.ID(),
+			Content: newTestPNG(t, 10, 10),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
+	t.Run("GenerateAvatar success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		oldAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(oldAvatar).Build()
+
+		// Mocks
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, oldAvatar.ID()).Return(oldAvatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, oldAvatar.ID()).Return(0, nil).Once()
+		mediasSvc.On("Delete", mock.Anything, oldAvatar.ID()).Return(nil).Once()
+
+		// Run
+		err := services.GenerateAvatar(ctx, &GenerateAvatarCmd{
+			UserID: user.ID(),
+			Style:  FemaleAvatar,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("GenerateAvatar with an invalid style", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		user := NewFakeUser(t).Build()
+
+		// Run
+		err := services.GenerateAvatar(ctx, &GenerateAvatarCmd{
+			UserID: user.ID(),
+			Style:  AvatarStyle("robot"),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("ResetAvatar success", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		moderator := NewFakeUser(t).Build()
+		oldAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		newAvatar := medias.NewFakeFileMeta(t).WithType(medias.Avatar).Build()
+		user := NewFakeUser(t).WithAvatar(oldAvatar).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", moderator, perms.Moderation).Return(true).Once()
+		storage.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasSvc.On("Upload", mock.Anything, medias.Avatar, mock.Anything).Return(newAvatar, nil).Once()
+		storage.On("Patch", mock.Anything, user.ID(), map[string]any{"avatar": newAvatar.ID()}).Return(nil).Once()
+		mediasSvc.On("GetMetadata", mock.Anything, oldAvatar.ID()).Return(oldAvatar, nil).Once()
+		storage.On("CountWithAvatar", mock.Anything, oldAvatar.ID()).Return(0, nil).Once()
+		mediasSvc.On("Delete", mock.Anything, oldAvatar.ID()).Return(nil).Once()
+
+		// Run
+		err := services.ResetAvatar(ctx, &ResetAvatarCmd{
+			Moderator: moderator,
+			UserID:    user.ID(),
+		})
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("ResetAvatar without the moderation permission", func(t *testing.T) {
+		t.Parallel()
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		mediasSvc := medias.NewMockService(t)
+		roles := perms.NewMockService(t)
+		sessions := NewMockSessionRevoker(t)
+		tasks := taskrunner.NewMockService(t)
+		services := newService(Config{}, tools, storage, mediasSvc, roles, sessions, tasks)
+
+		// Data
+		moderator := NewFakeUser(t).Build()
+		user := NewFakeUser(t).Build()
+
+		// Mocks
+		roles.On("IsAuthorized", moderator, perms.Moderation).Return(false).Once()
+
+		// Run
+		err := services.ResetAvatar(ctx, &ResetAvatarCmd{
+			Moderator: moderator,
+			UserID:    user.ID(),
+		})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
 }
@@ -5,9 +5,11 @@ package users
 import (
 	context "context"
 
-	sqlstorage "github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	perms "github.com/Peltoche/onlyfun/internal/services/perms"
 	mock "github.com/stretchr/testify/mock"
 
+	sqlstorage "github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+
 	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
@@ -16,6 +18,62 @@ type mockStorage struct {
 	mock.Mock
 }
 
+// CountWithAvatar provides a mock function with given fields: ctx, avatarID
+func (_m *mockStorage) CountWithAvatar(ctx context.Context, avatarID uuid.UUID) (int, error) {
+	ret := _m.Called(ctx, avatarID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountWithAvatar")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int, error)); ok {
+		return rf(ctx, avatarID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int); ok {
+		r0 = rf(ctx, avatarID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, avatarID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountWithRoles provides a mock function with given fields: ctx, roles, status
+func (_m *mockStorage) CountWithRoles(ctx context.Context, roles []perms.Role, status Status) (int, error) {
+	ret := _m.Called(ctx, roles, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountWithRoles")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []perms.Role, Status) (int, error)); ok {
+		return rf(ctx, roles, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []perms.Role, Status) int); ok {
+		r0 = rf(ctx, roles, status)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []perms.Role, Status) error); ok {
+		r1 = rf(ctx, roles, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAll provides a mock function with given fields: ctx, cmd
 func (_m *mockStorage) GetAll(ctx context.Context, cmd *sqlstorage.PaginateCmd) ([]User, error) {
 	ret := _m.Called(ctx, cmd)
@@ -46,6 +104,36 @@ func (_m *mockStorage) GetAll(ctx context.Context, cmd *sqlstorage.PaginateCmd)
 	return r0, r1
 }
 
+// GetByEmail provides a mock function with given fields: ctx, email
+func (_m *mockStorage) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByEmail")
+	}
+
+	var r0 *User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: ctx, userID
 func (_m *mockStorage) GetByID(ctx context.Context, userID uuid.UUID) (*User, error) {
 	ret := _m.Called(ctx, userID)
@@ -125,7 +213,7 @@ func (_m *mockStorage) HardDelete(ctx context.Context, userID uuid.UUID) error {
 }
 
 // Patch provides a mock function with given fields: ctx, userID, fields
-func (_m *mockStorage) Patch(ctx context.Context, userID uuid.UUID, fields map[string]interface{}) error {
+func (_m *mockStorage) Patch(ctx context.Context, userID uuid.UUID, fields map[string]any) error {
 	ret := _m.Called(ctx, userID, fields)
 
 	if len(ret) == 0 {
@@ -133,7 +221,7 @@ func (_m *mockStorage) Patch(ctx context.Context, userID uuid.UUID, fields map[s
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, map[string]interface{}) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, map[string]any) error); ok {
 		r0 = rf(ctx, userID, fields)
 	} else {
 		r0 = ret.Error(0)
@@ -160,6 +248,36 @@ func (_m *mockStorage) Save(ctx context.Context, user *User) error {
 	return r0
 }
 
+// Search provides a mock function with given fields: ctx, query, cmd
+func (_m *mockStorage) Search(ctx context.Context, query string, cmd *sqlstorage.PaginateCmd) ([]User, error) {
+	ret := _m.Called(ctx, query, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *sqlstorage.PaginateCmd) ([]User, error)); ok {
+		return rf(ctx, query, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *sqlstorage.PaginateCmd) []User); ok {
+		r0 = rf(ctx, query, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *sqlstorage.PaginateCmd) error); ok {
+		r1 = rf(ctx, query, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // newMockStorage creates a new instance of mockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockStorage(t interface {
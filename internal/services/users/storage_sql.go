@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/tools/ptr"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
@@ -16,7 +18,10 @@ const tableName = "users"
 
 var errNotFound = errors.New("not found")
 
-var allFields = []string{"id", "username", "role", "status", "avatar", "password", "password_changed_at", "created_at", "created_by"}
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var allFields = []string{"id", "username", "email", "role", "status", "avatar", "password", "password_changed_at", "created_at", "created_by"}
 
 // sqlStorage use to save/retrieve Users
 type sqlStorage struct {
@@ -35,6 +40,7 @@ func (s *sqlStorage) Save(ctx context.Context, u *User) error {
 		Columns(allFields...).
 		Values(u.id,
 			u.username,
+			sqlEmail(u.email),
 			u.role,
 			u.status,
 			u.avatar,
@@ -54,7 +60,23 @@ func (s *sqlStorage) Save(ctx context.Context, u *User) error {
 func (s *sqlStorage) GetAll(ctx context.Context, cmd *sqlstorage.PaginateCmd) ([]User, error) {
 	rows, err := sqlstorage.PaginateSelection(sq.
 		Select(allFields...).
-		From(tableName), cmd).
+		From(tableName).
+		Where(sq.NotEq{"status": Ghost}), cmd).
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+
+	return s.scanRows(rows)
+}
+
+func (s *sqlStorage) Search(ctx context.Context, query string, cmd *sqlstorage.PaginateCmd) ([]User, error) {
+	rows, err := sqlstorage.PaginateSelection(sq.
+		Select(allFields...).
+		From(tableName).
+		Where(sq.Expr(`username LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(query)+"%")).
+		Where(sq.NotEq{"status": Ghost}), cmd).
 		RunWith(s.db).
 		QueryContext(ctx)
 	if err != nil {
@@ -72,6 +94,10 @@ func (s *sqlStorage) GetByUsername(ctx context.Context, username string) (*User,
 	return s.getByKeys(ctx, sq.Eq{"username": username})
 }
 
+func (s *sqlStorage) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return s.getByKeys(ctx, sq.Eq{"email": email})
+}
+
 func (s *sqlStorage) Patch(ctx context.Context, userID uuid.UUID, fields map[string]any) error {
 	_, err := sq.Update(tableName).
 		SetMap(fields).
@@ -98,6 +124,36 @@ func (s *sqlStorage) HardDelete(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
+func (s *sqlStorage) CountWithRoles(ctx context.Context, roles []perms.Role, status Status) (int, error) {
+	var res int
+	err := sq.
+		Select("count(*)").
+		From(tableName).
+		Where(sq.Eq{"role": roles, "status": status}).
+		RunWith(s.db).
+		ScanContext(ctx, &res)
+	if err != nil {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *sqlStorage) CountWithAvatar(ctx context.Context, avatarID uuid.UUID) (int, error) {
+	var res int
+	err := sq.
+		Select("count(*)").
+		From(tableName).
+		Where(sq.Eq{"avatar": avatarID}).
+		RunWith(s.db).
+		ScanContext(ctx, &res)
+	if err != nil {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	return res, nil
+}
+
 func (s *sqlStorage) getByKeys(ctx context.Context, wheres ...any) (*User, error) {
 	res := User{}
 
@@ -111,11 +167,13 @@ func (s *sqlStorage) getByKeys(ctx context.Context, wheres ...any) (*User, error
 
 	var sqlCreatedAt sqlstorage.SQLTime
 	var sqlPasswordChangedAt sqlstorage.SQLTime
+	var sqlEmail sql.NullString
 	err := query.
 		RunWith(s.db).
 		ScanContext(ctx,
 			&res.id,
 			&res.username,
+			&sqlEmail,
 			&res.role,
 			&res.status,
 			&res.avatar,
@@ -127,6 +185,7 @@ func (s *sqlStorage) getByKeys(ctx context.Context, wheres ...any) (*User, error
 		return nil, errNotFound
 	}
 
+	res.email = sqlEmail.String
 	res.passwordChangedAt = sqlPasswordChangedAt.Time()
 	res.createdAt = sqlCreatedAt.Time()
 
@@ -144,9 +203,11 @@ func (s *sqlStorage) scanRows(rows *sql.Rows) ([]User, error) {
 		var res User
 		var sqlCreatedAt sqlstorage.SQLTime
 		var sqlPasswordChangedAt sqlstorage.SQLTime
+		var sqlEmail sql.NullString
 
 		err := rows.Scan(&res.id,
 			&res.username,
+			&sqlEmail,
 			&res.role,
 			&res.status,
 			&res.avatar,
@@ -158,6 +219,7 @@ func (s *sqlStorage) scanRows(rows *sql.Rows) ([]User, error) {
 			return nil, fmt.Errorf("failed to scan a row: %w", err)
 		}
 
+		res.email = sqlEmail.String
 		res.passwordChangedAt = sqlPasswordChangedAt.Time()
 		res.createdAt = sqlCreatedAt.Time()
 
@@ -170,3 +232,13 @@ func (s *sqlStorage) scanRows(rows *sql.Rows) ([]User, error) {
 
 	return users, nil
 }
+
+// sqlEmail saves the missing e-mails as NULL in order to keep them out of
+// the unique index.
+func sqlEmail(email string) *string {
+	if email == "" {
+		return nil
+	}
+
+	return &email
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -105,6 +106,56 @@ func Test_Users_SqlStorage(t *testing.T) {
 		assert.Equal(t, user, res)
 	})
 
+	t.Run("GetByEmail success", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		user := NewFakeUser(t).WithRole(role).WithAvatar(avatar).WithEmail("jane@example.com").BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetByEmail(ctx, "jane@example.com")
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("GetByEmail not found", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		// Run
+		res, err := store.GetByEmail(ctx, "jane@example.com")
+
+		// Asserts
+		assert.Nil(t, res)
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("Save several users without email", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetAll(ctx, &sqlstorage.PaginateCmd{Limit: 10})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Empty(t, res[0].Email())
+	})
+
 	t.Run("GetByUsername not found", func(t *testing.T) {
 		t.Parallel()
 		db := sqlstorage.NewTestStorage(t)
@@ -133,6 +184,75 @@ func Test_Users_SqlStorage(t *testing.T) {
 		assert.Equal(t, []User{*user}, res)
 	})
 
+	t.Run("Search success", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		john := NewFakeUser(t).WithUsername("john").WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		johnny := NewFakeUser(t).WithUsername("johnny").WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithUsername("jane").WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.Search(ctx, "ohn", &sqlstorage.PaginateCmd{
+			StartAfter: map[string]string{"username": ""},
+			Limit:      10,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []User{*john, *johnny}, res)
+
+		// Run the next page
+		res, err = store.Search(ctx, "ohn", &sqlstorage.PaginateCmd{
+			StartAfter: map[string]string{"username": "john"},
+			Limit:      10,
+		})
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, []User{*johnny}, res)
+	})
+
+	t.Run("GetAll and Search skip the ghost", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := NewFakeUser(t).WithUsername("john").WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithUsername(GhostUsername).WithStatus(Ghost).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		// Run
+		all, err := store.GetAll(ctx, &sqlstorage.PaginateCmd{Limit: 10})
+		require.NoError(t, err)
+
+		found, err := store.Search(ctx, "", nil)
+		require.NoError(t, err)
+
+		// Asserts
+		assert.Equal(t, []User{*user}, all)
+		assert.Equal(t, []User{*user}, found)
+	})
+
+	t.Run("Search escapes the wildcards", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		NewFakeUser(t).WithUsername("john").BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.Search(ctx, "%", nil)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Empty(t, res)
+	})
+
 	t.Run("HardDelete success", func(t *testing.T) {
 		t.Parallel()
 		db := sqlstorage.NewTestStorage(t)
@@ -149,4 +269,42 @@ func Test_Users_SqlStorage(t *testing.T) {
 		require.NoError(t, err)
 		assert.Empty(t, res)
 	})
+
+	t.Run("CountWithRoles success", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).WithStatus(Active).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).WithStatus(Deleting).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.CountWithRoles(ctx, []perms.Role{*role, "some-other-role"}, Active)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, 1, res)
+	})
+
+	t.Run("CountWithAvatar success", func(t *testing.T) {
+		t.Parallel()
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.CountWithAvatar(ctx, avatar.ID())
+		require.NoError(t, err)
+		assert.Equal(t, 2, res)
+
+		res, err = store.CountWithAvatar(ctx, uuid.UUID("f4d3b7a6-6c2e-4d5b-9d0e-8d1f3a2b1c0d"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, res)
+	})
 }
@@ -0,0 +1,30 @@
+package votes
+
+import (
+	"context"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+)
+
+type Service interface {
+	Vote(ctx context.Context, cmd *VoteCmd) (*Vote, error)
+	Unvote(ctx context.Context, cmd *UnvoteCmd) error
+	GetUserVotes(ctx context.Context, user *users.User, postIDs []uint) (map[uint]Value, error)
+	DeleteAll(ctx context.Context, user *users.User) error
+}
+
+func Init(
+	tools tools.Tools,
+	db sqlstorage.Querier,
+	transactor sqlstorage.Transactor,
+	postsSvc posts.Service,
+	permsSvc perms.Service,
+) Service {
+	storage := newSqlStorage(db)
+
+	return newService(tools, storage, transactor, postsSvc, permsSvc)
+}
@@ -0,0 +1,71 @@
+package votes
+
+import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	v "github.com/go-ozzo/ozzo-validation"
+)
+
+type Value int
+
+const (
+	Up   Value = 1
+	Down Value = -1
+)
+
+func (v Value) IsUp() bool   { return v == Up }
+func (v Value) IsDown() bool { return v == Down }
+
+// counters returns the impact of the vote value on the post
+// upvotes and downvotes counters.
+func (v Value) counters() (int, int) {
+	switch v {
+	case Up:
+		return 1, 0
+	case Down:
+		return 0, 1
+	default:
+		return 0, 0
+	}
+}
+
+type Vote struct {
+	votedAt time.Time
+	userID  uuid.UUID
+	postID  uint
+	value   Value
+}
+
+func (v Vote) PostID() uint       { return v.postID }
+func (v Vote) UserID() uuid.UUID  { return v.userID }
+func (v Vote) Value() Value       { return v.value }
+func (v Vote) VotedAt() time.Time { return v.votedAt }
+
+type VoteCmd struct {
+	User  *users.User
+	Post  *posts.Post
+	Value Value
+}
+
+func (t VoteCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Post, v.Required),
+		v.Field(&t.Value, v.Required, v.In(Up, Down)),
+	)
+}
+
+type UnvoteCmd struct {
+	User *users.User
+	Post *posts.Post
+}
+
+func (t UnvoteCmd) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.User, v.Required),
+		v.Field(&t.Post, v.Required),
+	)
+}
@@ -0,0 +1,77 @@
+package votes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+)
+
+type FakeVoteBuilder struct {
+	t    testing.TB
+	vote *Vote
+}
+
+func NewFakeVote(t testing.TB) *FakeVoteBuilder {
+	t.Helper()
+
+	uuidProvider := uuid.NewProvider()
+	votedAt := gofakeit.DateRange(time.Now().Add(-time.Hour*1000), time.Now())
+
+	return &FakeVoteBuilder{
+		t: t,
+		vote: &Vote{
+			postID:  gofakeit.Uint(),
+			userID:  uuidProvider.New(),
+			value:   Up,
+			votedAt: votedAt,
+		},
+	}
+}
+
+func (f *FakeVoteBuilder) WithPost(post *posts.Post) *FakeVoteBuilder {
+	f.vote.postID = post.ID()
+
+	return f
+}
+
+func (f *FakeVoteBuilder) WithValue(value Value) *FakeVoteBuilder {
+	f.vote.value = value
+
+	return f
+}
+
+func (f *FakeVoteBuilder) VotedAt(at time.Time) *FakeVoteBuilder {
+	f.vote.votedAt = at
+
+	return f
+}
+
+func (f *FakeVoteBuilder) CreatedBy(user *users.User) *FakeVoteBuilder {
+	f.vote.userID = user.ID()
+
+	return f
+}
+
+func (f *FakeVoteBuilder) Build() *Vote {
+	return f.vote
+}
+
+func (f *FakeVoteBuilder) BuildAndStore(ctx context.Context, db sqlstorage.Querier) *Vote {
+	f.t.Helper()
+
+	storage := newSqlStorage(db)
+
+	vote := f.Build()
+
+	_, err := storage.Upsert(ctx, vote)
+	require.NoError(f.t, err)
+
+	return vote
+}
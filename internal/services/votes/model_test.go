@@ -0,0 +1,66 @@
+package votes
+
+import (
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Vote_Getters(t *testing.T) {
+	vote := NewFakeVote(t).Build()
+
+	assert.Equal(t, vote.postID, vote.PostID())
+	assert.Equal(t, vote.userID, vote.UserID())
+	assert.Equal(t, vote.value, vote.Value())
+	assert.Equal(t, vote.votedAt, vote.VotedAt())
+}
+
+func Test_Value(t *testing.T) {
+	assert.True(t, Up.IsUp())
+	assert.False(t, Up.IsDown())
+	assert.True(t, Down.IsDown())
+	assert.False(t, Down.IsUp())
+	assert.False(t, Value(0).IsUp())
+	assert.False(t, Value(0).IsDown())
+}
+
+func Test_VoteCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(VoteCmd))
+}
+
+func Test_VoteCmd_Validate_success(t *testing.T) {
+	err := VoteCmd{
+		User:  users.NewFakeUser(t).Build(),
+		Post:  posts.NewFakePost(t).Build(),
+		Value: Down,
+	}.Validate()
+
+	require.NoError(t, err)
+}
+
+func Test_VoteCmd_Validate_with_an_invalid_value(t *testing.T) {
+	err := VoteCmd{
+		User:  users.NewFakeUser(t).Build(),
+		Post:  posts.NewFakePost(t).Build(),
+		Value: 0,
+	}.Validate()
+
+	require.EqualError(t, err, "Value: cannot be blank.")
+}
+
+func Test_UnvoteCmd_is_validatable(t *testing.T) {
+	assert.Implements(t, (*validation.Validatable)(nil), new(UnvoteCmd))
+}
+
+func Test_UnvoteCmd_Validate_success(t *testing.T) {
+	err := UnvoteCmd{
+		User: users.NewFakeUser(t).Build(),
+		Post: posts.NewFakePost(t).Build(),
+	}.Validate()
+
+	require.NoError(t, err)
+}
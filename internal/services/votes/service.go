@@ -0,0 +1,181 @@
+package votes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+var ErrPostNotListed = errors.New("post not listed")
+
+type storage interface {
+	Upsert(ctx context.Context, vote *Vote) (Value, error)
+	Delete(ctx context.Context, userID uuid.UUID, postID uint) (Value, error)
+	GetUserVotesForPosts(ctx context.Context, userID uuid.UUID, postIDs []uint) ([]Vote, error)
+	GetAllForUser(ctx context.Context, userID uuid.UUID) ([]Vote, error)
+}
+
+type service struct {
+	storage    storage
+	transactor sqlstorage.Transactor
+	postsSvc   posts.Service
+	permsSvc   perms.Service
+	clock      clock.Clock
+}
+
+func newService(tools tools.Tools, storage storage, transactor sqlstorage.Transactor, postsSvc posts.Service, permsSvc perms.Service) *service {
+	return &service{
+		storage:    storage,
+		transactor: transactor,
+		postsSvc:   postsSvc,
+		permsSvc:   permsSvc,
+		clock:      tools.Clock(),
+	}
+}
+
+// Vote registers the user vote for the given post.
+//
+// A user have only one vote per post, voting again replaces the previous vote.
+func (s *service) Vote(ctx context.Context, cmd *VoteCmd) (*Vote, error) {
+	err := cmd.Validate()
+	if err != nil {
+		return nil, errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.VotePost) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.VotePost))
+	}
+
+	if cmd.Post.Status() != posts.Listed {
+		return nil, errs.BadRequest(ErrPostNotListed, "post not listed")
+	}
+
+	vote := Vote{
+		postID:  cmd.Post.ID(),
+		userID:  cmd.User.ID(),
+		value:   cmd.Value,
+		votedAt: s.clock.Now(),
+	}
+
+	// The vote and the post counters are updated together, the counters are
+	// derived from the value replaced by the vote.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.storage.Upsert(ctx, &vote)
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to Upsert the vote: %w", err))
+		}
+
+		if previous == vote.value {
+			return nil
+		}
+
+		upvotes, downvotes := vote.value.counters()
+		oldUpvotes, oldDownvotes := previous.counters()
+
+		err = s.postsSvc.AddVotes(ctx, cmd.Post, upvotes-oldUpvotes, downvotes-oldDownvotes)
+		if err != nil {
+			return fmt.Errorf("failed to update the post counters: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &vote, nil
+}
+
+// Unvote removes the user vote for the given post. Nothing happens if the
+// user haven't voted yet.
+func (s *service) Unvote(ctx context.Context, cmd *UnvoteCmd) error {
+	err := cmd.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	if !s.permsSvc.IsAuthorized(cmd.User, perms.VotePost) {
+		return errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", cmd.User.ID(), perms.VotePost))
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, cmd.User, cmd.Post)
+	})
+}
+
+// GetUserVotes returns the user votes for each of the given posts. The
+// posts without any vote are absent from the result.
+func (s *service) GetUserVotes(ctx context.Context, user *users.User, postIDs []uint) (map[uint]Value, error) {
+	res := make(map[uint]Value, len(postIDs))
+
+	if len(postIDs) == 0 {
+		return res, nil
+	}
+
+	votes, err := s.storage.GetUserVotesForPosts(ctx, user.ID(), postIDs)
+	if err != nil {
+		return nil, errs.Internal(fmt.Errorf("failed to GetUserVotesForPosts: %w", err))
+	}
+
+	for _, vote := range votes {
+		res[vote.postID] = vote.value
+	}
+
+	return res, nil
+}
+
+// DeleteAll removes all the votes of the user and withdraws them from the
+// posts counters.
+func (s *service) DeleteAll(ctx context.Context, user *users.User) error {
+	votes, err := s.storage.GetAllForUser(ctx, user.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to GetAllForUser: %w", err))
+	}
+
+	for _, vote := range votes {
+		post, err := s.postsSvc.GetByID(ctx, vote.postID)
+		if err != nil {
+			return fmt.Errorf("failed to get the post %d: %w", vote.postID, err)
+		}
+
+		err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			return s.delete(ctx, user, post)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// delete removes the user vote for the post and withdraws it from the post
+// counters. It must run within a transaction.
+func (s *service) delete(ctx context.Context, user *users.User, post *posts.Post) error {
+	value, err := s.storage.Delete(ctx, user.ID(), post.ID())
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to Delete the vote: %w", err))
+	}
+
+	if value == 0 {
+		return nil
+	}
+
+	upvotes, downvotes := value.counters()
+
+	err = s.postsSvc.AddVotes(ctx, post, -upvotes, -downvotes)
+	if err != nil {
+		return fmt.Errorf("failed to update the post counters: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,404 @@
+package votes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Votes_Service(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Vote success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).VotedAt(now).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Upsert", ctx, vote).Return(Value(0), nil).Once()
+		postsSvc.On("AddVotes", ctx, post, 1, 0).Return(nil).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.NoError(t, err)
+		require.Equal(t, vote, res)
+	})
+
+	t.Run("Vote with a validation error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  nil,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrValidation)
+		require.Nil(t, res)
+	})
+
+	t.Run("Vote without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(false).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+		require.Nil(t, res)
+	})
+
+	t.Run("Vote with a post not listed", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Uploaded).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrPostNotListed)
+		require.Nil(t, res)
+	})
+
+	t.Run("Vote with the same value twice", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		now := time.Now()
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).VotedAt(now).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Upsert", ctx, vote).Return(Up, nil).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.NoError(t, err)
+		require.Equal(t, vote, res)
+	})
+
+	t.Run("Vote replacing a previous vote", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		newVote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Down).VotedAt(now).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Upsert", ctx, newVote).Return(Up, nil).Once()
+		postsSvc.On("AddVotes", ctx, post, -1, 1).Return(nil).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Down,
+		})
+		require.NoError(t, err)
+		require.Equal(t, newVote, res)
+	})
+
+	t.Run("Vote with an Upsert error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).VotedAt(now).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Upsert", ctx, vote).Return(Value(0), fmt.Errorf("some-error")).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("Vote with an AddVotes error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		now := time.Now()
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).VotedAt(now).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		storage.On("Upsert", ctx, vote).Return(Value(0), nil).Once()
+		postsSvc.On("AddVotes", ctx, post, 1, 0).Return(errs.Internal(fmt.Errorf("some-error"))).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+
+	t.Run("Vote with an AddVotes error rollbacks the vote", func(t *testing.T) {
+		db, transactor := sqlstorage.NewTestStorageWithTransactor(t)
+		tools := tools.NewMock(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, newSqlStorage(db), transactor, postsSvc, permsSvc)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).CreatedBy(user).BuildAndStore(ctx, db)
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+		postsSvc.On("AddVotes", mock.Anything, post, 1, 0).Return(errs.Internal(fmt.Errorf("some-error"))).Once()
+
+		res, err := svc.Vote(ctx, &VoteCmd{
+			User:  user,
+			Post:  post,
+			Value: Up,
+		})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.Nil(t, res)
+
+		votes, err := newSqlStorage(db).GetAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+		require.Empty(t, votes)
+	})
+
+	t.Run("Unvote success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		storage.On("Delete", ctx, user.ID(), post.ID()).Return(Down, nil).Once()
+		postsSvc.On("AddVotes", ctx, post, 0, -1).Return(nil).Once()
+
+		err := svc.Unvote(ctx, &UnvoteCmd{User: user, Post: post})
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteAll success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post1 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		post2 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		vote1 := NewFakeVote(t).WithPost(post1).CreatedBy(user).WithValue(Up).Build()
+		vote2 := NewFakeVote(t).WithPost(post2).CreatedBy(user).WithValue(Down).Build()
+
+		storage.On("GetAllForUser", ctx, user.ID()).Return([]Vote{*vote1, *vote2}, nil).Once()
+		postsSvc.On("GetByID", ctx, post1.ID()).Return(post1, nil).Once()
+		storage.On("Delete", ctx, user.ID(), post1.ID()).Return(Up, nil).Once()
+		postsSvc.On("AddVotes", ctx, post1, -1, 0).Return(nil).Once()
+		postsSvc.On("GetByID", ctx, post2.ID()).Return(post2, nil).Once()
+		storage.On("Delete", ctx, user.ID(), post2.ID()).Return(Down, nil).Once()
+		postsSvc.On("AddVotes", ctx, post2, 0, -1).Return(nil).Once()
+
+		err := svc.DeleteAll(ctx, user)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteAll with a GetAllForUser error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		storage.On("GetAllForUser", ctx, user.ID()).Return(nil, assert.AnError).Once()
+
+		err := svc.DeleteAll(ctx, user)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Unvote without any vote", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		storage.On("Delete", ctx, user.ID(), post.ID()).Return(Value(0), nil).Once()
+
+		err := svc.Unvote(ctx, &UnvoteCmd{User: user, Post: post})
+		require.NoError(t, err)
+	})
+
+	t.Run("Unvote without the permission", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(false).Once()
+
+		err := svc.Unvote(ctx, &UnvoteCmd{User: user, Post: post})
+		require.ErrorIs(t, err, errs.ErrUnauthorized)
+	})
+
+	t.Run("Unvote with a Delete error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		permsSvc.On("IsAuthorized", user, perms.VotePost).Return(true).Once()
+		storage.On("Delete", ctx, user.ID(), post.ID()).Return(Value(0), fmt.Errorf("some-error")).Once()
+
+		err := svc.Unvote(ctx, &UnvoteCmd{User: user, Post: post})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
+	t.Run("GetUserVotes success", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+		post1 := posts.NewFakePost(t).Build()
+		post2 := posts.NewFakePost(t).Build()
+		vote := NewFakeVote(t).WithPost(post1).CreatedBy(user).WithValue(Down).Build()
+
+		storage.On("GetUserVotesForPosts", ctx, user.ID(), []uint{post1.ID(), post2.ID()}).Return([]Vote{*vote}, nil).Once()
+
+		res, err := svc.GetUserVotes(ctx, user, []uint{post1.ID(), post2.ID()})
+		require.NoError(t, err)
+		require.Equal(t, map[uint]Value{post1.ID(): Down}, res)
+	})
+
+	t.Run("GetUserVotes without any post", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		res, err := svc.GetUserVotes(ctx, user, []uint{})
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+
+	t.Run("GetUserVotes with a storage error", func(t *testing.T) {
+		tools := tools.NewMock(t)
+		storage := newMockStorage(t)
+		postsSvc := posts.NewMockService(t)
+		permsSvc := perms.NewMockService(t)
+		svc := newService(tools, storage, sqlstorage.FakeTransactor{}, postsSvc, permsSvc)
+
+		user := users.NewFakeUser(t).Build()
+
+		storage.On("GetUserVotesForPosts", ctx, user.ID(), []uint{42}).Return(nil, fmt.Errorf("some-error")).Once()
+
+		res, err := svc.GetUserVotes(ctx, user, []uint{42})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+		require.Nil(t, res)
+	})
+}
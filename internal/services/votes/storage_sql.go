@@ -0,0 +1,134 @@
+package votes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+const tableName = "votes"
+
+var allFields = []string{"post_id", "user_id", "value", "voted_at"}
+
+type sqlStorage struct {
+	db sqlstorage.Querier
+}
+
+func newSqlStorage(db sqlstorage.Querier) *sqlStorage {
+	return &sqlStorage{db}
+}
+
+// Upsert saves the vote, replacing the previous vote of the user for the
+// post, and returns the previous value. Zero is returned if the user haven't
+// voted yet.
+//
+// It must run within a transaction: the transactions take the write lock from
+// their start so the previous value can't change before the upsert.
+func (s *sqlStorage) Upsert(ctx context.Context, vote *Vote) (Value, error) {
+	var previous Value
+
+	err := sq.
+		Select("value").
+		From(tableName).
+		Where(sq.Eq{"post_id": vote.postID, "user_id": vote.userID}).
+		RunWith(s.db).
+		ScanContext(ctx, &previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	_, err = sq.
+		Insert(tableName).
+		Columns(allFields...).
+		Values(
+			vote.postID,
+			vote.userID,
+			vote.value,
+			ptr.To(sqlstorage.SQLTime(vote.votedAt))).
+		Suffix(`ON CONFLICT(post_id, user_id) DO UPDATE SET
+			value = excluded.value,
+			voted_at = excluded.voted_at
+			WHERE value != excluded.value`).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	return previous, nil
+}
+
+// Delete removes the vote and returns its value. Zero is returned if the
+// user haven't voted.
+func (s *sqlStorage) Delete(ctx context.Context, userID uuid.UUID, postID uint) (Value, error) {
+	var value Value
+
+	err := sq.
+		Delete(tableName).
+		Where(sq.Eq{"post_id": postID, "user_id": userID}).
+		Suffix("RETURNING value").
+		RunWith(s.db).
+		ScanContext(ctx, &value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("sql error: %w", err)
+	}
+
+	return value, nil
+}
+
+func (s *sqlStorage) GetUserVotesForPosts(ctx context.Context, userID uuid.UUID, postIDs []uint) ([]Vote, error) {
+	return s.getAll(ctx, sq.Eq{"user_id": userID, "post_id": postIDs})
+}
+
+func (s *sqlStorage) GetAllForUser(ctx context.Context, userID uuid.UUID) ([]Vote, error) {
+	return s.getAll(ctx, sq.Eq{"user_id": userID})
+}
+
+func (s *sqlStorage) getAll(ctx context.Context, where any) ([]Vote, error) {
+	rows, err := sq.
+		Select(allFields...).
+		From(tableName).
+		Where(where).
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []Vote{}
+
+	for rows.Next() {
+		var res Vote
+		var sqlVotedAt sqlstorage.SQLTime
+
+		err := rows.Scan(
+			&res.postID,
+			&res.userID,
+			&res.value,
+			&sqlVotedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan a row: %w", err)
+		}
+
+		res.votedAt = sqlVotedAt.Time()
+
+		votes = append(votes, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return votes, nil
+}
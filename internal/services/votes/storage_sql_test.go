@@ -0,0 +1,180 @@
+package votes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoteSqlStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Upsert a new vote", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).Build()
+
+		// Run
+		previous, err := store.Upsert(ctx, vote)
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, Value(0), previous)
+
+		res, err := store.GetAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, []Vote{*vote}, res)
+	})
+
+	t.Run("Upsert replacing a previous vote", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).BuildAndStore(ctx, db)
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Down).Build()
+
+		// Run
+		previous, err := store.Upsert(ctx, vote)
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, Up, previous)
+
+		res, err := store.GetAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, []Vote{*vote}, res)
+	})
+
+	t.Run("Upsert with the same value keeps the previous vote", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).BuildAndStore(ctx, db)
+
+		// Run
+		previous, err := store.Upsert(ctx, NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).Build())
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, Up, previous)
+
+		res, err := store.GetAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+		require.Equal(t, []Vote{*vote}, res)
+	})
+
+	t.Run("Delete success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Down).BuildAndStore(ctx, db)
+
+		// Run
+		value, err := store.Delete(ctx, user.ID(), post.ID())
+		require.NoError(t, err)
+		require.Equal(t, Down, value)
+
+		// Asserts
+		res, err := store.GetAllForUser(ctx, user.ID())
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+
+	t.Run("Delete without any vote", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		user := users.NewFakeUser(t).Build()
+
+		// Run
+		value, err := store.Delete(ctx, user.ID(), 42)
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, Value(0), value)
+	})
+
+	t.Run("GetUserVotesForPosts success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		otherUser := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post1 := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		post2 := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+		post3 := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		vote1 := NewFakeVote(t).WithPost(post1).CreatedBy(user).WithValue(Up).BuildAndStore(ctx, db)
+		NewFakeVote(t).WithPost(post2).CreatedBy(otherUser).WithValue(Up).BuildAndStore(ctx, db)
+		NewFakeVote(t).WithPost(post3).CreatedBy(user).WithValue(Down).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetUserVotesForPosts(ctx, user.ID(), []uint{post1.ID(), post2.ID()})
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, []Vote{*vote1}, res)
+	})
+
+	t.Run("GetAllForUser success", func(t *testing.T) {
+		t.Parallel()
+
+		db := sqlstorage.NewTestStorage(t)
+		store := newSqlStorage(db)
+
+		role, _ := perms.NewFakePermissions(t).BuildAndStore(ctx, db)
+		avatar := medias.NewFakeFileMeta(t).BuildAndStore(ctx, db)
+		user := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		otherUser := users.NewFakeUser(t).WithRole(role).WithAvatar(avatar).BuildAndStore(ctx, db)
+		post := posts.NewFakePost(t).CreatedBy(user).BuildAndStore(ctx, db)
+
+		vote := NewFakeVote(t).WithPost(post).CreatedBy(user).WithValue(Up).BuildAndStore(ctx, db)
+		NewFakeVote(t).WithPost(post).CreatedBy(otherUser).WithValue(Down).BuildAndStore(ctx, db)
+
+		// Run
+		res, err := store.GetAllForUser(ctx, user.ID())
+
+		// Asserts
+		require.NoError(t, err)
+		require.Equal(t, []Vote{*vote}, res)
+	})
+}
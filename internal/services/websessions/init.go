@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
@@ -11,23 +12,47 @@ import (
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
+const (
+	DefaultLifetime      = 30 * 24 * time.Hour
+	DefaultIdleTimeout   = 7 * 24 * time.Hour
+	DefaultPurgeInterval = time.Hour
+)
+
 var (
 	ErrMissingSessionToken = errors.New("missing session token")
 	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionExpired      = errors.New("session expired")
 )
 
+// Config of the web sessions. The zero values are replaced by the
+// default ones.
+type Config struct {
+	// Lifetime is the maximum duration of a session, whatever its activity.
+	Lifetime time.Duration
+	// IdleTimeout is the duration after which an unused session expires.
+	IdleTimeout time.Duration
+	// PurgeInterval is the delay between two removals of the expired sessions.
+	PurgeInterval time.Duration
+}
+
 type Service interface {
 	Create(ctx context.Context, cmd *CreateCmd) (*Session, error)
 	GetByToken(ctx context.Context, token secret.Text) (*Session, error)
 	GetFromReq(r *http.Request) (*Session, error)
+	ExpiresAt(session *Session) time.Time
+	Refresh(w http.ResponseWriter, r *http.Request, session *Session) error
+	SetCookie(w http.ResponseWriter, session *Session)
 	Logout(r *http.Request, w http.ResponseWriter) error
 	GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *sqlstorage.PaginateCmd) ([]Session, error)
 	Delete(ctx context.Context, cmd *DeleteCmd) error
+	DeleteByID(ctx context.Context, userID uuid.UUID, sessionID string) error
+	DeleteOthers(ctx context.Context, current *Session) error
 	DeleteAll(ctx context.Context, userID uuid.UUID) error
+	PurgeExpired(ctx context.Context) error
 }
 
-func Init(tools tools.Tools, db sqlstorage.Querier) Service {
+func Init(cfg Config, tools tools.Tools, db sqlstorage.Querier) Service {
 	storage := newSQLStorage(db)
 
-	return newService(storage, tools)
+	return newService(cfg, storage, tools)
 }
@@ -1,6 +1,8 @@
 package websessions
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
@@ -10,11 +12,21 @@ import (
 )
 
 type Session struct {
-	createdAt time.Time
-	token     secret.Text
-	userID    uuid.UUID
-	ip        string
-	device    string
+	createdAt  time.Time
+	lastSeenAt time.Time
+	token      secret.Text
+	userID     uuid.UUID
+	ip         string
+	device     string
+	remember   bool
+}
+
+// ID identifies the session without exposing its token. Contrary to the
+// token, it can be displayed and used inside the urls.
+func (s *Session) ID() string {
+	sum := sha256.Sum256([]byte(s.token.Raw()))
+
+	return hex.EncodeToString(sum[:12])
 }
 
 func (s *Session) Token() secret.Text   { return s.token }
@@ -23,10 +35,18 @@ func (s *Session) IP() string           { return s.ip }
 func (s *Session) Device() string       { return s.device }
 func (s *Session) CreatedAt() time.Time { return s.createdAt }
 
+// LastSeenAt is the last time the session have been used, with a
+// precision of [lastSeenPrecision].
+func (s *Session) LastSeenAt() time.Time { return s.lastSeenAt }
+
+// Remember reports if the session cookie must outlive the browser session.
+func (s *Session) Remember() bool { return s.remember }
+
 type CreateCmd struct {
 	UserID     uuid.UUID
 	UserAgent  string
 	RemoteAddr string
+	Remember   bool
 }
 
 func (t CreateCmd) Validate() error {
@@ -19,22 +19,36 @@ func NewFakeSession(t *testing.T) *FakeSessionBuilder {
 
 	uuidProvider := uuid.NewProvider()
 
-	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*1000), time.Now())
+	createdAt := gofakeit.DateRange(time.Now().Add(-time.Hour*24), time.Now())
 	rawToken := gofakeit.Password(true, true, true, false, false, 8)
 
 	return &FakeSessionBuilder{
 		session: &Session{
-			createdAt: createdAt,
-			token:     secret.NewText(rawToken),
-			userID:    uuidProvider.New(),
-			ip:        gofakeit.IPv4Address(),
-			device:    gofakeit.AppName(),
+			createdAt:  createdAt,
+			lastSeenAt: createdAt,
+			token:      secret.NewText(rawToken),
+			userID:     uuidProvider.New(),
+			ip:         gofakeit.IPv4Address(),
+			device:     gofakeit.AppName(),
 		},
 	}
 }
 
 func (f *FakeSessionBuilder) CreatedAt(at time.Time) *FakeSessionBuilder {
 	f.session.createdAt = at
+	f.session.lastSeenAt = at
+
+	return f
+}
+
+func (f *FakeSessionBuilder) LastSeenAt(at time.Time) *FakeSessionBuilder {
+	f.session.lastSeenAt = at
+
+	return f
+}
+
+func (f *FakeSessionBuilder) WithRemember() *FakeSessionBuilder {
+	f.session.remember = true
 
 	return f
 }
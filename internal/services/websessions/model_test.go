@@ -13,11 +13,13 @@ import (
 func TestSessionTypes(t *testing.T) {
 	now := time.Now()
 	session := Session{
-		token:     secret.NewText("some-token"),
-		userID:    uuid.UUID("3a708fc5-dc10-4655-8fc2-33b08a4b33a5"),
-		ip:        "192.168.1.1",
-		device:    "Android - Chrome",
-		createdAt: now,
+		token:      secret.NewText("some-token"),
+		userID:     uuid.UUID("3a708fc5-dc10-4655-8fc2-33b08a4b33a5"),
+		ip:         "192.168.1.1",
+		device:     "Android - Chrome",
+		createdAt:  now,
+		lastSeenAt: now.Add(time.Minute),
+		remember:   true,
 	}
 
 	assert.Equal(t, "some-token", session.Token().Raw())
@@ -25,6 +27,11 @@ func TestSessionTypes(t *testing.T) {
 	assert.Equal(t, "192.168.1.1", session.IP())
 	assert.Equal(t, "Android - Chrome", session.Device())
 	assert.Equal(t, now, session.CreatedAt())
+	assert.Equal(t, now.Add(time.Minute), session.LastSeenAt())
+	assert.True(t, session.Remember())
+	assert.Len(t, session.ID(), 24)
+	assert.NotContains(t, session.ID(), "some-token")
+	assert.Equal(t, session.ID(), (&Session{token: secret.NewText("some-token")}).ID())
 }
 
 func Test_CreateCmd_Validate(t *testing.T) {
@@ -0,0 +1,41 @@
+package websessions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+)
+
+func Test_RunPurgeJob(t *testing.T) {
+	tools := tools.NewToolboxForTest(t)
+	svcMock := NewMockService(t)
+	lc := fxtest.NewLifecycle(t)
+
+	purged := make(chan struct{})
+
+	// Mocks
+	svcMock.On("PurgeExpired", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		select {
+		case purged <- struct{}{}:
+		default:
+		}
+	})
+
+	// Run
+	RunPurgeJob(lc, Config{PurgeInterval: time.Millisecond}, svcMock, tools)
+	require.NoError(t, lc.Start(context.Background()))
+
+	// Asserts
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("the expired sessions have not been purged")
+	}
+
+	require.NoError(t, lc.Stop(context.Background()))
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
@@ -16,26 +17,44 @@ import (
 	ua "github.com/mileusna/useragent"
 )
 
+// lastSeenPrecision is the minimal delay between two updates of the
+// session last seen date. It avoids a write for each request.
+const lastSeenPrecision = time.Minute
+
 var ErrUserIDNotMatching = errors.New("user ids are not matching")
 
 type storage interface {
 	Save(ctx context.Context, session *Session) error
 	GetByToken(ctx context.Context, token secret.Text) (*Session, error)
 	RemoveByToken(ctx context.Context, token secret.Text) error
+	UpdateLastSeen(ctx context.Context, token secret.Text, lastSeenAt time.Time) error
+	RemoveExpired(ctx context.Context, createdBefore, lastSeenBefore time.Time) error
 	GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *sqlstorage.PaginateCmd) ([]Session, error)
 }
 
 type services struct {
-	clock   clock.Clock
-	storage storage
-	uuid    uuid.Service
+	clock       clock.Clock
+	storage     storage
+	uuid        uuid.Service
+	lifetime    time.Duration
+	idleTimeout time.Duration
 }
 
-func newService(storage storage, tools tools.Tools) *services {
+func newService(cfg Config, storage storage, tools tools.Tools) *services {
+	if cfg.Lifetime <= 0 {
+		cfg.Lifetime = DefaultLifetime
+	}
+
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+
 	return &services{
-		clock:   tools.Clock(),
-		uuid:    tools.UUID(),
-		storage: storage,
+		clock:       tools.Clock(),
+		uuid:        tools.UUID(),
+		storage:     storage,
+		lifetime:    cfg.Lifetime,
+		idleTimeout: cfg.IdleTimeout,
 	}
 }
 
@@ -46,13 +65,16 @@ func (s *services) Create(ctx context.Context, cmd *CreateCmd) (*Session, error)
 	}
 
 	uaRes := ua.Parse(cmd.UserAgent)
+	now := s.clock.Now()
 
 	session := &Session{
-		token:     secret.NewText(string(s.uuid.New())),
-		userID:    cmd.UserID,
-		ip:        cmd.RemoteAddr,
-		device:    fmt.Sprintf("%s - %s", uaRes.OS, uaRes.Name),
-		createdAt: s.clock.Now(),
+		token:      secret.NewText(string(s.uuid.New())),
+		userID:     cmd.UserID,
+		ip:         cmd.RemoteAddr,
+		device:     fmt.Sprintf("%s - %s", uaRes.OS, uaRes.Name),
+		createdAt:  now,
+		lastSeenAt: now,
+		remember:   cmd.Remember,
 	}
 
 	err = s.storage.Save(ctx, session)
@@ -100,19 +122,84 @@ func (s *services) GetByToken(ctx context.Context, token secret.Text) (*Session,
 		return nil, errs.Internal(err)
 	}
 
-	// TODO: Handle session expiration
+	if !s.clock.Now().Before(s.ExpiresAt(session)) {
+		return nil, errs.NotFound(ErrSessionExpired)
+	}
 
 	return session, nil
 }
 
+// ExpiresAt returns the date at which the session expires if it is not
+// used before. It is the soonest date between the end of the session
+// lifetime and the end of its idle timeout.
+func (s *services) ExpiresAt(session *Session) time.Time {
+	expiresAt := session.CreatedAt().Add(s.lifetime)
+
+	idleExpiresAt := session.LastSeenAt().Add(s.idleTimeout)
+	if idleExpiresAt.Before(expiresAt) {
+		return idleExpiresAt
+	}
+
+	return expiresAt
+}
+
+// Refresh marks the session as used now and extends the expiration date
+// of the session cookie if the request used one. The update is skipped if
+// the session have been seen less than a minute ago.
+func (s *services) Refresh(w http.ResponseWriter, r *http.Request, session *Session) error {
+	now := s.clock.Now()
+
+	if now.Sub(session.LastSeenAt()) < lastSeenPrecision {
+		return nil
+	}
+
+	err := s.storage.UpdateLastSeen(r.Context(), session.Token(), now)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to UpdateLastSeen: %w", err))
+	}
+
+	session.lastSeenAt = now
+
+	// The API clients use the "Authorization" header and don't have any cookie
+	// to renew.
+	if _, err := r.Cookie("session_token"); err == nil {
+		s.SetCookie(w, session)
+	}
+
+	return nil
+}
+
+// SetCookie writes the "session_token" cookie used by the browsers. The cookie
+// expires with the session if the user asked to be remembered, otherwise it
+// is removed at the end of the browser session.
+func (s *services) SetCookie(w http.ResponseWriter, session *Session) {
+	var expirationDate time.Time
+	if session.Remember() {
+		expirationDate = s.ExpiresAt(session)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_token",
+		Value:    session.Token().Raw(),
+		Expires:  expirationDate,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     "/",
+	})
+}
+
+// GetFromReq retrieves the session from the "session_token" cookie used by
+// the browsers or from the "Authorization: Bearer" header used by the API
+// clients.
 func (s *services) GetFromReq(r *http.Request) (*Session, error) {
-	c, err := r.Cookie("session_token")
-	if errors.Is(err, http.ErrNoCookie) {
+	token, ok := tokenFromReq(r)
+	if !ok {
 		return nil, errs.BadRequest(ErrMissingSessionToken, "invalid_request")
 	}
 
-	session, err := s.GetByToken(r.Context(), secret.NewText(c.Value))
-	if errors.Is(err, errNotFound) {
+	session, err := s.GetByToken(r.Context(), secret.NewText(token))
+	if errors.Is(err, errs.ErrNotFound) {
 		return nil, errs.BadRequest(ErrSessionNotFound, "session not found")
 	}
 
@@ -154,6 +241,51 @@ func (s *services) GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *sql
 	return res, nil
 }
 
+// DeleteByID removes the session of the user identified by the given
+// [Session.ID].
+func (s *services) DeleteByID(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	sessions, err := s.storage.GetAllForUser(ctx, userID, nil)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to GetAllForUser: %w", err))
+	}
+
+	for _, session := range sessions {
+		if session.ID() != sessionID {
+			continue
+		}
+
+		err = s.storage.RemoveByToken(ctx, session.Token())
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to RemoveByToken: %w", err))
+		}
+
+		return nil
+	}
+
+	return errs.NotFound(ErrSessionNotFound)
+}
+
+// DeleteOthers removes all the sessions of the user except the given one.
+func (s *services) DeleteOthers(ctx context.Context, current *Session) error {
+	sessions, err := s.storage.GetAllForUser(ctx, current.UserID(), nil)
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to GetAllForUser: %w", err))
+	}
+
+	for _, session := range sessions {
+		if session.ID() == current.ID() {
+			continue
+		}
+
+		err = s.storage.RemoveByToken(ctx, session.Token())
+		if err != nil {
+			return errs.Internal(fmt.Errorf("failed to RemoveByToken %q: %w", session.ID(), err))
+		}
+	}
+
+	return nil
+}
+
 func (s *services) DeleteAll(ctx context.Context, userID uuid.UUID) error {
 	sessions, err := s.GetAllForUser(ctx, userID, nil)
 	if err != nil {
@@ -172,3 +304,29 @@ func (s *services) DeleteAll(ctx context.Context, userID uuid.UUID) error {
 
 	return nil
 }
+
+func tokenFromReq(r *http.Request) (string, bool) {
+	c, err := r.Cookie("session_token")
+	if err == nil {
+		return c.Value, true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// PurgeExpired removes all the sessions which have expired.
+func (s *services) PurgeExpired(ctx context.Context) error {
+	now := s.clock.Now()
+
+	err := s.storage.RemoveExpired(ctx, now.Add(-s.lifetime), now.Add(-s.idleTimeout))
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to RemoveExpired: %w", err))
+	}
+
+	return nil
+}
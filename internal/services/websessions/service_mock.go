@@ -12,6 +12,8 @@ import (
 
 	sqlstorage "github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 
+	time "time"
+
 	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
@@ -86,6 +88,60 @@ func (_m *MockService) DeleteAll(ctx context.Context, userID uuid.UUID) error {
 	return r0
 }
 
+// DeleteByID provides a mock function with given fields: ctx, userID, sessionID
+func (_m *MockService) DeleteByID(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteByID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteOthers provides a mock function with given fields: ctx, current
+func (_m *MockService) DeleteOthers(ctx context.Context, current *Session) error {
+	ret := _m.Called(ctx, current)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOthers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Session) error); ok {
+		r0 = rf(ctx, current)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExpiresAt provides a mock function with given fields: session
+func (_m *MockService) ExpiresAt(session *Session) time.Time {
+	ret := _m.Called(session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpiresAt")
+	}
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func(*Session) time.Time); ok {
+		r0 = rf(session)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	return r0
+}
+
 // GetAllForUser provides a mock function with given fields: ctx, userID, cmd
 func (_m *MockService) GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *sqlstorage.PaginateCmd) ([]Session, error) {
 	ret := _m.Called(ctx, userID, cmd)
@@ -194,6 +250,47 @@ func (_m *MockService) Logout(r *http.Request, w http.ResponseWriter) error {
 	return r0
 }
 
+// PurgeExpired provides a mock function with given fields: ctx
+func (_m *MockService) PurgeExpired(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeExpired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: w, r, session
+func (_m *MockService) Refresh(w http.ResponseWriter, r *http.Request, session *Session) error {
+	ret := _m.Called(w, r, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(http.ResponseWriter, *http.Request, *Session) error); ok {
+		r0 = rf(w, r, session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCookie provides a mock function with given fields: w, session
+func (_m *MockService) SetCookie(w http.ResponseWriter, session *Session) {
+	_m.Called(w, session)
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
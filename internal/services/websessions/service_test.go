@@ -30,7 +30,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		now := time.Now().UTC()
@@ -66,7 +66,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 
@@ -90,7 +90,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		now := time.Now().UTC()
@@ -127,7 +127,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -149,7 +149,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -158,6 +158,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		// Mocks
 		storageMock.On("GetByToken", mock.Anything, secret.NewText(rawToken)).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
 
 		// Run
 		res, err := services.GetByToken(ctx, secret.NewText(rawToken))
@@ -172,7 +173,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -187,6 +188,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		// Mocks
 		storageMock.On("GetByToken", mock.Anything, secret.NewText(rawToken)).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
 
 		// Run
 		res, err := services.GetFromReq(req)
@@ -196,12 +198,58 @@ func Test_WebSessions_Service(t *testing.T) {
 		assert.EqualValues(t, session, res)
 	})
 
+	t.Run("GetFromReq with a bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		rawToken := "some-token"
+		session := NewFakeSession(t).WithToken(rawToken).CreatedBy(user).Build()
+
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Authorization", "Bearer "+rawToken)
+
+		// Mocks
+		storageMock.On("GetByToken", mock.Anything, secret.NewText(rawToken)).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(time.Now()).Once()
+
+		// Run
+		res, err := services.GetFromReq(req)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.EqualValues(t, session, res)
+	})
+
+	t.Run("GetFromReq with an invalid authorization header", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Authorization", "Basic c29tZTp0aGluZw==")
+
+		// Run
+		res, err := services.GetFromReq(req)
+
+		// Asserts
+		assert.Nil(t, res)
+		require.ErrorIs(t, err, ErrMissingSessionToken)
+	})
+
 	t.Run("GetFromReq with no cookie", func(t *testing.T) {
 		t.Parallel()
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		req, _ := http.NewRequest(http.MethodGet, "/foo", nil) // No cookie
@@ -219,7 +267,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		rawToken := "some-token"
@@ -246,7 +294,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		rawToken := "some-token"
@@ -268,12 +316,292 @@ func Test_WebSessions_Service(t *testing.T) {
 		require.ErrorContains(t, err, "some-error")
 	})
 
+	t.Run("GetByToken with a session idle for too long", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{IdleTimeout: time.Hour}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-2 * time.Hour)).Build()
+
+		// Mocks
+		storageMock.On("GetByToken", mock.Anything, session.Token()).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := services.GetByToken(ctx, session.Token())
+
+		// Asserts
+		assert.Nil(t, res)
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrSessionExpired)
+	})
+
+	t.Run("GetByToken with a session older than its lifetime", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{Lifetime: 24 * time.Hour}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-48 * time.Hour)).LastSeenAt(now.Add(-time.Minute)).Build()
+
+		// Mocks
+		storageMock.On("GetByToken", mock.Anything, session.Token()).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := services.GetByToken(ctx, session.Token())
+
+		// Asserts
+		assert.Nil(t, res)
+		require.ErrorIs(t, err, ErrSessionExpired)
+	})
+
+	t.Run("GetFromReq with an expired session", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{IdleTimeout: time.Hour}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		rawToken := "some-token"
+		session := NewFakeSession(t).WithToken(rawToken).CreatedAt(now.Add(-2 * time.Hour)).Build()
+
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "session_token",
+			Value: rawToken,
+		})
+
+		// Mocks
+		storageMock.On("GetByToken", mock.Anything, secret.NewText(rawToken)).Return(session, nil).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+
+		// Run
+		res, err := services.GetFromReq(req)
+
+		// Asserts
+		assert.Nil(t, res)
+		require.ErrorIs(t, err, errs.ErrBadRequest)
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("ExpiresAt", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{Lifetime: 24 * time.Hour, IdleTimeout: time.Hour}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		idle := NewFakeSession(t).CreatedAt(now).Build()
+		active := NewFakeSession(t).CreatedAt(now).LastSeenAt(now.Add(23 * time.Hour)).Build()
+
+		// Asserts
+		assert.Equal(t, now.Add(time.Hour), services.ExpiresAt(idle))
+		assert.Equal(t, now.Add(24*time.Hour), services.ExpiresAt(active))
+	})
+
+	t.Run("Refresh success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-time.Hour)).WithRemember().Build()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "session_token",
+			Value: session.Token().Raw(),
+		})
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("UpdateLastSeen", mock.Anything, session.Token(), now).Return(nil).Once()
+
+		// Run
+		err := services.Refresh(w, req, session)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, now, session.LastSeenAt())
+
+		res := w.Result() // Check that the cookie expiration have been extended.
+		res.Body.Close()
+		require.Len(t, res.Cookies(), 1)
+		assert.Equal(t, "session_token", res.Cookies()[0].Name)
+		assert.Equal(t, session.Token().Raw(), res.Cookies()[0].Value)
+		assert.WithinDuration(t, now.Add(DefaultIdleTimeout), res.Cookies()[0].Expires, time.Second)
+	})
+
+	t.Run("Refresh with a bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-time.Hour)).Build()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Authorization", "Bearer "+session.Token().Raw())
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("UpdateLastSeen", mock.Anything, session.Token(), now).Return(nil).Once()
+
+		// Run
+		err := services.Refresh(w, req, session)
+
+		// Asserts
+		require.NoError(t, err)
+
+		res := w.Result() // No cookie to renew
+		res.Body.Close()
+		assert.Empty(t, res.Cookies())
+	})
+
+	t.Run("Refresh with a session seen recently", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-time.Hour)).LastSeenAt(now.Add(-10 * time.Second)).Build()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		// No call to UpdateLastSeen
+
+		// Run
+		err := services.Refresh(w, req, session)
+
+		// Asserts
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-10*time.Second), session.LastSeenAt())
+	})
+
+	t.Run("Refresh with a storage error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		session := NewFakeSession(t).CreatedAt(now.Add(-time.Hour)).Build()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("UpdateLastSeen", mock.Anything, session.Token(), now).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := services.Refresh(w, req, session)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "failed to UpdateLastSeen: some-error")
+	})
+
+	t.Run("SetCookie without the remember option", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		session := NewFakeSession(t).Build()
+		w := httptest.NewRecorder()
+
+		// Run
+		services.SetCookie(w, session)
+
+		// Asserts
+		res := w.Result()
+		res.Body.Close()
+		require.Len(t, res.Cookies(), 1)
+		assert.Equal(t, session.Token().Raw(), res.Cookies()[0].Value)
+		assert.Empty(t, res.Cookies()[0].Expires)
+		assert.True(t, res.Cookies()[0].HttpOnly)
+	})
+
+	t.Run("PurgeExpired success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{Lifetime: 24 * time.Hour, IdleTimeout: time.Hour}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveExpired", mock.Anything, now.Add(-24*time.Hour), now.Add(-time.Hour)).Return(nil).Once()
+
+		// Run
+		err := services.PurgeExpired(ctx)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("PurgeExpired with a storage error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		storageMock.On("RemoveExpired", mock.Anything, now.Add(-DefaultLifetime), now.Add(-DefaultIdleTimeout)).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := services.PurgeExpired(ctx)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "failed to RemoveExpired: some-error")
+	})
+
 	t.Run("Logout success", func(t *testing.T) {
 		t.Parallel()
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		rawToken := "some-token"
@@ -305,7 +633,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		w := httptest.NewRecorder()
 
@@ -327,7 +655,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		rawToken := "some-token"
@@ -354,7 +682,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -380,7 +708,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		session := NewFakeSession(t).Build()
@@ -401,7 +729,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -426,7 +754,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -448,12 +776,130 @@ func Test_WebSessions_Service(t *testing.T) {
 		require.ErrorIs(t, err, errs.ErrNotFound)
 	})
 
+	t.Run("DeleteByID success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := NewFakeSession(t).CreatedBy(user).Build()
+		session2 := NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		storageMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]Session{*session, *session2}, nil).Once()
+		storageMock.On("RemoveByToken", mock.Anything, session2.Token()).Return(nil).Once()
+
+		// Run
+		err := services.DeleteByID(ctx, user.ID(), session2.ID())
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteByID with an unknown id", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		storageMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]Session{*session}, nil).Once()
+
+		// Run
+		err := services.DeleteByID(ctx, user.ID(), "some-invalid-id")
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrNotFound)
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("DeleteByID with a GetAllForUser error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		storageMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).Return(nil, fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := services.DeleteByID(ctx, user.ID(), "some-id")
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "failed to GetAllForUser: some-error")
+	})
+
+	t.Run("DeleteOthers success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		current := NewFakeSession(t).CreatedBy(user).Build()
+		session2 := NewFakeSession(t).CreatedBy(user).Build()
+		session3 := NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		storageMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).
+			Return([]Session{*session2, *current, *session3}, nil).Once()
+		storageMock.On("RemoveByToken", mock.Anything, session2.Token()).Return(nil).Once()
+		storageMock.On("RemoveByToken", mock.Anything, session3.Token()).Return(nil).Once()
+		// The current session is kept
+
+		// Run
+		err := services.DeleteOthers(ctx, current)
+
+		// Asserts
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteOthers with a RemoveByToken error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		storageMock := newMockStorage(t)
+		services := newService(Config{}, storageMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		current := NewFakeSession(t).CreatedBy(user).Build()
+		session2 := NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		storageMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).
+			Return([]Session{*current, *session2}, nil).Once()
+		storageMock.On("RemoveByToken", mock.Anything, session2.Token()).Return(fmt.Errorf("some-error")).Once()
+
+		// Run
+		err := services.DeleteOthers(ctx, current)
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
 	t.Run("DeleteAll success", func(t *testing.T) {
 		t.Parallel()
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -477,7 +923,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -498,7 +944,7 @@ func Test_WebSessions_Service(t *testing.T) {
 
 		tools := tools.NewMock(t)
 		storageMock := newMockStorage(t)
-		services := newService(storageMock, tools)
+		services := newService(Config{}, storageMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
@@ -10,6 +10,8 @@ import (
 
 	sqlstorage "github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
 
+	time "time"
+
 	uuid "github.com/Peltoche/onlyfun/internal/tools/uuid"
 )
 
@@ -96,6 +98,24 @@ func (_m *mockStorage) RemoveByToken(ctx context.Context, token secret.Text) err
 	return r0
 }
 
+// RemoveExpired provides a mock function with given fields: ctx, createdBefore, lastSeenBefore
+func (_m *mockStorage) RemoveExpired(ctx context.Context, createdBefore time.Time, lastSeenBefore time.Time) error {
+	ret := _m.Called(ctx, createdBefore, lastSeenBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveExpired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) error); ok {
+		r0 = rf(ctx, createdBefore, lastSeenBefore)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: ctx, session
 func (_m *mockStorage) Save(ctx context.Context, session *Session) error {
 	ret := _m.Called(ctx, session)
@@ -114,6 +134,24 @@ func (_m *mockStorage) Save(ctx context.Context, session *Session) error {
 	return r0
 }
 
+// UpdateLastSeen provides a mock function with given fields: ctx, token, lastSeenAt
+func (_m *mockStorage) UpdateLastSeen(ctx context.Context, token secret.Text, lastSeenAt time.Time) error {
+	ret := _m.Called(ctx, token, lastSeenAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLastSeen")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, secret.Text, time.Time) error); ok {
+		r0 = rf(ctx, token, lastSeenAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // newMockStorage creates a new instance of mockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockStorage(t interface {
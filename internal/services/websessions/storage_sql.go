@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Peltoche/onlyfun/internal/tools/ptr"
@@ -17,7 +18,7 @@ const tableName = "web_sessions"
 
 var errNotFound = errors.New("not found")
 
-var allFields = []string{"token", "user_id", "ip", "device", "created_at"}
+var allFields = []string{"token", "user_id", "ip", "device", "remember", "created_at", "last_seen_at"}
 
 type sqlStorage struct {
 	db sqlstorage.Querier
@@ -31,7 +32,13 @@ func (s *sqlStorage) Save(ctx context.Context, session *Session) error {
 	_, err := sq.
 		Insert(tableName).
 		Columns(allFields...).
-		Values(session.token, session.userID, session.ip, session.device, ptr.To(sqlstorage.SQLTime(session.createdAt))).
+		Values(session.token,
+			session.userID,
+			session.ip,
+			session.device,
+			session.remember,
+			ptr.To(sqlstorage.SQLTime(session.createdAt)),
+			ptr.To(sqlstorage.SQLTime(session.lastSeenAt))).
 		RunWith(s.db).
 		ExecContext(ctx)
 	if err != nil {
@@ -44,13 +51,14 @@ func (s *sqlStorage) Save(ctx context.Context, session *Session) error {
 func (s *sqlStorage) GetByToken(ctx context.Context, token secret.Text) (*Session, error) {
 	var res Session
 	var sqlCreatedAt sqlstorage.SQLTime
+	var sqlLastSeenAt sqlstorage.SQLTime
 
 	err := sq.
 		Select(allFields...).
 		From(tableName).
 		Where(sq.Eq{"token": token}).
 		RunWith(s.db).
-		ScanContext(ctx, &res.token, &res.userID, &res.ip, &res.device, &sqlCreatedAt)
+		ScanContext(ctx, &res.token, &res.userID, &res.ip, &res.device, &res.remember, &sqlCreatedAt, &sqlLastSeenAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errNotFound
 	}
@@ -60,6 +68,7 @@ func (s *sqlStorage) GetByToken(ctx context.Context, token secret.Text) (*Sessio
 	}
 
 	res.createdAt = sqlCreatedAt.Time()
+	res.lastSeenAt = sqlLastSeenAt.Time()
 
 	return &res, nil
 }
@@ -77,6 +86,36 @@ func (s *sqlStorage) RemoveByToken(ctx context.Context, token secret.Text) error
 	return nil
 }
 
+func (s *sqlStorage) UpdateLastSeen(ctx context.Context, token secret.Text, lastSeenAt time.Time) error {
+	_, err := sq.
+		Update(tableName).
+		Set("last_seen_at", ptr.To(sqlstorage.SQLTime(lastSeenAt))).
+		Where(sq.Eq{"token": token}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStorage) RemoveExpired(ctx context.Context, createdBefore, lastSeenBefore time.Time) error {
+	_, err := sq.
+		Delete(tableName).
+		Where(sq.Or{
+			sq.Expr("julianday(created_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(createdBefore))),
+			sq.Expr("julianday(last_seen_at) <= julianday(?)", ptr.To(sqlstorage.SQLTime(lastSeenBefore))),
+		}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+
+	return nil
+}
+
 func (s *sqlStorage) GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *sqlstorage.PaginateCmd) ([]Session, error) {
 	sessions := []Session{}
 
@@ -94,13 +133,15 @@ func (s *sqlStorage) GetAllForUser(ctx context.Context, userID uuid.UUID, cmd *s
 	for rows.Next() {
 		var res Session
 		var sqlCreatedAt sqlstorage.SQLTime
+		var sqlLastSeenAt sqlstorage.SQLTime
 
-		err = rows.Scan(&res.token, &res.userID, &res.ip, &res.device, &sqlCreatedAt)
+		err = rows.Scan(&res.token, &res.userID, &res.ip, &res.device, &res.remember, &sqlCreatedAt, &sqlLastSeenAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan a row: %w", err)
 		}
 
 		res.createdAt = sqlCreatedAt.Time()
+		res.lastSeenAt = sqlLastSeenAt.Time()
 
 		sessions = append(sessions, res)
 	}
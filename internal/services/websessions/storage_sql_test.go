@@ -3,6 +3,7 @@ package websessions
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
@@ -53,6 +54,48 @@ func TestSessionSqlStorage(t *testing.T) {
 		assert.Equal(t, []Session{*session}, res)
 	})
 
+	t.Run("UpdateLastSeen success", func(t *testing.T) {
+		// Data
+		lastSeenAt := session.CreatedAt().Add(time.Hour)
+
+		// Run
+		err := storage.UpdateLastSeen(context.Background(), session.Token(), lastSeenAt)
+
+		// Asserts
+		require.NoError(t, err)
+		res, err := storage.GetByToken(context.Background(), session.Token())
+		require.NoError(t, err)
+		assert.WithinDuration(t, lastSeenAt, res.LastSeenAt(), time.Millisecond)
+	})
+
+	t.Run("RemoveExpired success", func(t *testing.T) {
+		// Data
+		now := time.Now().UTC()
+		tooOld := NewFakeSession(t).CreatedBy(user).CreatedAt(now.Add(-48 * time.Hour)).LastSeenAt(now).Build()
+		idle := NewFakeSession(t).CreatedBy(user).CreatedAt(now.Add(-2 * time.Hour)).Build()
+		active := NewFakeSession(t).CreatedBy(user).CreatedAt(now.Add(-2 * time.Hour)).LastSeenAt(now).WithRemember().Build()
+
+		for _, s := range []*Session{tooOld, idle, active} {
+			require.NoError(t, storage.Save(context.Background(), s))
+		}
+
+		// Run
+		err := storage.RemoveExpired(context.Background(), now.Add(-24*time.Hour), now.Add(-time.Hour))
+
+		// Asserts
+		require.NoError(t, err)
+
+		_, err = storage.GetByToken(context.Background(), tooOld.Token())
+		require.ErrorIs(t, err, errNotFound)
+		_, err = storage.GetByToken(context.Background(), idle.Token())
+		require.ErrorIs(t, err, errNotFound)
+		res, err := storage.GetByToken(context.Background(), active.Token())
+		require.NoError(t, err)
+		assert.True(t, res.Remember())
+
+		require.NoError(t, storage.RemoveByToken(context.Background(), active.Token()))
+	})
+
 	t.Run("GetByToken not found", func(t *testing.T) {
 		// Run
 		res, err := storage.GetByToken(context.Background(), secret.NewText("some-invalid-token"))
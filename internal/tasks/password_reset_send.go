@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+)
+
+// PasswordResetSendTaskRunner sends the password reset link requested on the
+// login page, if an account uses the e-mail.
+type PasswordResetSendTaskRunner struct {
+	recoverySvc recovery.Service
+}
+
+func NewPasswordResetSendTaskRunner(recoverySvc recovery.Service) *PasswordResetSendTaskRunner {
+	return &PasswordResetSendTaskRunner{
+		recoverySvc: recoverySvc,
+	}
+}
+
+func (r *PasswordResetSendTaskRunner) Name() string { return recovery.SendResetTaskName }
+
+func (r *PasswordResetSendTaskRunner) Run(ctx context.Context, rawArgs json.RawMessage) error {
+	var args recovery.SendResetTask
+
+	err := json.Unmarshal(rawArgs, &args)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal the args: %w", err)
+	}
+
+	return r.RunArgs(ctx, &args)
+}
+
+func (r *PasswordResetSendTaskRunner) RunArgs(ctx context.Context, args *recovery.SendResetTask) error {
+	err := r.recoverySvc.SendReset(ctx, args.Email)
+	if err != nil {
+		return fmt.Errorf("failed to SendReset: %w", err)
+	}
+
+	return nil
+}
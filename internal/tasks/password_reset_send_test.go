@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PasswordResetSendTaskRunner(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Name", func(t *testing.T) {
+		t.Parallel()
+
+		runner := NewPasswordResetSendTaskRunner(recovery.NewMockService(t))
+
+		require.Equal(t, recovery.SendResetTaskName, runner.Name())
+	})
+
+	t.Run("Run with an invalid json", func(t *testing.T) {
+		t.Parallel()
+
+		runner := NewPasswordResetSendTaskRunner(recovery.NewMockService(t))
+
+		err := runner.Run(ctx, json.RawMessage(`some-invalid json`))
+		require.ErrorContains(t, err, "failed to unmarshal the args")
+	})
+
+	t.Run("Run success", func(t *testing.T) {
+		t.Parallel()
+
+		recoveryMock := recovery.NewMockService(t)
+		runner := NewPasswordResetSendTaskRunner(recoveryMock)
+
+		recoveryMock.On("SendReset", ctx, "jane@example.com").Return(nil).Once()
+
+		err := runner.Run(ctx, (&recovery.SendResetTask{Email: "jane@example.com"}).Args())
+		require.NoError(t, err)
+	})
+
+	t.Run("RunArgs with a SendReset error", func(t *testing.T) {
+		t.Parallel()
+
+		recoveryMock := recovery.NewMockService(t)
+		runner := NewPasswordResetSendTaskRunner(recoveryMock)
+
+		recoveryMock.On("SendReset", ctx, "jane@example.com").Return(errs.Internal(errors.New("some-error"))).Once()
+
+		err := runner.RunArgs(ctx, &recovery.SendResetTask{Email: "jane@example.com"})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+}
@@ -0,0 +1,184 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/moderations"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+)
+
+// UserDeleteTaskRunner removes a user marked for deletion.
+//
+// The personal data (sessions, second factor, identities, links, invitations,
+// votes and ban) are removed. The published content (posts, comments,
+// moderations and sections) and the issued bans are kept and reassigned to
+// the ghost user.
+type UserDeleteTaskRunner struct {
+	usersSvc         users.Service
+	webSessionsSvc   websessions.Service
+	twoFactorSvc     twofactor.Service
+	identitiesSvc    identities.Service
+	recoverySvc      recovery.Service
+	invitationsSvc   invitations.Service
+	votesSvc         votes.Service
+	postsSvc         posts.Service
+	commentsSvc      comments.Service
+	moderationsSvc   moderations.Service
+	sectionsSvc      sections.Service
+	loginAttemptsSvc loginattempts.Service
+	bansSvc          bans.Service
+}
+
+func NewUserDeleteTaskRunner(
+	usersSvc users.Service,
+	webSessionsSvc websessions.Service,
+	twoFactorSvc twofactor.Service,
+	identitiesSvc identities.Service,
+	recoverySvc recovery.Service,
+	invitationsSvc invitations.Service,
+	votesSvc votes.Service,
+	postsSvc posts.Service,
+	commentsSvc comments.Service,
+	moderationsSvc moderations.Service,
+	sectionsSvc sections.Service,
+	loginAttemptsSvc loginattempts.Service,
+	bansSvc bans.Service,
+) *UserDeleteTaskRunner {
+	return &UserDeleteTaskRunner{
+		usersSvc:         usersSvc,
+		webSessionsSvc:   webSessionsSvc,
+		twoFactorSvc:     twoFactorSvc,
+		identitiesSvc:    identitiesSvc,
+		recoverySvc:      recoverySvc,
+		invitationsSvc:   invitationsSvc,
+		votesSvc:         votesSvc,
+		postsSvc:         postsSvc,
+		commentsSvc:      commentsSvc,
+		moderationsSvc:   moderationsSvc,
+		sectionsSvc:      sectionsSvc,
+		loginAttemptsSvc: loginAttemptsSvc,
+		bansSvc:          bansSvc,
+	}
+}
+
+func (r *UserDeleteTaskRunner) Name() string { return users.DeleteTaskName }
+
+func (r *UserDeleteTaskRunner) Run(ctx context.Context, rawArgs json.RawMessage) error {
+	var args users.DeleteTask
+
+	err := json.Unmarshal(rawArgs, &args)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal the args: %w", err)
+	}
+
+	return r.RunArgs(ctx, &args)
+}
+
+func (r *UserDeleteTaskRunner) RunArgs(ctx context.Context, args *users.DeleteTask) error {
+	user, err := r.usersSvc.GetByID(ctx, args.UserID)
+	if errors.Is(err, errs.ErrNotFound) {
+		// Already deleted by a previous run.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the user %q: %w", args.UserID, err)
+	}
+
+	if user.Status() != users.Deleting {
+		return errs.Internal(fmt.Errorf("user %q: %w", user.ID(), users.ErrInvalidStatus))
+	}
+
+	ghost, err := r.usersSvc.GetGhost(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the ghost user: %w", err)
+	}
+
+	// The sessions are already revoked when the user is marked for deletion
+	// but one could have been created in between.
+	err = r.webSessionsSvc.DeleteAll(ctx, user.ID())
+	if err != nil {
+		return fmt.Errorf("failed to delete the web sessions: %w", err)
+	}
+
+	err = r.twoFactorSvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the two-factor data: %w", err)
+	}
+
+	err = r.identitiesSvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the identities: %w", err)
+	}
+
+	err = r.recoverySvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the recovery tokens: %w", err)
+	}
+
+	err = r.invitationsSvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the invitations: %w", err)
+	}
+
+	err = r.votesSvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the votes: %w", err)
+	}
+
+	err = r.bansSvc.DeleteAll(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to delete the ban: %w", err)
+	}
+
+	err = r.postsSvc.Reassign(ctx, user, ghost)
+	if err != nil {
+		return fmt.Errorf("failed to reassign the posts: %w", err)
+	}
+
+	err = r.commentsSvc.Reassign(ctx, user, ghost)
+	if err != nil {
+		return fmt.Errorf("failed to reassign the comments: %w", err)
+	}
+
+	err = r.moderationsSvc.Reassign(ctx, user, ghost)
+	if err != nil {
+		return fmt.Errorf("failed to reassign the moderations: %w", err)
+	}
+
+	err = r.sectionsSvc.Reassign(ctx, user, ghost)
+	if err != nil {
+		return fmt.Errorf("failed to reassign the sections: %w", err)
+	}
+
+	err = r.bansSvc.Reassign(ctx, user, ghost)
+	if err != nil {
+		return fmt.Errorf("failed to reassign the bans: %w", err)
+	}
+
+	err = r.loginAttemptsSvc.Unlock(ctx, user.Username())
+	if err != nil {
+		return fmt.Errorf("failed to clear the login attempts: %w", err)
+	}
+
+	err = r.usersSvc.HardDelete(ctx, user.ID())
+	if err != nil {
+		return fmt.Errorf("failed to HardDelete: %w", err)
+	}
+
+	return nil
+}
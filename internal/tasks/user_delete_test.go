@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/moderations"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/stretchr/testify/require"
+)
+
+type userDeleteMocks struct {
+	Users         *users.MockService
+	WebSessions   *websessions.MockService
+	TwoFactor     *twofactor.MockService
+	Identities    *identities.MockService
+	Recovery      *recovery.MockService
+	Invitations   *invitations.MockService
+	Votes         *votes.MockService
+	Posts         *posts.MockService
+	Comments      *comments.MockService
+	Moderations   *moderations.MockService
+	Sections      *sections.MockService
+	LoginAttempts *loginattempts.MockService
+	Bans          *bans.MockService
+}
+
+func newUserDeleteTaskRunnerWithMocks(t *testing.T) (*UserDeleteTaskRunner, *userDeleteMocks) {
+	m := &userDeleteMocks{
+		Users:         users.NewMockService(t),
+		WebSessions:   websessions.NewMockService(t),
+		TwoFactor:     twofactor.NewMockService(t),
+		Identities:    identities.NewMockService(t),
+		Recovery:      recovery.NewMockService(t),
+		Invitations:   invitations.NewMockService(t),
+		Votes:         votes.NewMockService(t),
+		Posts:         posts.NewMockService(t),
+		Comments:      comments.NewMockService(t),
+		Moderations:   moderations.NewMockService(t),
+		Sections:      sections.NewMockService(t),
+		LoginAttempts: loginattempts.NewMockService(t),
+		Bans:          bans.NewMockService(t),
+	}
+
+	runner := NewUserDeleteTaskRunner(m.Users, m.WebSessions, m.TwoFactor, m.Identities, m.Recovery,
+		m.Invitations, m.Votes, m.Posts, m.Comments, m.Moderations, m.Sections, m.LoginAttempts, m.Bans)
+
+	return runner, m
+}
+
+func Test_UserDeleteTaskRunner(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Name", func(t *testing.T) {
+		t.Parallel()
+
+		runner, _ := newUserDeleteTaskRunnerWithMocks(t)
+
+		require.Equal(t, users.DeleteTaskName, runner.Name())
+	})
+
+	t.Run("Run with an invalid json", func(t *testing.T) {
+		t.Parallel()
+
+		runner, _ := newUserDeleteTaskRunnerWithMocks(t)
+
+		err := runner.Run(ctx, json.RawMessage(`some-invalid json`))
+		require.ErrorContains(t, err, "failed to unmarshal the args")
+	})
+
+	t.Run("RunArgs success", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+		ghost := users.NewFakeUser(t).WithUsername(users.GhostUsername).WithStatus(users.Ghost).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		m.Users.On("GetGhost", ctx).Return(ghost, nil).Once()
+		m.WebSessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		m.TwoFactor.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Identities.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Recovery.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Invitations.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Votes.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Bans.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Posts.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Comments.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Moderations.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Sections.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Bans.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.LoginAttempts.On("Unlock", ctx, user.Username()).Return(nil).Once()
+		m.Users.On("HardDelete", ctx, user.ID()).Return(nil).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.NoError(t, err)
+	})
+
+	t.Run("RunArgs with an already deleted user", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(nil, errs.NotFound(errors.New("not found"))).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.NoError(t, err)
+	})
+
+	t.Run("RunArgs with a user not marked for deletion", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).WithStatus(users.Active).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorIs(t, err, users.ErrInvalidStatus)
+	})
+
+	t.Run("RunArgs with a users.GetGhost error", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		m.Users.On("GetGhost", ctx).Return(nil, errs.Internal(errors.New("some-error"))).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
+	t.Run("RunArgs with a votes.DeleteAll error", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+		ghost := users.NewFakeUser(t).WithUsername(users.GhostUsername).WithStatus(users.Ghost).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		m.Users.On("GetGhost", ctx).Return(ghost, nil).Once()
+		m.WebSessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		m.TwoFactor.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Identities.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Recovery.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Invitations.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Votes.On("DeleteAll", ctx, user).Return(errs.Internal(errors.New("some-error"))).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
+	t.Run("RunArgs with a users.HardDelete error", func(t *testing.T) {
+		t.Parallel()
+
+		runner, m := newUserDeleteTaskRunnerWithMocks(t)
+
+		user := users.NewFakeUser(t).WithStatus(users.Deleting).Build()
+		ghost := users.NewFakeUser(t).WithUsername(users.GhostUsername).WithStatus(users.Ghost).Build()
+
+		m.Users.On("GetByID", ctx, user.ID()).Return(user, nil).Once()
+		m.Users.On("GetGhost", ctx).Return(ghost, nil).Once()
+		m.WebSessions.On("DeleteAll", ctx, user.ID()).Return(nil).Once()
+		m.TwoFactor.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Identities.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Recovery.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Invitations.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Votes.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Bans.On("DeleteAll", ctx, user).Return(nil).Once()
+		m.Posts.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Comments.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Moderations.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Sections.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.Bans.On("Reassign", ctx, user, ghost).Return(nil).Once()
+		m.LoginAttempts.On("Unlock", ctx, user.Username()).Return(nil).Once()
+		m.Users.On("HardDelete", ctx, user.ID()).Return(errs.Internal(errors.New("some-error"))).Once()
+
+		err := runner.RunArgs(ctx, &users.DeleteTask{UserID: user.ID()})
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+}
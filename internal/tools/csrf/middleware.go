@@ -0,0 +1,139 @@
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+const (
+	// CookieName is the cookie holding the token of the browser.
+	CookieName = "csrf"
+	// HeaderName is the header used by the htmx requests to send the token.
+	HeaderName = "X-CSRF-Token"
+	// FieldName is the hidden form field used by the classic forms to send
+	// the token.
+	FieldName = "csrf_token"
+
+	tokenSize = 32
+)
+
+var ErrInvalidToken = errors.New("invalid csrf token")
+
+var tokenCtxKey = &contextKey{"token"}
+
+// Middleware protects the state-changing requests with a double-submit
+// token: the token saved inside the cookie must be sent back inside the
+// [HeaderName] header or the [FieldName] form field. A cross-site page can't
+// read the cookie so it can't forge a valid request.
+//
+// The token is made available to the templates with [Token].
+func Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		token := tokenFromCookie(r)
+
+		if isProtected(r) && !isValidSubmission(r, token) {
+			http.Error(w, ErrInvalidToken.Error(), http.StatusForbidden)
+			return
+		}
+
+		if token == "" {
+			token = newToken()
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     CookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		ctx := context.WithValue(r.Context(), tokenCtxKey, token)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// Token returns the token of the request. It is empty if the request didn't
+// go through [Middleware].
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(tokenCtxKey).(string)
+
+	return token
+}
+
+// isProtected returns true for the requests a cross-site page could forge
+// with the cookies of the user.
+func isProtected(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	// The browsers never set the "Authorization" header by themselves.
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+
+	// A cross-site page can't send a json body without a CORS preflight and
+	// the CORS policy only allows the configured hosts.
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return mediaType != "application/json"
+}
+
+func isValidSubmission(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	sent := r.Header.Get(HeaderName)
+	if sent == "" {
+		sent = r.PostFormValue(FieldName)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// tokenFromCookie returns the token saved inside the cookie or an empty
+// string if there is none or if it has been tampered.
+func tokenFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return ""
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(raw) != tokenSize {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+func newToken() string {
+	raw := make([]byte, tokenSize)
+
+	// rand.Read never returns an error on the supported platforms.
+	_, _ = rand.Read(raw)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// contextKey is a value for use with context.WithValue. It's used as
+// a pointer so it fits in an interface{} without allocation.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "tools/csrf context value " + k.name
+}
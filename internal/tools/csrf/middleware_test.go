@@ -0,0 +1,152 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	validToken := newToken()
+
+	serve := func(r *http.Request) (*httptest.ResponseRecorder, string) {
+		var token string
+
+		w := httptest.NewRecorder()
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token = Token(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, r)
+
+		return w, token
+	}
+
+	newForm := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(url.Values{FieldName: {token}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return r
+	}
+
+	t.Run("GET without cookie creates a token", func(t *testing.T) {
+		t.Parallel()
+
+		w, token := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		require.NotEmpty(t, token)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, CookieName, cookies[0].Name)
+		assert.Equal(t, token, cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	})
+
+	t.Run("GET with a cookie keeps the token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: validToken})
+
+		w, token := serve(r)
+
+		assert.Equal(t, validToken, token)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("GET with a tampered cookie creates a new token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "some-invalid-token"})
+
+		_, token := serve(r)
+
+		assert.NotEqual(t, "some-invalid-token", token)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("POST with the form field", func(t *testing.T) {
+		t.Parallel()
+
+		r := newForm(validToken)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: validToken})
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("POST with the htmx header", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodPost, "/posts/1/vote", nil)
+		r.Header.Set(HeaderName, validToken)
+		r.Header.Set("HX-Request", "true")
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: validToken})
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("POST without token", func(t *testing.T) {
+		t.Parallel()
+
+		r := newForm("")
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: validToken})
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("POST with a mismatched token", func(t *testing.T) {
+		t.Parallel()
+
+		r := newForm(newToken())
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: validToken})
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("POST without cookie", func(t *testing.T) {
+		t.Parallel()
+
+		w, _ := serve(newForm(validToken))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("POST with a bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+		r.Header.Set("Authorization", "Bearer some-token")
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("POST with a json body", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+		w, _ := serve(r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -15,6 +15,7 @@ import (
 type Config struct {
 	Log      logger.Config   `json:"log"`
 	Response response.Config `json:"response"`
+	Password password.Config `json:"password"`
 }
 
 type Toolbox struct {
@@ -33,7 +34,7 @@ func NewToolbox(cfg Config) *Toolbox {
 		uuid:      uuid.NewProvider(),
 		log:       log,
 		resWriter: response.Init(cfg.Response),
-		password:  password.NewArgon2IDPassword(),
+		password:  password.NewArgon2IDPassword(cfg.Password, &password.BcryptVerifier{}),
 	}
 }
 
@@ -46,7 +47,7 @@ func NewToolboxForTest(t testing.TB) *Toolbox {
 		uuid:      uuid.NewProvider(),
 		log:       log,
 		resWriter: response.Init(response.Config{PrettyRender: true}),
-		password:  password.NewArgon2IDPassword(),
+		password:  password.NewArgon2IDPassword(password.Config{}, &password.BcryptVerifier{}),
 	}
 }
 
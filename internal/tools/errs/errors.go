@@ -8,12 +8,13 @@ import (
 )
 
 var (
-	ErrBadRequest   = fmt.Errorf("bad request")  // HTTP code: 400
-	ErrUnauthorized = fmt.Errorf("unauthorized") // HTTP code: 401
-	ErrNotFound     = fmt.Errorf("not found")    // HTTP code: 404
-	ErrValidation   = fmt.Errorf("validation")   // HTTP code: 422
-	ErrUnhandled    = fmt.Errorf("unhandled")    // HTTP code: 500
-	ErrInternal     = fmt.Errorf("internal")     // HTTP code: 500
+	ErrBadRequest      = fmt.Errorf("bad request")       // HTTP code: 400
+	ErrUnauthorized    = fmt.Errorf("unauthorized")      // HTTP code: 401
+	ErrNotFound        = fmt.Errorf("not found")         // HTTP code: 404
+	ErrValidation      = fmt.Errorf("validation")        // HTTP code: 422
+	ErrTooManyRequests = fmt.Errorf("too many requests") // HTTP code: 429
+	ErrUnhandled       = fmt.Errorf("unhandled")         // HTTP code: 500
+	ErrInternal        = fmt.Errorf("internal")          // HTTP code: 500
 )
 
 type errResponse struct {
@@ -36,6 +37,8 @@ func (t *Error) Code() int {
 		return http.StatusNotFound
 	case errors.Is(t.err, ErrValidation):
 		return http.StatusUnprocessableEntity
+	case errors.Is(t.err, ErrTooManyRequests):
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
@@ -45,6 +48,11 @@ func (t *Error) Error() string {
 	return t.err.Error()
 }
 
+// Message returns the message safe to display to the end user.
+func (t *Error) Message() string {
+	return t.msg
+}
+
 func (t *Error) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&errResponse{Message: t.msg})
 }
@@ -73,6 +81,10 @@ func Unauthorized(err error, msgAndArgs ...any) error {
 	return &Error{err: fmt.Errorf("%w: %w", ErrUnauthorized, err), msg: messageFromMsgAndArgs(ErrUnauthorized, msgAndArgs...)}
 }
 
+func TooManyRequests(err error, msgAndArgs ...any) error {
+	return &Error{err: fmt.Errorf("%w: %w", ErrTooManyRequests, err), msg: messageFromMsgAndArgs(ErrTooManyRequests, msgAndArgs...)}
+}
+
 func Internal(err error) error {
 	return &Error{err: fmt.Errorf("%w: %w", ErrInternal, err), msg: "internal error"}
 }
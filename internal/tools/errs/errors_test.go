@@ -61,6 +61,18 @@ func TestErrorMsgFormat(t *testing.T) {
 			UserJSON:      `{"message": "some details: 42"}`,
 			InternalError: "not found: some-error",
 		},
+		{
+			Name:          "TooManyRequests with the default message",
+			Err:           TooManyRequests(fmt.Errorf("some-error")),
+			UserJSON:      `{"message": "too many requests"}`,
+			InternalError: "too many requests: some-error",
+		},
+		{
+			Name:          "TooManyRequests with a custom message",
+			Err:           TooManyRequests(fmt.Errorf("some-error"), "some details: %d", 42),
+			UserJSON:      `{"message": "some details: 42"}`,
+			InternalError: "too many requests: some-error",
+		},
 		{
 			Name:          "Unhandled with the default message",
 			Err:           Unhandled(fmt.Errorf("some-error")),
@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/spf13/afero"
+)
+
+// FileMailer writes each e-mail into a .eml file instead of sending it.
+type FileMailer struct {
+	fs     afero.Fs
+	clock  clock.Clock
+	uuid   uuid.Service
+	log    *slog.Logger
+	from   string
+	folder string
+}
+
+func NewFileMailer(cfg Config, fs afero.Fs, tools tools.Tools) *FileMailer {
+	return &FileMailer{
+		fs:     fs,
+		clock:  tools.Clock(),
+		uuid:   tools.UUID(),
+		log:    tools.Logger(),
+		from:   cfg.From,
+		folder: cfg.Folder,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, email *Email) error {
+	err := email.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	err = m.fs.MkdirAll(m.folder, 0o700)
+	if err != nil {
+		return fmt.Errorf("failed to create the mail folder: %w", err)
+	}
+
+	now := m.clock.Now()
+	filePath := path.Join(m.folder, fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405"), m.uuid.New()))
+
+	err = afero.WriteFile(m.fs, filePath, buildMessage(m.from, email, now), 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write the email: %w", err)
+	}
+
+	m.log.InfoContext(ctx, "email written", slog.String("to", email.To), slog.String("path", filePath))
+
+	return nil
+}
+
+// LogMailer writes the e-mails into the logs instead of sending them.
+type LogMailer struct {
+	log *slog.Logger
+}
+
+func NewLogMailer(cfg Config, tools tools.Tools) *LogMailer {
+	return &LogMailer{log: tools.Logger()}
+}
+
+func (m *LogMailer) Send(ctx context.Context, email *Email) error {
+	err := email.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	m.log.InfoContext(ctx, "email not sent, no SMTP server configured",
+		slog.String("to", email.To),
+		slog.String("subject", email.Subject),
+		slog.String("body", email.Body))
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Send success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		fs := afero.NewMemMapFs()
+		mailer := NewFileMailer(Config{From: "onlyfun@example.com", Folder: "/mails"}, fs, tools)
+
+		// Data
+		now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+		// Mocks
+		tools.ClockMock.On("Now").Return(now).Once()
+		tools.UUIDMock.On("New").Return(uuid.UUID("some-uuid")).Once()
+
+		// Run
+		err := mailer.Send(ctx, &Email{To: "jane@example.com", Subject: "some-subject", Body: "some-body"})
+
+		// Asserts
+		require.NoError(t, err)
+		content, err := afero.ReadFile(fs, "/mails/20240301T100000-some-uuid.eml")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "To: jane@example.com\r\n")
+		assert.Contains(t, string(content), "\r\n\r\nsome-body")
+	})
+
+	t.Run("Send with an invalid email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		fs := afero.NewMemMapFs()
+		mailer := NewFileMailer(Config{From: "onlyfun@example.com", Folder: "/mails"}, fs, tools)
+
+		// Run
+		err := mailer.Send(ctx, &Email{To: "not-an-email", Subject: "some-subject", Body: "some-body"})
+
+		// Asserts
+		require.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestLogMailer(t *testing.T) {
+	tools := tools.NewMock(t)
+	mailer := NewLogMailer(Config{}, tools)
+
+	err := mailer.Send(context.Background(), &Email{To: "jane@example.com", Subject: "some-subject", Body: "some-body"})
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	v "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/spf13/afero"
+)
+
+const DefaultSMTPPort = 587
+
+var singleLineRegexp = regexp.MustCompile(`^[^\r\n]*$`)
+
+// Config of the mailer. The e-mails are sent with the SMTP server when
+// [Config.Host] is set. Else they are written into [Config.Folder] or,
+// without folder, into the logs. Those two last modes are made for the
+// development only.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password secret.Text
+	// From is the address of the sender.
+	From   string
+	Folder string
+}
+
+// Email is a plain text e-mail.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+func (t Email) Validate() error {
+	return v.ValidateStruct(&t,
+		v.Field(&t.To, v.Required, is.Email, v.Match(singleLineRegexp)),
+		v.Field(&t.Subject, v.Required, v.Match(singleLineRegexp)),
+		v.Field(&t.Body, v.Required),
+	)
+}
+
+type Mailer interface {
+	Send(ctx context.Context, email *Email) error
+}
+
+func Init(cfg Config, fs afero.Fs, tools tools.Tools) Mailer {
+	switch {
+	case cfg.Host != "":
+		return NewSMTPMailer(cfg, tools)
+	case cfg.Folder != "":
+		return NewFileMailer(cfg, fs, tools)
+	default:
+		return NewLogMailer(cfg, tools)
+	}
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildMessage(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	res := buildMessage("onlyfun@example.com", &Email{
+		To:      "jane@example.com",
+		Subject: "Réinitialisation",
+		Body:    "Hello\nWorld",
+	}, now)
+
+	assert.Equal(t, "From: onlyfun@example.com\r\n"+
+		"To: jane@example.com\r\n"+
+		"Subject: =?utf-8?q?R=C3=A9initialisation?=\r\n"+
+		"Date: Fri, 01 Mar 2024 10:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"Hello\r\nWorld", string(res))
+}
+
+func Test_Email_Validate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := Email{To: "jane@example.com", Subject: "some-subject", Body: "some-body"}.Validate()
+
+		require.NoError(t, err)
+	})
+
+	t.Run("with an header injection", func(t *testing.T) {
+		err := Email{To: "jane@example.com", Subject: "foo\r\nBcc: john@example.com", Body: "some-body"}.Validate()
+
+		require.EqualError(t, err, "Subject: must be in a valid format.")
+	})
+}
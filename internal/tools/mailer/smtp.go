@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+)
+
+// SMTPMailer sends the e-mails with an SMTP server. The connection is
+// upgraded with STARTTLS when the server supports it.
+type SMTPMailer struct {
+	clock clock.Clock
+	auth  smtp.Auth
+	addr  string
+	from  string
+}
+
+func NewSMTPMailer(cfg Config, tools tools.Tools) *SMTPMailer {
+	port := cfg.Port
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password.Raw(), cfg.Host)
+	}
+
+	return &SMTPMailer{
+		clock: tools.Clock(),
+		auth:  auth,
+		addr:  net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		from:  cfg.From,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email *Email) error {
+	err := email.Validate()
+	if err != nil {
+		return errs.Validation(err)
+	}
+
+	err = smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, buildMessage(m.from, email, m.clock.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to send the email to %q: %w", m.addr, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSMTP accepts a single SMTP session and returns the received data.
+func serveSMTP(t *testing.T, listener net.Listener) <-chan string {
+	t.Helper()
+
+	res := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			res <- ""
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var data strings.Builder
+		inData := false
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				res <- data.String()
+				return
+			}
+
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				reply("250 OK")
+			case inData:
+				data.WriteString(line)
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 Go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 Bye")
+				res <- data.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return res
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	tools := tools.NewMock(t)
+	port := listener.Addr().(*net.TCPAddr).Port
+	mailer := NewSMTPMailer(Config{Host: "127.0.0.1", Port: port, From: "onlyfun@example.com"}, tools)
+
+	// Mocks
+	tools.ClockMock.On("Now").Return(time.Now()).Once()
+
+	// Run
+	received := serveSMTP(t, listener)
+	err = mailer.Send(context.Background(), &Email{To: "jane@example.com", Subject: "some-subject", Body: "some-body"})
+
+	// Asserts
+	require.NoError(t, err)
+	data := <-received
+	assert.Contains(t, data, "To: jane@example.com\r\n")
+	assert.Contains(t, data, "\r\n\r\nsome-body")
+	assert.Equal(t, "127.0.0.1:"+strconv.Itoa(port), mailer.addr)
+}
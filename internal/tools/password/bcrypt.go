@@ -0,0 +1,32 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptVerifier accepts the bcrypt hashes ("$2a$", "$2b$" or "$2y$") of the
+// users imported from other systems. They are migrated to argon2id at their
+// first login.
+type BcryptVerifier struct{}
+
+func (v *BcryptVerifier) CanVerify(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+func (v *BcryptVerifier) Verify(hash, password secret.Text) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash.Raw()), []byte(password.Raw()))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
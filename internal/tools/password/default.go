@@ -26,21 +26,22 @@ type params struct {
 	keyLength   uint32
 }
 
-type Argon2IDPassword struct{}
+// Argon2IDPassword hashes the passwords with argon2id. The hashes with another
+// format are checked by the first [Verifier] able to handle them.
+type Argon2IDPassword struct {
+	cfg       Config
+	verifiers []Verifier
+}
 
-func NewArgon2IDPassword() *Argon2IDPassword {
-	return &Argon2IDPassword{}
+func NewArgon2IDPassword(cfg Config, verifiers ...Verifier) *Argon2IDPassword {
+	return &Argon2IDPassword{
+		cfg:       cfg,
+		verifiers: verifiers,
+	}
 }
 
 func (p *Argon2IDPassword) Encrypt(ctx context.Context, password secret.Text) (secret.Text, error) {
-	// Those values have been taken from https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#argon2id
-	params := &params{
-		saltLength:  16,
-		iterations:  3,
-		parallelism: 1,
-		memory:      12 * 1024, // 12MB
-		keyLength:   32,
-	}
+	params := p.params()
 
 	// Generate a cryptographically secure random salt.
 	salt, err := generateRandomBytes(params.saltLength)
@@ -58,6 +59,10 @@ func (p *Argon2IDPassword) Encrypt(ctx context.Context, password secret.Text) (s
 }
 
 func (p *Argon2IDPassword) Compare(ctx context.Context, hashStr, password secret.Text) (bool, error) {
+	if verifier := p.verifierFor(hashStr.Raw()); verifier != nil {
+		return verifier.Verify(hashStr, password)
+	}
+
 	// Extract the parameters, salt and derived key from the encoded password
 	// hash.
 	params, salt, hash, err := decodeHash(hashStr.Raw())
@@ -78,6 +83,66 @@ func (p *Argon2IDPassword) Compare(ctx context.Context, hashStr, password secret
 	return false, nil
 }
 
+// NeedsRehash returns true for the hashes checked by a [Verifier] and for the
+// argon2id hashes generated with weaker parameters than the configured ones.
+func (p *Argon2IDPassword) NeedsRehash(hash secret.Text) bool {
+	if !strings.HasPrefix(hash.Raw(), "$argon2id$") {
+		return true
+	}
+
+	current, _, _, err := decodeHash(hash.Raw())
+	if err != nil {
+		return true
+	}
+
+	expected := p.params()
+
+	return current.memory < expected.memory ||
+		current.iterations < expected.iterations ||
+		current.parallelism < expected.parallelism ||
+		current.saltLength < expected.saltLength ||
+		current.keyLength < expected.keyLength
+}
+
+// params returns the parameters used for the new hashes.
+func (p *Argon2IDPassword) params() *params {
+	res := &params{
+		saltLength:  16,
+		iterations:  p.cfg.Iterations,
+		parallelism: p.cfg.Parallelism,
+		memory:      p.cfg.Memory,
+		keyLength:   32,
+	}
+
+	if res.memory == 0 {
+		res.memory = DefaultMemory
+	}
+
+	if res.iterations == 0 {
+		res.iterations = DefaultIterations
+	}
+
+	if res.parallelism == 0 {
+		res.parallelism = DefaultParallelism
+	}
+
+	return res
+}
+
+func (p *Argon2IDPassword) verifierFor(hash string) Verifier {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return nil
+	}
+
+	for _, verifier := range p.verifiers {
+		if verifier.CanVerify(hash) {
+			return verifier
+		}
+	}
+
+	return nil
+}
+
 func generateRandomBytes(n uint32) ([]byte, error) {
 	b := make([]byte, n)
 	_, err := rand.Read(b)
@@ -2,11 +2,13 @@ package password
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestBcryptPassword(t *testing.T) {
@@ -31,4 +33,56 @@ func TestBcryptPassword(t *testing.T) {
 		assert.False(t, ok)
 		require.EqualError(t, err, "failed to decode the hash: the encoded hash is not in the correct format")
 	})
+	t.Run("Encrypt with the configured parameters", func(t *testing.T) {
+		password := NewArgon2IDPassword(Config{Memory: 8 * 1024, Iterations: 2, Parallelism: 2})
+
+		hashed, err := password.Encrypt(ctx, secret.NewText("some-password"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hashed.Raw(), "$argon2id$v=19$m=8192,t=2,p=2$"))
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		weak := NewArgon2IDPassword(Config{Memory: 8 * 1024, Iterations: 1, Parallelism: 1})
+		strong := NewArgon2IDPassword(Config{Memory: 8 * 1024, Iterations: 2, Parallelism: 1})
+
+		weakHash, err := weak.Encrypt(ctx, secret.NewText("some-password"))
+		require.NoError(t, err)
+
+		strongHash, err := strong.Encrypt(ctx, secret.NewText("some-password"))
+		require.NoError(t, err)
+
+		assert.True(t, strong.NeedsRehash(weakHash))
+		assert.False(t, strong.NeedsRehash(strongHash))
+		assert.False(t, weak.NeedsRehash(strongHash))
+		assert.True(t, strong.NeedsRehash(secret.NewText("not a hash")))
+	})
+
+	t.Run("Compare a bcrypt hash with the verifier", func(t *testing.T) {
+		password := NewArgon2IDPassword(Config{}, &BcryptVerifier{})
+
+		rawHash, err := bcrypt.GenerateFromPassword([]byte("some-password"), bcrypt.MinCost)
+		require.NoError(t, err)
+		hashed := secret.NewText(string(rawHash))
+
+		ok, err := password.Compare(ctx, hashed, secret.NewText("some-password"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = password.Compare(ctx, hashed, secret.NewText("some-invalid-password"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		assert.True(t, password.NeedsRehash(hashed))
+	})
+
+	t.Run("Compare a bcrypt hash without verifier", func(t *testing.T) {
+		password := NewArgon2IDPassword(Config{})
+
+		rawHash, err := bcrypt.GenerateFromPassword([]byte("some-password"), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		ok, err := password.Compare(ctx, secret.NewText(string(rawHash)), secret.NewText("some-password"))
+		assert.False(t, ok)
+		require.ErrorIs(t, err, ErrInvalidHash)
+	})
 }
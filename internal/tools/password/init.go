@@ -6,7 +6,36 @@ import (
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 )
 
+const (
+	// Those values have been taken from https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#argon2id
+	DefaultMemory      = 12 * 1024 // 12MB
+	DefaultIterations  = 3
+	DefaultParallelism = 1
+)
+
+// Config contains the argon2id parameters used to hash the new passwords. A
+// zero value is replaced by its default.
+type Config struct {
+	// Memory is the amount of memory used by the algorithm, in KiB.
+	Memory      uint32 `json:"memory"`
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
 type Password interface {
 	Encrypt(ctx context.Context, password secret.Text) (secret.Text, error)
 	Compare(ctx context.Context, hash, password secret.Text) (bool, error)
+	// NeedsRehash returns true if the hash has not been generated by Encrypt
+	// with the current parameters. The password should then be encrypted
+	// again on the next successful Compare.
+	NeedsRehash(hash secret.Text) bool
+}
+
+// Verifier checks the passwords hashed by another system. It allows to
+// import the users with their hash and migrate them at their first login.
+type Verifier interface {
+	// CanVerify returns true if the hash has the format handled by the
+	// verifier.
+	CanVerify(hash string) bool
+	Verify(hash, password secret.Text) (bool, error)
 }
@@ -70,6 +70,24 @@ func (_m *Mock) Encrypt(ctx context.Context, password secret.Text) (secret.Text,
 	return r0, r1
 }
 
+// NeedsRehash provides a mock function with given fields: hash
+func (_m *Mock) NeedsRehash(hash secret.Text) bool {
+	ret := _m.Called(hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NeedsRehash")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(secret.Text) bool); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // NewMock creates a new instance of Mock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMock(t interface {
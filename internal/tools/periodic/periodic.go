@@ -0,0 +1,74 @@
+package periodic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Job is a function run at a fixed interval in the background.
+type Job struct {
+	// Name is used to identify the job in the logs.
+	Name string
+	// Interval is the delay between two runs.
+	Interval time.Duration
+	// RunAtStart triggers a first run as soon as the application starts
+	// instead of waiting for the first interval.
+	RunAtStart bool
+	Run        func(ctx context.Context) error
+}
+
+// Register runs the job periodically for as long as the application is
+// running. The failed runs are logged and the job continues.
+//
+// The context given to the job is canceled on stop and the stop waits for
+// the current run to return.
+func Register(lc fx.Lifecycle, log *slog.Logger, job Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	run := func() {
+		err := job.Run(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "periodic job failed", slog.String("job", job.Name), slog.String("error", err.Error()))
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+
+				if job.RunAtStart {
+					run()
+				}
+
+				ticker := time.NewTicker(job.Interval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						run()
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
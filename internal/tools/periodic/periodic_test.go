@@ -0,0 +1,87 @@
+package periodic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+)
+
+func Test_Register(t *testing.T) {
+	t.Run("runs the job at each interval", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		runs := make(chan struct{}, 10)
+
+		Register(lc, slog.Default(), Job{
+			Name:     "test",
+			Interval: time.Millisecond,
+			Run: func(context.Context) error {
+				select {
+				case runs <- struct{}{}:
+				default:
+				}
+				return errors.New("some-error")
+			},
+		})
+		require.NoError(t, lc.Start(context.Background()))
+
+		// A failed run doesn't stop the job.
+		for i := 0; i < 2; i++ {
+			select {
+			case <-runs:
+			case <-time.After(time.Second):
+				t.Fatal("the job has not been run")
+			}
+		}
+
+		require.NoError(t, lc.Stop(context.Background()))
+	})
+
+	t.Run("with RunAtStart", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		runs := make(chan struct{}, 1)
+
+		Register(lc, slog.Default(), Job{
+			Name:       "test",
+			Interval:   time.Hour,
+			RunAtStart: true,
+			Run: func(context.Context) error {
+				runs <- struct{}{}
+				return nil
+			},
+		})
+		require.NoError(t, lc.Start(context.Background()))
+
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("the job has not been run at start")
+		}
+
+		require.NoError(t, lc.Stop(context.Background()))
+	})
+
+	t.Run("Stop cancels the running job", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		started := make(chan struct{})
+
+		Register(lc, slog.Default(), Job{
+			Name:       "test",
+			Interval:   time.Hour,
+			RunAtStart: true,
+			Run: func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		require.NoError(t, lc.Start(context.Background()))
+		<-started
+
+		require.NoError(t, lc.Stop(context.Background()))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/web/html"
@@ -25,8 +26,11 @@ type Config struct {
 	CertFile  string
 	KeyFile   string
 	HostNames []string
-	TLS       bool
-	Secure    bool
+	// TrustedProxies are the reverse proxies allowed to give the client
+	// address with the "X-Forwarded-For" and "X-Real-IP" headers.
+	TrustedProxies []netip.Prefix
+	TLS            bool
+	Secure         bool
 }
 
 type Registerer interface {
@@ -8,6 +8,7 @@ import (
 	"slices"
 
 	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/csrf"
 	"github.com/Peltoche/onlyfun/internal/tools/language"
 	"github.com/Peltoche/onlyfun/internal/tools/logger"
 	"github.com/Peltoche/onlyfun/internal/web/middlewares"
@@ -25,6 +26,7 @@ type Middlewares struct {
 	OnlyJSON     Middleware
 	RealIP       Middleware
 	CORS         Middleware
+	CSRF         Middleware
 }
 
 func (m *Middlewares) Defaults() []func(next http.Handler) http.Handler {
@@ -33,6 +35,7 @@ func (m *Middlewares) Defaults() []func(next http.Handler) http.Handler {
 		m.RealIP,
 		m.StripSlashed,
 		m.CORS,
+		m.CSRF,
 		m.BrowserLang,
 		m.Bootstrap,
 	}
@@ -45,7 +48,8 @@ func InitMiddlewares(tools tools.Tools, cfg Config, bootstrapMid *middlewares.Bo
 		Logger:       logger.NewRouterLogger(tools.Logger()),
 		OnlyJSON:     middleware.AllowContentType("application/json"),
 		Bootstrap:    bootstrapMid.Handle,
-		RealIP:       middleware.RealIP,
+		RealIP:       NewRealIP(cfg.TrustedProxies),
+		CSRF:         csrf.Middleware,
 		CORS: cors.Handler(cors.Options{
 			AllowOriginFunc: func(_ *http.Request, origin string) bool {
 				url, err := url.ParseRequestURI(origin)
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// NewRealIP replaces the remote address by the client address given by the
+// reverse proxies. The headers are only trusted when the request comes from
+// one of the trusted proxies, otherwise any client could spoof its address.
+//
+// "X-Forwarded-For" is read from the right, skipping the trusted proxies,
+// because each proxy appends the address it received the request from.
+func NewRealIP(trustedProxies []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if len(trustedProxies) > 0 && isTrusted(trustedProxies, r.RemoteAddr) {
+				if ip := clientIP(trustedProxies, r); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func clientIP(trustedProxies []netip.Prefix, r *http.Request) string {
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+
+		if !isTrusted(trustedProxies, addr) {
+			return validIP(addr)
+		}
+	}
+
+	return validIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+func isTrusted(trustedProxies []netip.Prefix, addr string) bool {
+	ip, err := netip.ParseAddr(hostFromAddr(addr))
+	if err != nil {
+		return false
+	}
+
+	ip = ip.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validIP returns the given address if it's a valid IP, an empty string
+// otherwise.
+func validIP(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return ""
+	}
+
+	return ip.Unmap().String()
+}
+
+func hostFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,84 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RealIP(t *testing.T) {
+	t.Parallel()
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	serve := func(mid Middleware, remoteAddr string, headers map[string]string) string {
+		var res string
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		mid(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			res = r.RemoteAddr
+		})).ServeHTTP(httptest.NewRecorder(), r)
+
+		return res
+	}
+
+	t.Run("without trusted proxies the headers are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		res := serve(NewRealIP(nil), "192.0.2.1:1234", map[string]string{
+			"X-Forwarded-For": "198.51.100.1",
+			"X-Real-IP":       "198.51.100.2",
+		})
+
+		assert.Equal(t, "192.0.2.1:1234", res)
+	})
+
+	t.Run("from an untrusted peer the headers are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		res := serve(NewRealIP(trusted), "192.0.2.1:1234", map[string]string{
+			"X-Forwarded-For": "198.51.100.1",
+		})
+
+		assert.Equal(t, "192.0.2.1:1234", res)
+	})
+
+	t.Run("from a trusted proxy with X-Forwarded-For", func(t *testing.T) {
+		t.Parallel()
+
+		res := serve(NewRealIP(trusted), "10.0.0.1:1234", map[string]string{
+			// The first address is set by the client and can't be trusted.
+			"X-Forwarded-For": "203.0.113.9, 198.51.100.1, 10.0.0.2",
+		})
+
+		assert.Equal(t, "198.51.100.1", res)
+	})
+
+	t.Run("from a trusted proxy with X-Real-IP", func(t *testing.T) {
+		t.Parallel()
+
+		res := serve(NewRealIP(trusted), "10.0.0.1:1234", map[string]string{
+			"X-Real-IP": "198.51.100.1",
+		})
+
+		assert.Equal(t, "198.51.100.1", res)
+	})
+
+	t.Run("from a trusted proxy with an invalid header", func(t *testing.T) {
+		t.Parallel()
+
+		res := serve(NewRealIP(trusted), "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "not-an-ip",
+		})
+
+		assert.Equal(t, "10.0.0.1:1234", res)
+	})
+}
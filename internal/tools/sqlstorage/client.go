@@ -27,6 +27,8 @@ type Querier interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
+// Client is the [Querier] used by the storages. The queries given a context
+// run within the transaction of the context, if any. See [Transactor].
 type Client struct {
 	db *sql.DB
 }
@@ -69,6 +71,10 @@ func (c *Client) Exec(query string, args ...any) (sql.Result, error) {
 }
 
 func (c *Client) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx, ok := ctx.Value(txKey).(*sql.Tx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+
 	return c.db.ExecContext(ctx, query, args...)
 }
 
@@ -77,6 +83,10 @@ func (c *Client) Query(query string, args ...any) (*sql.Rows, error) {
 }
 
 func (c *Client) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx, ok := ctx.Value(txKey).(*sql.Tx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+
 	return c.db.QueryContext(ctx, query, args...)
 }
 
@@ -85,5 +95,9 @@ func (c *Client) QueryRow(query string, args ...any) *sql.Row {
 }
 
 func (c *Client) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if tx, ok := ctx.Value(txKey).(*sql.Tx); ok {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+
 	return c.db.QueryRowContext(ctx, query, args...)
 }
@@ -0,0 +1,10 @@
+//go:build !sqlite_fts5
+
+package sqlstorage
+
+// The posts search uses the sqlite fts5 module which is only compiled in with
+// the sqlite_fts5 build tag. Without it the migrations fail at the first start,
+// so the build is refused instead:
+//
+//	go build -tags sqlite_fts5 ./cmd/onlyfun
+var _ = onlyfun_must_be_built_with_the_sqlite_fts5_build_tag
@@ -35,7 +35,7 @@ func Init(cfg Config, tools tools.Tools) (Result, error) {
 
 	return Result{
 		DB:         db,
-		Querier:    db,
+		Querier:    NewSQLQuerier(db),
 		Transactor: NewTransacGenerator(db, tools),
 	}, nil
 }
@@ -1,6 +1,9 @@
 package sqlstorage
 
 import (
+	"context"
+	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/Peltoche/onlyfun/internal/migrations"
@@ -8,6 +11,28 @@ import (
 )
 
 func NewTestStorage(t *testing.T) Querier {
+	db := newTestDB(t)
+
+	return NewSQLQuerier(db)
+}
+
+// NewTestStorageWithTransactor returns a [Querier] and a [Transactor] sharing
+// the same database.
+func NewTestStorageWithTransactor(t *testing.T) (Querier, Transactor) {
+	db := newTestDB(t)
+
+	return NewSQLQuerier(db), &TransacService{db: db, logger: slog.Default()}
+}
+
+// FakeTransactor runs the functions directly, without any transaction. It is
+// used to test the services with a mocked storage.
+type FakeTransactor struct{}
+
+func (FakeTransactor) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) error) error {
+	return tFunc(ctx)
+}
+
+func newTestDB(t *testing.T) *sql.DB {
 	cfg := Config{Path: ":memory:"}
 
 	db, err := NewSQliteClient(&cfg)
@@ -19,5 +44,5 @@ func NewTestStorage(t *testing.T) Querier {
 	err = migrations.Run(db, nil)
 	require.NoError(t, err)
 
-	return NewSQLQuerier(db)
+	return db
 }
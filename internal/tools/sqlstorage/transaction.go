@@ -32,34 +32,38 @@ func NewTransacGenerator(db *sql.DB, tools tools.Tools) *TransacService {
 
 // WithinTransaction runs function within transaction
 //
-// The transaction commits when function were finished without error
+// The transaction commits when function were finished without error. A call
+// within an existing transaction joins it.
 func (t *TransacService) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey).(*sql.Tx); ok {
+		return tFunc(ctx)
+	}
+
 	// begin transaction
-	tx, err := t.db.Begin()
+	tx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errs.Internal(fmt.Errorf("failed to start transaction: %w", err))
 	}
 
 	defer func() {
-		// finalize transaction on panic, etc.
-		errTx := tx.Commit()
+		// rollback on panic, etc.
+		errTx := tx.Rollback()
 		if errTx != nil && !errors.Is(errTx, sql.ErrTxDone) {
-			logger.LogEntrySetAttrs(ctx, slog.String("commit-error", err.Error()))
+			logger.LogEntrySetAttrs(ctx, slog.String("rollback-error", errTx.Error()))
 		}
 	}()
 
 	// run callback
 	err = tFunc(context.WithValue(ctx, txKey, tx))
 	if err != nil {
-		// if error, rollback
-		if errRollback := tx.Rollback(); errRollback != nil {
-			logger.LogEntrySetAttrs(ctx, slog.String("rollback-error", err.Error()))
-		}
 		return err
 	}
+
 	// if no error, commit
-	if errCommit := tx.Commit(); errCommit != nil {
-		logger.LogEntrySetAttrs(ctx, slog.String("commit-error", err.Error()))
+	err = tx.Commit()
+	if err != nil {
+		return errs.Internal(fmt.Errorf("failed to commit the transaction: %w", err))
 	}
+
 	return nil
 }
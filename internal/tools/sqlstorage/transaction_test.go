@@ -0,0 +1,69 @@
+package sqlstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	countRows := func(t *testing.T, ctx context.Context, db Querier, key string) int {
+		t.Helper()
+
+		var count int
+		err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM login_counters WHERE key = ?", key).Scan(&count)
+		require.NoError(t, err)
+
+		return count
+	}
+
+	t.Run("success commits the queries", func(t *testing.T) {
+		db, transactor := NewTestStorageWithTransactor(t)
+
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, "INSERT INTO login_counters (kind, key, failures, last_failure_at) VALUES ('ip', 'some-ip', 1, '')")
+			return err
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, countRows(t, ctx, db, "some-ip"))
+	})
+
+	t.Run("with an error rollbacks the queries", func(t *testing.T) {
+		db, transactor := NewTestStorageWithTransactor(t)
+
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, "INSERT INTO login_counters (kind, key, failures, last_failure_at) VALUES ('ip', 'some-ip', 1, '')")
+			require.NoError(t, err)
+
+			// The queries of the transaction see its changes.
+			assert.Equal(t, 1, countRows(t, ctx, db, "some-ip"))
+
+			return assert.AnError
+		})
+		require.ErrorIs(t, err, assert.AnError)
+
+		assert.Equal(t, 0, countRows(t, ctx, db, "some-ip"))
+	})
+
+	t.Run("a nested call joins the transaction", func(t *testing.T) {
+		db, transactor := NewTestStorageWithTransactor(t)
+
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, err := db.ExecContext(ctx, "INSERT INTO login_counters (kind, key, failures, last_failure_at) VALUES ('ip', 'some-ip', 1, '')")
+				return err
+			})
+			require.NoError(t, err)
+
+			return assert.AnError
+		})
+		require.ErrorIs(t, err, assert.AnError)
+
+		assert.Equal(t, 0, countRows(t, ctx, db, "some-ip"))
+	})
+}
@@ -0,0 +1,222 @@
+// Package feeds renders the RSS 2.0 and Atom 1.0 syndication formats.
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"time"
+)
+
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the absolute url of the html page.
+	Link string
+	// Self is the absolute url of the feed itself.
+	Self    string
+	Updated time.Time
+	Entries []Entry
+}
+
+type Entry struct {
+	// ID is the permalink of the entry, it must be an absolute url.
+	ID        string
+	Title     string
+	Author    string
+	Published time.Time
+	Tags      []string
+	Media     Media
+}
+
+// Media is the image embedded inside an entry.
+type Media struct {
+	URL      string
+	Mimetype string
+	Size     uint64
+}
+
+// content returns the html content of the entry.
+func (e *Entry) content() string {
+	return fmt.Sprintf(`<p><img src="%s" alt="%s"></p>`, html.EscapeString(e.Media.URL), html.EscapeString(e.Title))
+}
+
+type link struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length uint64 `xml:"length,attr,omitempty"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          link      `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	GUID        rssGUID      `xml:"guid"`
+	Creator     string       `xml:"dc:creator,omitempty"`
+	PubDate     string       `xml:"pubDate"`
+	Categories  []string     `xml:"category"`
+	Description string       `xml:"description"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length uint64 `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// WriteRSS writes the feed in the RSS 2.0 format.
+func WriteRSS(w io.Writer, feed *Feed) error {
+	res := rss{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: feed.Description,
+			Self:        link{Href: feed.Self, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, len(feed.Entries)),
+		},
+	}
+
+	if !feed.Updated.IsZero() {
+		res.Channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for i, entry := range feed.Entries {
+		res.Channel.Items[i] = rssItem{
+			Title:       entry.Title,
+			Link:        entry.ID,
+			GUID:        rssGUID{IsPermaLink: true, Value: entry.ID},
+			Creator:     entry.Author,
+			PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+			Categories:  entry.Tags,
+			Description: entry.content(),
+			Enclosure: rssEnclosure{
+				URL:    entry.Media.URL,
+				Length: entry.Media.Size,
+				Type:   entry.Media.Mimetype,
+			},
+		}
+	}
+
+	return write(w, &res)
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []link      `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Author     atomPerson     `xml:"author"`
+	Links      []link         `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteAtom writes the feed in the Atom 1.0 format.
+func WriteAtom(w io.Writer, feed *Feed) error {
+	res := atomFeed{
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		ID:       feed.Self,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+		Links: []link{
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+			{Href: feed.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, len(feed.Entries)),
+	}
+
+	for i, entry := range feed.Entries {
+		categories := make([]atomCategory, len(entry.Tags))
+		for j, tag := range entry.Tags {
+			categories[j] = atomCategory{Term: tag}
+		}
+
+		res.Entries[i] = atomEntry{
+			Title:     entry.Title,
+			ID:        entry.ID,
+			Updated:   entry.Published.UTC().Format(time.RFC3339),
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: entry.Author},
+			Links: []link{
+				{Href: entry.ID, Rel: "alternate", Type: "text/html"},
+				{Href: entry.Media.URL, Rel: "enclosure", Type: entry.Media.Mimetype, Length: entry.Media.Size},
+			},
+			Categories: categories,
+			Content:    atomContent{Type: "html", Value: entry.content()},
+		}
+	}
+
+	return write(w, &res)
+}
+
+func write(w io.Writer, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("failed to write the header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode the feed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeed() *Feed {
+	published := time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	return &Feed{
+		Title:       "OnlyFun",
+		Description: "The latest posts",
+		Link:        "https://example.com/",
+		Self:        "https://example.com/feed.rss",
+		Updated:     published,
+		Entries: []Entry{
+			{
+				ID:        "https://example.com/posts/1",
+				Title:     "Cats & dogs",
+				Author:    "jane",
+				Published: published,
+				Tags:      []string{"cats", "dogs"},
+				Media: Media{
+					URL:      "https://example.com/medias/some-id",
+					Mimetype: "image/png",
+					Size:     1024,
+				},
+			},
+		},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	err := WriteRSS(buf, newTestFeed())
+	require.NoError(t, err)
+
+	var res rss
+	err = xml.Unmarshal(buf.Bytes(), &res)
+	require.NoError(t, err)
+
+	require.Len(t, res.Channel.Items, 1)
+	item := res.Channel.Items[0]
+	assert.Equal(t, "Cats & dogs", item.Title)
+	assert.Equal(t, "https://example.com/posts/1", item.GUID.Value)
+	assert.Equal(t, "Thu, 01 Aug 2024 10:00:00 +0000", item.PubDate)
+	assert.Equal(t, []string{"cats", "dogs"}, item.Categories)
+	assert.Equal(t, rssEnclosure{URL: "https://example.com/medias/some-id", Length: 1024, Type: "image/png"}, item.Enclosure)
+	assert.Equal(t, `<p><img src="https://example.com/medias/some-id" alt="Cats &amp; dogs"></p>`, item.Description)
+}
+
+func TestWriteAtom(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	err := WriteAtom(buf, newTestFeed())
+	require.NoError(t, err)
+
+	var res atomFeed
+	err = xml.Unmarshal(buf.Bytes(), &res)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-08-01T10:00:00Z", res.Updated)
+	require.Len(t, res.Entries, 1)
+	entry := res.Entries[0]
+	assert.Equal(t, "https://example.com/posts/1", entry.ID)
+	assert.Equal(t, "jane", entry.Author.Name)
+	assert.Equal(t, []atomCategory{{Term: "cats"}, {Term: "dogs"}}, entry.Categories)
+	assert.Contains(t, entry.Links, link{Href: "https://example.com/medias/some-id", Rel: "enclosure", Type: "image/png", Length: 1024})
+}
@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// BansPage lists the active bans and lets the admins ban the users and lift
+// the bans.
+type BansPage struct {
+	bans  bans.Service
+	users users.Service
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+}
+
+func NewBansPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	bans bans.Service,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *BansPage {
+	return &BansPage{
+		html:  html,
+		auth:  auth,
+		bans:  bans,
+		users: users,
+		roles: roles,
+	}
+}
+
+func (h *BansPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/bans", h.printPage)
+	r.Post("/admin/bans", h.createBan)
+	r.Post("/admin/bans/{username}/lift", h.liftBan)
+}
+
+func (h *BansPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderPage(w, r, user, http.StatusOK, &admin.BansPageTmpl{
+		// The user page links here with the username pre-filled.
+		Username: r.URL.Query().Get("username"),
+	})
+}
+
+func (h *BansPage) createBan(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl := &admin.BansPageTmpl{
+		Username: r.FormValue("username"),
+		Reason:   r.FormValue("reason"),
+	}
+
+	target, err := h.users.GetByUsername(r.Context(), tmpl.Username)
+	if errors.Is(err, errs.ErrNotFound) {
+		tmpl.Error = "Unknown user"
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, tmpl)
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByUsername: %w", err))
+		return
+	}
+
+	// A zero duration makes the ban permanent.
+	days, _ := strconv.Atoi(r.FormValue("duration"))
+
+	_, err = h.bans.Create(r.Context(), &bans.CreateCmd{
+		User:     user,
+		Target:   target,
+		Reason:   tmpl.Reason,
+		Duration: time.Duration(days) * 24 * time.Hour,
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/admin/bans", http.StatusFound)
+	case errors.Is(err, bans.ErrAdminTarget):
+		tmpl.Error = "An admin can't be banned, change its role first"
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, tmpl)
+	case errors.Is(err, users.ErrGhost):
+		tmpl.Error = "This account owns the content of the deleted users, it can't be banned"
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, tmpl)
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.Error = err.Error()
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, tmpl)
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the ban: %w", err))
+	}
+}
+
+func (h *BansPage) liftBan(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+	if !ok {
+		return
+	}
+
+	err := h.bans.Lift(r.Context(), &bans.LiftCmd{
+		User:   user,
+		Target: target.ID(),
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to lift the ban: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/bans", http.StatusFound)
+}
+
+func (h *BansPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, status int, tmpl *admin.BansPageTmpl) {
+	banList, err := h.bans.GetAll(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAll: %w", err))
+		return
+	}
+
+	// Users contains the banned users and the issuers of the bans.
+	tmpl.Users = map[uuid.UUID]*users.User{}
+	for _, ban := range banList {
+		for _, userID := range []uuid.UUID{ban.UserID(), ban.CreatedBy()} {
+			if _, ok := tmpl.Users[userID]; ok {
+				continue
+			}
+
+			u, err := h.users.GetByID(r.Context(), userID)
+			if err != nil {
+				h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByID for the user %q: %w", userID, err))
+				return
+			}
+
+			tmpl.Users[userID] = u
+		}
+	}
+
+	tmpl.Header = &partials.HeaderTmpl{
+		User:        user,
+		CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+		PostButton:  false,
+	}
+	tmpl.Bans = banList
+
+	h.html.WriteHTMLTemplate(w, r, status, tmpl)
+}
+
+func (h *BansPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+}
@@ -0,0 +1,294 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_BansPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printPage lists the bans with the banned users and their issuers", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		banned := users.NewFakeUser(t).Build()
+		ban := bans.NewFakeBan(t).WithUser(banned).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		bansMock.On("GetAll", mock.Anything).Return([]bans.Ban{*ban}, nil).Once()
+		usersMock.On("GetByID", mock.Anything, banned.ID()).Return(banned, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.BansPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			Bans: []bans.Ban{*ban},
+			Users: map[uuid.UUID]*users.User{
+				banned.ID(): banned,
+				user.ID():   user,
+			},
+			// The user page links here with the username pre-filled.
+			Username: banned.Username(),
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/bans?username="+banned.Username(), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/bans", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("createBan success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+		ban := bans.NewFakeBan(t).WithUser(target).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		bansMock.On("Create", mock.Anything, &bans.CreateCmd{
+			User:     user,
+			Target:   target,
+			Reason:   "Spam",
+			Duration: 7 * 24 * time.Hour,
+		}).Return(ban, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(url.Values{
+			"username": []string{target.Username()},
+			"reason":   []string{"Spam"},
+			"duration": []string{"7"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/bans", res.Header.Get("Location"))
+	})
+
+	t.Run("createBan of an unknown user keeps the form", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, "unknown").Return(nil, errs.ErrNotFound).Once()
+		bansMock.On("GetAll", mock.Anything).Return([]bans.Ban{}, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.BansPageTmpl) bool {
+				return tmpl.Error == "Unknown user" && tmpl.Username == "unknown" && tmpl.Reason == "Spam"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(url.Values{
+			"username": []string{"unknown"},
+			"reason":   []string{"Spam"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createBan of an admin", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		bansMock.On("Create", mock.Anything, &bans.CreateCmd{
+			User:   user,
+			Target: target,
+			Reason: "Spam",
+		}).Return(nil, errs.BadRequest(bans.ErrAdminTarget, "an admin can't be banned")).Once()
+		bansMock.On("GetAll", mock.Anything).Return([]bans.Ban{}, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.BansPageTmpl) bool {
+				return tmpl.Error == "An admin can't be banned, change its role first"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(url.Values{
+			"username": []string{target.Username()},
+			"reason":   []string{"Spam"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("liftBan success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewBansPage(htmlMock, authenticator, bansMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		bansMock.On("Lift", mock.Anything, &bans.LiftCmd{
+			User:   user,
+			Target: target.ID(),
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/bans/"+target.Username()+"/lift", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/bans", res.Header.Get("Location"))
+	})
+}
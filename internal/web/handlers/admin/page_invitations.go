@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// InvitationsPage lists the invitations of every user and lets the admins
+// create and revoke them.
+type InvitationsPage struct {
+	invitations invitations.Service
+	users       users.Service
+	roles       perms.Service
+	auth        *auth.Authenticator
+	html        html.Writer
+	clock       clock.Clock
+}
+
+func NewInvitationsPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	invitations invitations.Service,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *InvitationsPage {
+	return &InvitationsPage{
+		html:        html,
+		auth:        auth,
+		invitations: invitations,
+		users:       users,
+		roles:       roles,
+		clock:       tools.Clock(),
+	}
+}
+
+func (h *InvitationsPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/invitations", h.printPage)
+	r.Post("/admin/invitations", h.createInvitation)
+	r.Post("/admin/invitations/{code}/revoke", h.revokeInvitation)
+}
+
+func (h *InvitationsPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderPage(w, r, user, http.StatusOK, "")
+}
+
+func (h *InvitationsPage) createInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	maxUses, _ := strconv.Atoi(r.FormValue("max_uses"))
+	days, _ := strconv.Atoi(r.FormValue("lifetime"))
+
+	_, err := h.invitations.Create(r.Context(), &invitations.CreateCmd{
+		CreatedBy: user,
+		MaxUses:   maxUses,
+		Lifetime:  time.Duration(days) * 24 * time.Hour,
+	})
+	if errors.Is(err, errs.ErrValidation) || errors.Is(err, errs.ErrBadRequest) {
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the invitation: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invitations", http.StatusFound)
+}
+
+func (h *InvitationsPage) revokeInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.invitations.GetByCode(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByCode: %w", err))
+		return
+	}
+
+	err = h.invitations.Revoke(r.Context(), &invitations.RevokeCmd{
+		User:       user,
+		Invitation: invitation,
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke the invitation: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invitations", http.StatusFound)
+}
+
+func (h *InvitationsPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, status int, errMsg string) {
+	invitationList, err := h.invitations.GetAll(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAll: %w", err))
+		return
+	}
+
+	creators := map[uuid.UUID]*users.User{}
+	for _, invitation := range invitationList {
+		if _, ok := creators[invitation.CreatedBy()]; ok {
+			continue
+		}
+
+		creator, err := h.users.GetByID(r.Context(), invitation.CreatedBy())
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByID for the creator %q: %w", invitation.CreatedBy(), err))
+			return
+		}
+
+		creators[invitation.CreatedBy()] = creator
+	}
+
+	h.html.WriteHTMLTemplate(w, r, status, &admin.InvitationsPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Invitations: invitationList,
+		Creators:    creators,
+		Now:         h.clock.Now(),
+		Error:       errMsg,
+	})
+}
+
+func (h *InvitationsPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+}
@@ -0,0 +1,282 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_InvitationsPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printPage lists the invitations with their creators", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		creator := users.NewFakeUser(t).Build()
+		invitation1 := invitations.NewFakeInvitation(t).CreatedBy(creator).Build()
+		invitation2 := invitations.NewFakeInvitation(t).CreatedBy(creator).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		invitationsMock.On("GetAll", mock.Anything).Return([]invitations.Invitation{*invitation1, *invitation2}, nil).Once()
+		// The creator shared by the two invitations is fetched once.
+		usersMock.On("GetByID", mock.Anything, creator.ID()).Return(creator, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.InvitationsPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			Invitations: []invitations.Invitation{*invitation1, *invitation2},
+			Creators:    map[uuid.UUID]*users.User{creator.ID(): creator},
+			Now:         now,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/invitations", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/invitations", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("createInvitation success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		invitation := invitations.NewFakeInvitation(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		invitationsMock.On("Create", mock.Anything, &invitations.CreateCmd{
+			CreatedBy: user,
+			MaxUses:   3,
+			Lifetime:  7 * 24 * time.Hour,
+		}).Return(invitation, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/invitations", strings.NewReader(url.Values{
+			"max_uses": []string{"3"},
+			"lifetime": []string{"7"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/invitations", res.Header.Get("Location"))
+	})
+
+	t.Run("createInvitation over the quota displays the error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		invitationsMock.On("Create", mock.Anything, &invitations.CreateCmd{
+			CreatedBy: user,
+			MaxUses:   1,
+			Lifetime:  24 * time.Hour,
+		}).Return(nil, errs.BadRequest(invitations.ErrQuotaExceeded, "you can't create more invitations")).Once()
+		invitationsMock.On("GetAll", mock.Anything).Return([]invitations.Invitation{}, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		tools.ClockMock.On("Now").Return(now).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.InvitationsPageTmpl) bool {
+				return strings.Contains(tmpl.Error, invitations.ErrQuotaExceeded.Error())
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/invitations", strings.NewReader(url.Values{
+			"max_uses": []string{"1"},
+			"lifetime": []string{"1"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("revokeInvitation success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		invitation := invitations.NewFakeInvitation(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		invitationsMock.On("GetByCode", mock.Anything, invitation.Code()).Return(invitation, nil).Once()
+		invitationsMock.On("Revoke", mock.Anything, &invitations.RevokeCmd{
+			User:       user,
+			Invitation: invitation,
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/invitations/"+invitation.Code()+"/revoke", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/invitations", res.Header.Get("Location"))
+	})
+
+	t.Run("revokeInvitation with an unknown code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewInvitationsPage(htmlMock, authenticator, invitationsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		invitationsMock.On("GetByCode", mock.Anything, "unknown").Return(nil, errs.ErrNotFound).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrNotFound)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/invitations/unknown/revoke", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
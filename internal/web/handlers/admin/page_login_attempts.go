@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// recentFailuresLimit is the number of failed logins displayed for the
+// audit.
+const recentFailuresLimit = 100
+
+// LoginAttemptsPage lets the admins audit the failed logins and unlock the
+// accounts locked after too many failures.
+type LoginAttemptsPage struct {
+	loginAttempts loginattempts.Service
+	roles         perms.Service
+	auth          *auth.Authenticator
+	html          html.Writer
+}
+
+func NewLoginAttemptsPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	loginAttempts loginattempts.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *LoginAttemptsPage {
+	return &LoginAttemptsPage{
+		html:          html,
+		auth:          auth,
+		loginAttempts: loginAttempts,
+		roles:         roles,
+	}
+}
+
+func (h *LoginAttemptsPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/logins", h.printPage)
+	r.Post("/admin/logins/{username}/unlock", h.unlock)
+}
+
+func (h *LoginAttemptsPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	locked, err := h.loginAttempts.GetLocked(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetLocked: %w", err))
+		return
+	}
+
+	failures, err := h.loginAttempts.GetRecentFailures(r.Context(), recentFailuresLimit)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetRecentFailures: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &admin.LoginAttemptsPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Locked:   locked,
+		Failures: failures,
+	})
+}
+
+func (h *LoginAttemptsPage) unlock(w http.ResponseWriter, r *http.Request) {
+	_, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.loginAttempts.Unlock(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to Unlock: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/logins", http.StatusFound)
+}
+
+func (h *LoginAttemptsPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+}
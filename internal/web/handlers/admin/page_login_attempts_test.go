@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_LoginAttemptsPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printPage lists the locked accounts and the recent failures", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewLoginAttemptsPage(htmlMock, authenticator, loginAttemptsMock, permsMock, tools)
+
+		// Data
+		now := time.Now().UTC()
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		counter := loginattempts.NewFakeCounter(t).ForUsername("jane").WithFailures(5, now).LockedUntil(now.Add(time.Hour)).Build()
+		failure := loginattempts.NewFakeFailure(t).CreatedAt(now).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		loginAttemptsMock.On("GetLocked", mock.Anything).Return([]loginattempts.Counter{*counter}, nil).Once()
+		loginAttemptsMock.On("GetRecentFailures", mock.Anything, recentFailuresLimit).Return([]loginattempts.Failure{*failure}, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.LoginAttemptsPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			Locked:   []loginattempts.Counter{*counter},
+			Failures: []loginattempts.Failure{*failure},
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/logins", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewLoginAttemptsPage(htmlMock, authenticator, loginAttemptsMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/logins", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("printPage with a GetLocked error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewLoginAttemptsPage(htmlMock, authenticator, loginAttemptsMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		loginAttemptsMock.On("GetLocked", mock.Anything).Return(nil, errs.ErrInternal).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrInternal)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/logins", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("unlock success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewLoginAttemptsPage(htmlMock, authenticator, loginAttemptsMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		loginAttemptsMock.On("Unlock", mock.Anything, "jane").Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/logins/jane/unlock", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/logins", res.Header.Get("Location"))
+	})
+
+	t.Run("unlock without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewLoginAttemptsPage(htmlMock, authenticator, loginAttemptsMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/logins/jane/unlock", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// RegistrationsPage lists the accounts waiting for an approval when the
+// registration mode is [users.RegistrationApproval].
+type RegistrationsPage struct {
+	users users.Service
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+	uuid  uuid.Service
+}
+
+func NewRegistrationsPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *RegistrationsPage {
+	return &RegistrationsPage{
+		html:  html,
+		users: users,
+		roles: roles,
+		auth:  auth,
+		uuid:  tools.UUID(),
+	}
+}
+
+func (h *RegistrationsPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/registrations", h.printPage)
+	r.Post("/admin/registrations/{userID}/approve", h.approve)
+	r.Post("/admin/registrations/{userID}/reject", h.reject)
+}
+
+func (h *RegistrationsPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	pendingUsers, err := h.users.GetAllWithStatus(r.Context(), users.Pending, nil)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAllWithStatus: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &admin.RegistrationsPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Mode:  h.users.RegistrationMode(),
+		Users: pendingUsers,
+	})
+}
+
+func (h *RegistrationsPage) approve(w http.ResponseWriter, r *http.Request) {
+	h.applyDecision(w, r, h.users.Approve)
+}
+
+func (h *RegistrationsPage) reject(w http.ResponseWriter, r *http.Request) {
+	h.applyDecision(w, r, h.users.Reject)
+}
+
+func (h *RegistrationsPage) applyDecision(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, userID uuid.UUID) error) {
+	_, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := h.uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, errs.NotFound(err))
+		return
+	}
+
+	err = decide(r.Context(), userID)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to apply the decision: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/registrations", http.StatusFound)
+}
+
+func (h *RegistrationsPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageRegistrations)
+}
@@ -0,0 +1,247 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_RegistrationsPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printPage lists the pending users", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		pending := users.NewFakeUser(t).WithStatus(users.Pending).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(true).Once()
+		usersMock.On("GetAllWithStatus", mock.Anything, users.Pending, (*sqlstorage.PaginateCmd)(nil)).
+			Return([]users.User{*pending}, nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationApproval).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.RegistrationsPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			Mode:  users.RegistrationApproval,
+			Users: []users.User{*pending},
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/registrations", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage without the registrations.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/registrations", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("approve success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		pending := users.NewFakeUser(t).WithStatus(users.Pending).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(true).Once()
+		tools.UUIDMock.On("Parse", string(pending.ID())).Return(pending.ID(), nil).Once()
+		usersMock.On("Approve", mock.Anything, pending.ID()).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/registrations/"+string(pending.ID())+"/approve", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/registrations", res.Header.Get("Location"))
+	})
+
+	t.Run("reject success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		pending := users.NewFakeUser(t).WithStatus(users.Pending).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(true).Once()
+		tools.UUIDMock.On("Parse", string(pending.ID())).Return(pending.ID(), nil).Once()
+		usersMock.On("Reject", mock.Anything, pending.ID()).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/registrations/"+string(pending.ID())+"/reject", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/registrations", res.Header.Get("Location"))
+	})
+
+	t.Run("approve with an invalid user id", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(true).Once()
+		tools.UUIDMock.On("Parse", "invalid").Return(uuid.UUID(""), errs.ErrValidation).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrNotFound)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/registrations/invalid/approve", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("approve an account which isn't pending", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRegistrationsPage(htmlMock, authenticator, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		active := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageRegistrations).Return(true).Once()
+		tools.UUIDMock.On("Parse", string(active.ID())).Return(active.ID(), nil).Once()
+		usersMock.On("Approve", mock.Anything, active.ID()).Return(errs.ErrBadRequest).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrBadRequest)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/registrations/"+string(active.ID())+"/approve", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// RolesPage lets the admins create the roles and edit their permissions.
+type RolesPage struct {
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+}
+
+func NewRolesPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	roles perms.Service,
+	tools tools.Tools,
+) *RolesPage {
+	return &RolesPage{
+		html:  html,
+		roles: roles,
+		auth:  auth,
+	}
+}
+
+func (h *RolesPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/roles", h.printPage)
+	r.Post("/admin/roles", h.createRole)
+	r.Post("/admin/roles/{role}", h.updateRole)
+	r.Post("/admin/roles/{role}/delete", h.deleteRole)
+}
+
+func (h *RolesPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderPage(w, r, user, http.StatusOK, "")
+}
+
+func (h *RolesPage) createRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.roles.CreateRole(r.Context(), &perms.CreateRoleCmd{
+		User:        user,
+		Role:        perms.Role(r.FormValue("name")),
+		Permissions: formPermissions(r),
+	})
+
+	h.handleResult(w, r, user, err, "failed to CreateRole")
+}
+
+func (h *RolesPage) updateRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.roles.UpdateRole(r.Context(), &perms.UpdateRoleCmd{
+		User:        user,
+		Role:        perms.Role(chi.URLParam(r, "role")),
+		NewName:     perms.Role(r.FormValue("name")),
+		Permissions: formPermissions(r),
+	})
+
+	h.handleResult(w, r, user, err, "failed to UpdateRole")
+}
+
+func (h *RolesPage) deleteRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.roles.DeleteRole(r.Context(), &perms.DeleteRoleCmd{
+		User: user,
+		Role: perms.Role(chi.URLParam(r, "role")),
+	})
+
+	h.handleResult(w, r, user, err, "failed to DeleteRole")
+}
+
+// handleResult redirects to the roles list on success. The errors caused by
+// the admin input are displayed on the page.
+func (h *RolesPage) handleResult(w http.ResponseWriter, r *http.Request, user *users.User, err error, errCtx string) {
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/admin/roles", http.StatusFound)
+	case errors.Is(err, errs.ErrValidation), errors.Is(err, errs.ErrBadRequest), errors.Is(err, errs.ErrNotFound):
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("%s: %w", errCtx, err))
+	}
+}
+
+func (h *RolesPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, status int, errMsg string) {
+	roles := h.roles.GetRoles()
+
+	permissions := make(map[perms.Role][]perms.Permission, len(roles))
+	for _, role := range roles {
+		permissions[role] = h.roles.GetPermissions(role)
+	}
+
+	h.html.WriteHTMLTemplate(w, r, status, &admin.RolesPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Roles:          roles,
+		Permissions:    permissions,
+		AllPermissions: perms.AllPermissions,
+		Error:          errMsg,
+	})
+}
+
+func (h *RolesPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+}
+
+// formPermissions returns the checked permissions.
+func formPermissions(r *http.Request) []perms.Permission {
+	_ = r.ParseForm()
+
+	res := []perms.Permission{}
+	for _, perm := range r.PostForm["permissions"] {
+		res = append(res, perms.Permission(perm))
+	}
+
+	return res
+}
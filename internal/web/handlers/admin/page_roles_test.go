@@ -0,0 +1,319 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_RolesPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printPage lists the roles with their permissions", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.Role("editor")}).Once()
+		permsMock.On("GetPermissions", perms.DefaultAdminRole).Return([]perms.Permission{perms.ManageUsers}).Once()
+		permsMock.On("GetPermissions", perms.Role("editor")).Return([]perms.Permission{perms.ManageSections}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.RolesPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			Roles: []perms.Role{perms.DefaultAdminRole, perms.Role("editor")},
+			Permissions: map[perms.Role][]perms.Permission{
+				perms.DefaultAdminRole: {perms.ManageUsers},
+				perms.Role("editor"):   {perms.ManageSections},
+			},
+			AllPermissions: perms.AllPermissions,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/roles", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/roles", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("createRole with the checked permissions", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("CreateRole", mock.Anything, &perms.CreateRoleCmd{
+			User:        user,
+			Role:        perms.Role("editor"),
+			Permissions: []perms.Permission{perms.UploadPost, perms.ManageSections},
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/roles", strings.NewReader(url.Values{
+			"name":        []string{"editor"},
+			"permissions": []string{string(perms.UploadPost), string(perms.ManageSections)},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/roles", res.Header.Get("Location"))
+	})
+
+	t.Run("updateRole renames the role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("UpdateRole", mock.Anything, &perms.UpdateRoleCmd{
+			User:        user,
+			Role:        perms.Role("editor"),
+			NewName:     perms.Role("curator"),
+			Permissions: []perms.Permission{},
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/roles/editor", strings.NewReader(url.Values{
+			"name": []string{"curator"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/roles", res.Header.Get("Location"))
+	})
+
+	t.Run("updateRole removing users.manage from the admin role displays the error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("UpdateRole", mock.Anything, &perms.UpdateRoleCmd{
+			User:        user,
+			Role:        perms.DefaultAdminRole,
+			NewName:     perms.DefaultAdminRole,
+			Permissions: []perms.Permission{perms.Moderation},
+		}).Return(errs.BadRequest(perms.ErrAdminLockout, "the admin role must keep the %q permission", perms.ManageUsers)).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole}).Once()
+		permsMock.On("GetPermissions", perms.DefaultAdminRole).Return([]perms.Permission{perms.ManageUsers}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.RolesPageTmpl) bool {
+				return strings.Contains(tmpl.Error, perms.ErrAdminLockout.Error())
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/roles/admin", strings.NewReader(url.Values{
+			"name":        []string{"admin"},
+			"permissions": []string{string(perms.Moderation)},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("deleteRole success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("DeleteRole", mock.Anything, &perms.DeleteRoleCmd{
+			User: user,
+			Role: perms.Role("editor"),
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/roles/editor/delete", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/roles", res.Header.Get("Location"))
+	})
+
+	t.Run("deleteRole still used by some users displays the error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewRolesPage(htmlMock, authenticator, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		permsMock.On("DeleteRole", mock.Anything, &perms.DeleteRoleCmd{
+			User: user,
+			Role: perms.Role("editor"),
+		}).Return(errs.BadRequest(perms.ErrRoleInUse)).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.Role("editor")}).Once()
+		permsMock.On("GetPermissions", perms.Role("editor")).Return([]perms.Permission{}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(false).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.RolesPageTmpl) bool {
+				return strings.Contains(tmpl.Error, perms.ErrRoleInUse.Error())
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/roles/editor/delete", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/misc"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+type SectionsPage struct {
+	sections sections.Service
+	roles    perms.Service
+	auth     *auth.Authenticator
+	html     html.Writer
+}
+
+func NewSectionsPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	sections sections.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *SectionsPage {
+	return &SectionsPage{
+		html:     html,
+		sections: sections,
+		roles:    roles,
+		auth:     auth,
+	}
+}
+
+func (h *SectionsPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/sections", h.printPage)
+	r.Post("/admin/sections", h.createSection)
+	r.Post("/admin/sections/{name}/delete", h.deleteSection)
+}
+
+func (h *SectionsPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderPage(w, r, user, http.StatusOK, "")
+}
+
+func (h *SectionsPage) createSection(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.sections.Create(r.Context(), &sections.CreateCmd{
+		User:        user,
+		Name:        r.FormValue("name"),
+		Description: r.FormValue("description"),
+	})
+	if errors.Is(err, errs.ErrValidation) || errors.Is(err, errs.ErrBadRequest) {
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the section: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/sections", http.StatusFound)
+}
+
+func (h *SectionsPage) deleteSection(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	section, err := h.sections.GetByName(r.Context(), chi.URLParam(r, "name"))
+	if errors.Is(err, errs.ErrNotFound) {
+		h.html.WriteHTMLTemplate(w, r, http.StatusNotFound, &misc.NotFoundPageTmpl{})
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByName: %w", err))
+		return
+	}
+
+	err = h.sections.Delete(r.Context(), &sections.DeleteCmd{
+		User:    user,
+		Section: section,
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to delete the section: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/sections", http.StatusFound)
+}
+
+func (h *SectionsPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, status int, errMsg string) {
+	sectionList, err := h.sections.GetAll(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the sections: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, status, &admin.SectionsPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Sections: sectionList,
+		Error:    errMsg,
+	})
+}
+
+func (h *SectionsPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageSections)
+}
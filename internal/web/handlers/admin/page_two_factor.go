@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// TwoFactorPage lets the admins require the two-factor authentication for
+// the roles with the [perms.Moderation] permission.
+type TwoFactorPage struct {
+	twoFactor twofactor.Service
+	roles     perms.Service
+	auth      *auth.Authenticator
+	html      html.Writer
+}
+
+func NewTwoFactorPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	twoFactor twofactor.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *TwoFactorPage {
+	return &TwoFactorPage{
+		html:      html,
+		auth:      auth,
+		twoFactor: twoFactor,
+		roles:     roles,
+	}
+}
+
+func (h *TwoFactorPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/2fa", h.printPage)
+	r.Post("/admin/2fa/{role}", h.setRequired)
+}
+
+func (h *TwoFactorPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	required, err := h.twoFactor.GetRequiredRoles(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetRequiredRoles: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &admin.TwoFactorPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		Roles:    h.roles.RolesWith(perms.Moderation),
+		Required: required,
+	})
+}
+
+func (h *TwoFactorPage) setRequired(w http.ResponseWriter, r *http.Request) {
+	_, ok := h.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.twoFactor.SetRequired(r.Context(), &twofactor.SetRequiredCmd{
+		Role:     perms.Role(chi.URLParam(r, "role")),
+		Required: r.FormValue("required") == "true",
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to SetRequired: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/2fa", http.StatusFound)
+}
+
+func (h *TwoFactorPage) getAdmin(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	return getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+}
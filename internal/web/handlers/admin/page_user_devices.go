@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// UserDevicesPage lists the sessions of any user and lets an admin revoke
+// them.
+type UserDevicesPage struct {
+	users       users.Service
+	webSessions websessions.Service
+	roles       perms.Service
+	auth        *auth.Authenticator
+	html        html.Writer
+}
+
+func NewUserDevicesPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	users users.Service,
+	webSessions websessions.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *UserDevicesPage {
+	return &UserDevicesPage{
+		html:        html,
+		auth:        auth,
+		users:       users,
+		webSessions: webSessions,
+		roles:       roles,
+	}
+}
+
+func (h *UserDevicesPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/users/{username}/devices", h.printPage)
+	r.Post("/admin/users/{username}/devices/revoke-all", h.revokeAll)
+	r.Post("/admin/users/{username}/devices/{sessionID}/revoke", h.revokeSession)
+}
+
+func (h *UserDevicesPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := h.getAdminAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.webSessions.GetAllForUser(r.Context(), target.ID(), nil)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAllForUser: %w", err))
+		return
+	}
+
+	slices.SortFunc(sessions, func(a, b websessions.Session) int {
+		return b.LastSeenAt().Compare(a.LastSeenAt())
+	})
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &admin.UserDevicesPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  false,
+		},
+		User:     target,
+		Sessions: sessions,
+	})
+}
+
+func (h *UserDevicesPage) revokeSession(w http.ResponseWriter, r *http.Request) {
+	_, target, ok := h.getAdminAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.webSessions.DeleteByID(r.Context(), target.ID(), chi.URLParam(r, "sessionID"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke the session: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users/"+target.Username()+"/devices", http.StatusFound)
+}
+
+func (h *UserDevicesPage) revokeAll(w http.ResponseWriter, r *http.Request) {
+	_, target, ok := h.getAdminAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.webSessions.DeleteAll(r.Context(), target.ID())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke all the sessions: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users/"+target.Username()+"/devices", http.StatusFound)
+}
+
+func (h *UserDevicesPage) getAdminAndTarget(w http.ResponseWriter, r *http.Request) (*users.User, *users.User, bool) {
+	return getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+}
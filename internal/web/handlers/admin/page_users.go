@@ -0,0 +1,241 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// usersPagination is the number of users displayed per page.
+const usersPagination = 20
+
+// UsersPage lets the users with the [perms.ManageUsers] permission search the
+// users, change their role, reset their password and delete them.
+type UsersPage struct {
+	users       users.Service
+	posts       posts.Service
+	webSessions websessions.Service
+	recovery    recovery.Service
+	roles       perms.Service
+	auth        *auth.Authenticator
+	html        html.Writer
+}
+
+func NewUsersPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	users users.Service,
+	posts posts.Service,
+	webSessions websessions.Service,
+	recovery recovery.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *UsersPage {
+	return &UsersPage{
+		html:        html,
+		auth:        auth,
+		users:       users,
+		posts:       posts,
+		webSessions: webSessions,
+		recovery:    recovery,
+		roles:       roles,
+	}
+}
+
+func (h *UsersPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/admin/users", h.printListPage)
+	r.Get("/admin/users/{username}", h.printUserPage)
+	r.Post("/admin/users/{username}/role", h.updateRole)
+	r.Post("/admin/users/{username}/password-reset", h.resetPassword)
+	r.Post("/admin/users/{username}/delete", h.deleteUser)
+}
+
+func (h *UsersPage) printListPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := getAuthorizedUser(w, r, h.auth, h.roles, h.html, perms.ManageUsers)
+	if !ok {
+		return
+	}
+
+	tmpl := &admin.UsersPageTmpl{
+		Header: h.newHeader(user),
+		Query:  r.URL.Query().Get("q"),
+	}
+
+	res, err := h.users.Search(r.Context(), &users.SearchCmd{
+		Query: tmpl.Query,
+		After: r.URL.Query().Get("after"),
+		Limit: usersPagination,
+	})
+	switch {
+	case err == nil:
+		tmpl.Users = res
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.Error = "Invalid search"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to Search: %w", err))
+		return
+	}
+
+	if len(res) == usersPagination {
+		tmpl.NextPage = "/admin/users?" + url.Values{
+			"q":     {tmpl.Query},
+			"after": {res[len(res)-1].Username()},
+		}.Encode()
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *UsersPage) printUserPage(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.newUserTemplate(r, user, target)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *UsersPage) updateRole(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+	if !ok {
+		return
+	}
+
+	err := h.users.UpdateRole(r.Context(), &users.UpdateRoleCmd{
+		Admin:  user,
+		UserID: target.ID(),
+		Role:   perms.Role(r.FormValue("role")),
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/admin/users/"+target.Username(), http.StatusFound)
+	case errors.Is(err, users.ErrUnknownRole):
+		h.writeUserPageWithError(w, r, user, target, "Unknown role")
+	case errors.Is(err, users.ErrLastAdmin):
+		h.writeUserPageWithError(w, r, user, target, "The last admin can't lose its role")
+	case errors.Is(err, users.ErrGhost):
+		h.writeUserPageWithError(w, r, user, target, "This account owns the content of the deleted users, it can't be modified")
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to UpdateRole: %w", err))
+	}
+}
+
+func (h *UsersPage) resetPassword(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+	if !ok {
+		return
+	}
+
+	link, err := h.recovery.ForceReset(r.Context(), target)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to ForceReset: %w", err))
+		return
+	}
+
+	tmpl, err := h.newUserTemplate(r, user, target)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	// The link is only displayed once: it is not saved in clear. It is empty
+	// when it has been sent by e-mail.
+	tmpl.PasswordReset = true
+	tmpl.ResetLink = link
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *UsersPage) deleteUser(w http.ResponseWriter, r *http.Request) {
+	user, target, ok := getAdminAndTarget(w, r, h.auth, h.roles, h.users, h.html)
+	if !ok {
+		return
+	}
+
+	err := h.users.AddToDeletion(r.Context(), &users.AddToDeletionCmd{
+		Admin:  user,
+		UserID: target.ID(),
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/admin/users", http.StatusFound)
+	case errors.Is(err, users.ErrLastAdmin):
+		h.writeUserPageWithError(w, r, user, target, "The last admin can't be deleted")
+	case errors.Is(err, users.ErrGhost):
+		h.writeUserPageWithError(w, r, user, target, "This account owns the content of the deleted users, it can't be deleted")
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to AddToDeletion: %w", err))
+	}
+}
+
+func (h *UsersPage) writeUserPageWithError(w http.ResponseWriter, r *http.Request, user, target *users.User, msg string) {
+	tmpl, err := h.newUserTemplate(r, user, target)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	tmpl.Error = msg
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+}
+
+func (h *UsersPage) newUserTemplate(r *http.Request, user, target *users.User) (*admin.UserPageTmpl, error) {
+	stats, err := h.posts.GetUserStats(r.Context(), target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetUserStats: %w", err)
+	}
+
+	sessions, err := h.webSessions.GetAllForUser(r.Context(), target.ID(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetAllForUser: %w", err)
+	}
+
+	slices.SortFunc(sessions, func(a, b websessions.Session) int {
+		return b.LastSeenAt().Compare(a.LastSeenAt())
+	})
+
+	return &admin.UserPageTmpl{
+		Header:   h.newHeader(user),
+		User:     target,
+		Roles:    h.roles.GetRoles(),
+		Stats:    stats,
+		Sessions: sessions,
+	}, nil
+}
+
+func (h *UsersPage) newHeader(user *users.User) *partials.HeaderTmpl {
+	return &partials.HeaderTmpl{
+		User:        user,
+		CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+		PostButton:  false,
+	}
+}
@@ -0,0 +1,623 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/ptr"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/sqlstorage"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/admin"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_UsersPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("printListPage without session redirects to the login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(nil, errs.BadRequest(websessions.ErrMissingSessionToken)).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("printListPage without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("printUserPage success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		postsMock.On("GetUserStats", mock.Anything, target).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, target.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &admin.UserPageTmpl{
+			Header: &partials.HeaderTmpl{
+				User:        user,
+				CanModerate: true,
+				PostButton:  false,
+			},
+			User:     target,
+			Roles:    []perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole},
+			Stats:    map[posts.Status]int{posts.Listed: 2},
+			Sessions: []websessions.Session{},
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/users/"+target.Username(), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("updateRole without the users.manage permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/some-username/role", strings.NewReader(url.Values{
+			"role": []string{"admin"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("updateRole success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		usersMock.On("UpdateRole", mock.Anything, &users.UpdateRoleCmd{
+			Admin:  user,
+			UserID: target.ID(),
+			Role:   perms.DefaultAdminRole,
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/role", strings.NewReader(url.Values{
+			"role": []string{"admin"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/users/"+target.Username(), res.Header.Get("Location"))
+	})
+
+	t.Run("updateRole with an unknown role", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		usersMock.On("UpdateRole", mock.Anything, &users.UpdateRoleCmd{
+			Admin:  user,
+			UserID: target.ID(),
+			Role:   perms.Role("unknown"),
+		}).Return(errs.Validation(users.ErrUnknownRole)).Once()
+		postsMock.On("GetUserStats", mock.Anything, target).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, target.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == target && tmpl.Error == "Unknown role"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/role", strings.NewReader(url.Values{
+			"role": []string{"unknown"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("updateRole of the last admin", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, user.Username()).Return(user, nil).Once()
+		usersMock.On("UpdateRole", mock.Anything, &users.UpdateRoleCmd{
+			Admin:  user,
+			UserID: user.ID(),
+			Role:   perms.DefaultUserRole,
+		}).Return(errs.Unauthorized(users.ErrLastAdmin)).Once()
+		postsMock.On("GetUserStats", mock.Anything, user).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == user && tmpl.Error == "The last admin can't lose its role"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+user.Username()+"/role", strings.NewReader(url.Values{
+			"role": []string{"user"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("resetPassword shows the link", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+		link := secret.NewText("http://localhost/password-reset?token=some-token")
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		recoveryMock.On("ForceReset", mock.Anything, target).Return(link, nil).Once()
+		postsMock.On("GetUserStats", mock.Anything, target).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, target.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == target && tmpl.PasswordReset && tmpl.ResetLink == link
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/password-reset", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("resetPassword with the link sent by email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).WithEmail("jane@example.com").Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		recoveryMock.On("ForceReset", mock.Anything, target).Return(secret.Text{}, nil).Once()
+		postsMock.On("GetUserStats", mock.Anything, target).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, target.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == target && tmpl.PasswordReset && tmpl.ResetLink.Raw() == ""
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/password-reset", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printUserPage doesn't show the reset link again", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		postsMock.On("GetUserStats", mock.Anything, target).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, target.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == target && !tmpl.PasswordReset && tmpl.ResetLink.Raw() == ""
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/admin/users/"+target.Username(), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("resetPassword with a ForceReset error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		recoveryMock.On("ForceReset", mock.Anything, target).Return(secret.Text{}, errs.ErrInternal).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrInternal)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/password-reset", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("deleteUser success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		target := users.NewFakeUser(t).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, target.Username()).Return(target, nil).Once()
+		usersMock.On("AddToDeletion", mock.Anything, &users.AddToDeletionCmd{
+			Admin:  user,
+			UserID: target.ID(),
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+target.Username()+"/delete", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/admin/users", res.Header.Get("Location"))
+	})
+
+	t.Run("deleteUser of the last admin", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).WithRole(ptr.To(perms.DefaultAdminRole)).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, user.Username()).Return(user, nil).Once()
+		usersMock.On("AddToDeletion", mock.Anything, &users.AddToDeletionCmd{
+			Admin:  user,
+			UserID: user.ID(),
+		}).Return(errs.Unauthorized(users.ErrLastAdmin)).Once()
+		postsMock.On("GetUserStats", mock.Anything, user).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, user.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == user && tmpl.Error == "The last admin can't be deleted"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+user.Username()+"/delete", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("deleteUser of the ghost", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		recoveryMock := recovery.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+		handler := NewUsersPage(htmlMock, authenticator, usersMock, postsMock, webSessionsMock, recoveryMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		ghost := users.NewFakeUser(t).WithStatus(users.Ghost).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.ManageUsers).Return(true).Once()
+		usersMock.On("GetByUsername", mock.Anything, ghost.Username()).Return(ghost, nil).Once()
+		usersMock.On("AddToDeletion", mock.Anything, &users.AddToDeletionCmd{
+			Admin:  user,
+			UserID: ghost.ID(),
+		}).Return(errs.BadRequest(users.ErrGhost)).Once()
+		postsMock.On("GetUserStats", mock.Anything, ghost).Return(map[posts.Status]int{posts.Listed: 2}, nil).Once()
+		webSessionsMock.On("GetAllForUser", mock.Anything, ghost.ID(), (*sqlstorage.PaginateCmd)(nil)).Return([]websessions.Session{}, nil).Once()
+		permsMock.On("GetRoles").Return([]perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole}).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(true).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *admin.UserPageTmpl) bool {
+				return tmpl.User == ghost && tmpl.Error == "This account owns the content of the deleted users, it can't be deleted"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/admin/users/"+ghost.Username()+"/delete", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
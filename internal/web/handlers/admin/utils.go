@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/go-chi/chi/v5"
+)
+
+// getAuthorizedUser returns the authenticated user if it has the given
+// permission. If not, the response is written and false is returned.
+func getAuthorizedUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	authenticator *auth.Authenticator,
+	roles perms.Service,
+	html html.Writer,
+	perm perms.Permission,
+) (*users.User, bool) {
+	user, _, err := authenticator.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		html.WriteHTMLErrorPage(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, false
+	}
+
+	if !roles.IsAuthorized(user, perm) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// getAdminAndTarget returns the authenticated user managing the users and
+// the user targeted by the url. If one of them is missing, the response is
+// written and false is returned.
+func getAdminAndTarget(
+	w http.ResponseWriter,
+	r *http.Request,
+	authenticator *auth.Authenticator,
+	roles perms.Service,
+	usersSvc users.Service,
+	html html.Writer,
+) (*users.User, *users.User, bool) {
+	user, ok := getAuthorizedUser(w, r, authenticator, roles, html, perms.ManageUsers)
+	if !ok {
+		return nil, nil, false
+	}
+
+	target, err := usersSvc.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByUsername: %w", err))
+		return nil, nil, false
+	}
+
+	return user, target, true
+}
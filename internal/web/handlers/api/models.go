@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+)
+
+type postJSON struct {
+	ID        uint         `json:"id"`
+	Title     string       `json:"title"`
+	Status    posts.Status `json:"status"`
+	MediaURL  string       `json:"mediaURL"`
+	Tags      []string     `json:"tags"`
+	Upvotes   int          `json:"upvotes"`
+	Downvotes int          `json:"downvotes"`
+	Score     int          `json:"score"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Author    authorJSON   `json:"author"`
+}
+
+type authorJSON struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatarURL"`
+}
+
+type userJSON struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	AvatarURL string    `json:"avatarURL"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func mediaURL(fileID uuid.UUID) string {
+	return "/medias/" + string(fileID)
+}
+
+func newPostJSON(post *posts.Post, author *users.User) *postJSON {
+	return &postJSON{
+		ID:        post.ID(),
+		Title:     post.Title(),
+		Status:    post.Status(),
+		MediaURL:  mediaURL(post.FileID()),
+		Tags:      post.Tags(),
+		Upvotes:   post.Upvotes(),
+		Downvotes: post.Downvotes(),
+		Score:     post.Score(),
+		CreatedAt: post.CreatedAt(),
+		Author: authorJSON{
+			ID:        author.ID(),
+			Username:  author.Username(),
+			AvatarURL: mediaURL(author.Avatar()),
+		},
+	}
+}
+
+func newUserJSON(user *users.User) *userJSON {
+	res := userJSON{
+		ID:        user.ID(),
+		Username:  user.Username(),
+		AvatarURL: mediaURL(user.Avatar()),
+		CreatedAt: user.CreatedAt(),
+	}
+
+	if user.Role() != nil {
+		res.Role = string(*user.Role())
+	}
+
+	return &res
+}
+
+// newPostsJSON converts the posts and fetches their authors. Each author is
+// fetched only once.
+func newPostsJSON(ctx context.Context, usersSvc users.Service, postList []posts.Post) ([]postJSON, error) {
+	res := make([]postJSON, len(postList))
+	authors := map[uuid.UUID]*users.User{}
+
+	for i, post := range postList {
+		author, ok := authors[post.CreatedBy()]
+		if !ok {
+			var err error
+
+			author, err = usersSvc.GetByID(ctx, post.CreatedBy())
+			if err != nil {
+				return nil, fmt.Errorf("failed to GetByID for the author %q: %w", post.CreatedBy(), err)
+			}
+
+			authors[post.CreatedBy()] = author
+		}
+
+		res[i] = *newPostJSON(&post, author)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/moderations"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/response"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+type moderationQueueJSON struct {
+	Waiting int `json:"waiting"`
+	// Next is the next post to moderate, nil if the queue is empty.
+	Next *postJSON `json:"next"`
+}
+
+type moderationDecisionRequest struct {
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason"`
+	// Tags replaces the post tags before listing it. The tags are kept
+	// as is if nil.
+	Tags *[]string `json:"tags"`
+}
+
+type ModerationHandler struct {
+	auth        *auth.Authenticator
+	posts       posts.Service
+	moderations moderations.Service
+	users       users.Service
+	perms       perms.Service
+	response    response.Writer
+}
+
+func NewModerationHandler(
+	auth *auth.Authenticator,
+	posts posts.Service,
+	moderations moderations.Service,
+	users users.Service,
+	perms perms.Service,
+	tools tools.Tools,
+) *ModerationHandler {
+	return &ModerationHandler{
+		auth:        auth,
+		posts:       posts,
+		moderations: moderations,
+		users:       users,
+		perms:       perms,
+		response:    tools.ResWriter(),
+	}
+}
+
+func (h *ModerationHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...).With(mids.OnlyJSON)
+	}
+
+	r.Get(apiPrefix+"/moderation/queue", h.getQueue)
+	r.Post(apiPrefix+"/moderation/posts/{postID}", h.applyDecision)
+}
+
+func (h *ModerationHandler) getModerator(w http.ResponseWriter, r *http.Request) (*users.User, error) {
+	user, err := getUser(h.auth, w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !h.perms.IsAuthorized(user, perms.Moderation) {
+		return nil, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", user.ID(), perms.Moderation))
+	}
+
+	return user, nil
+}
+
+func (h *ModerationHandler) getQueue(w http.ResponseWriter, r *http.Request) {
+	_, err := h.getModerator(w, r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	waiting, err := h.posts.CountPostsWaitingModeration(r.Context())
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to CountPostsWaitingModeration: %w", err))
+		return
+	}
+
+	res := moderationQueueJSON{Waiting: waiting}
+
+	post, err := h.posts.GetNextPostToModerate(r.Context())
+	if err != nil && !errors.Is(err, errs.ErrNotFound) {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetNextPostToModerate: %w", err))
+		return
+	}
+
+	if post != nil {
+		author, err := h.users.GetByID(r.Context(), post.CreatedBy())
+		if err != nil {
+			h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetByID for the author: %w", err))
+			return
+		}
+
+		res.Next = newPostJSON(post, author)
+	}
+
+	h.response.WriteJSON(w, r, http.StatusOK, &res)
+}
+
+func (h *ModerationHandler) applyDecision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := h.getModerator(w, r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	postID, err := parsePostID(r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	var req moderationDecisionRequest
+	err = decodeJSONBody(r, &req)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	post, err := h.posts.GetByID(ctx, postID)
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetByID: %w", err))
+		return
+	}
+
+	if !req.Accepted {
+		_, err = h.moderations.ModeratePost(ctx, &moderations.PostModerationCmd{
+			User:   user,
+			Post:   post,
+			Reason: req.Reason,
+		})
+		if err != nil {
+			h.response.WriteJSONError(w, r, fmt.Errorf("failed to moderate post %d: %w", postID, err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if req.Tags != nil {
+		err = h.posts.SetTags(ctx, &posts.SetTagsCmd{
+			User: user,
+			Post: post,
+			Tags: *req.Tags,
+		})
+		if err != nil {
+			h.response.WriteJSONError(w, r, fmt.Errorf("failed to set the tags of post %d: %w", postID, err))
+			return
+		}
+	}
+
+	err = h.posts.ValidatePost(ctx, &posts.ValidatePostcmd{
+		User: user,
+		Post: post,
+	})
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to validate post %d: %w", postID, err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
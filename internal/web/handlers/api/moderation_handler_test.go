@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/moderations"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ModerationHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("getQueue success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		moderationsMock := moderations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewModerationHandler(authenticator, postsMock, moderationsMock, usersMock, permsMock, tools)
+
+		// Data
+		moderator := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(moderator).Build()
+		author := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).CreatedBy(author).WithStatus(posts.Uploaded).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, moderator.ID()).Return(moderator, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", moderator, perms.Moderation).Return(true).Once()
+		postsMock.On("CountPostsWaitingModeration", mock.Anything).Return(3, nil).Once()
+		postsMock.On("GetNextPostToModerate", mock.Anything).Return(post, nil).Once()
+		usersMock.On("GetByID", mock.Anything, author.ID()).Return(author, nil).Once()
+		tools.ResWriterMock.On("WriteJSON", mock.Anything, mock.Anything, http.StatusOK, &moderationQueueJSON{
+			Waiting: 3,
+			Next:    newPostJSON(post, author),
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/moderation/queue", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("getQueue without the moderation permission", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		moderationsMock := moderations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewModerationHandler(authenticator, postsMock, moderationsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", user, perms.Moderation).Return(false).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrUnauthorized)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/moderation/queue", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("applyDecision accepting a post with new tags", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		moderationsMock := moderations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewModerationHandler(authenticator, postsMock, moderationsMock, usersMock, permsMock, tools)
+
+		// Data
+		moderator := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(moderator).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Uploaded).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, moderator.ID()).Return(moderator, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", moderator, perms.Moderation).Return(true).Once()
+		postsMock.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		postsMock.On("SetTags", mock.Anything, &posts.SetTagsCmd{
+			User: moderator,
+			Post: post,
+			Tags: []string{"cats"},
+		}).Return(nil).Once()
+		postsMock.On("ValidatePost", mock.Anything, &posts.ValidatePostcmd{
+			User: moderator,
+			Post: post,
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/moderation/posts/"+formatUint(post.ID()),
+			strings.NewReader(`{"accepted": true, "tags": ["cats"]}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("applyDecision rejecting a post", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		moderationsMock := moderations.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewModerationHandler(authenticator, postsMock, moderationsMock, usersMock, permsMock, tools)
+
+		// Data
+		moderator := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(moderator).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Uploaded).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, moderator.ID()).Return(moderator, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		permsMock.On("IsAuthorized", moderator, perms.Moderation).Return(true).Once()
+		postsMock.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		moderationsMock.On("ModeratePost", mock.Anything, &moderations.PostModerationCmd{
+			User:   moderator,
+			Post:   post,
+			Reason: "off topic",
+		}).Return(nil, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/moderation/posts/"+formatUint(post.ID()),
+			strings.NewReader(`{"accepted": false, "reason": "off topic"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/response"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPostsLimit = 20
+	maxPostsLimit     = 100
+)
+
+type postsListJSON struct {
+	Posts []postJSON `json:"posts"`
+	// Next is the cursor of the next page, it is empty at the end of the feed.
+	Next string `json:"next,omitempty"`
+}
+
+type PostsHandler struct {
+	auth     *auth.Authenticator
+	posts    posts.Service
+	users    users.Service
+	perms    perms.Service
+	response response.Writer
+}
+
+func NewPostsHandler(
+	auth *auth.Authenticator,
+	posts posts.Service,
+	users users.Service,
+	perms perms.Service,
+	tools tools.Tools,
+) *PostsHandler {
+	return &PostsHandler{
+		auth:     auth,
+		posts:    posts,
+		users:    users,
+		perms:    perms,
+		response: tools.ResWriter(),
+	}
+}
+
+func (h *PostsHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get(apiPrefix+"/posts", h.listPosts)
+	r.Post(apiPrefix+"/posts", h.createPost)
+	r.Get(apiPrefix+"/posts/{postID}", h.getPost)
+}
+
+func (h *PostsHandler) listPosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	feed := posts.Hot
+	if query.Has("feed") {
+		feed = posts.Feed(query.Get("feed"))
+	}
+
+	limit := uint64(defaultPostsLimit)
+	if query.Has("limit") {
+		var err error
+
+		limit, err = strconv.ParseUint(query.Get("limit"), 10, 0)
+		if err != nil || limit == 0 || limit > maxPostsLimit {
+			h.response.WriteJSONError(w, r, errs.BadRequest(fmt.Errorf("invalid limit: %q", query.Get("limit")),
+				"limit must be between 1 and %d", maxPostsLimit))
+			return
+		}
+	}
+
+	var cursor *posts.FeedCursor
+	if query.Has("cursor") {
+		var err error
+
+		cursor, err = posts.DecodeFeedCursor(query.Get("cursor"))
+		if err != nil {
+			h.response.WriteJSONError(w, r, errs.BadRequest(err, "invalid cursor"))
+			return
+		}
+	}
+
+	postList, next, err := h.posts.GetFeed(r.Context(), &posts.GetFeedCmd{
+		Feed:   feed,
+		Tag:    query.Get("tag"),
+		Cursor: cursor,
+		Limit:  uint(limit),
+	})
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetFeed: %w", err))
+		return
+	}
+
+	res, err := newPostsJSON(r.Context(), h.users, postList)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	resp := postsListJSON{Posts: res}
+	if next != nil {
+		resp.Next = next.Encode()
+	}
+
+	h.response.WriteJSON(w, r, http.StatusOK, &resp)
+}
+
+func (h *PostsHandler) getPost(w http.ResponseWriter, r *http.Request) {
+	user, err := getOptionalUser(h.auth, w, r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	postID, err := parsePostID(r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	post, err := h.posts.GetByID(r.Context(), postID)
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetByID: %w", err))
+		return
+	}
+
+	// The posts not listed yet are only visible by their author and the
+	// moderators.
+	if post.Status() != posts.Listed &&
+		(user == nil || (user.ID() != post.CreatedBy() && !h.perms.IsAuthorized(user, perms.Moderation))) {
+		h.response.WriteJSONError(w, r, errs.NotFound(fmt.Errorf("post %d is not listed", postID), "post not found"))
+		return
+	}
+
+	author, err := h.users.GetByID(r.Context(), post.CreatedBy())
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetByID for the author: %w", err))
+		return
+	}
+
+	h.response.WriteJSON(w, r, http.StatusOK, newPostJSON(post, author))
+}
+
+// createPost creates a post from a multipart form with the fields "title",
+// "tags" (comma separated) and "file".
+func (h *PostsHandler) createPost(w http.ResponseWriter, r *http.Request) {
+	user, err := getUser(h.auth, w, r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	if !h.perms.IsAuthorized(user, perms.UploadPost) {
+		h.response.WriteJSONError(w, r, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", user.ID(), perms.UploadPost)))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.response.WriteJSONError(w, r, errs.BadRequest(fmt.Errorf("failed to retrieve the FormFile: %w", err), "missing file"))
+		return
+	}
+	defer file.Close()
+
+	post, err := h.posts.Create(r.Context(), &posts.CreateCmd{
+		Title:     r.FormValue("title"),
+		Media:     file,
+		CreatedBy: user,
+		Tags:      posts.ParseTags(r.FormValue("tags")),
+	})
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to create the post: %w", err))
+		return
+	}
+
+	h.response.WriteJSON(w, r, http.StatusCreated, newPostJSON(post, user))
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_PostsHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("listPosts success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		post1 := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).Build()
+		post2 := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Listed).Build()
+
+		cursor := &posts.FeedCursor{At: time.Now().UTC(), Rank: 12, PostID: 12}
+		next := &posts.FeedCursor{At: cursor.At, Rank: 10, PostID: 10}
+
+		// Mocks
+		postsMock.On("GetFeed", mock.Anything, &posts.GetFeedCmd{
+			Feed:   posts.Fresh,
+			Tag:    "cats",
+			Cursor: cursor,
+			Limit:  2,
+		}).Return([]posts.Post{*post1, *post2}, next, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		tools.ResWriterMock.On("WriteJSON", mock.Anything, mock.Anything, http.StatusOK, &postsListJSON{
+			Posts: []postJSON{*newPostJSON(post1, user), *newPostJSON(post2, user)},
+			Next:  next.Encode(),
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/posts?feed=fresh&tag=cats&limit=2&cursor="+cursor.Encode(), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("listPosts with an invalid limit", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Mocks
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrBadRequest)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/posts?limit=1000", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("listPosts with an invalid cursor", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Mocks
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrBadRequest) && errors.Is(err, posts.ErrInvalidCursor)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/posts?cursor=invalid", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("getPost with a post not listed and an anonymous user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Data
+		post := posts.NewFakePost(t).WithStatus(posts.Uploaded).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(nil, websessions.ErrMissingSessionToken).Once()
+		postsMock.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrNotFound)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/posts/"+formatUint(post.ID()), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("getPost with a post not listed and its author", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		post := posts.NewFakePost(t).CreatedBy(user).WithStatus(posts.Uploaded).Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Twice()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+		postsMock.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		tools.ResWriterMock.On("WriteJSON", mock.Anything, mock.Anything, http.StatusOK, newPostJSON(post, user)).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/posts/"+formatUint(post.ID()), nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createPost without being authenticated", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		postsMock := posts.NewMockService(t)
+		permsMock := perms.NewMockService(t)
+		authenticator := auth.NewAuthenticator(webSessionsMock, usersMock, html.NewMock(t))
+		handler := NewPostsHandler(authenticator, postsMock, usersMock, permsMock, tools)
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(nil, websessions.ErrMissingSessionToken).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrUnauthorized)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
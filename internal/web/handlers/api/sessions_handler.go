@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/response"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/go-chi/chi/v5"
+)
+
+type createSessionRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Code is the TOTP code or a recovery code, required when the
+	// two-factor authentication is enabled.
+	Code string `json:"code"`
+}
+
+type sessionJSON struct {
+	// Token must be given inside the "Authorization: Bearer" header.
+	Token string `json:"token"`
+}
+
+// SessionsHandler opens the sessions of the API clients. The browsers use
+// the login page and the session cookie instead.
+type SessionsHandler struct {
+	users         users.Service
+	webSessions   websessions.Service
+	twoFactor     twofactor.Service
+	loginAttempts loginattempts.Service
+	bans          bans.Service
+	response      response.Writer
+}
+
+func NewSessionsHandler(
+	users users.Service,
+	webSessions websessions.Service,
+	twoFactor twofactor.Service,
+	loginAttempts loginattempts.Service,
+	bans bans.Service,
+	tools tools.Tools,
+) *SessionsHandler {
+	return &SessionsHandler{
+		users:         users,
+		webSessions:   webSessions,
+		twoFactor:     twoFactor,
+		loginAttempts: loginAttempts,
+		bans:          bans,
+		response:      tools.ResWriter(),
+	}
+}
+
+func (h *SessionsHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...).With(mids.OnlyJSON)
+	}
+
+	r.Post(apiPrefix+"/sessions", h.createSession)
+	r.Delete(apiPrefix+"/sessions/current", h.deleteSession)
+}
+
+func (h *SessionsHandler) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	attempt := &loginattempts.AttemptCmd{
+		Username:   req.Username,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.Header.Get("User-Agent"),
+	}
+
+	// The attempt is reserved before checking the password in order to
+	// avoid spending any hashing time on a brute-force attack.
+	err = h.loginAttempts.Reserve(r.Context(), attempt)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	user, err := h.users.Authenticate(r.Context(), req.Username, secret.NewText(req.Password))
+	if errors.Is(err, users.ErrInvalidUsername) || errors.Is(err, users.ErrInvalidPassword) {
+		failErr := h.loginAttempts.RegisterFailure(r.Context(), attempt)
+		if failErr != nil {
+			h.response.WriteJSONError(w, r, fmt.Errorf("failed to RegisterFailure: %w", failErr))
+			return
+		}
+
+		h.response.WriteJSONError(w, r, errs.Unauthorized(err, "invalid username or password"))
+		return
+	}
+
+	if err != nil {
+		h.response.WriteJSONError(w, r, h.release(r, attempt, fmt.Errorf("failed to Authenticate: %w", err)))
+		return
+	}
+
+	err = h.bans.EnsureNotBanned(r.Context(), user.ID())
+	if err != nil {
+		h.response.WriteJSONError(w, r, h.release(r, attempt, err))
+		return
+	}
+
+	err = h.checkTwoFactor(r, attempt, user, req.Code)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	// The counter is only reset once all the factors are checked.
+	err = h.loginAttempts.RegisterSuccess(r.Context(), attempt)
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to RegisterSuccess: %w", err))
+		return
+	}
+
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		userAgent = "API client"
+	}
+
+	session, err := h.webSessions.Create(r.Context(), &websessions.CreateCmd{
+		UserID:     user.ID(),
+		UserAgent:  userAgent,
+		RemoteAddr: r.RemoteAddr,
+	})
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to create the websession: %w", err))
+		return
+	}
+
+	h.response.WriteJSON(w, r, http.StatusCreated, &sessionJSON{Token: session.Token().Raw()})
+}
+
+// checkTwoFactor verifies the code of the users with the two-factor
+// authentication enabled. The users who must enroll have to do it from the
+// website first.
+//
+// A wrong code is registered as a failed login, like a wrong password, so the
+// codes can't be brute-forced.
+func (h *SessionsHandler) checkTwoFactor(r *http.Request, attempt *loginattempts.AttemptCmd, user *users.User, code string) error {
+	needsChallenge, err := h.twoFactor.NeedsChallenge(r.Context(), user)
+	if err != nil {
+		return h.release(r, attempt, fmt.Errorf("failed to check the two-factor authentication: %w", err))
+	}
+
+	if !needsChallenge {
+		return nil
+	}
+
+	if code == "" {
+		// Nothing has been guessed, the password is valid.
+		return h.release(r, attempt, errs.Unauthorized(twofactor.ErrInvalidCode, "two-factor code required"))
+	}
+
+	err = h.twoFactor.Verify(r.Context(), &twofactor.VerifyCmd{User: user, Code: code})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, twofactor.ErrNotEnabled):
+		return h.release(r, attempt, errs.Unauthorized(err, "two-factor authentication must be configured from the website first"))
+	case errors.Is(err, errs.ErrUnauthorized), errors.Is(err, errs.ErrValidation):
+		failErr := h.loginAttempts.RegisterFailure(r.Context(), attempt)
+		if failErr != nil {
+			return fmt.Errorf("failed to RegisterFailure: %w", failErr)
+		}
+
+		return errs.Unauthorized(twofactor.ErrInvalidCode, "invalid two-factor code")
+	default:
+		return h.release(r, attempt, fmt.Errorf("failed to verify the two-factor code: %w", err))
+	}
+}
+
+// release ends an attempt which is neither a failure nor a success and
+// returns the error to respond with.
+func (h *SessionsHandler) release(r *http.Request, attempt *loginattempts.AttemptCmd, err error) error {
+	releaseErr := h.loginAttempts.Release(r.Context(), attempt)
+	if releaseErr != nil {
+		return fmt.Errorf("failed to Release: %w", releaseErr)
+	}
+
+	return err
+}
+
+func (h *SessionsHandler) deleteSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.webSessions.GetFromReq(r)
+	if errors.Is(err, websessions.ErrMissingSessionToken) || errors.Is(err, websessions.ErrSessionNotFound) {
+		h.response.WriteJSONError(w, r, errs.Unauthorized(err, "authentication required"))
+		return
+	}
+
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to GetFromReq: %w", err))
+		return
+	}
+
+	err = h.webSessions.Delete(r.Context(), &websessions.DeleteCmd{
+		UserID: session.UserID(),
+		Token:  session.Token(),
+	})
+	if err != nil {
+		h.response.WriteJSONError(w, r, fmt.Errorf("failed to delete the websession: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_SessionsHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("createSession success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).WithToken("some-token").Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(nil).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, nil).Once()
+		webSessionsMock.On("Create", mock.Anything, &websessions.CreateCmd{
+			UserID:     user.ID(),
+			UserAgent:  "some-bot",
+			RemoteAddr: httptest.DefaultRemoteAddr,
+		}).Return(session, nil).Once()
+		tools.ResWriterMock.On("WriteJSON", mock.Anything, mock.Anything, http.StatusCreated, &sessionJSON{Token: "some-token"}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password"}`))
+		r.RemoteAddr = httptest.DefaultRemoteAddr
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("User-Agent", "some-bot")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with a two-factor code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).WithToken("some-token").Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(nil).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "123456"}).Return(nil).Once()
+		webSessionsMock.On("Create", mock.Anything, mock.Anything).Return(session, nil).Once()
+		tools.ResWriterMock.On("WriteJSON", mock.Anything, mock.Anything, http.StatusCreated, &sessionJSON{Token: "some-token"}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password", "code": "123456"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with a missing two-factor code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(nil).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(true, nil).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrUnauthorized)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with an invalid two-factor code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(nil).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "000000"}).
+			Return(errs.Unauthorized(twofactor.ErrInvalidCode, "invalid code")).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrUnauthorized) && errors.Is(err, twofactor.ErrInvalidCode)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password", "code": "000000"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with a banned user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		banErr := errs.Unauthorized(bans.ErrBanned, "you are banned")
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(banErr).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, banErr).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with an invalid password", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, "some-user", secret.NewText("invalid")).
+			Return(nil, errs.BadRequest(users.ErrInvalidPassword)).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   "some-user",
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrUnauthorized)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "some-user", "password": "invalid"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with an Authenticate error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, "some-user", secret.NewText("some-password")).
+			Return(nil, errs.ErrInternal).Once()
+		loginAttemptsMock.On("Release", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   "some-user",
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrInternal)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "some-user", "password": "some-password"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with a NeedsChallenge error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(user, nil).Once()
+		bansMock.On("EnsureNotBanned", mock.Anything, user.ID()).Return(nil).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, errs.ErrInternal).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrInternal)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "`+user.Username()+`", "password": "some-password"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("createSession with too many attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).
+			Return(errs.TooManyRequests(loginattempts.ErrLocked)).Once()
+		tools.ResWriterMock.On("WriteJSONError", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, errs.ErrTooManyRequests)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions",
+			strings.NewReader(`{"username": "some-user", "password": "some-password"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("deleteSession success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		webSessionsMock := websessions.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		handler := NewSessionsHandler(usersMock, webSessionsMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).WithToken("some-token").Build()
+
+		// Mocks
+		webSessionsMock.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+		webSessionsMock.On("Delete", mock.Anything, &websessions.DeleteCmd{
+			UserID: user.ID(),
+			Token:  secret.NewText("some-token"),
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/api/v1/sessions/current", nil)
+		r.Header.Set("Authorization", "Bearer some-token")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/response"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+type UsersHandler struct {
+	auth     *auth.Authenticator
+	response response.Writer
+}
+
+func NewUsersHandler(auth *auth.Authenticator, tools tools.Tools) *UsersHandler {
+	return &UsersHandler{
+		auth:     auth,
+		response: tools.ResWriter(),
+	}
+}
+
+func (h *UsersHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get(apiPrefix+"/users/me", h.getMe)
+}
+
+func (h *UsersHandler) getMe(w http.ResponseWriter, r *http.Request) {
+	user, err := getUser(h.auth, w, r)
+	if err != nil {
+		h.response.WriteJSONError(w, r, err)
+		return
+	}
+
+	h.response.WriteJSON(w, r, http.StatusOK, newUserJSON(user))
+}
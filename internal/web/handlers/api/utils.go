@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// apiPrefix is the root of the JSON API. A breaking change in the API must
+// be done in a new version.
+const apiPrefix = "/api/v1"
+
+// getUser returns the authenticated user or an errs.ErrUnauthorized error.
+func getUser(authenticator *auth.Authenticator, w http.ResponseWriter, r *http.Request) (*users.User, error) {
+	user, _, err := authenticator.GetUserAndSession(w, r)
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		return nil, errs.Unauthorized(err, "authentication required")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetUserAndSession: %w", err)
+	}
+
+	return user, nil
+}
+
+// getOptionalUser returns the authenticated user or nil for the anonymous
+// requests.
+func getOptionalUser(authenticator *auth.Authenticator, w http.ResponseWriter, r *http.Request) (*users.User, error) {
+	user, err := getUser(authenticator, w, r)
+	if errors.Is(err, errs.ErrUnauthorized) {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func parsePostID(r *http.Request) (uint, error) {
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 0)
+	if err != nil {
+		return 0, errs.BadRequest(err, "invalid post id")
+	}
+
+	return uint(postID), nil
+}
+
+func decodeJSONBody(r *http.Request, dst any) error {
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if err != nil {
+		return errs.BadRequest(err, "invalid json body")
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package api
+
+import "strconv"
+
+func formatUint(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
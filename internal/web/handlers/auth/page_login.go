@@ -4,12 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/services/websessions"
 	"github.com/Peltoche/onlyfun/internal/tools"
-	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/logger"
 	"github.com/Peltoche/onlyfun/internal/tools/router"
 	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
@@ -18,28 +23,36 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-const cookieLifeTime = time.Hour * 24 * 365
-
 type LoginPage struct {
-	webSessions websessions.Service
-	uuid        uuid.Service
-	html        html.Writer
-	users       users.Service
-	clock       clock.Clock
+	webSessions   websessions.Service
+	uuid          uuid.Service
+	html          html.Writer
+	users         users.Service
+	identities    identities.Service
+	twoFactor     twofactor.Service
+	loginAttempts loginattempts.Service
+	bans          bans.Service
 }
 
 func NewLoginPage(
 	html html.Writer,
 	webSessions websessions.Service,
 	users users.Service,
+	identities identities.Service,
+	twoFactor twofactor.Service,
+	loginAttempts loginattempts.Service,
+	bans bans.Service,
 	tools tools.Tools,
 ) *LoginPage {
 	return &LoginPage{
-		html:        html,
-		webSessions: webSessions,
-		users:       users,
-		uuid:        tools.UUID(),
-		clock:       tools.Clock(),
+		html:          html,
+		webSessions:   webSessions,
+		users:         users,
+		identities:    identities,
+		twoFactor:     twoFactor,
+		loginAttempts: loginAttempts,
+		bans:          bans,
+		uuid:          tools.UUID(),
 	}
 }
 
@@ -61,7 +74,10 @@ func (h *LoginPage) printPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.LoginPageTmpl{})
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.LoginPageTmpl{
+		CanRegister: h.users.RegistrationMode() != users.RegistrationClosed,
+		OIDC:        h.identities.IsEnabled(),
+	})
 }
 
 func (h *LoginPage) applyLogin(w http.ResponseWriter, r *http.Request) {
@@ -69,52 +85,106 @@ func (h *LoginPage) applyLogin(w http.ResponseWriter, r *http.Request) {
 
 	tmpl.Username = r.FormValue("username")
 
-	user, err := h.users.Authenticate(r.Context(), r.FormValue("username"), secret.NewText(r.FormValue("password")))
+	attempt := &loginattempts.AttemptCmd{
+		Username:   r.FormValue("username"),
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.Header.Get("User-Agent"),
+	}
+
+	// The attempts are rejected before checking the password in order to
+	// avoid spending any hashing time on a brute-force attack.
+	err := h.loginAttempts.Reserve(r.Context(), attempt)
+	reserved := err == nil
+
+	var user *users.User
+	if reserved {
+		user, err = h.users.Authenticate(r.Context(), r.FormValue("username"), secret.NewText(r.FormValue("password")))
+	}
+
+	var attemptErr error
+	switch {
+	case !reserved, err == nil:
+		// Nothing to end yet.
+	case errors.Is(err, users.ErrInvalidUsername), errors.Is(err, users.ErrInvalidPassword):
+		attemptErr = h.loginAttempts.RegisterFailure(r.Context(), attempt)
+	default:
+		// Nothing has been guessed: the account waits for an approval or the
+		// check itself failed.
+		attemptErr = h.loginAttempts.Release(r.Context(), attempt)
+	}
+
+	if attemptErr != nil {
+		h.html.WriteHTMLErrorPage(w, r, attemptErr)
+		return
+	}
+
 	var status int
 	switch {
 	case err == nil:
 		// continue
+	case errors.Is(err, errs.ErrTooManyRequests):
+		tmpl.UsernameError = errTooManyAttemptsMsg(err)
+		status = http.StatusTooManyRequests
 	case errors.Is(err, users.ErrInvalidUsername):
 		tmpl.UsernameError = "User doesn't exists"
 		status = http.StatusBadRequest
 	case errors.Is(err, users.ErrInvalidPassword):
 		tmpl.PasswordError = "Invalid password"
 		status = http.StatusBadRequest
+	case errors.Is(err, users.ErrPendingApproval):
+		tmpl.UsernameError = "Your account is waiting for an admin approval"
+		status = http.StatusUnauthorized
 	default:
 		h.html.WriteHTMLErrorPage(w, r, err)
 		return
 	}
 
 	if err != nil {
+		tmpl.CanRegister = h.users.RegistrationMode() != users.RegistrationClosed
+		tmpl.OIDC = h.identities.IsEnabled()
 		h.html.WriteHTMLTemplate(w, r, status, &tmpl)
 		return
 	}
 
-	session, err := h.webSessions.Create(r.Context(), &websessions.CreateCmd{
-		UserID:     user.ID(),
-		UserAgent:  r.Header.Get("User-Agent"),
-		RemoteAddr: r.RemoteAddr,
-	})
+	if refuseBanned(w, r, h.html, h.bans, user) {
+		// The page is already written, a failure only leaves the attempt
+		// counted until the end of the reset window.
+		err = h.loginAttempts.Release(r.Context(), attempt)
+		if err != nil {
+			logger.LogEntrySetError(r.Context(), fmt.Errorf("failed to Release: %w", err))
+		}
+
+		return
+	}
+
+	challenged, err := logIn(w, r, h.webSessions, h.twoFactor, user, r.FormValue("remember") != "")
 	if err != nil {
-		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the websession: %w", err))
+		releaseErr := h.loginAttempts.Release(r.Context(), attempt)
+		if releaseErr != nil {
+			err = fmt.Errorf("failed to Release: %w", releaseErr)
+		}
+
+		h.html.WriteHTMLErrorPage(w, r, err)
 		return
 	}
 
-	var expirationDate time.Time
-	if r.FormValue("remember") != "" {
-		expirationDate = h.clock.Now().Add(cookieLifeTime)
+	if challenged {
+		// The previous failures are kept until the second step succeeds,
+		// otherwise restarting the login would reset the codes brute-force
+		// protection.
+		err = h.loginAttempts.Release(r.Context(), attempt)
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, err)
+		}
+
+		return
 	}
 
-	c := http.Cookie{
-		Name:     "session_token",
-		Value:    session.Token().Raw(),
-		Expires:  expirationDate,
-		Secure:   true,
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		Path:     "/",
+	err = h.loginAttempts.RegisterSuccess(r.Context(), attempt)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
 	}
-	http.SetCookie(w, &c)
 
 	h.chooseRedirection(w, r)
 }
@@ -169,3 +239,78 @@ func (h *LoginPage) chooseRedirection(w http.ResponseWriter, r *http.Request) {
 	//  		http.Redirect(w, r, "/consent?"+r.Form.Encode(), http.StatusFound)
 	//  	}
 }
+
+// errTooManyAttemptsMsg explains to the user why the login attempt have
+// been rejected.
+func errTooManyAttemptsMsg(err error) string {
+	var ierr *errs.Error
+	if !errors.As(err, &ierr) || ierr.Message() == "" {
+		return "Too many failed attempts, retry later"
+	}
+
+	msg := ierr.Message()
+
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// refuseBanned prints the reason of the ban and returns true if the user
+// is banned. It must be checked before any web session creation.
+func refuseBanned(w http.ResponseWriter, r *http.Request, htmlWriter html.Writer, bansSvc bans.Service, user *users.User) bool {
+	ban, err := bansSvc.GetActive(r.Context(), user.ID())
+	switch {
+	case err == nil:
+		htmlWriter.WriteHTMLTemplate(w, r, http.StatusForbidden, &auth.BannedPageTmpl{Ban: ban})
+		return true
+	case errors.Is(err, bans.ErrNotBanned):
+		return false
+	default:
+		htmlWriter.WriteHTMLErrorPage(w, r, err)
+		return true
+	}
+}
+
+// logIn creates the web session of an authenticated user. When the
+// two-factor authentication is needed, the user is redirected to the
+// challenge instead and true is returned: the web session is only created
+// once the second step is done.
+func logIn(
+	w http.ResponseWriter,
+	r *http.Request,
+	webSessions websessions.Service,
+	twoFactor twofactor.Service,
+	user *users.User,
+	remember bool,
+) (bool, error) {
+	needsChallenge, err := twoFactor.NeedsChallenge(r.Context(), user)
+	if err != nil {
+		return false, fmt.Errorf("failed to check the two-factor authentication: %w", err)
+	}
+
+	if needsChallenge {
+		challenge, err := twoFactor.CreateChallenge(r.Context(), &twofactor.CreateChallengeCmd{
+			User:     user,
+			Remember: remember,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to create the login challenge: %w", err)
+		}
+
+		setChallengeCookie(w, challenge)
+		http.Redirect(w, r, "/login/2fa", http.StatusFound)
+		return true, nil
+	}
+
+	session, err := webSessions.Create(r.Context(), &websessions.CreateCmd{
+		UserID:     user.ID(),
+		UserAgent:  r.Header.Get("User-Agent"),
+		RemoteAddr: r.RemoteAddr,
+		Remember:   remember,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create the websession: %w", err)
+	}
+
+	webSessions.SetCookie(w, session)
+
+	return false, nil
+}
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/logger"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+const challengeCookieName = "login_challenge"
+
+// TwoFactorLoginPage is the second step of the login for the users with the
+// two-factor authentication enabled or required by their role.
+type TwoFactorLoginPage struct {
+	webSessions   websessions.Service
+	twoFactor     twofactor.Service
+	users         users.Service
+	loginAttempts loginattempts.Service
+	bans          bans.Service
+	html          html.Writer
+}
+
+func NewTwoFactorLoginPage(
+	html html.Writer,
+	webSessions websessions.Service,
+	users users.Service,
+	twoFactor twofactor.Service,
+	loginAttempts loginattempts.Service,
+	bans bans.Service,
+	tools tools.Tools,
+) *TwoFactorLoginPage {
+	return &TwoFactorLoginPage{
+		html:          html,
+		webSessions:   webSessions,
+		users:         users,
+		twoFactor:     twoFactor,
+		loginAttempts: loginAttempts,
+		bans:          bans,
+	}
+}
+
+func (h *TwoFactorLoginPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/login/2fa", h.printPage)
+	r.Post("/login/2fa", h.applyCode)
+}
+
+func (h *TwoFactorLoginPage) printPage(w http.ResponseWriter, r *http.Request) {
+	_, user, ok := h.getChallengeAndUser(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.newTemplate(r, user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *TwoFactorLoginPage) applyCode(w http.ResponseWriter, r *http.Request) {
+	challenge, user, ok := h.getChallengeAndUser(w, r)
+	if !ok {
+		return
+	}
+
+	// The codes share the failures counter of the passwords so the account
+	// is delayed and locked the same way.
+	attempt := &loginattempts.AttemptCmd{
+		Username:   user.Username(),
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.Header.Get("User-Agent"),
+	}
+
+	err := h.loginAttempts.Reserve(r.Context(), attempt)
+	if errors.Is(err, errs.ErrTooManyRequests) {
+		tmpl, tmplErr := h.newTemplate(r, user)
+		if tmplErr != nil {
+			h.html.WriteHTMLErrorPage(w, r, tmplErr)
+			return
+		}
+
+		tmpl.CodeError = errTooManyAttemptsMsg(err)
+		h.html.WriteHTMLTemplate(w, r, http.StatusTooManyRequests, tmpl)
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	enabled, err := h.twoFactor.IsEnabled(r.Context(), user)
+	if err != nil {
+		h.writeReleasedError(w, r, attempt, err)
+		return
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = h.twoFactor.Verify(r.Context(), &twofactor.VerifyCmd{User: user, Code: r.FormValue("code")})
+	} else {
+		// The role requires the two-factor authentication and the user is
+		// enrolling.
+		recoveryCodes, err = h.twoFactor.Confirm(r.Context(), &twofactor.ConfirmCmd{User: user, Code: r.FormValue("code")})
+	}
+
+	switch {
+	case err == nil:
+		// continue
+	case errors.Is(err, errs.ErrUnauthorized), errors.Is(err, errs.ErrValidation):
+		h.handleInvalidCode(w, r, challenge, user, attempt)
+		return
+	default:
+		h.writeReleasedError(w, r, attempt, err)
+		return
+	}
+
+	err = h.twoFactor.DeleteChallenge(r.Context(), challenge)
+	if err != nil {
+		h.writeReleasedError(w, r, attempt, err)
+		return
+	}
+
+	clearChallengeCookie(w)
+
+	// The user can be banned between the two login steps.
+	if refuseBanned(w, r, h.html, h.bans, user) {
+		err = h.loginAttempts.Release(r.Context(), attempt)
+		if err != nil {
+			logger.LogEntrySetError(r.Context(), fmt.Errorf("failed to Release: %w", err))
+		}
+
+		return
+	}
+
+	err = h.loginAttempts.RegisterSuccess(r.Context(), attempt)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	session, err := h.webSessions.Create(r.Context(), &websessions.CreateCmd{
+		UserID:     user.ID(),
+		UserAgent:  r.Header.Get("User-Agent"),
+		RemoteAddr: r.RemoteAddr,
+		Remember:   challenge.Remember(),
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the websession: %w", err))
+		return
+	}
+
+	h.webSessions.SetCookie(w, session)
+
+	if recoveryCodes != nil {
+		h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.RecoveryCodesPageTmpl{Codes: recoveryCodes})
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// writeReleasedError ends an attempt which is neither a failure nor a success
+// before writing the error page.
+func (h *TwoFactorLoginPage) writeReleasedError(w http.ResponseWriter, r *http.Request, attempt *loginattempts.AttemptCmd, err error) {
+	releaseErr := h.loginAttempts.Release(r.Context(), attempt)
+	if releaseErr != nil {
+		err = fmt.Errorf("failed to Release: %w", releaseErr)
+	}
+
+	h.html.WriteHTMLErrorPage(w, r, err)
+}
+
+func (h *TwoFactorLoginPage) handleInvalidCode(
+	w http.ResponseWriter,
+	r *http.Request,
+	challenge *twofactor.Challenge,
+	user *users.User,
+	attempt *loginattempts.AttemptCmd,
+) {
+	err := h.loginAttempts.RegisterFailure(r.Context(), attempt)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	err = h.twoFactor.RegisterChallengeFailure(r.Context(), challenge)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if challenge.Attempts()+1 >= twofactor.ChallengeMaxAttempts {
+		// The challenge is deleted, the login must be restarted.
+		clearChallengeCookie(w)
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	tmpl, err := h.newTemplate(r, user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	tmpl.CodeError = "Invalid code"
+	h.html.WriteHTMLTemplate(w, r, http.StatusUnauthorized, tmpl)
+}
+
+func (h *TwoFactorLoginPage) newTemplate(r *http.Request, user *users.User) (*auth.LoginTwoFactorPageTmpl, error) {
+	enabled, err := h.twoFactor.IsEnabled(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		return &auth.LoginTwoFactorPageTmpl{}, nil
+	}
+
+	enrollment, err := h.twoFactor.Enroll(r.Context(), user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll: %w", err)
+	}
+
+	return &auth.LoginTwoFactorPageTmpl{Enrollment: enrollment}, nil
+}
+
+// getChallengeAndUser returns the pending challenge and its user. If there is
+// none, the user is redirected to the login page and false is returned.
+func (h *TwoFactorLoginPage) getChallengeAndUser(w http.ResponseWriter, r *http.Request) (*twofactor.Challenge, *users.User, bool) {
+	c, err := r.Cookie(challengeCookieName)
+	if err != nil || c.Value == "" {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, nil, false
+	}
+
+	challenge, err := h.twoFactor.GetChallenge(r.Context(), secret.NewText(c.Value))
+	if errors.Is(err, errs.ErrNotFound) {
+		clearChallengeCookie(w)
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, nil, false
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, nil, false
+	}
+
+	user, err := h.users.GetByID(r.Context(), challenge.UserID())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to users.GetByID: %w", err))
+		return nil, nil, false
+	}
+
+	if user == nil {
+		clearChallengeCookie(w)
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, nil, false
+	}
+
+	return challenge, user, true
+}
+
+func setChallengeCookie(w http.ResponseWriter, challenge *twofactor.Challenge) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     challengeCookieName,
+		Value:    challenge.Token().Raw(),
+		Path:     "/login",
+		Expires:  challenge.ExpiresAt(),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func clearChallengeCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     challengeCookieName,
+		Value:    "",
+		Path:     "/login",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_TwoFactorLoginPage(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(method string, challenge *twofactor.Challenge, code string) *http.Request {
+		r := httptest.NewRequest(method, "/login/2fa", strings.NewReader(url.Values{
+			"code": []string{code},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("User-Agent", "firefox 4.4.4.4")
+		r.RemoteAddr = httptest.DefaultRemoteAddr
+		r.AddCookie(&http.Cookie{Name: challengeCookieName, Value: challenge.Token().Raw()})
+
+		return r
+	}
+
+	t.Run("printPage without challenge redirects to the login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/login/2fa", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("printPage success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.LoginTwoFactorPageTmpl{})
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodGet, challenge, "")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("applyCode success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).WithRemember().Build()
+		webSession := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "123456"}).Return(nil).Once()
+		twoFactorMock.On("DeleteChallenge", mock.Anything, challenge).Return(nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		webSessionsMock.On("Create", mock.Anything, &websessions.CreateCmd{
+			UserID:     user.ID(),
+			UserAgent:  "firefox 4.4.4.4",
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			Remember:   true,
+		}).Return(webSession, nil).Once()
+		webSessionsMock.On("SetCookie", mock.Anything, webSession).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "123456")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/", res.Header.Get("Location"))
+	})
+
+	t.Run("applyCode with a Verify error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "123456"}).Return(errs.ErrInternal).Once()
+		loginAttemptsMock.On("Release", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, errs.ErrInternal).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "123456")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("applyCode with an enrollment displays the recovery codes", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+		webSession := websessions.NewFakeSession(t).CreatedBy(user).Build()
+		codes := []string{"abcde-12345", "fghij-67890"}
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(false, nil).Once()
+		twoFactorMock.On("Confirm", mock.Anything, &twofactor.ConfirmCmd{User: user, Code: "123456"}).Return(codes, nil).Once()
+		twoFactorMock.On("DeleteChallenge", mock.Anything, challenge).Return(nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		webSessionsMock.On("Create", mock.Anything, mock.Anything).Return(webSession, nil).Once()
+		webSessionsMock.On("SetCookie", mock.Anything, webSession).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.RecoveryCodesPageTmpl{
+			Codes: codes,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "123456")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("applyCode with an invalid code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Twice()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "000000"}).
+			Return(errs.Unauthorized(twofactor.ErrInvalidCode)).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, mock.Anything).Return(nil).Once()
+		twoFactorMock.On("RegisterChallengeFailure", mock.Anything, challenge).Return(nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnauthorized, &auth.LoginTwoFactorPageTmpl{
+			CodeError: "Invalid code",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "000000")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("applyCode with the last attempt restarts the login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).WithAttempts(twofactor.ChallengeMaxAttempts - 1).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("Verify", mock.Anything, &twofactor.VerifyCmd{User: user, Code: "000000"}).
+			Return(errs.Unauthorized(twofactor.ErrInvalidCode)).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, mock.Anything).Return(nil).Once()
+		twoFactorMock.On("RegisterChallengeFailure", mock.Anything, challenge).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "000000")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+	t.Run("applyCode with too many attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewTwoFactorLoginPage(htmlMock, webSessionsMock, usersMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+
+		// Mocks
+		twoFactorMock.On("GetChallenge", mock.Anything, challenge.Token()).Return(challenge, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).
+			Return(errs.TooManyRequests(loginattempts.ErrLocked, "this account is locked")).Once()
+		twoFactorMock.On("IsEnabled", mock.Anything, user).Return(true, nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusTooManyRequests, &auth.LoginTwoFactorPageTmpl{
+			CodeError: "This account is locked",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newRequest(http.MethodPost, challenge, "123456")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	oidcCookieName = "login_oidc"
+
+	// oidcCookieLifetime is the time given to the user to log in on the
+	// provider.
+	oidcCookieLifetime = 10 * time.Minute
+)
+
+// OIDCLoginPage logs the users in with the OpenID Connect provider, using the
+// authorization code flow with PKCE.
+type OIDCLoginPage struct {
+	webSessions websessions.Service
+	users       users.Service
+	identities  identities.Service
+	twoFactor   twofactor.Service
+	bans        bans.Service
+	html        html.Writer
+}
+
+func NewOIDCLoginPage(
+	html html.Writer,
+	webSessions websessions.Service,
+	users users.Service,
+	identities identities.Service,
+	twoFactor twofactor.Service,
+	bans bans.Service,
+	tools tools.Tools,
+) *OIDCLoginPage {
+	return &OIDCLoginPage{
+		html:        html,
+		webSessions: webSessions,
+		users:       users,
+		identities:  identities,
+		twoFactor:   twoFactor,
+		bans:        bans,
+	}
+}
+
+func (h *OIDCLoginPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/login/oidc", h.startLogin)
+	r.Get("/login/oidc/callback", h.finishLogin)
+}
+
+func (h *OIDCLoginPage) startLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.identities.IsEnabled() {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	authorization, err := h.identities.StartLogin(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	setOIDCCookie(w, authorization)
+	http.Redirect(w, r, authorization.URL, http.StatusFound)
+}
+
+func (h *OIDCLoginPage) finishLogin(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie(oidcCookieName)
+	if err != nil || c.Value == "" {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	// The state, the verifier and the nonce can't be used twice.
+	clearOIDCCookie(w)
+
+	login, err := url.ParseQuery(c.Value)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	// The provider reports the errors, like a denied consent, with an
+	// "error" parameter instead of a code.
+	query := r.URL.Query()
+	if query.Get("error") != "" ||
+		subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(login.Get("state"))) != 1 {
+		h.printError(w, r, http.StatusBadRequest, "The login with your identity provider failed")
+		return
+	}
+
+	user, err := h.identities.FinishLogin(r.Context(), &identities.FinishLoginCmd{
+		Code:     query.Get("code"),
+		Verifier: secret.NewText(login.Get("verifier")),
+		Nonce:    login.Get("nonce"),
+	})
+	switch {
+	case err == nil:
+		// continue
+	case errors.Is(err, identities.ErrInactiveUser):
+		h.printError(w, r, http.StatusUnauthorized, "Your account is not active")
+		return
+	case errors.Is(err, users.ErrRegistrationClosed):
+		h.printError(w, r, http.StatusForbidden, "The registration of new accounts is closed")
+		return
+	case errors.Is(err, errs.ErrUnauthorized), errors.Is(err, errs.ErrValidation):
+		h.printError(w, r, http.StatusUnauthorized, "The login with your identity provider failed")
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if refuseBanned(w, r, h.html, h.bans, user) {
+		return
+	}
+
+	challenged, err := logIn(w, r, h.webSessions, h.twoFactor, user, false)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if !challenged {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+func (h *OIDCLoginPage) printError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	h.html.WriteHTMLTemplate(w, r, status, &auth.LoginPageTmpl{
+		CanRegister: h.users.RegistrationMode() != users.RegistrationClosed,
+		OIDC:        true,
+		OIDCError:   msg,
+	})
+}
+
+// setOIDCCookie keeps the login secrets until the provider redirects the user
+// to the callback. The cookie must be sent with this cross-site redirection
+// so the "Lax" mode is required.
+func setOIDCCookie(w http.ResponseWriter, authorization *identities.Authorization) {
+	http.SetCookie(w, &http.Cookie{
+		Name: oidcCookieName,
+		Value: url.Values{
+			"state":    {authorization.State},
+			"nonce":    {authorization.Nonce},
+			"verifier": {authorization.Verifier.Raw()},
+		}.Encode(),
+		Path:     "/login/oidc",
+		MaxAge:   int(oidcCookieLifetime.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearOIDCCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    "",
+		Path:     "/login/oidc",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OIDCLoginPage(t *testing.T) {
+	t.Parallel()
+
+	authorization := &identities.Authorization{
+		URL:      "https://some.issuer/authorize?foo=bar",
+		State:    "some-state",
+		Verifier: secret.NewText("some-verifier"),
+		Nonce:    "some-nonce",
+	}
+
+	newCallbackRequest := func(query url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?"+query.Encode(), nil)
+		r.Header.Set("User-Agent", "firefox 4.4.4.4")
+		r.RemoteAddr = httptest.DefaultRemoteAddr
+
+		w := httptest.NewRecorder()
+		setOIDCCookie(w, authorization)
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+
+		return r
+	}
+
+	t.Run("startLogin success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		identitiesMock.On("IsEnabled").Return(true).Once()
+		identitiesMock.On("StartLogin", mock.Anything).Return(authorization, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/login/oidc", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, authorization.URL, res.Header.Get("Location"))
+
+		require.Len(t, res.Cookies(), 1)
+		cookie := res.Cookies()[0]
+		assert.Equal(t, oidcCookieName, cookie.Name)
+		assert.Equal(t, "/login/oidc", cookie.Path)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+		values, err := url.ParseQuery(cookie.Value)
+		require.NoError(t, err)
+		assert.Equal(t, "some-state", values.Get("state"))
+		assert.Equal(t, "some-verifier", values.Get("verifier"))
+		assert.Equal(t, "some-nonce", values.Get("nonce"))
+	})
+
+	t.Run("startLogin while disabled redirects to the login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		identitiesMock.On("IsEnabled").Return(false).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/login/oidc", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("finishLogin success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		webSession := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		// Mocks
+		identitiesMock.On("FinishLogin", mock.Anything, &identities.FinishLoginCmd{
+			Code:     "some-code",
+			Verifier: secret.NewText("some-verifier"),
+			Nonce:    "some-nonce",
+		}).Return(user, nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, nil).Once()
+		webSessionsMock.On("Create", mock.Anything, &websessions.CreateCmd{
+			UserID:     user.ID(),
+			UserAgent:  "firefox 4.4.4.4",
+			RemoteAddr: httptest.DefaultRemoteAddr,
+		}).Return(webSession, nil).Once()
+		webSessionsMock.On("SetCookie", mock.Anything, webSession).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"code": {"some-code"}, "state": {"some-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/", res.Header.Get("Location"))
+	})
+
+	t.Run("finishLogin with 2FA redirects to the challenge", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).Build()
+
+		// Mocks
+		identitiesMock.On("FinishLogin", mock.Anything, mock.Anything).Return(user, nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("CreateChallenge", mock.Anything, &twofactor.CreateChallengeCmd{User: user}).
+			Return(challenge, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"code": {"some-code"}, "state": {"some-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login/2fa", res.Header.Get("Location"))
+	})
+
+	t.Run("finishLogin without cookie redirects to the login", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?code=some-code&state=some-state", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("finishLogin with an invalid state", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.LoginPageTmpl{
+			OIDC:      true,
+			OIDCError: "The login with your identity provider failed",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"code": {"some-code"}, "state": {"another-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("finishLogin with a provider error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.LoginPageTmpl{
+			OIDC:      true,
+			OIDCError: "The login with your identity provider failed",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"error": {"access_denied"}, "state": {"some-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("finishLogin with an invalid code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		identitiesMock.On("FinishLogin", mock.Anything, mock.Anything).
+			Return(nil, errs.Unauthorized(identities.ErrInvalidLogin)).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnauthorized, &auth.LoginPageTmpl{
+			OIDC:      true,
+			OIDCError: "The login with your identity provider failed",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"code": {"some-code"}, "state": {"some-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("finishLogin of a new user with the registration closed", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewOIDCLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, bansMock, tools)
+
+		// Mocks
+		identitiesMock.On("FinishLogin", mock.Anything, mock.Anything).
+			Return(nil, errs.Unauthorized(users.ErrRegistrationClosed, "registration is closed")).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusForbidden, &auth.LoginPageTmpl{
+			OIDC:      true,
+			OIDCError: "The registration of new accounts is closed",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := newCallbackRequest(url.Values{"code": {"some-code"}, "state": {"some-state"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
@@ -6,8 +6,11 @@ import (
 	"net/url"
 	"strings"
 	"testing"
-	"time"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/identities"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/services/websessions"
 	"github.com/Peltoche/onlyfun/internal/tools"
@@ -30,13 +33,19 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 
 		// Mocks
 		webSessionsMock.On("GetFromReq", mock.Anything).Return(nil, nil).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		identitiesMock.On("IsEnabled").Return(false).Once()
 		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.LoginPageTmpl{})
 
 		// Run
@@ -58,8 +67,12 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 		webSession := websessions.NewFakeSession(t).Build()
@@ -87,8 +100,12 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 		userPassword := gofakeit.Password(true, true, true, false, false, 8)
@@ -100,13 +117,18 @@ func Test_LoginPage(t *testing.T) {
 			Build()
 
 		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
 		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText(userPassword)).
 			Return(user, nil).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, nil).Once()
 		webSessionsMock.On("Create", mock.Anything, &websessions.CreateCmd{
 			UserID:     user.ID(),
 			UserAgent:  "firefox 4.4.4.4",
 			RemoteAddr: httptest.DefaultRemoteAddr,
 		}).Return(webSession, nil).Once()
+		webSessionsMock.On("SetCookie", mock.Anything, webSession).Once()
 
 		// Run
 		w := httptest.NewRecorder()
@@ -126,9 +148,6 @@ func Test_LoginPage(t *testing.T) {
 		defer res.Body.Close()
 		assert.Equal(t, http.StatusFound, res.StatusCode)
 		assert.Equal(t, "/", res.Header.Get("Location"))
-		assert.Len(t, res.Cookies(), 1)
-		assert.Equal(t, "session_token", res.Cookies()[0].Name)
-		assert.Empty(t, res.Cookies()[0].Expires)
 	})
 
 	t.Run("ApplyLogin success with the remember option", func(t *testing.T) {
@@ -137,11 +156,14 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
-		now := time.Now().UTC()
 		userPassword := gofakeit.Password(true, true, true, false, false, 8)
 		user := users.NewFakeUser(t).WithPassword(userPassword).Build()
 		webSession := websessions.NewFakeSession(t).
@@ -151,14 +173,19 @@ func Test_LoginPage(t *testing.T) {
 			Build()
 
 		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
 		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText(userPassword)).
 			Return(user, nil).Once()
+		loginAttemptsMock.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, nil).Once()
 		webSessionsMock.On("Create", mock.Anything, &websessions.CreateCmd{
 			UserID:     user.ID(),
 			UserAgent:  "firefox 4.4.4.4",
 			RemoteAddr: httptest.DefaultRemoteAddr,
+			Remember:   true,
 		}).Return(webSession, nil).Once()
-		tools.ClockMock.On("Now").Return(now).Once()
+		webSessionsMock.On("SetCookie", mock.Anything, webSession).Once()
 
 		// Run
 		w := httptest.NewRecorder()
@@ -179,9 +206,143 @@ func Test_LoginPage(t *testing.T) {
 		defer res.Body.Close()
 		assert.Equal(t, http.StatusFound, res.StatusCode)
 		assert.Equal(t, "/", res.Header.Get("Location"))
+	})
+
+	t.Run("ApplyLogin with 2FA redirects to the challenge", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		userPassword := gofakeit.Password(true, true, true, false, false, 8)
+		user := users.NewFakeUser(t).WithPassword(userPassword).Build()
+		challenge := twofactor.NewFakeChallenge(t).WithUser(user).WithRemember().Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText(userPassword)).
+			Return(user, nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(true, nil).Once()
+		twoFactorMock.On("CreateChallenge", mock.Anything, &twofactor.CreateChallengeCmd{
+			User:     user,
+			Remember: true,
+		}).Return(challenge, nil).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{
+			"username": []string{user.Username()},
+			"password": []string{userPassword},
+			"remember": []string{"on"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login/2fa", res.Header.Get("Location"))
 		assert.Len(t, res.Cookies(), 1)
-		assert.Equal(t, "session_token", res.Cookies()[0].Name)
-		assert.WithinDuration(t, now.Add(cookieLifeTime), res.Cookies()[0].Expires, 2*time.Second)
+		assert.Equal(t, challengeCookieName, res.Cookies()[0].Name)
+		assert.Equal(t, challenge.Token().Raw(), res.Cookies()[0].Value)
+	})
+
+	t.Run("ApplyLogin with a NeedsChallenge error", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		userPassword := gofakeit.Password(true, true, true, false, false, 8)
+		user := users.NewFakeUser(t).WithPassword(userPassword).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText(userPassword)).
+			Return(user, nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(nil, errs.NotFound(bans.ErrNotBanned)).Once()
+		twoFactorMock.On("NeedsChallenge", mock.Anything, user).Return(false, errs.ErrInternal).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrInternal)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{
+			"username": []string{user.Username()},
+			"password": []string{userPassword},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("ApplyLogin with a banned user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		userPassword := gofakeit.Password(true, true, true, false, false, 8)
+		user := users.NewFakeUser(t).WithPassword(userPassword).Build()
+		ban := bans.NewFakeBan(t).WithUser(user).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText(userPassword)).
+			Return(user, nil).Once()
+		bansMock.On("GetActive", mock.Anything, user.ID()).Return(ban, nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusForbidden, &auth.BannedPageTmpl{
+			Ban: ban,
+		}).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{
+			"username": []string{user.Username()},
+			"password": []string{userPassword},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 	})
 
 	t.Run("ApplyLogin with an invalid username", func(t *testing.T) {
@@ -190,14 +351,26 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 
 		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
 		usersMock.On("Authenticate", mock.Anything, "invalid-username", secret.NewText("some-password")).
 			Return(nil, users.ErrInvalidUsername).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   "invalid-username",
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		identitiesMock.On("IsEnabled").Return(false).Once()
 		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.LoginPageTmpl{
 			Username:      "invalid-username",
 			UsernameError: "User doesn't exists",
@@ -230,15 +403,27 @@ func Test_LoginPage(t *testing.T) {
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
 
 		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
 		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-invalid-password")).
 			Return(nil, users.ErrInvalidPassword).Once()
+		loginAttemptsMock.On("RegisterFailure", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		identitiesMock.On("IsEnabled").Return(false).Once()
 		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.LoginPageTmpl{
 			Username:      user.Username(),
 			UsernameError: "",
@@ -265,21 +450,78 @@ func Test_LoginPage(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	})
 
+	t.Run("ApplyLogin with an account waiting for approval", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).WithStatus(users.Pending).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-password")).
+			Return(nil, errs.Unauthorized(users.ErrPendingApproval)).Once()
+		loginAttemptsMock.On("Release", mock.Anything, mock.Anything).Return(nil).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationApproval).Once()
+		identitiesMock.On("IsEnabled").Return(false).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnauthorized, &auth.LoginPageTmpl{
+			Username:      user.Username(),
+			UsernameError: "Your account is waiting for an admin approval",
+			CanRegister:   true,
+		})
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{
+			"username": []string{user.Username()},
+			"password": []string{"some-password"},
+		}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
 	t.Run("ApplyLogin with an authentication error", func(t *testing.T) {
 		t.Parallel()
 
 		tools := tools.NewMock(t)
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
 		htmlMock := html.NewMock(t)
-		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, tools)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
 
 		// Data
 		user := users.NewFakeUser(t).Build()
 
 		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, mock.Anything).Return(nil).Once()
 		usersMock.On("Authenticate", mock.Anything, user.Username(), secret.NewText("some-invalid-password")).
 			Return(nil, errs.ErrInternal).Once()
+		loginAttemptsMock.On("Release", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(nil).Once()
 		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, errs.ErrInternal)
 
 		// Run
@@ -301,4 +543,53 @@ func Test_LoginPage(t *testing.T) {
 		defer res.Body.Close()
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	})
+
+	t.Run("ApplyLogin with too many attempts", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		identitiesMock := identities.NewMockService(t)
+		twoFactorMock := twofactor.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		bansMock := bans.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewLoginPage(htmlMock, webSessionsMock, usersMock, identitiesMock, twoFactorMock, loginAttemptsMock, bansMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+
+		// Mocks
+		loginAttemptsMock.On("Reserve", mock.Anything, &loginattempts.AttemptCmd{
+			Username:   user.Username(),
+			RemoteAddr: httptest.DefaultRemoteAddr,
+			UserAgent:  "firefox 4.4.4.4",
+		}).Return(errs.TooManyRequests(loginattempts.ErrTooManyAttempts, "too many failed attempts, retry in 4s")).Once()
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		identitiesMock.On("IsEnabled").Return(false).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusTooManyRequests, &auth.LoginPageTmpl{
+			Username:      user.Username(),
+			UsernameError: "Too many failed attempts, retry in 4s",
+		})
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{
+			"username": []string{user.Username()},
+			"password": []string{"some-password"},
+		}.Encode()))
+		r.RemoteAddr = httptest.DefaultRemoteAddr
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("User-Agent", "firefox 4.4.4.4")
+
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
 }
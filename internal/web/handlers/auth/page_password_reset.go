@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+const errInvalidLinkMsg = "This link is invalid or has expired"
+
+type PasswordResetPage struct {
+	html          html.Writer
+	recovery      recovery.Service
+	loginAttempts loginattempts.Service
+}
+
+func NewPasswordResetPage(
+	html html.Writer,
+	recovery recovery.Service,
+	loginAttempts loginattempts.Service,
+	tools tools.Tools,
+) *PasswordResetPage {
+	return &PasswordResetPage{
+		html:          html,
+		recovery:      recovery,
+		loginAttempts: loginAttempts,
+	}
+}
+
+func (h *PasswordResetPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/password-reset/request", h.printRequestPage)
+	r.Post("/password-reset/request", h.postRequest)
+	r.Get("/password-reset", h.printResetPage)
+	r.Post("/password-reset", h.postReset)
+}
+
+func (h *PasswordResetPage) printRequestPage(w http.ResponseWriter, r *http.Request) {
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.ForgotPasswordPageTmpl{})
+}
+
+func (h *PasswordResetPage) postRequest(w http.ResponseWriter, r *http.Request) {
+	tmpl := auth.ForgotPasswordPageTmpl{
+		Email: r.FormValue("email"),
+	}
+
+	err := h.loginAttempts.ThrottleReset(r.Context(), &loginattempts.ResetRequestCmd{
+		Email:      users.NormalizeEmail(tmpl.Email),
+		RemoteAddr: r.RemoteAddr,
+	})
+	switch {
+	case err == nil:
+		// continue
+	case errors.Is(err, errs.ErrTooManyRequests):
+		tmpl.EmailError = errTooManyAttemptsMsg(err)
+		h.html.WriteHTMLTemplate(w, r, http.StatusTooManyRequests, &tmpl)
+		return
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.EmailError = "Invalid e-mail"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, &tmpl)
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to throttle the password reset: %w", err))
+		return
+	}
+
+	err = h.recovery.RequestReset(r.Context(), &recovery.RequestResetCmd{
+		Email: tmpl.Email,
+	})
+	switch {
+	case err == nil:
+		tmpl.Sent = true
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.EmailError = "Invalid e-mail"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, &tmpl)
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to request a password reset: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &tmpl)
+}
+
+func (h *PasswordResetPage) printResetPage(w http.ResponseWriter, r *http.Request) {
+	tmpl := auth.ResetPasswordPageTmpl{
+		Token: secret.NewText(r.URL.Query().Get("token")),
+	}
+
+	err := h.recovery.CheckResetToken(r.Context(), tmpl.Token)
+	switch {
+	case err == nil:
+		h.html.WriteHTMLTemplate(w, r, http.StatusOK, &tmpl)
+	case errors.Is(err, recovery.ErrInvalidToken):
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &auth.ResetPasswordPageTmpl{TokenError: errInvalidLinkMsg})
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to CheckResetToken: %w", err))
+	}
+}
+
+func (h *PasswordResetPage) postReset(w http.ResponseWriter, r *http.Request) {
+	tmpl := auth.ResetPasswordPageTmpl{
+		Token: secret.NewText(r.FormValue("token")),
+	}
+
+	password := secret.NewText(r.FormValue("password"))
+	confirm := secret.NewText(r.FormValue("confirm"))
+
+	switch {
+	case len(password.Raw()) < users.SecretMinLength:
+		tmpl.PasswordError = fmt.Sprintf("must be at least %d characters long", users.SecretMinLength)
+	case confirm != password:
+		tmpl.ConfirmError = "not identical"
+	}
+
+	if tmpl.PasswordError != "" || tmpl.ConfirmError != "" {
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &tmpl)
+		return
+	}
+
+	err := h.recovery.ResetPassword(r.Context(), &recovery.ResetPasswordCmd{
+		Token:       tmpl.Token,
+		NewPassword: password,
+	})
+	switch {
+	case err == nil:
+		// continue
+	case errors.Is(err, recovery.ErrInvalidToken):
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &auth.ResetPasswordPageTmpl{TokenError: errInvalidLinkMsg})
+		return
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.PasswordError = "Invalid password"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, &tmpl)
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to reset the password: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/login", http.StatusFound)
+}
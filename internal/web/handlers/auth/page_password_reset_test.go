@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_PasswordResetPage(t *testing.T) {
+	t.Parallel()
+
+	postForm := func(path string, values url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return r
+	}
+
+	t.Run("printRequestPage success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.ForgotPasswordPageTmpl{}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/password-reset/request", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postRequest success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("ThrottleReset", mock.Anything, &loginattempts.ResetRequestCmd{
+			Email:      "foo@example.com",
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(nil).Once()
+		recoveryMock.On("RequestReset", mock.Anything, &recovery.RequestResetCmd{
+			Email: "foo@example.com",
+		}).Return(nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.ForgotPasswordPageTmpl{
+			Email: "foo@example.com",
+			Sent:  true,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset/request", url.Values{"email": []string{"foo@example.com"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postRequest with an invalid email", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("ThrottleReset", mock.Anything, &loginattempts.ResetRequestCmd{
+			Email:      "not-an-email",
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(nil).Once()
+		recoveryMock.On("RequestReset", mock.Anything, &recovery.RequestResetCmd{
+			Email: "not-an-email",
+		}).Return(errs.Validation(assert.AnError)).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity, &auth.ForgotPasswordPageTmpl{
+			Email:      "not-an-email",
+			EmailError: "Invalid e-mail",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset/request", url.Values{"email": []string{"not-an-email"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postRequest with too many requests", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		loginAttemptsMock.On("ThrottleReset", mock.Anything, &loginattempts.ResetRequestCmd{
+			Email:      "foo@example.com",
+			RemoteAddr: "192.0.2.1:1234",
+		}).Return(errs.TooManyRequests(loginattempts.ErrTooManyAttempts, "too many password reset requests, retry later")).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusTooManyRequests, &auth.ForgotPasswordPageTmpl{
+			Email:      "Foo@example.com",
+			EmailError: "Too many password reset requests, retry later",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset/request", url.Values{"email": []string{"Foo@example.com"}})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printResetPage success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		recoveryMock.On("CheckResetToken", mock.Anything, secret.NewText("some-token")).Return(nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.ResetPasswordPageTmpl{
+			Token: secret.NewText("some-token"),
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/password-reset?token=some-token", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printResetPage with an invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		recoveryMock.On("CheckResetToken", mock.Anything, secret.NewText("some-token")).
+			Return(errs.BadRequest(recovery.ErrInvalidToken)).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.ResetPasswordPageTmpl{
+			TokenError: errInvalidLinkMsg,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/password-reset?token=some-token", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postReset success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		recoveryMock.On("ResetPassword", mock.Anything, &recovery.ResetPasswordCmd{
+			Token:       secret.NewText("some-token"),
+			NewPassword: secret.NewText("some-new-secret"),
+		}).Return(nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset", url.Values{
+			"token":    []string{"some-token"},
+			"password": []string{"some-new-secret"},
+			"confirm":  []string{"some-new-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("postReset with a different confirmation", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.ResetPasswordPageTmpl{
+			Token:        secret.NewText("some-token"),
+			ConfirmError: "not identical",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset", url.Values{
+			"token":    []string{"some-token"},
+			"password": []string{"some-new-secret"},
+			"confirm":  []string{"some-other-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postReset with an invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		recoveryMock := recovery.NewMockService(t)
+		loginAttemptsMock := loginattempts.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewPasswordResetPage(htmlMock, recoveryMock, loginAttemptsMock, tools)
+
+		// Mocks
+		recoveryMock.On("ResetPassword", mock.Anything, &recovery.ResetPasswordCmd{
+			Token:       secret.NewText("some-token"),
+			NewPassword: secret.NewText("some-new-secret"),
+		}).Return(errs.BadRequest(recovery.ErrInvalidToken)).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.ResetPasswordPageTmpl{
+			TokenError: errInvalidLinkMsg,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm("/password-reset", url.Values{
+			"token":    []string{"some-token"},
+			"password": []string{"some-new-secret"},
+			"confirm":  []string{"some-new-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterPage lets the visitors create their own account when the
+// registration is not closed or when they have an invitation code.
+type RegisterPage struct {
+	html        html.Writer
+	users       users.Service
+	invitations invitations.Service
+}
+
+func NewRegisterPage(
+	html html.Writer,
+	users users.Service,
+	invitations invitations.Service,
+	tools tools.Tools,
+) *RegisterPage {
+	return &RegisterPage{
+		html:        html,
+		users:       users,
+		invitations: invitations,
+	}
+}
+
+func (h *RegisterPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/register", h.printPage)
+	r.Post("/register", h.postForm)
+}
+
+func (h *RegisterPage) printPage(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+
+	mode := h.users.RegistrationMode()
+	if mode == users.RegistrationClosed && code == "" {
+		h.html.WriteHTMLErrorPage(w, r, errs.NotFound(users.ErrRegistrationClosed))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.RegisterPageTmpl{
+		Code:       code,
+		Approval:   mode == users.RegistrationApproval,
+		InviteOnly: mode == users.RegistrationClosed,
+	})
+}
+
+func (h *RegisterPage) postForm(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+
+	mode := h.users.RegistrationMode()
+	if mode == users.RegistrationClosed && code == "" {
+		h.html.WriteHTMLErrorPage(w, r, errs.NotFound(users.ErrRegistrationClosed))
+		return
+	}
+
+	tmpl := auth.RegisterPageTmpl{
+		Username:   r.FormValue("username"),
+		Code:       code,
+		Approval:   mode == users.RegistrationApproval,
+		InviteOnly: mode == users.RegistrationClosed,
+	}
+
+	password := secret.NewText(r.FormValue("password"))
+	confirm := secret.NewText(r.FormValue("confirm"))
+
+	switch {
+	case len(password.Raw()) < users.SecretMinLength:
+		tmpl.PasswordError = fmt.Sprintf("must be at least %d characters long", users.SecretMinLength)
+	case confirm != password:
+		tmpl.ConfirmError = "not identical"
+	}
+
+	if tmpl.PasswordError != "" || tmpl.ConfirmError != "" {
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &tmpl)
+		return
+	}
+
+	var user *users.User
+	var err error
+	if code != "" {
+		user, err = h.invitations.Redeem(r.Context(), &invitations.RedeemCmd{
+			Code:     code,
+			Username: tmpl.Username,
+			Password: password,
+		})
+	} else {
+		user, err = h.users.Register(r.Context(), &users.RegisterCmd{
+			Username: tmpl.Username,
+			Password: password,
+		})
+	}
+
+	switch {
+	case errors.Is(err, invitations.ErrInvalidCode):
+		tmpl.CodeError = "Invalid or expired invitation code"
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &tmpl)
+		return
+	case errors.Is(err, users.ErrUsernameTaken):
+		tmpl.UsernameError = "Username already taken"
+		h.html.WriteHTMLTemplate(w, r, http.StatusBadRequest, &tmpl)
+		return
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.UsernameError = "Only letters, digits and dashes, up to 20 characters"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, &tmpl)
+		return
+	case err != nil:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to register the user: %w", err))
+		return
+	}
+
+	if user.Status() == users.Pending {
+		h.html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.RegisterPageTmpl{
+			Username: user.Username(),
+			Approval: true,
+			Pending:  true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/login", http.StatusFound)
+}
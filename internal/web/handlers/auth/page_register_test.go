@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_RegisterPage(t *testing.T) {
+	t.Parallel()
+
+	postForm := func(values url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return r
+	}
+
+	t.Run("printPage success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationApproval).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.RegisterPageTmpl{
+			Approval: true,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/register", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("printPage with the registration closed", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, errs.ErrNotFound) && assert.ErrorIs(t, err, users.ErrRegistrationClosed)
+		})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/register", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm success with the open mode", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Data
+		newUser := users.NewFakeUser(t).WithUsername("some-username").Build()
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		usersMock.On("Register", mock.Anything, &users.RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("some-secret"),
+		}).Return(newUser, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("postForm success with the approval mode", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Data
+		newUser := users.NewFakeUser(t).WithUsername("some-username").WithStatus(users.Pending).Build()
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationApproval).Once()
+		usersMock.On("Register", mock.Anything, &users.RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("some-secret"),
+		}).Return(newUser, nil).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.RegisterPageTmpl{
+			Username: "some-username",
+			Approval: true,
+			Pending:  true,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm with the registration closed", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLErrorPage", mock.Anything, mock.Anything, mock.Anything).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm with an invalid confirmation", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.RegisterPageTmpl{
+			Username:     "some-username",
+			ConfirmError: "not identical",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"not-the-same-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm with a password too short", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.RegisterPageTmpl{
+			Username:      "some-username",
+			PasswordError: "must be at least 8 characters long",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"short"},
+			"confirm":  []string{"short"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm with a taken username", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationOpen).Once()
+		usersMock.On("Register", mock.Anything, &users.RegisterCmd{
+			Username: "some-username",
+			Password: secret.NewText("some-secret"),
+		}).Return(nil, errs.BadRequest(users.ErrUsernameTaken)).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.RegisterPageTmpl{
+			Username:      "some-username",
+			UsernameError: "Username already taken",
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("printPage with the registration closed and an invitation code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusOK, &auth.RegisterPageTmpl{
+			Code:       "some-code",
+			InviteOnly: true,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/register?code=some-code", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+
+	t.Run("postForm success with an invitation code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Data
+		inviter := users.NewFakeUser(t).Build()
+		newUser := users.NewFakeUser(t).WithUsername("some-username").CreatedBy(inviter).Build()
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		invitationsMock.On("Redeem", mock.Anything, &invitations.RedeemCmd{
+			Code:     "some-code",
+			Username: "some-username",
+			Password: secret.NewText("some-secret"),
+		}).Return(newUser, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+			"code":     []string{"some-code"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+
+	t.Run("postForm with an invalid invitation code", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		usersMock := users.NewMockService(t)
+		invitationsMock := invitations.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		handler := NewRegisterPage(htmlMock, usersMock, invitationsMock, tools)
+
+		// Mocks
+		usersMock.On("RegistrationMode").Return(users.RegistrationClosed).Once()
+		invitationsMock.On("Redeem", mock.Anything, &invitations.RedeemCmd{
+			Code:     "some-code",
+			Username: "some-username",
+			Password: secret.NewText("some-secret"),
+		}).Return(nil, errs.BadRequest(invitations.ErrInvalidCode)).Once()
+		htmlMock.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest, &auth.RegisterPageTmpl{
+			Username:   "some-username",
+			Code:       "some-code",
+			CodeError:  "Invalid or expired invitation code",
+			InviteOnly: true,
+		}).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := postForm(url.Values{
+			"username": []string{"some-username"},
+			"password": []string{"some-secret"},
+			"confirm":  []string{"some-secret"},
+			"code":     []string{"some-code"},
+		})
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+	})
+}
@@ -54,6 +54,11 @@ func (a *Authenticator) GetUserAndSession(w http.ResponseWriter, r *http.Request
 		return nil, nil, ErrNotAuthenticated
 	}
 
+	err = a.webSessions.Refresh(w, r, currentSession)
+	if err != nil {
+		return nil, nil, errs.Internal(fmt.Errorf("failed to websessions.Refresh: %w", err))
+	}
+
 	return user, currentSession, nil
 }
 
@@ -27,6 +27,7 @@ func Test_Utils_Authenticator(t *testing.T) {
 
 		webSessionsMock.On("GetFromReq", mock.Anything, mock.Anything).Return(session, nil).Once()
 		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/foo", nil)
@@ -40,6 +41,28 @@ func Test_Utils_Authenticator(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	})
 
+	t.Run("getUserAndSession with a refresh error", func(t *testing.T) {
+		webSessionsMock := websessions.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		htmlMock := html.NewMock(t)
+		auth := NewAuthenticator(webSessionsMock, usersMock, htmlMock)
+
+		user := users.NewFakeUser(t).Build()
+		session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+		webSessionsMock.On("GetFromReq", mock.Anything, mock.Anything).Return(session, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		webSessionsMock.On("Refresh", mock.Anything, mock.Anything, session).Return(fmt.Errorf("some-error")).Once()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		resUser, resSession, err := auth.GetUserAndSession(w, r)
+		assert.Nil(t, resUser)
+		assert.Nil(t, resSession)
+		require.ErrorIs(t, err, errs.ErrInternal)
+		require.ErrorContains(t, err, "some-error")
+	})
+
 	t.Run("getUserAndSession with a websession error", func(t *testing.T) {
 		webSessionsMock := websessions.NewMockService(t)
 		usersMock := users.NewMockService(t)
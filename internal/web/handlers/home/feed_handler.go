@@ -0,0 +1,229 @@
+package home
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/logger"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/feeds"
+	"github.com/go-chi/chi/v5"
+)
+
+const feedSize = 30
+
+type feedFormat struct {
+	contentType string
+	write       func(w io.Writer, feed *feeds.Feed) error
+}
+
+var (
+	rssFormat  = feedFormat{contentType: feeds.RSSContentType, write: feeds.WriteRSS}
+	atomFormat = feedFormat{contentType: feeds.AtomContentType, write: feeds.WriteAtom}
+)
+
+// FeedHandler serves the RSS and Atom feeds of the freshly listed posts.
+type FeedHandler struct {
+	baseURL string
+	posts   posts.Service
+	users   users.Service
+	medias  medias.Service
+}
+
+func NewFeedHandler(
+	cfg Config,
+	posts posts.Service,
+	users users.Service,
+	medias medias.Service,
+	tools tools.Tools,
+) *FeedHandler {
+	return &FeedHandler{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		posts:   posts,
+		users:   users,
+		medias:  medias,
+	}
+}
+
+func (h *FeedHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	for _, prefix := range []string{"", "/tag/{tag}", "/u/{username}"} {
+		r.Get(prefix+"/feed.rss", h.serveFeed(rssFormat))
+		r.Get(prefix+"/feed.atom", h.serveFeed(atomFormat))
+	}
+}
+
+func (h *FeedHandler) serveFeed(format feedFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		baseURL := h.baseURL
+
+		feed := &feeds.Feed{
+			Title:       "OnlyFun",
+			Description: "The latest posts",
+			Link:        baseURL + "/fresh",
+			Self:        baseURL + r.URL.Path,
+		}
+
+		cmd := &posts.GetFeedCmd{
+			Feed:  posts.Fresh,
+			Tag:   chi.URLParam(r, "tag"),
+			Limit: feedSize,
+		}
+
+		if cmd.Tag != "" {
+			if !posts.TagRegexp.MatchString(cmd.Tag) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			feed.Title = "OnlyFun - #" + cmd.Tag
+			feed.Description = "The latest posts tagged #" + cmd.Tag
+			feed.Link = baseURL + "/tag/" + cmd.Tag + "/fresh"
+		}
+
+		if username := chi.URLParam(r, "username"); username != "" {
+			author, err := h.users.GetByUsername(r.Context(), username)
+			if errors.Is(err, errs.ErrNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if err != nil {
+				writeFeedError(w, r, fmt.Errorf("failed to GetByUsername: %w", err))
+				return
+			}
+
+			cmd.Author = author
+			feed.Title = "OnlyFun - " + author.Username()
+			feed.Description = "The latest posts of " + author.Username()
+			feed.Link = baseURL + "/u/" + author.Username()
+		}
+
+		version, err := h.posts.GetFeedVersion(r.Context(), &posts.GetFeedVersionCmd{
+			Tag:    cmd.Tag,
+			Author: cmd.Author,
+		})
+		if err != nil {
+			writeFeedError(w, r, fmt.Errorf("failed to GetFeedVersion: %w", err))
+			return
+		}
+
+		// The conditional requests are answered from the feed version alone,
+		// this way the polling clients don't load the posts.
+		etag := fmt.Sprintf(`W/"%d-%d-%d"`, version.Count, version.LastPostID, version.LastListedAt.Unix())
+		modTime := version.LastListedAt.Truncate(time.Second)
+
+		w.Header().Set("ETag", etag)
+		if !modTime.IsZero() {
+			w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+		}
+
+		if isNotModified(r, etag, modTime) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		postList, _, err := h.posts.GetFeed(r.Context(), cmd)
+		if err != nil {
+			writeFeedError(w, r, fmt.Errorf("failed to GetFeed: %w", err))
+			return
+		}
+
+		feed.Updated = version.LastListedAt
+		feed.Entries = make([]feeds.Entry, len(postList))
+		usernames := map[uuid.UUID]string{}
+
+		for i, post := range postList {
+			username, ok := usernames[post.CreatedBy()]
+			if !ok {
+				author, err := h.users.GetByID(r.Context(), post.CreatedBy())
+				if err != nil {
+					writeFeedError(w, r, fmt.Errorf("failed to GetByID for the author %q: %w", post.CreatedBy(), err))
+					return
+				}
+
+				username = author.Username()
+				usernames[post.CreatedBy()] = username
+			}
+
+			media, err := h.medias.GetMetadata(r.Context(), post.FileID())
+			if err != nil {
+				writeFeedError(w, r, fmt.Errorf("failed to GetMetadata for the post %d: %w", post.ID(), err))
+				return
+			}
+
+			// The posts appear in the feed once listed by the moderation.
+			published := post.CreatedAt()
+			if post.ListedAt() != nil {
+				published = *post.ListedAt()
+			}
+
+			feed.Entries[i] = feeds.Entry{
+				ID:        baseURL + "/posts/" + strconv.FormatUint(uint64(post.ID()), 10),
+				Title:     post.Title(),
+				Author:    username,
+				Published: published,
+				Tags:      post.Tags(),
+				Media: feeds.Media{
+					URL:      baseURL + "/medias/" + string(post.FileID()),
+					Mimetype: media.Mimetype(),
+					Size:     media.Size(),
+				},
+			}
+		}
+
+		buf := bytes.NewBuffer(nil)
+		err = format.write(buf, feed)
+		if err != nil {
+			writeFeedError(w, r, fmt.Errorf("failed to write the feed: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", format.contentType)
+
+		http.ServeContent(w, r, "", modTime, bytes.NewReader(buf.Bytes()))
+	}
+}
+
+// isNotModified returns true if the client already has the given version of
+// the feed, according to the If-None-Match or else the If-Modified-Since
+// header.
+func isNotModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modTime.IsZero() {
+		return false
+	}
+
+	return !modTime.After(since)
+}
+
+func writeFeedError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.LogEntrySetError(r.Context(), err)
+	w.WriteHeader(http.StatusInternalServerError)
+}
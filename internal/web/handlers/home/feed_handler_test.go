@@ -0,0 +1,203 @@
+package home
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/medias"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/feeds"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FeedHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RSS feed success", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		postsMock := posts.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		mediasMock := medias.NewMockService(t)
+		handler := NewFeedHandler(Config{BaseURL: "https://onlyfun.example"}, postsMock, usersMock, mediasMock, tools)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		media := medias.NewFakeFileMeta(t).Build()
+		post := posts.NewFakePost(t).WithMedia(media).CreatedBy(user).Build()
+
+		// Mocks
+		postsMock.On("GetFeedVersion", mock.Anything, &posts.GetFeedVersionCmd{}).
+			Return(&posts.FeedVersion{Count: 1, LastPostID: post.ID(), LastListedAt: post.CreatedAt()}, nil).Once()
+		postsMock.On("GetFeed", mock.Anything, &posts.GetFeedCmd{
+			Feed:  posts.Fresh,
+			Limit: feedSize,
+		}).Return([]posts.Post{*post}, nil, nil).Once()
+		usersMock.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+		mediasMock.On("GetMetadata", mock.Anything, media.ID()).Return(media, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		// The links must not depend on the headers given by the client.
+		r.Host = "attacker.example"
+		r.Header.Set("X-Forwarded-Proto", "http")
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, feeds.RSSContentType, res.Header.Get("Content-Type"))
+		assert.NotContains(t, w.Body.String(), "attacker.example")
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+		assert.NotEmpty(t, res.Header.Get("Last-Modified"))
+		assert.Contains(t, w.Body.String(), "https://onlyfun.example/medias/"+string(media.ID()))
+	})
+
+	t.Run("Atom feed with a matching ETag", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		postsMock := posts.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		mediasMock := medias.NewMockService(t)
+		handler := NewFeedHandler(Config{BaseURL: "https://onlyfun.example"}, postsMock, usersMock, mediasMock, tools)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+
+		// Mocks
+		postsMock.On("GetFeedVersion", mock.Anything, &posts.GetFeedVersionCmd{Tag: "cats"}).
+			Return(&posts.FeedVersion{}, nil).Twice()
+		// The second request is answered without loading the posts.
+		postsMock.On("GetFeed", mock.Anything, &posts.GetFeedCmd{
+			Feed:  posts.Fresh,
+			Tag:   "cats",
+			Limit: feedSize,
+		}).Return([]posts.Post{}, nil, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tag/cats/feed.atom", nil)
+		srv.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, feeds.AtomContentType, res.Header.Get("Content-Type"))
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/tag/cats/feed.atom", nil)
+		r.Header.Set("If-None-Match", res.Header.Get("ETag"))
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res = w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	})
+
+	t.Run("RSS feed with an If-Modified-Since header", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		postsMock := posts.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		mediasMock := medias.NewMockService(t)
+		handler := NewFeedHandler(Config{BaseURL: "https://onlyfun.example"}, postsMock, usersMock, mediasMock, tools)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+
+		listedAt := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+		// Mocks
+		postsMock.On("GetFeedVersion", mock.Anything, &posts.GetFeedVersionCmd{}).
+			Return(&posts.FeedVersion{Count: 3, LastPostID: 42, LastListedAt: listedAt}, nil).Twice()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		r.Header.Set("If-Modified-Since", listedAt.Format(http.TimeFormat))
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+		assert.Equal(t, listedAt.Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+
+		// An older post listed later moves the feed date.
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		r.Header.Set("If-Modified-Since", listedAt.Add(-time.Hour).Format(http.TimeFormat))
+
+		postsMock.On("GetFeed", mock.Anything, &posts.GetFeedCmd{
+			Feed:  posts.Fresh,
+			Limit: feedSize,
+		}).Return([]posts.Post{}, nil, nil).Once()
+
+		srv.ServeHTTP(w, r)
+
+		res = w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Feed of an unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		postsMock := posts.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		mediasMock := medias.NewMockService(t)
+		handler := NewFeedHandler(Config{BaseURL: "https://onlyfun.example"}, postsMock, usersMock, mediasMock, tools)
+
+		// Mocks
+		usersMock.On("GetByUsername", mock.Anything, "unknown").Return(nil, errs.NotFound(errs.ErrNotFound)).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/u/unknown/feed.rss", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("Feed with an invalid tag", func(t *testing.T) {
+		t.Parallel()
+
+		tools := tools.NewMock(t)
+		postsMock := posts.NewMockService(t)
+		usersMock := users.NewMockService(t)
+		mediasMock := medias.NewMockService(t)
+		handler := NewFeedHandler(Config{BaseURL: "https://onlyfun.example"}, postsMock, usersMock, mediasMock, tools)
+
+		// Run
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tag/Not_Valid/feed.rss", nil)
+		srv := chi.NewRouter()
+		handler.Register(srv, nil)
+		srv.ServeHTTP(w, r)
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,185 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// AvatarPage lets the user upload or generate its avatar and the moderators
+// reset the offensive ones.
+type AvatarPage struct {
+	users users.Service
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+}
+
+func NewAvatarPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *AvatarPage {
+	return &AvatarPage{
+		html:  html,
+		auth:  auth,
+		users: users,
+		roles: roles,
+	}
+}
+
+func (h *AvatarPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/settings/avatar", h.printPage)
+	r.Post("/settings/avatar", h.uploadAvatar)
+	r.Post("/settings/avatar/generate", h.generateAvatar)
+	r.Post("/u/{username}/avatar/reset", h.resetAvatar)
+}
+
+func (h *AvatarPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, h.newTemplate(user))
+}
+
+func (h *AvatarPage) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to retrieve the FormFile: %w", err))
+		return
+	}
+	defer file.Close()
+
+	err = h.users.UploadAvatar(r.Context(), &users.UploadAvatarCmd{
+		UserID:  user.ID(),
+		Content: file,
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/settings/avatar", http.StatusFound)
+	case errors.Is(err, users.ErrInvalidImage):
+		tmpl := h.newTemplate(user)
+		tmpl.Error = invalidImageMsg(err)
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to UploadAvatar: %w", err))
+	}
+}
+
+func (h *AvatarPage) generateAvatar(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.users.GenerateAvatar(r.Context(), &users.GenerateAvatarCmd{
+		UserID: user.ID(),
+		Style:  users.AvatarStyle(r.FormValue("style")),
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/settings/avatar", http.StatusFound)
+	case errors.Is(err, errs.ErrValidation):
+		tmpl := h.newTemplate(user)
+		tmpl.Error = "Unknown style"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GenerateAvatar: %w", err))
+	}
+}
+
+func (h *AvatarPage) resetAvatar(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.roles.IsAuthorized(user, perms.Moderation) {
+		h.html.WriteHTMLErrorPage(w, r, errs.Unauthorized(fmt.Errorf("user %q doesn't have the authorization %q", user.ID(), perms.Moderation)))
+		return
+	}
+
+	profile, err := h.users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByUsername: %w", err))
+		return
+	}
+
+	err = h.users.ResetAvatar(r.Context(), &users.ResetAvatarCmd{
+		Moderator: user,
+		UserID:    profile.ID(),
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to ResetAvatar: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/u/"+profile.Username(), http.StatusFound)
+}
+
+// invalidImageMsg explains to the user why the uploaded image has been
+// rejected.
+func invalidImageMsg(err error) string {
+	var ierr *errs.Error
+	if !errors.As(err, &ierr) || ierr.Message() == "" {
+		return "Invalid image"
+	}
+
+	msg := ierr.Message()
+
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+func (h *AvatarPage) newTemplate(user *users.User) *home.AvatarPageTmpl {
+	return &home.AvatarPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Avatar: user.Avatar(),
+		Styles: []users.AvatarStyle{users.MaleAvatar, users.FemaleAvatar},
+	}
+}
+
+// getUser returns the authenticated user. If there is none, the response is
+// written and false is returned.
+func (h *AvatarPage) getUser(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, false
+	}
+
+	return user, true
+}
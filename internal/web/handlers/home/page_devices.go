@@ -0,0 +1,131 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// DevicesPage lists the sessions of the user and lets them be revoked.
+type DevicesPage struct {
+	webSessions websessions.Service
+	roles       perms.Service
+	auth        *auth.Authenticator
+	html        html.Writer
+}
+
+func NewDevicesPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	webSessions websessions.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *DevicesPage {
+	return &DevicesPage{
+		html:        html,
+		auth:        auth,
+		webSessions: webSessions,
+		roles:       roles,
+	}
+}
+
+func (h *DevicesPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/devices", h.printPage)
+	r.Post("/devices/revoke-others", h.revokeOthers)
+	r.Post("/devices/{sessionID}/revoke", h.revokeSession)
+}
+
+func (h *DevicesPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, session, ok := h.getUserAndSession(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.webSessions.GetAllForUser(r.Context(), user.ID(), nil)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAllForUser: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &home.DevicesPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Sessions: sortByLastSeen(sessions),
+		Current:  session,
+	})
+}
+
+func (h *DevicesPage) revokeSession(w http.ResponseWriter, r *http.Request) {
+	user, _, ok := h.getUserAndSession(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.webSessions.DeleteByID(r.Context(), user.ID(), chi.URLParam(r, "sessionID"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke the session: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/devices", http.StatusFound)
+}
+
+func (h *DevicesPage) revokeOthers(w http.ResponseWriter, r *http.Request) {
+	_, session, ok := h.getUserAndSession(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.webSessions.DeleteOthers(r.Context(), session)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke the other sessions: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/devices", http.StatusFound)
+}
+
+// getUserAndSession returns the authenticated user and its session. If there
+// is none, the response is written and false is returned.
+func (h *DevicesPage) getUserAndSession(w http.ResponseWriter, r *http.Request) (*users.User, *websessions.Session, bool) {
+	user, session, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, nil, false
+	}
+
+	return user, session, true
+}
+
+// sortByLastSeen puts the most recently used sessions first.
+func sortByLastSeen(sessions []websessions.Session) []websessions.Session {
+	slices.SortFunc(sessions, func(a, b websessions.Session) int {
+		return b.LastSeenAt().Compare(a.LastSeenAt())
+	})
+
+	return sessions
+}
@@ -0,0 +1,152 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/recovery"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// EmailPage lets the user set, verify and remove its e-mail.
+type EmailPage struct {
+	recovery recovery.Service
+	users    users.Service
+	roles    perms.Service
+	auth     *auth.Authenticator
+	html     html.Writer
+}
+
+func NewEmailPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	recovery recovery.Service,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *EmailPage {
+	return &EmailPage{
+		html:     html,
+		auth:     auth,
+		recovery: recovery,
+		users:    users,
+		roles:    roles,
+	}
+}
+
+func (h *EmailPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/settings/email", h.printPage)
+	r.Post("/settings/email", h.requestEmail)
+	r.Post("/settings/email/remove", h.removeEmail)
+	r.Get("/settings/email/verify", h.verifyEmail)
+}
+
+func (h *EmailPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, h.newTemplate(user))
+}
+
+func (h *EmailPage) requestEmail(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl := h.newTemplate(user)
+	tmpl.NewEmail = r.FormValue("email")
+
+	err := h.recovery.RequestEmail(r.Context(), &recovery.RequestEmailCmd{
+		User:  user,
+		Email: tmpl.NewEmail,
+	})
+	switch {
+	case err == nil:
+		tmpl.Sent = true
+	case errors.Is(err, errs.ErrValidation):
+		tmpl.EmailError = "Invalid e-mail"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+		return
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to request the email: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *EmailPage) removeEmail(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.users.UpdateEmail(r.Context(), &users.UpdateEmailCmd{
+		UserID: user.ID(),
+		Email:  "",
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to remove the email: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/settings/email", http.StatusFound)
+}
+
+// verifyEmail is opened from the link sent by e-mail. The token is enough to
+// identify the user so it works even from a device without any session.
+func (h *EmailPage) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	err := h.recovery.VerifyEmail(r.Context(), secret.NewText(r.URL.Query().Get("token")))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to verify the email: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/settings/email", http.StatusFound)
+}
+
+func (h *EmailPage) newTemplate(user *users.User) *home.EmailPageTmpl {
+	return &home.EmailPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Email: user.Email(),
+	}
+}
+
+// getUser returns the authenticated user. If there is none, the response is
+// written and false is returned.
+func (h *EmailPage) getUser(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,174 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/clock"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// InvitationsPage lets the users create and revoke their own invitation
+// codes, within the quota of their role.
+type InvitationsPage struct {
+	baseURL     string
+	invitations invitations.Service
+	roles       perms.Service
+	auth        *auth.Authenticator
+	html        html.Writer
+	clock       clock.Clock
+}
+
+func NewInvitationsPage(
+	cfg Config,
+	html html.Writer,
+	auth *auth.Authenticator,
+	invitations invitations.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *InvitationsPage {
+	return &InvitationsPage{
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+		html:        html,
+		auth:        auth,
+		invitations: invitations,
+		roles:       roles,
+		clock:       tools.Clock(),
+	}
+}
+
+func (h *InvitationsPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/invitations", h.printPage)
+	r.Post("/invitations", h.createInvitation)
+	r.Post("/invitations/{code}/revoke", h.revokeInvitation)
+}
+
+func (h *InvitationsPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderPage(w, r, user, http.StatusOK, "")
+}
+
+func (h *InvitationsPage) createInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	maxUses, lifetime := parseInvitationForm(r)
+
+	_, err := h.invitations.Create(r.Context(), &invitations.CreateCmd{
+		CreatedBy: user,
+		MaxUses:   maxUses,
+		Lifetime:  lifetime,
+	})
+	if errors.Is(err, errs.ErrValidation) || errors.Is(err, errs.ErrBadRequest) {
+		h.renderPage(w, r, user, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the invitation: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/invitations", http.StatusFound)
+}
+
+func (h *InvitationsPage) revokeInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.invitations.GetByCode(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByCode: %w", err))
+		return
+	}
+
+	err = h.invitations.Revoke(r.Context(), &invitations.RevokeCmd{
+		User:       user,
+		Invitation: invitation,
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to revoke the invitation: %w", err))
+		return
+	}
+
+	http.Redirect(w, r, "/invitations", http.StatusFound)
+}
+
+func (h *InvitationsPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, status int, errMsg string) {
+	invitationList, err := h.invitations.GetAllCreatedBy(r.Context(), user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetAllCreatedBy: %w", err))
+		return
+	}
+
+	remaining, err := h.invitations.RemainingQuota(r.Context(), user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the remaining quota: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, status, &home.InvitationsPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Invitations: invitationList,
+		Remaining:   remaining,
+		BaseURL:     h.baseURL,
+		Now:         h.clock.Now(),
+		Error:       errMsg,
+	})
+}
+
+// getUser returns the authenticated user. If there is none, the response is
+// written and false is returned.
+func (h *InvitationsPage) getUser(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// parseInvitationForm reads the number of uses and the lifetime in days of a
+// new invitation. The invalid values are left to the validation.
+func parseInvitationForm(r *http.Request) (int, time.Duration) {
+	maxUses, _ := strconv.Atoi(r.FormValue("max_uses"))
+	days, _ := strconv.Atoi(r.FormValue("lifetime"))
+
+	return maxUses, time.Duration(days) * 24 * time.Hour
+}
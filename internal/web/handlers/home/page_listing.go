@@ -1,23 +1,23 @@
 package home
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
 	"github.com/Peltoche/onlyfun/internal/tools"
-	"github.com/Peltoche/onlyfun/internal/tools/errs"
 	"github.com/Peltoche/onlyfun/internal/tools/router"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
 	"github.com/Peltoche/onlyfun/internal/web/html"
 	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/misc"
 	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
 	"github.com/go-chi/chi/v5"
 )
@@ -25,53 +25,36 @@ import (
 const postPagination = 50
 
 type ListingPage struct {
-	roles      perms.Service
-	auth       *auth.Authenticator
-	posts      posts.Service
-	medias     medias.Service
-	html       html.Writer
-	l          *sync.Mutex
-	latestPost *posts.Post
-	uuid       uuid.Service
+	roles    perms.Service
+	auth     *auth.Authenticator
+	posts    posts.Service
+	medias   medias.Service
+	votes    votes.Service
+	sections sections.Service
+	html     html.Writer
+	uuid     uuid.Service
 }
 
 func NewListingPage(
-	ctx context.Context,
 	html html.Writer,
 	posts posts.Service,
 	roles perms.Service,
 	auth *auth.Authenticator,
 	medias medias.Service,
+	votes votes.Service,
+	sections sections.Service,
 	tools tools.Tools,
-) (*ListingPage, error) {
-	latest, err := posts.GetLatestPost(ctx)
-	if err != nil && !errors.Is(err, errs.ErrNotFound) {
-		return nil, fmt.Errorf("failed to GetLatestPost: %w", err)
-	}
-
-	handler := &ListingPage{
-		html:       html,
-		uuid:       uuid.NewProvider(),
-		posts:      posts,
-		roles:      roles,
-		medias:     medias,
-		auth:       auth,
-		l:          new(sync.Mutex),
-		latestPost: latest,
-	}
-
-	postChan := posts.SuscribeToNewPost()
-
-	go func() {
-		for post := range postChan {
-			p := post
-			handler.l.Lock()
-			handler.latestPost = &p
-			handler.l.Unlock()
-		}
-	}()
-
-	return handler, nil
+) *ListingPage {
+	return &ListingPage{
+		html:     html,
+		uuid:     uuid.NewProvider(),
+		posts:    posts,
+		roles:    roles,
+		medias:   medias,
+		votes:    votes,
+		sections: sections,
+		auth:     auth,
+	}
 }
 
 func (h *ListingPage) Register(r chi.Router, mids *router.Middlewares) {
@@ -80,35 +63,92 @@ func (h *ListingPage) Register(r chi.Router, mids *router.Middlewares) {
 	}
 
 	r.Get("/", h.printPage)
+	r.Get("/{feed:hot|trending|fresh}", h.printPage)
+	r.Get("/tag/{tag}", h.printPage)
+	r.Get("/tag/{tag}/{feed:hot|trending|fresh}", h.printPage)
 	r.Get("/medias/{fileID}", h.serveMedia)
 }
 
 func (h *ListingPage) printPage(w http.ResponseWriter, r *http.Request) {
-	var err error
-	var posts []posts.Post
-
 	user, _, err := h.auth.GetUserAndSession(w, r)
 	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
 		h.html.WriteHTMLErrorPage(w, r, err)
 		return
 	}
 
-	if h.latestPost != nil {
-		posts, err = h.posts.GetPosts(r.Context(), h.latestPost.ID(), postPagination)
+	feed := posts.Hot
+	if feedName := chi.URLParam(r, "feed"); feedName != "" {
+		feed = posts.Feed(feedName)
+	}
+
+	tag := chi.URLParam(r, "tag")
+	if tag != "" && !posts.TagRegexp.MatchString(tag) {
+		h.html.WriteHTMLTemplate(w, r, http.StatusNotFound, &misc.NotFoundPageTmpl{})
+		return
+	}
+
+	cursor, err := parseFeedCursor(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sectionList, err := h.sections.GetAll(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the sections: %w", err))
+		return
+	}
+
+	posts, next, err := h.posts.GetFeed(r.Context(), &posts.GetFeedCmd{
+		Feed:   feed,
+		Tag:    tag,
+		Cursor: cursor,
+		Limit:  postPagination,
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetFeed: %w", err))
+		return
+	}
+
+	userVotes := map[uint]votes.Value{}
+	if user != nil {
+		postIDs := make([]uint, len(posts))
+		for i, post := range posts {
+			postIDs[i] = post.ID()
+		}
+
+		userVotes, err = h.votes.GetUserVotes(r.Context(), user, postIDs)
 		if err != nil {
-			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetPosts: %w", err))
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetUserVotes: %w", err))
 			return
 		}
 	}
 
-	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &home.ListingPageTmpl{
+	tmpl := &home.ListingPageTmpl{
 		Header: &partials.HeaderTmpl{
 			User:        user,
 			CanModerate: user != nil && h.roles.IsAuthorized(user, perms.Moderation),
 			PostButton:  true,
 		},
-		Posts: posts,
-	})
+		Feed:     feed,
+		Tag:      tag,
+		Sections: sectionList,
+		Posts:    posts,
+		Votes:    userVotes,
+		CanVote:  user == nil || h.roles.IsAuthorized(user, perms.VotePost),
+	}
+
+	if next != nil {
+		tmpl.NextPage = tmpl.FeedURL(feed) + "?" + formatFeedCursor(next).Encode()
+	}
+
+	// The infinite scroll only needs the next posts, not the whole page.
+	if cursor != nil && r.Header.Get("HX-Request") == "true" {
+		h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl.PostsList())
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
 }
 
 func (h *ListingPage) serveMedia(w http.ResponseWriter, r *http.Request) {
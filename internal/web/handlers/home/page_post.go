@@ -0,0 +1,347 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/misc"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+type PostPage struct {
+	baseURL  string
+	posts    posts.Service
+	votes    votes.Service
+	comments comments.Service
+	users    users.Service
+	roles    perms.Service
+	auth     *auth.Authenticator
+	html     html.Writer
+}
+
+func NewPostPage(
+	cfg Config,
+	html html.Writer,
+	auth *auth.Authenticator,
+	posts posts.Service,
+	votes votes.Service,
+	comments comments.Service,
+	users users.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *PostPage {
+	return &PostPage{
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		html:     html,
+		posts:    posts,
+		votes:    votes,
+		comments: comments,
+		users:    users,
+		roles:    roles,
+		auth:     auth,
+	}
+}
+
+func (h *PostPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/posts/{postID}", h.printPage)
+	r.Post("/posts/{postID}/comments", h.createComment)
+	r.Post("/comments/{commentID}/remove", h.removeComment)
+}
+
+func (h *PostPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	post, err := h.getPost(r)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	// The posts not listed yet are only visible by their author and the
+	// moderators.
+	if post.Status() != posts.Listed &&
+		(user == nil || (user.ID() != post.CreatedBy() && !h.roles.IsAuthorized(user, perms.Moderation))) {
+		h.html.WriteHTMLErrorPage(w, r, errs.NotFound(fmt.Errorf("post %d not listed", post.ID())))
+		return
+	}
+
+	h.renderPage(w, r, user, post, http.StatusOK, &commentForm{})
+}
+
+func (h *PostPage) createComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	post, err := h.getListedPost(r)
+	if errors.Is(err, errs.ErrNotFound) {
+		h.html.WriteHTMLTemplate(w, r, http.StatusNotFound, &misc.NotFoundPageTmpl{})
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	form := &commentForm{content: r.FormValue("content")}
+
+	var parent *comments.Comment
+	if parentID := r.FormValue("parent"); parentID != "" {
+		id, err := strconv.ParseUint(parentID, 10, 0)
+		if err != nil {
+			form.err = "Invalid parent comment"
+			h.renderPage(w, r, user, post, http.StatusBadRequest, form)
+			return
+		}
+
+		parent, err = h.comments.GetByID(ctx, uint(id))
+		if errors.Is(err, errs.ErrNotFound) {
+			form.err = "The replied comment doesn't exist"
+			h.renderPage(w, r, user, post, http.StatusNotFound, form)
+			return
+		}
+
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the parent comment %d: %w", id, err))
+			return
+		}
+	}
+
+	comment, err := h.comments.Create(ctx, &comments.CreateCmd{
+		User:    user,
+		Post:    post,
+		Parent:  parent,
+		Content: form.content,
+	})
+	switch {
+	case err == nil:
+		http.Redirect(w, r, fmt.Sprintf("/posts/%d#comment-%d", post.ID(), comment.ID()), http.StatusFound)
+	case errors.Is(err, errs.ErrValidation):
+		form.err = "A comment must contain between 1 and 2000 characters"
+		h.renderPage(w, r, user, post, http.StatusUnprocessableEntity, form)
+	case errors.Is(err, errs.ErrBadRequest):
+		form.err = invalidCommentMsg(err)
+		h.renderPage(w, r, user, post, http.StatusBadRequest, form)
+	case errors.Is(err, errs.ErrNotFound):
+		form.err = invalidCommentMsg(err)
+		h.renderPage(w, r, user, post, http.StatusNotFound, form)
+	default:
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the comment: %w", err))
+	}
+}
+
+// commentForm is the comment to print back in the form after an error.
+type commentForm struct {
+	content string
+	err     string
+}
+
+// renderPage prints the post with its comments. The form is filled with the
+// given comment, if any.
+func (h *PostPage) renderPage(w http.ResponseWriter, r *http.Request, user *users.User, post *posts.Post, status int, form *commentForm) {
+	ctx := r.Context()
+
+	author, err := h.users.GetByID(ctx, post.CreatedBy())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the post author: %w", err))
+		return
+	}
+
+	threads, err := h.comments.GetPostThreads(ctx, post)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetPostThreads: %w", err))
+		return
+	}
+
+	authors := map[uuid.UUID]*users.User{author.ID(): author}
+	err = h.fetchAuthors(r, threads, authors)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	var vote votes.Value
+	if user != nil {
+		userVotes, err := h.votes.GetUserVotes(ctx, user, []uint{post.ID()})
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetUserVotes: %w", err))
+			return
+		}
+
+		vote = userVotes[post.ID()]
+	}
+
+	h.html.WriteHTMLTemplate(w, r, status, &home.PostPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: user != nil && h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Post:     post,
+		Author:   author,
+		URL:      h.baseURL + "/posts/" + strconv.FormatUint(uint64(post.ID()), 10),
+		ImageURL: h.baseURL + "/medias/" + string(post.FileID()),
+		VoteButtons: &partials.VoteButtonsTmpl{
+			Post:    post,
+			Vote:    vote,
+			CanVote: user == nil || h.roles.IsAuthorized(user, perms.VotePost),
+		},
+		User:                user,
+		Threads:             threads,
+		Authors:             authors,
+		CanComment:          user != nil && h.roles.IsAuthorized(user, perms.WriteComment),
+		CanModerateComments: user != nil && h.roles.IsAuthorized(user, perms.ModerateComment),
+		Content:             form.content,
+		CommentError:        form.err,
+	})
+}
+
+func (h *PostPage) removeComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	commentID, err := strconv.ParseUint(chi.URLParam(r, "commentID"), 10, 0)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.comments.GetByID(ctx, uint(commentID))
+	if errors.Is(err, errs.ErrNotFound) {
+		h.html.WriteHTMLTemplate(w, r, http.StatusNotFound, &misc.NotFoundPageTmpl{})
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	// The authors delete their own comments, the moderators need to give a reason.
+	if comment.CreatedBy() == user.ID() {
+		err = h.comments.Delete(ctx, &comments.DeleteCmd{
+			User:    user,
+			Comment: comment,
+		})
+	} else {
+		_, err = h.comments.Moderate(ctx, &comments.ModerationCmd{
+			User:    user,
+			Comment: comment,
+			Reason:  r.FormValue("reason"),
+		})
+	}
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to remove the comment %d: %w", commentID, err))
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/posts/%d#comment-%d", comment.PostID(), comment.ID()), http.StatusFound)
+}
+
+// invalidCommentMsg explains to the user why the comment has been rejected.
+func invalidCommentMsg(err error) string {
+	var ierr *errs.Error
+	if !errors.As(err, &ierr) || ierr.Message() == "" {
+		return "Invalid comment"
+	}
+
+	msg := ierr.Message()
+
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// getPost returns the post from the url params whatever its status.
+func (h *PostPage) getPost(r *http.Request) (*posts.Post, error) {
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 0)
+	if err != nil {
+		return nil, errs.NotFound(fmt.Errorf("invalid post id: %w", err))
+	}
+
+	post, err := h.posts.GetByID(r.Context(), uint(postID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetByID: %w", err)
+	}
+
+	return post, nil
+}
+
+// getListedPost returns the post from the url params. The posts not listed
+// are reported as not found.
+func (h *PostPage) getListedPost(r *http.Request) (*posts.Post, error) {
+	post, err := h.getPost(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Status() != posts.Listed {
+		return nil, errs.NotFound(fmt.Errorf("post %d not listed", post.ID()))
+	}
+
+	return post, nil
+}
+
+func (h *PostPage) fetchAuthors(r *http.Request, threads []comments.Thread, authors map[uuid.UUID]*users.User) error {
+	for _, thread := range threads {
+		authorID := thread.Comment.CreatedBy()
+
+		if _, ok := authors[authorID]; !ok {
+			author, err := h.users.GetByID(r.Context(), authorID)
+			if err != nil {
+				return fmt.Errorf("failed to get the comment author %q: %w", authorID, err)
+			}
+
+			authors[authorID] = author
+		}
+
+		err := h.fetchAuthors(r, thread.Replies, authors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
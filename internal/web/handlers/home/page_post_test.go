@@ -0,0 +1,238 @@
+package home
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type postPageMocks struct {
+	Posts       *posts.MockService
+	Votes       *votes.MockService
+	Comments    *comments.MockService
+	Users       *users.MockService
+	Perms       *perms.MockService
+	WebSessions *websessions.MockService
+	HTML        *html.Mock
+}
+
+func newPostPageTest(t *testing.T) (*chi.Mux, *postPageMocks) {
+	t.Helper()
+
+	m := &postPageMocks{
+		Posts:       posts.NewMockService(t),
+		Votes:       votes.NewMockService(t),
+		Comments:    comments.NewMockService(t),
+		Users:       users.NewMockService(t),
+		Perms:       perms.NewMockService(t),
+		WebSessions: websessions.NewMockService(t),
+		HTML:        html.NewMock(t),
+	}
+
+	authenticator := auth.NewAuthenticator(m.WebSessions, m.Users, m.HTML)
+	handler := NewPostPage(Config{BaseURL: "https://onlyfun.example"}, m.HTML, authenticator,
+		m.Posts, m.Votes, m.Comments, m.Users, m.Perms, tools.NewMock(t))
+
+	srv := chi.NewRouter()
+	handler.Register(srv, nil)
+
+	return srv, m
+}
+
+// expectUser sets the mocks authenticating the given user.
+func (m *postPageMocks) expectUser(t *testing.T, user *users.User) {
+	t.Helper()
+
+	session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+
+	m.WebSessions.On("GetFromReq", mock.Anything).Return(session, nil).Once()
+	m.Users.On("GetByID", mock.Anything, user.ID()).Return(user, nil).Once()
+	m.WebSessions.On("Refresh", mock.Anything, mock.Anything, session).Return(nil).Once()
+}
+
+// expectPostPage sets the mocks used to render the page of a post without
+// comments.
+func (m *postPageMocks) expectPostPage(user, author *users.User, post *posts.Post) {
+	m.Users.On("GetByID", mock.Anything, post.CreatedBy()).Return(author, nil).Once()
+	m.Comments.On("GetPostThreads", mock.Anything, post).Return([]comments.Thread{}, nil).Once()
+	m.Votes.On("GetUserVotes", mock.Anything, user, []uint{post.ID()}).Return(map[uint]votes.Value{}, nil).Once()
+	m.Perms.On("IsAuthorized", user, mock.Anything).Return(true)
+}
+
+func newCommentRequest(post *posts.Post, values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/posts/"+formatID(post.ID())+"/comments", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func Test_PostPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("createComment success", func(t *testing.T) {
+		t.Parallel()
+
+		srv, m := newPostPageTest(t)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+		comment := comments.NewFakeComment(t).WithPost(post).Build()
+
+		// Mocks
+		m.expectUser(t, user)
+		m.Posts.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		m.Comments.On("Create", mock.Anything, &comments.CreateCmd{
+			User:    user,
+			Post:    post,
+			Content: "some-content",
+		}).Return(comment, nil).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newCommentRequest(post, url.Values{"content": []string{"some-content"}}))
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/posts/"+formatID(post.ID())+"#comment-"+formatID(comment.ID()), res.Header.Get("Location"))
+	})
+
+	t.Run("createComment with an empty content", func(t *testing.T) {
+		t.Parallel()
+
+		srv, m := newPostPageTest(t)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		author := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).CreatedBy(author).Build()
+
+		// Mocks
+		m.expectUser(t, user)
+		m.Posts.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		m.Comments.On("Create", mock.Anything, &comments.CreateCmd{
+			User:    user,
+			Post:    post,
+			Content: "",
+		}).Return(nil, errs.Validation(assert.AnError)).Once()
+		m.expectPostPage(user, author, post)
+		m.HTML.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusUnprocessableEntity,
+			mock.MatchedBy(func(tmpl *home.PostPageTmpl) bool {
+				return tmpl.Post == post && tmpl.Content == "" &&
+					tmpl.CommentError == "A comment must contain between 1 and 2000 characters"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newCommentRequest(post, url.Values{"content": []string{""}}))
+	})
+
+	t.Run("createComment with a removed parent", func(t *testing.T) {
+		t.Parallel()
+
+		srv, m := newPostPageTest(t)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		author := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).CreatedBy(author).Build()
+		parent := comments.NewFakeComment(t).WithPost(post).WithStatus(comments.Deleted).Build()
+
+		// Mocks
+		m.expectUser(t, user)
+		m.Posts.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		m.Comments.On("GetByID", mock.Anything, parent.ID()).Return(parent, nil).Once()
+		m.Comments.On("Create", mock.Anything, &comments.CreateCmd{
+			User:    user,
+			Post:    post,
+			Parent:  parent,
+			Content: "some-content",
+		}).Return(nil, errs.BadRequest(comments.ErrCommentRemoved, "the parent comment have been removed")).Once()
+		m.expectPostPage(user, author, post)
+		m.HTML.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusBadRequest,
+			mock.MatchedBy(func(tmpl *home.PostPageTmpl) bool {
+				return tmpl.Content == "some-content" && tmpl.CommentError == "The parent comment have been removed"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newCommentRequest(post, url.Values{
+			"content": []string{"some-content"},
+			"parent":  []string{formatID(parent.ID())},
+		}))
+	})
+
+	t.Run("createComment with an unknown parent", func(t *testing.T) {
+		t.Parallel()
+
+		srv, m := newPostPageTest(t)
+
+		// Data
+		user := users.NewFakeUser(t).Build()
+		author := users.NewFakeUser(t).Build()
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).CreatedBy(author).Build()
+
+		// Mocks
+		m.expectUser(t, user)
+		m.Posts.On("GetByID", mock.Anything, post.ID()).Return(post, nil).Once()
+		m.Comments.On("GetByID", mock.Anything, uint(42)).Return(nil, errs.NotFound(errs.ErrNotFound)).Once()
+		m.expectPostPage(user, author, post)
+		m.HTML.On("WriteHTMLTemplate", mock.Anything, mock.Anything, http.StatusNotFound,
+			mock.MatchedBy(func(tmpl *home.PostPageTmpl) bool {
+				return tmpl.Content == "some-content" && tmpl.CommentError == "The replied comment doesn't exist"
+			})).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newCommentRequest(post, url.Values{
+			"content": []string{"some-content"},
+			"parent":  []string{"42"},
+		}))
+	})
+
+	t.Run("createComment without being authenticated", func(t *testing.T) {
+		t.Parallel()
+
+		srv, m := newPostPageTest(t)
+
+		// Data
+		post := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+		// Mocks
+		m.WebSessions.On("GetFromReq", mock.Anything).Return(nil, websessions.ErrMissingSessionToken).Once()
+
+		// Run
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newCommentRequest(post, url.Values{"content": []string{"some-content"}}))
+
+		// Asserts
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/login", res.Header.Get("Location"))
+	})
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,145 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+const profilePagination = 20
+
+type ProfilePage struct {
+	users users.Service
+	posts posts.Service
+	votes votes.Service
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+}
+
+func NewProfilePage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	users users.Service,
+	posts posts.Service,
+	votes votes.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *ProfilePage {
+	return &ProfilePage{
+		html:  html,
+		users: users,
+		posts: posts,
+		votes: votes,
+		roles: roles,
+		auth:  auth,
+	}
+}
+
+func (h *ProfilePage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/u/{username}", h.printPage)
+}
+
+func (h *ProfilePage) printPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	profile, err := h.users.GetByUsername(ctx, chi.URLParam(r, "username"))
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByUsername: %w", err))
+		return
+	}
+
+	var beforeID uint64
+	if rawBefore := r.URL.Query().Get("before"); rawBefore != "" {
+		beforeID, err = strconv.ParseUint(rawBefore, 10, 0)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.posts.GetUserStats(ctx, profile)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetUserStats: %w", err))
+		return
+	}
+
+	postList, err := h.posts.GetUserPosts(ctx, &posts.GetUserPostsCmd{
+		User:     profile,
+		Status:   posts.Listed,
+		BeforeID: uint(beforeID),
+		Limit:    profilePagination,
+	})
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetUserPosts: %w", err))
+		return
+	}
+
+	userVotes := map[uint]votes.Value{}
+	if user != nil && len(postList) > 0 {
+		postIDs := make([]uint, len(postList))
+		for i, post := range postList {
+			postIDs[i] = post.ID()
+		}
+
+		userVotes, err = h.votes.GetUserVotes(ctx, user, postIDs)
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetUserVotes: %w", err))
+			return
+		}
+	}
+
+	canModerate := user != nil && h.roles.IsAuthorized(user, perms.Moderation)
+
+	tmpl := &home.ProfilePageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: canModerate,
+			PostButton:  true,
+		},
+		Profile: profile,
+		Stats:   stats,
+		// The posts waiting for or rejected by the moderation are private.
+		ShowPrivateStats: canModerate || (user != nil && user.ID() == profile.ID()),
+		Posts:            postList,
+		Votes:            userVotes,
+		CanVote:          user == nil || h.roles.IsAuthorized(user, perms.VotePost),
+		CanManageUser:    user != nil && h.roles.IsAuthorized(user, perms.ManageUsers),
+		CanResetAvatar:   canModerate && user.ID() != profile.ID(),
+	}
+
+	if len(postList) == profilePagination {
+		tmpl.NextPage = fmt.Sprintf("/u/%s?before=%d", profile.Username(), postList[len(postList)-1].ID())
+	}
+
+	// The infinite scroll only needs the next posts, not the whole page.
+	if beforeID != 0 && r.Header.Get("HX-Request") == "true" {
+		h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl.PostsList())
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
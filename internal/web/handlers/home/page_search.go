@@ -0,0 +1,102 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/search"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+const searchPagination = 20
+
+type SearchPage struct {
+	search search.Service
+	roles  perms.Service
+	auth   *auth.Authenticator
+	html   html.Writer
+}
+
+func NewSearchPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	search search.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *SearchPage {
+	return &SearchPage{
+		html:   html,
+		search: search,
+		roles:  roles,
+		auth:   auth,
+	}
+}
+
+func (h *SearchPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/search", h.printPage)
+}
+
+func (h *SearchPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+
+	page := uint64(1)
+	if rawPage := r.URL.Query().Get("page"); rawPage != "" {
+		page, err = strconv.ParseUint(rawPage, 10, 0)
+		if err != nil || page == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	tmpl := &home.SearchPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: user != nil && h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Query: query,
+	}
+
+	if query != "" {
+		res, hasMore, err := h.search.SearchPosts(r.Context(), &search.SearchCmd{
+			Query:  query,
+			Offset: uint(page-1) * searchPagination,
+			Limit:  searchPagination,
+		})
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to SearchPosts: %w", err))
+			return
+		}
+
+		tmpl.Posts = res
+
+		if hasMore {
+			tmpl.NextPage = "/search?" + url.Values{
+				"q":    []string{query},
+				"page": []string{strconv.FormatUint(page+1, 10)},
+			}.Encode()
+		}
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
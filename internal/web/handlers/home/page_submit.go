@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
 	"github.com/Peltoche/onlyfun/internal/tools"
 	"github.com/Peltoche/onlyfun/internal/tools/router"
 	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
@@ -17,10 +19,11 @@ import (
 )
 
 type SubmitPage struct {
-	posts posts.Service
-	roles perms.Service
-	auth  *auth.Authenticator
-	html  html.Writer
+	posts    posts.Service
+	roles    perms.Service
+	sections sections.Service
+	auth     *auth.Authenticator
+	html     html.Writer
 }
 
 func NewSubmitPage(
@@ -28,13 +31,15 @@ func NewSubmitPage(
 	auth *auth.Authenticator,
 	posts posts.Service,
 	roles perms.Service,
+	sections sections.Service,
 	tools tools.Tools,
 ) *SubmitPage {
 	return &SubmitPage{
-		html:  html,
-		posts: posts,
-		roles: roles,
-		auth:  auth,
+		html:     html,
+		posts:    posts,
+		roles:    roles,
+		sections: sections,
+		auth:     auth,
 	}
 }
 
@@ -59,12 +64,19 @@ func (h *SubmitPage) printPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sectionList, err := h.sections.GetAll(r.Context())
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the sections: %w", err))
+		return
+	}
+
 	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &home.SubmitPageTmpl{
 		Header: &partials.HeaderTmpl{
 			User:        user,
 			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
 			PostButton:  true,
 		},
+		Sections: sectionList,
 	})
 }
 
@@ -88,10 +100,14 @@ func (h *SubmitPage) submitPost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	// The tags are the checked sections followed by the free text ones.
+	tags := slices.Concat(r.Form["sections"], posts.ParseTags(r.FormValue("tags")))
+
 	_, err = h.posts.Create(r.Context(), &posts.CreateCmd{
 		Title:     r.FormValue("title"),
 		Media:     file,
 		CreatedBy: user,
+		Tags:      tags,
 	})
 	if err != nil {
 		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to create the post: %w", err))
@@ -0,0 +1,210 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/home"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+// TwoFactorPage lets the user enable or disable the two-factor
+// authentication.
+type TwoFactorPage struct {
+	twoFactor twofactor.Service
+	roles     perms.Service
+	auth      *auth.Authenticator
+	html      html.Writer
+}
+
+func NewTwoFactorPage(
+	html html.Writer,
+	auth *auth.Authenticator,
+	twoFactor twofactor.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *TwoFactorPage {
+	return &TwoFactorPage{
+		html:      html,
+		auth:      auth,
+		twoFactor: twoFactor,
+		roles:     roles,
+	}
+}
+
+func (h *TwoFactorPage) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Get("/settings/2fa", h.printPage)
+	r.Post("/settings/2fa/enroll", h.enroll)
+	r.Post("/settings/2fa/confirm", h.confirm)
+	r.Post("/settings/2fa/disable", h.disable)
+}
+
+func (h *TwoFactorPage) printPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.newTemplate(r, user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *TwoFactorPage) enroll(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.newTemplate(r, user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	tmpl.Enrollment, err = h.twoFactor.Enroll(r.Context(), user)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to enroll: %w", err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *TwoFactorPage) confirm(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactor.Confirm(r.Context(), &twofactor.ConfirmCmd{
+		User: user,
+		Code: r.FormValue("code"),
+	})
+	if err != nil && !isInvalidCode(err) {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to confirm: %w", err))
+		return
+	}
+
+	tmpl, tmplErr := h.newTemplate(r, user)
+	if tmplErr != nil {
+		h.html.WriteHTMLErrorPage(w, r, tmplErr)
+		return
+	}
+
+	if err != nil {
+		tmpl.CodeError = "Invalid code"
+		tmpl.Enrollment, err = h.twoFactor.Enroll(r.Context(), user)
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to enroll: %w", err))
+			return
+		}
+
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+		return
+	}
+
+	tmpl.RecoveryCodes = codes
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, tmpl)
+}
+
+func (h *TwoFactorPage) disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.getUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.twoFactor.Disable(r.Context(), &twofactor.DisableCmd{
+		User: user,
+		Code: r.FormValue("code"),
+	})
+	if err != nil && !isInvalidCode(err) {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to disable: %w", err))
+		return
+	}
+
+	if err != nil {
+		tmpl, err := h.newTemplate(r, user)
+		if err != nil {
+			h.html.WriteHTMLErrorPage(w, r, err)
+			return
+		}
+
+		tmpl.DisableError = "Invalid code"
+		h.html.WriteHTMLTemplate(w, r, http.StatusUnprocessableEntity, tmpl)
+		return
+	}
+
+	http.Redirect(w, r, "/settings/2fa", http.StatusFound)
+}
+
+func (h *TwoFactorPage) newTemplate(r *http.Request, user *users.User) (*home.TwoFactorPageTmpl, error) {
+	enabled, err := h.twoFactor.IsEnabled(r.Context(), user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if enabled: %w", err)
+	}
+
+	required, err := h.twoFactor.IsRequired(r.Context(), user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if required: %w", err)
+	}
+
+	remaining := 0
+	if enabled {
+		remaining, err = h.twoFactor.RemainingRecoveryCodes(r.Context(), user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count the recovery codes: %w", err)
+		}
+	}
+
+	return &home.TwoFactorPageTmpl{
+		Header: &partials.HeaderTmpl{
+			User:        user,
+			CanModerate: h.roles.IsAuthorized(user, perms.Moderation),
+			PostButton:  true,
+		},
+		Enabled:        enabled,
+		Required:       required,
+		RemainingCodes: remaining,
+	}, nil
+}
+
+// getUser returns the authenticated user. If there is none, the response is
+// written and false is returned.
+func (h *TwoFactorPage) getUser(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// isInvalidCode returns true for the errors caused by a wrong user input.
+func isInvalidCode(err error) bool {
+	return errors.Is(err, twofactor.ErrInvalidCode) || errors.Is(err, errs.ErrValidation)
+}
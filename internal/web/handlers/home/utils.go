@@ -0,0 +1,28 @@
+package home
+
+import (
+	"net/url"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+)
+
+// Config of the home pages.
+type Config struct {
+	// BaseURL is the public url of the server, used to build the absolute
+	// links of the feeds, the social metadata and the invitations.
+	BaseURL string
+}
+
+// parseFeedCursor retrieves the opaque feed cursor from the query parameters.
+// A nil cursor is returned for the first page.
+func parseFeedCursor(query url.Values) (*posts.FeedCursor, error) {
+	if !query.Has("cursor") {
+		return nil, nil
+	}
+
+	return posts.DecodeFeedCursor(query.Get("cursor"))
+}
+
+func formatFeedCursor(cursor *posts.FeedCursor) url.Values {
+	return url.Values{"cursor": []string{cursor.Encode()}}
+}
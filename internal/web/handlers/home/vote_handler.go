@@ -0,0 +1,120 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/tools"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/tools/router"
+	"github.com/Peltoche/onlyfun/internal/web/handlers/auth"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/go-chi/chi/v5"
+)
+
+type VoteHandler struct {
+	posts posts.Service
+	votes votes.Service
+	roles perms.Service
+	auth  *auth.Authenticator
+	html  html.Writer
+}
+
+func NewVoteHandler(
+	html html.Writer,
+	auth *auth.Authenticator,
+	posts posts.Service,
+	votes votes.Service,
+	roles perms.Service,
+	tools tools.Tools,
+) *VoteHandler {
+	return &VoteHandler{
+		html:  html,
+		posts: posts,
+		votes: votes,
+		roles: roles,
+		auth:  auth,
+	}
+}
+
+func (h *VoteHandler) Register(r chi.Router, mids *router.Middlewares) {
+	if mids != nil {
+		r = r.With(mids.Defaults()...)
+	}
+
+	r.Post("/posts/{postID}/vote", h.handleVote)
+}
+
+func (h *VoteHandler) handleVote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, _, err := h.auth.GetUserAndSession(w, r)
+	if err != nil && !errors.Is(err, auth.ErrNotAuthenticated) {
+		h.html.WriteHTMLErrorPage(w, r, err)
+		return
+	}
+
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		w.Header().Set("HX-Redirect", "/login")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 0)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.posts.GetByID(ctx, uint(postID))
+	if errors.Is(err, errs.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to GetByID: %w", err))
+		return
+	}
+
+	var value votes.Value
+
+	switch r.FormValue("vote") {
+	case "up":
+		value = votes.Up
+	case "down":
+		value = votes.Down
+	case "none":
+		err = h.votes.Unvote(ctx, &votes.UnvoteCmd{User: user, Post: post})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if value != 0 {
+		_, err = h.votes.Vote(ctx, &votes.VoteCmd{User: user, Post: post, Value: value})
+	}
+
+	var errResp *errs.Error
+	if errors.As(err, &errResp) && errResp.Code() < http.StatusInternalServerError {
+		w.WriteHeader(errResp.Code())
+		return
+	}
+
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to vote for post %d: %w", postID, err))
+		return
+	}
+
+	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &partials.VoteButtonsTmpl{
+		Post:    post,
+		Vote:    value,
+		CanVote: true,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Peltoche/onlyfun/internal/services/moderations"
 	"github.com/Peltoche/onlyfun/internal/services/perms"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
 	"github.com/Peltoche/onlyfun/internal/services/taskrunner"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/tools"
@@ -31,6 +32,7 @@ type ModerationHandler struct {
 	mediasSvc     medias.Service
 	usersSvc      users.Service
 	permsSvc      perms.Service
+	sectionsSvc   sections.Service
 	html          html.Writer
 }
 
@@ -44,6 +46,7 @@ func NewModerationHandler(
 	users users.Service,
 	roles perms.Service,
 	medias medias.Service,
+	sections sections.Service,
 	tools tools.Tools,
 ) *ModerationHandler {
 	return &ModerationHandler{
@@ -54,6 +57,7 @@ func NewModerationHandler(
 		usersSvc:      users,
 		mediasSvc:     medias,
 		permsSvc:      roles,
+		sectionsSvc:   sections,
 		auth:          auth,
 	}
 }
@@ -102,6 +106,8 @@ func (h *ModerationHandler) printOverviewPage(w http.ResponseWriter, r *http.Req
 		},
 
 		PostsWaitingModeration: waitingModeration,
+		CanManageSections:      h.permsSvc.IsAuthorized(user, perms.ManageSections),
+		CanManageUsers:         h.permsSvc.IsAuthorized(user, perms.ManageUsers),
 	})
 }
 
@@ -154,6 +160,12 @@ func (h *ModerationHandler) printPostsPage(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	sectionList, err := h.sectionsSvc.GetAll(ctx)
+	if err != nil {
+		h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the sections: %w", err))
+		return
+	}
+
 	h.html.WriteHTMLTemplate(w, r, http.StatusOK, &moderation.NextPostsPageTmpl{
 		Header: &partials.HeaderTmpl{
 			User:        user,
@@ -166,6 +178,7 @@ func (h *ModerationHandler) printPostsPage(w http.ResponseWriter, r *http.Reques
 		Author:       author,
 		AuthorAvatar: avatarMeta,
 		AuthorStats:  stats,
+		Sections:     sectionList,
 	})
 }
 
@@ -203,6 +216,20 @@ func (h *ModerationHandler) handleValidation(w http.ResponseWriter, r *http.Requ
 
 	switch isAccepted {
 	case true:
+		// The moderators can fix the tags chosen by the author before
+		// listing the post.
+		if r.Form.Has("tags") {
+			err = h.postsSvc.SetTags(ctx, &posts.SetTagsCmd{
+				User: user,
+				Post: post,
+				Tags: posts.ParseTags(r.FormValue("tags")),
+			})
+			if err != nil {
+				h.html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to set the tags of post %q: %w", postID, err))
+				return
+			}
+		}
+
 		err = h.postsSvc.ValidatePost(ctx, &posts.ValidatePostcmd{
 			User: user,
 			Post: post,
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+)
+
+type SectionsPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	Sections []sections.Section
+	Error    string
+}
+
+func (t *SectionsPageTmpl) Template() string { return "admin/page_sections" }
+
+type RolesPageTmpl struct {
+	Header         *partials.HeaderTmpl
+	Roles          []perms.Role
+	Permissions    map[perms.Role][]perms.Permission
+	AllPermissions []perms.Permission
+	Error          string
+}
+
+func (t *RolesPageTmpl) Template() string { return "admin/page_roles" }
+
+func (t *RolesPageTmpl) HasPermission(role perms.Role, perm perms.Permission) bool {
+	return slices.Contains(t.Permissions[role], perm)
+}
+
+type RegistrationsPageTmpl struct {
+	Header *partials.HeaderTmpl
+	Mode   users.RegistrationMode
+	Users  []users.User
+}
+
+func (t *RegistrationsPageTmpl) Template() string { return "admin/page_registrations" }
+
+type TwoFactorPageTmpl struct {
+	Header *partials.HeaderTmpl
+	// Roles are the roles with the moderation permission, the only ones
+	// which can require the two-factor authentication.
+	Roles    []perms.Role
+	Required []perms.Role
+}
+
+func (t *TwoFactorPageTmpl) Template() string { return "admin/page_two_factor" }
+
+func (t *TwoFactorPageTmpl) IsRequired(role perms.Role) bool {
+	return slices.Contains(t.Required, role)
+}
+
+type InvitationsPageTmpl struct {
+	Header      *partials.HeaderTmpl
+	Invitations []invitations.Invitation
+	Creators    map[uuid.UUID]*users.User
+	Now         time.Time
+	Error       string
+}
+
+func (t *InvitationsPageTmpl) Template() string { return "admin/page_invitations" }
+
+func (t *InvitationsPageTmpl) IsUsable(invitation invitations.Invitation) bool {
+	return invitation.IsUsable(t.Now)
+}
+
+type UserDevicesPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	User     *users.User
+	Sessions []websessions.Session
+}
+
+func (t *UserDevicesPageTmpl) Template() string { return "admin/page_user_devices" }
+
+func (t *UserDevicesPageTmpl) SessionsList() *partials.SessionsListTmpl {
+	return &partials.SessionsListTmpl{
+		Sessions:     t.Sessions,
+		ActionPrefix: "/admin/users/" + t.User.Username() + "/devices",
+	}
+}
+
+type LoginAttemptsPageTmpl struct {
+	Header *partials.HeaderTmpl
+	// Locked are the username counters locked after too many failures.
+	Locked   []loginattempts.Counter
+	Failures []loginattempts.Failure
+}
+
+func (t *LoginAttemptsPageTmpl) Template() string { return "admin/page_login_attempts" }
+
+type UsersPageTmpl struct {
+	Header *partials.HeaderTmpl
+	Users  []users.User
+	Query  string
+	// NextPage is the url of the next page, empty on the last one.
+	NextPage string
+	Error    string
+}
+
+func (t *UsersPageTmpl) Template() string { return "admin/page_users" }
+
+type UserPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	User     *users.User
+	Roles    []perms.Role
+	Stats    map[posts.Status]int
+	Sessions []websessions.Session
+	// PasswordReset is set right after a forced password reset.
+	PasswordReset bool
+	// ResetLink is the link to hand over to a user without e-mail, the
+	// others receive it by e-mail.
+	ResetLink secret.Text
+	Error     string
+}
+
+func (t *UserPageTmpl) Template() string { return "admin/page_user" }
+
+func (t *UserPageTmpl) ListedCount() int    { return t.Stats[posts.Listed] }
+func (t *UserPageTmpl) UploadedCount() int  { return t.Stats[posts.Uploaded] }
+func (t *UserPageTmpl) ModeratedCount() int { return t.Stats[posts.Moderated] }
+
+func (t *UserPageTmpl) HasRole(role perms.Role) bool {
+	return t.User.Role() != nil && *t.User.Role() == role
+}
+
+func (t *UserPageTmpl) SessionsList() *partials.SessionsListTmpl {
+	return &partials.SessionsListTmpl{
+		Sessions:     t.Sessions,
+		ActionPrefix: "/admin/users/" + t.User.Username() + "/devices",
+	}
+}
+
+type BansPageTmpl struct {
+	Header *partials.HeaderTmpl
+	Bans   []bans.Ban
+	// Users are the banned users and the issuers of the bans.
+	Users    map[uuid.UUID]*users.User
+	Username string
+	Reason   string
+	Error    string
+}
+
+func (t *BansPageTmpl) Template() string { return "admin/page_bans" }
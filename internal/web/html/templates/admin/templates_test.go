@@ -0,0 +1,280 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/loginattempts"
+	"github.com/Peltoche/onlyfun/internal/services/perms"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Templates(t *testing.T) {
+	renderer := html.NewRenderer(html.Config{
+		PrettyRender: false,
+		HotReload:    false,
+	})
+
+	user := users.NewFakeUser(t).Build()
+	banned := users.NewFakeUser(t).Build()
+
+	tests := []struct {
+		Template html.Templater
+		Name     string
+		Layout   bool
+	}{
+		{
+			Name:   "SectionsPageTmpl",
+			Layout: true,
+			Template: &SectionsPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, CanModerate: true},
+				Sections: []sections.Section{*sections.NewFakeSection(t).Build(), *sections.NewFakeSection(t).Build()},
+			},
+		},
+		{
+			Name:   "SectionsPageTmpl with an error",
+			Layout: true,
+			Template: &SectionsPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, CanModerate: true},
+				Sections: []sections.Section{},
+				Error:    "section already exists",
+			},
+		},
+		{
+			Name:   "RolesPageTmpl",
+			Layout: true,
+			Template: &RolesPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Roles:  []perms.Role{perms.DefaultAdminRole, perms.Role("editor")},
+				Permissions: map[perms.Role][]perms.Permission{
+					perms.DefaultAdminRole: perms.DefaultRoles[perms.DefaultAdminRole],
+					perms.Role("editor"):   {perms.UploadPost, perms.ManageSections},
+				},
+				AllPermissions: perms.AllPermissions,
+			},
+		},
+		{
+			Name:   "RolesPageTmpl with an error",
+			Layout: true,
+			Template: &RolesPageTmpl{
+				Header:         &partials.HeaderTmpl{User: user, CanModerate: true},
+				Roles:          []perms.Role{},
+				Permissions:    map[perms.Role][]perms.Permission{},
+				AllPermissions: perms.AllPermissions,
+				Error:          "role already exists",
+			},
+		},
+		{
+			Name:   "InvitationsPageTmpl",
+			Layout: true,
+			Template: &InvitationsPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Invitations: []invitations.Invitation{
+					*invitations.NewFakeInvitation(t).CreatedBy(user).Build(),
+					*invitations.NewFakeInvitation(t).CreatedBy(user).WithUses(3, 3).Build(),
+				},
+				Creators: map[uuid.UUID]*users.User{user.ID(): user},
+				Now:      time.Now(),
+			},
+		},
+		{
+			Name:   "InvitationsPageTmpl with an error",
+			Layout: true,
+			Template: &InvitationsPageTmpl{
+				Header:      &partials.HeaderTmpl{User: user},
+				Invitations: []invitations.Invitation{},
+				Creators:    map[uuid.UUID]*users.User{},
+				Error:       "invalid max uses",
+			},
+		},
+		{
+			Name:   "UserDevicesPageTmpl",
+			Layout: true,
+			Template: &UserDevicesPageTmpl{
+				Header: &partials.HeaderTmpl{User: user},
+				User:   user,
+				Sessions: []websessions.Session{
+					*websessions.NewFakeSession(t).CreatedBy(user).Build(),
+					*websessions.NewFakeSession(t).CreatedBy(user).WithRemember().Build(),
+				},
+			},
+		},
+		{
+			Name:   "UserDevicesPageTmpl without sessions",
+			Layout: true,
+			Template: &UserDevicesPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user},
+				User:     user,
+				Sessions: []websessions.Session{},
+			},
+		},
+		{
+			Name:   "RegistrationsPageTmpl",
+			Layout: true,
+			Template: &RegistrationsPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Mode:   users.RegistrationApproval,
+				Users:  []users.User{*users.NewFakeUser(t).WithStatus(users.Pending).Build()},
+			},
+		},
+		{
+			Name:   "RegistrationsPageTmpl without pending users",
+			Layout: true,
+			Template: &RegistrationsPageTmpl{
+				Header: &partials.HeaderTmpl{User: user},
+				Mode:   users.RegistrationOpen,
+				Users:  []users.User{},
+			},
+		},
+		{
+			Name:   "TwoFactorPageTmpl",
+			Layout: true,
+			Template: &TwoFactorPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, CanModerate: true},
+				Roles:    []perms.Role{perms.DefaultAdminRole, perms.DefaultModeratorRole},
+				Required: []perms.Role{perms.DefaultModeratorRole},
+			},
+		},
+		{
+			Name:   "LoginAttemptsPageTmpl",
+			Layout: true,
+			Template: &LoginAttemptsPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Locked: []loginattempts.Counter{
+					*loginattempts.NewFakeCounter(t).ForUsername("jane").LockedUntil(time.Now().Add(time.Hour)).Build(),
+				},
+				Failures: []loginattempts.Failure{*loginattempts.NewFakeFailure(t).Build()},
+			},
+		},
+		{
+			Name:   "LoginAttemptsPageTmpl without failures",
+			Layout: true,
+			Template: &LoginAttemptsPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user},
+				Locked:   []loginattempts.Counter{},
+				Failures: []loginattempts.Failure{},
+			},
+		},
+		{
+			Name:   "UsersPageTmpl",
+			Layout: true,
+			Template: &UsersPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Users: []users.User{
+					*user,
+					*users.NewFakeUser(t).WithStatus(users.Deleting).Build(),
+				},
+				Query:    "jo",
+				NextPage: "/admin/users?after=john&q=jo",
+			},
+		},
+		{
+			Name:   "UsersPageTmpl with an error",
+			Layout: true,
+			Template: &UsersPageTmpl{
+				Header: &partials.HeaderTmpl{User: user},
+				Users:  []users.User{},
+				Error:  "Invalid search",
+			},
+		},
+		{
+			Name:   "UserPageTmpl",
+			Layout: true,
+			Template: &UserPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, CanModerate: true},
+				User:     user,
+				Roles:    []perms.Role{perms.DefaultAdminRole, perms.DefaultUserRole},
+				Stats:    map[posts.Status]int{posts.Listed: 3, posts.Uploaded: 1},
+				Sessions: []websessions.Session{*websessions.NewFakeSession(t).CreatedBy(user).Build()},
+			},
+		},
+		{
+			Name:   "UserPageTmpl after a password reset",
+			Layout: true,
+			Template: &UserPageTmpl{
+				Header:        &partials.HeaderTmpl{User: user},
+				User:          users.NewFakeUser(t).WithEmail("").Build(),
+				Roles:         []perms.Role{perms.DefaultUserRole},
+				Stats:         map[posts.Status]int{},
+				Sessions:      []websessions.Session{},
+				PasswordReset: true,
+				ResetLink:     secret.NewText("https://onlyfun.example.com/password-reset?token=some-token"),
+				Error:         "The last admin can't lose its role",
+			},
+		},
+		{
+			Name:   "UserPageTmpl after a password reset sent by email",
+			Layout: true,
+			Template: &UserPageTmpl{
+				Header:        &partials.HeaderTmpl{User: user},
+				User:          users.NewFakeUser(t).WithEmail("jane@example.com").Build(),
+				Roles:         []perms.Role{perms.DefaultUserRole},
+				Stats:         map[posts.Status]int{},
+				Sessions:      []websessions.Session{},
+				PasswordReset: true,
+			},
+		},
+		{
+			Name:   "BansPageTmpl",
+			Layout: true,
+			Template: &BansPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, CanModerate: true},
+				Bans: []bans.Ban{
+					*bans.NewFakeBan(t).WithUser(banned).CreatedBy(user).ExpiresAt(time.Now().Add(time.Hour)).Build(),
+					*bans.NewFakeBan(t).WithUser(user).CreatedBy(banned).Build(),
+				},
+				Users: map[uuid.UUID]*users.User{
+					user.ID():   user,
+					banned.ID(): banned,
+				},
+			},
+		},
+		{
+			Name:   "BansPageTmpl with an error",
+			Layout: true,
+			Template: &BansPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user},
+				Bans:     []bans.Ban{},
+				Users:    map[uuid.UUID]*users.User{},
+				Username: "jane",
+				Reason:   "spam",
+				Error:    "Unknown user",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+
+			if !test.Layout {
+				r.Header.Add("HX-Boosted", "true")
+			}
+
+			renderer.WriteHTMLTemplate(w, r, http.StatusOK, test.Template)
+
+			if !assert.Equal(t, http.StatusOK, w.Code) {
+				res := w.Result()
+				res.Body.Close()
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				t.Log(string(body))
+			}
+		})
+	}
+}
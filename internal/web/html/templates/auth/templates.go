@@ -1,11 +1,21 @@
 package auth
 
-import "github.com/Peltoche/onlyfun/internal/tools/secret"
+import (
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+)
 
 type LoginPageTmpl struct {
 	Username      string
 	UsernameError string
 	PasswordError string
+	CanRegister   bool
+	// OIDC is set when the login with an OpenID Connect provider is
+	// enabled.
+	OIDC      bool
+	OIDCError string
 }
 
 func (t *LoginPageTmpl) Template() string { return "auth/page_login" }
@@ -21,3 +31,70 @@ type BootstrapPageTmpl struct {
 func (t *BootstrapPageTmpl) Template() string {
 	return "auth/page_bootstrap"
 }
+
+type RegisterPageTmpl struct {
+	Username      string
+	UsernameError string
+	PasswordError string
+	ConfirmError  string
+	Code          string
+	CodeError     string
+	// Approval is set when the accounts created without invitation must be
+	// approved by an admin.
+	Approval bool
+	// InviteOnly is set when an invitation code is required.
+	InviteOnly bool
+	// Pending is set once the account is created and waits for approval.
+	Pending bool
+}
+
+func (t *RegisterPageTmpl) Template() string { return "auth/page_register" }
+
+type LoginTwoFactorPageTmpl struct {
+	CodeError string
+	// Enrollment is set when the role of the user requires the two-factor
+	// authentication but it isn't configured yet.
+	Enrollment *twofactor.Enrollment
+}
+
+func (t *LoginTwoFactorPageTmpl) Template() string { return "auth/page_login_2fa" }
+
+func (t *LoginTwoFactorPageTmpl) Enroll() *partials.TwoFactorEnrollTmpl {
+	return &partials.TwoFactorEnrollTmpl{
+		Enrollment: t.Enrollment,
+		CodeError:  t.CodeError,
+		Action:     "/login/2fa",
+	}
+}
+
+type RecoveryCodesPageTmpl struct {
+	Codes []string
+}
+
+func (t *RecoveryCodesPageTmpl) Template() string { return "auth/page_recovery_codes" }
+
+type ForgotPasswordPageTmpl struct {
+	Email      string
+	EmailError string
+	// Sent is set once the request is done, whatever an account uses the
+	// e-mail or not.
+	Sent bool
+}
+
+func (t *ForgotPasswordPageTmpl) Template() string { return "auth/page_forgot_password" }
+
+type ResetPasswordPageTmpl struct {
+	Token         secret.Text
+	TokenError    string
+	PasswordError string
+	ConfirmError  string
+}
+
+func (t *ResetPasswordPageTmpl) Template() string { return "auth/page_reset_password" }
+
+// BannedPageTmpl explains to a banned user why the login is refused.
+type BannedPageTmpl struct {
+	Ban *bans.Ban
+}
+
+func (t *BannedPageTmpl) Template() string { return "auth/page_banned" }
@@ -5,7 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Peltoche/onlyfun/internal/services/bans"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/tools/secret"
 	"github.com/Peltoche/onlyfun/internal/web/html"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,8 +33,96 @@ func Test_Templates(t *testing.T) {
 				Username:      "some-user-input",
 				UsernameError: "some-error-msg",
 				PasswordError: "",
+				CanRegister:   true,
 			},
 		},
+		{
+			Name:   "LoginPageTmpl with OIDC",
+			Layout: true,
+			Template: &LoginPageTmpl{
+				OIDC:      true,
+				OIDCError: "some-error-msg",
+			},
+		},
+		{
+			Name:   "RegisterPageTmpl",
+			Layout: true,
+			Template: &RegisterPageTmpl{
+				Username:      "some-user-input",
+				UsernameError: "some-error-msg",
+				ConfirmError:  "not identical",
+				Approval:      true,
+			},
+		},
+		{
+			Name:   "RegisterPageTmpl invite only",
+			Layout: true,
+			Template: &RegisterPageTmpl{
+				Code:       "some-code",
+				CodeError:  "some-error-msg",
+				InviteOnly: true,
+			},
+		},
+		{
+			Name:   "RegisterPageTmpl pending",
+			Layout: true,
+			Template: &RegisterPageTmpl{
+				Username: "some-user-input",
+				Approval: true,
+				Pending:  true,
+			},
+		},
+		{
+			Name:     "LoginTwoFactorPageTmpl",
+			Layout:   true,
+			Template: &LoginTwoFactorPageTmpl{CodeError: "Invalid code"},
+		},
+		{
+			Name:   "LoginTwoFactorPageTmpl with an enrollment",
+			Layout: true,
+			Template: &LoginTwoFactorPageTmpl{
+				Enrollment: &twofactor.Enrollment{URL: "otpauth://totp/OnlyFun:user", Secret: "JBSWY3DPEHPK3PXP", QRCode: []byte("some-png")},
+			},
+		},
+		{
+			Name:     "RecoveryCodesPageTmpl",
+			Layout:   true,
+			Template: &RecoveryCodesPageTmpl{Codes: []string{"abcde-12345", "fghij-67890"}},
+		},
+		{
+			Name:     "ForgotPasswordPageTmpl",
+			Layout:   true,
+			Template: &ForgotPasswordPageTmpl{Email: "some-user-input", EmailError: "some-error-msg"},
+		},
+		{
+			Name:     "ForgotPasswordPageTmpl sent",
+			Layout:   true,
+			Template: &ForgotPasswordPageTmpl{Email: "foo@example.com", Sent: true},
+		},
+		{
+			Name:   "ResetPasswordPageTmpl",
+			Layout: true,
+			Template: &ResetPasswordPageTmpl{
+				Token:         secret.NewText("some-token"),
+				PasswordError: "some-error-msg",
+				ConfirmError:  "not identical",
+			},
+		},
+		{
+			Name:     "ResetPasswordPageTmpl with an invalid token",
+			Layout:   true,
+			Template: &ResetPasswordPageTmpl{TokenError: "invalid or expired link"},
+		},
+		{
+			Name:     "BannedPageTmpl",
+			Layout:   true,
+			Template: &BannedPageTmpl{Ban: bans.NewFakeBan(t).ExpiresAt(time.Now().Add(time.Hour)).Build()},
+		},
+		{
+			Name:     "BannedPageTmpl permanent",
+			Layout:   true,
+			Template: &BannedPageTmpl{Ban: bans.NewFakeBan(t).Build()},
+		},
 	}
 
 	for _, test := range tests {
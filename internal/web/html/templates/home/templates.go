@@ -1,19 +1,243 @@
 package home
 
 import (
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
 )
 
 type ListingPageTmpl struct {
-	Header *partials.HeaderTmpl
-	Posts  []posts.Post
+	Header   *partials.HeaderTmpl
+	Feed     posts.Feed
+	Tag      string
+	Sections []sections.Section
+	Posts    []posts.Post
+	NextPage string
+	Votes    map[uint]votes.Value
+	CanVote  bool
 }
 
 func (t *ListingPageTmpl) Template() string { return "home/page_listing" }
 
-type SubmitPageTmpl struct {
+func (t *ListingPageTmpl) Feeds() []posts.Feed { return posts.Feeds }
+
+// FeedURL returns the url of the given feed, restricted to the current tag
+// if any.
+func (t *ListingPageTmpl) FeedURL(feed posts.Feed) string {
+	if t.Tag == "" {
+		return "/" + string(feed)
+	}
+
+	return "/tag/" + t.Tag + "/" + string(feed)
+}
+
+func (t *ListingPageTmpl) PostsList() *ListingPostsTmpl {
+	return &ListingPostsTmpl{
+		Posts:    t.Posts,
+		NextPage: t.NextPage,
+		Votes:    t.Votes,
+		CanVote:  t.CanVote,
+	}
+}
+
+// ListingPostsTmpl renders a page of posts followed by a loader fetching the
+// next page. It is rendered alone for the infinite scroll requests.
+type ListingPostsTmpl struct {
+	Posts    []posts.Post
+	NextPage string
+	Votes    map[uint]votes.Value
+	CanVote  bool
+}
+
+func (t *ListingPostsTmpl) Template() string { return "home/listing_posts" }
+
+func (t *ListingPostsTmpl) VoteButtons(post posts.Post) *partials.VoteButtonsTmpl {
+	return &partials.VoteButtonsTmpl{
+		Post:    &post,
+		Vote:    t.Votes[post.ID()],
+		CanVote: t.CanVote,
+	}
+}
+
+type ProfilePageTmpl struct {
+	Header           *partials.HeaderTmpl
+	Profile          *users.User
+	Stats            map[posts.Status]int
+	ShowPrivateStats bool
+	Posts            []posts.Post
+	NextPage         string
+	Votes            map[uint]votes.Value
+	CanVote          bool
+	CanManageUser    bool
+	// CanResetAvatar is set for the moderators visiting another profile.
+	CanResetAvatar bool
+}
+
+func (t *ProfilePageTmpl) Template() string { return "home/page_profile" }
+
+func (t *ProfilePageTmpl) ListedCount() int    { return t.Stats[posts.Listed] }
+func (t *ProfilePageTmpl) UploadedCount() int  { return t.Stats[posts.Uploaded] }
+func (t *ProfilePageTmpl) ModeratedCount() int { return t.Stats[posts.Moderated] }
+
+func (t *ProfilePageTmpl) PostsList() *ListingPostsTmpl {
+	return &ListingPostsTmpl{
+		Posts:    t.Posts,
+		NextPage: t.NextPage,
+		Votes:    t.Votes,
+		CanVote:  t.CanVote,
+	}
+}
+
+type DevicesPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	Sessions []websessions.Session
+	Current  *websessions.Session
+}
+
+func (t *DevicesPageTmpl) Template() string { return "home/page_devices" }
+
+func (t *DevicesPageTmpl) SessionsList() *partials.SessionsListTmpl {
+	return &partials.SessionsListTmpl{
+		Sessions:     t.Sessions,
+		Current:      t.Current,
+		ActionPrefix: "/devices",
+	}
+}
+
+type TwoFactorPageTmpl struct {
+	Header *partials.HeaderTmpl
+	// Enabled is set once the enrollment is confirmed.
+	Enabled bool
+	// Required is set when the role of the user requires it, it can't be
+	// disabled.
+	Required       bool
+	RemainingCodes int
+	// Enrollment is set during the enrollment.
+	Enrollment *twofactor.Enrollment
+	CodeError  string
+	// RecoveryCodes is set right after the enrollment confirmation.
+	RecoveryCodes []string
+	DisableError  string
+}
+
+func (t *TwoFactorPageTmpl) Template() string { return "home/page_two_factor" }
+
+func (t *TwoFactorPageTmpl) Enroll() *partials.TwoFactorEnrollTmpl {
+	return &partials.TwoFactorEnrollTmpl{
+		Enrollment: t.Enrollment,
+		CodeError:  t.CodeError,
+		Action:     "/settings/2fa/confirm",
+	}
+}
+
+type EmailPageTmpl struct {
 	Header *partials.HeaderTmpl
+	// Email is the verified e-mail of the user, empty if none.
+	Email      string
+	NewEmail   string
+	EmailError string
+	// Sent is set once the verification link is sent to NewEmail.
+	Sent bool
+}
+
+func (t *EmailPageTmpl) Template() string { return "home/page_email" }
+
+type AvatarPageTmpl struct {
+	Header *partials.HeaderTmpl
+	Avatar uuid.UUID
+	Styles []users.AvatarStyle
+	Error  string
+}
+
+func (t *AvatarPageTmpl) Template() string { return "home/page_avatar" }
+
+type SearchPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	Query    string
+	Posts    []posts.Post
+	NextPage string
+}
+
+func (t *SearchPageTmpl) Template() string { return "home/page_search" }
+
+type SubmitPageTmpl struct {
+	Header   *partials.HeaderTmpl
+	Sections []sections.Section
 }
 
 func (t *SubmitPageTmpl) Template() string { return "home/page_submit" }
+
+type PostPageTmpl struct {
+	Header *partials.HeaderTmpl
+	Post   *posts.Post
+	Author *users.User
+	// URL and ImageURL are the absolute urls of the post and its media
+	// required by the OpenGraph and Twitter card metadata.
+	URL                 string
+	ImageURL            string
+	VoteButtons         *partials.VoteButtonsTmpl
+	User                *users.User
+	Threads             []comments.Thread
+	Authors             map[uuid.UUID]*users.User
+	CanComment          bool
+	CanModerateComments bool
+	// Content and CommentError are set when a comment is rejected, to print
+	// it back in the form.
+	Content      string
+	CommentError string
+}
+
+func (t *PostPageTmpl) Template() string { return "home/page_post" }
+
+func (t *PostPageTmpl) CommentThread(thread comments.Thread) *CommentThreadTmpl {
+	return &CommentThreadTmpl{
+		Thread: thread,
+		Author: t.Authors[thread.Comment.CreatedBy()],
+		Page:   t,
+	}
+}
+
+// CommentThreadTmpl renders a comment and all its replies.
+type CommentThreadTmpl struct {
+	Thread comments.Thread
+	Author *users.User
+	Page   *PostPageTmpl
+}
+
+func (t *CommentThreadTmpl) Template() string { return "home/comment_thread" }
+
+func (t *CommentThreadTmpl) IsOwner() bool {
+	return t.Page.User != nil && t.Page.User.ID() == t.Thread.Comment.CreatedBy()
+}
+
+type InvitationsPageTmpl struct {
+	Header      *partials.HeaderTmpl
+	Invitations []invitations.Invitation
+	// Remaining is the number of invitations the user can still create or
+	// [invitations.Unlimited].
+	Remaining int
+	BaseURL   string
+	Now       time.Time
+	Error     string
+}
+
+func (t *InvitationsPageTmpl) Template() string { return "home/page_invitations" }
+
+func (t *InvitationsPageTmpl) Unlimited() bool { return t.Remaining == invitations.Unlimited }
+
+func (t *InvitationsPageTmpl) IsUsable(invitation invitations.Invitation) bool {
+	return invitation.IsUsable(t.Now)
+}
+
+func (t *InvitationsPageTmpl) Link(invitation invitations.Invitation) string {
+	return t.BaseURL + "/register?code=" + invitation.Code()
+}
@@ -0,0 +1,334 @@
+package home
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/onlyfun/internal/services/comments"
+	"github.com/Peltoche/onlyfun/internal/services/invitations"
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+	"github.com/Peltoche/onlyfun/internal/tools/uuid"
+	"github.com/Peltoche/onlyfun/internal/web/html"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Templates(t *testing.T) {
+	renderer := html.NewRenderer(html.Config{
+		PrettyRender: false,
+		HotReload:    false,
+	})
+
+	user := users.NewFakeUser(t).Build()
+	post1 := posts.NewFakePost(t).WithStatus(posts.Listed).WithVotes(3, 1).WithTags("cats", "funny-animals").Build()
+	post2 := posts.NewFakePost(t).WithStatus(posts.Listed).Build()
+
+	author := users.NewFakeUser(t).Build()
+	session := websessions.NewFakeSession(t).CreatedBy(user).Build()
+	uploaded := posts.NewFakePost(t).WithStatus(posts.Uploaded).CreatedBy(author).Build()
+	comment := comments.NewFakeComment(t).WithPost(post1).CreatedBy(author).Build()
+	reply := comments.NewFakeComment(t).ReplyTo(comment).CreatedBy(user).Build()
+	removed := comments.NewFakeComment(t).ReplyTo(comment).CreatedBy(author).WithStatus(comments.Moderated).Build()
+
+	postPage := &PostPageTmpl{
+		Header:      &partials.HeaderTmpl{User: user, PostButton: true},
+		Post:        post1,
+		Author:      author,
+		URL:         "https://example.com/posts/1",
+		ImageURL:    "https://example.com/medias/" + string(post1.FileID()),
+		VoteButtons: &partials.VoteButtonsTmpl{Post: post1, Vote: votes.Up, CanVote: true},
+		User:        user,
+		Threads: []comments.Thread{
+			{Comment: *comment, Replies: []comments.Thread{
+				{Comment: *reply, Replies: []comments.Thread{}},
+				{Comment: *removed, Replies: []comments.Thread{}},
+			}},
+		},
+		Authors: map[uuid.UUID]*users.User{
+			user.ID():   user,
+			author.ID(): author,
+		},
+		CanComment:          true,
+		CanModerateComments: true,
+	}
+
+	tests := []struct {
+		Template html.Templater
+		Name     string
+		Layout   bool
+	}{
+		{
+			Name:   "ListingPageTmpl",
+			Layout: true,
+			Template: &ListingPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, PostButton: true},
+				Feed:     posts.Hot,
+				Posts:    []posts.Post{*post1, *post2},
+				NextPage: "/hot?cursor=eyJhIjoiMjAyNC0wOC0wMVQxMDowMDowMFoiLCJyIjowLjUsInAiOjJ9",
+				Votes:    map[uint]votes.Value{post1.ID(): votes.Up},
+				CanVote:  true,
+			},
+		},
+		{
+			Name:   "ListingPageTmpl with a tag",
+			Layout: true,
+			Template: &ListingPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, PostButton: true},
+				Feed:     posts.Fresh,
+				Tag:      "cats",
+				Sections: []sections.Section{*sections.NewFakeSection(t).WithName("cats").Build()},
+				Posts:    []posts.Post{*post1},
+				NextPage: "/tag/cats/fresh?cursor=eyJhIjoiMjAyNC0wOC0wMVQxMDowMDowMFoiLCJyIjoyLCJwIjoyfQ",
+				Votes:    map[uint]votes.Value{},
+				CanVote:  true,
+			},
+		},
+		{
+			Name:   "ListingPageTmpl without posts",
+			Layout: true,
+			Template: &ListingPageTmpl{
+				Header: &partials.HeaderTmpl{},
+				Feed:   posts.Fresh,
+				Posts:  []posts.Post{},
+			},
+		},
+		{
+			Name:   "ListingPostsTmpl",
+			Layout: false,
+			Template: &ListingPostsTmpl{
+				Posts:    []posts.Post{*post1, *post2},
+				NextPage: "/hot?cursor=eyJhIjoiMjAyNC0wOC0wMVQxMDowMDowMFoiLCJyIjowLjUsInAiOjJ9",
+				Votes:    map[uint]votes.Value{post2.ID(): votes.Down},
+				CanVote:  false,
+			},
+		},
+		{
+			Name:   "ProfilePageTmpl",
+			Layout: true,
+			Template: &ProfilePageTmpl{
+				Header:           &partials.HeaderTmpl{User: user, PostButton: true},
+				Profile:          author,
+				Stats:            map[posts.Status]int{posts.Listed: 2, posts.Uploaded: 1, posts.Moderated: 0},
+				ShowPrivateStats: true,
+				Posts:            []posts.Post{*post1, *post2},
+				NextPage:         "/u/" + author.Username() + "?before=2",
+				Votes:            map[uint]votes.Value{},
+				CanVote:          true,
+				CanResetAvatar:   true,
+			},
+		},
+		{
+			Name:   "ProfilePageTmpl without posts",
+			Layout: true,
+			Template: &ProfilePageTmpl{
+				Header:  &partials.HeaderTmpl{},
+				Profile: author,
+				Stats:   map[posts.Status]int{},
+				Posts:   []posts.Post{},
+			},
+		},
+		{
+			Name:   "InvitationsPageTmpl",
+			Layout: true,
+			Template: &InvitationsPageTmpl{
+				Header: &partials.HeaderTmpl{User: user, PostButton: true},
+				Invitations: []invitations.Invitation{
+					*invitations.NewFakeInvitation(t).CreatedBy(user).Build(),
+					*invitations.NewFakeInvitation(t).CreatedBy(user).WithUses(1, 1).Build(),
+				},
+				Remaining: 2,
+				BaseURL:   "https://example.com",
+				Now:       time.Now(),
+			},
+		},
+		{
+			Name:   "InvitationsPageTmpl without quota",
+			Layout: true,
+			Template: &InvitationsPageTmpl{
+				Header:      &partials.HeaderTmpl{User: user, PostButton: true},
+				Invitations: []invitations.Invitation{},
+				Remaining:   0,
+				Error:       "you can't create more invitations",
+			},
+		},
+		{
+			Name:   "DevicesPageTmpl",
+			Layout: true,
+			Template: &DevicesPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, PostButton: true},
+				Sessions: []websessions.Session{*session, *websessions.NewFakeSession(t).CreatedBy(user).Build()},
+				Current:  session,
+			},
+		},
+		{
+			Name:   "DevicesPageTmpl without sessions",
+			Layout: true,
+			Template: &DevicesPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user},
+				Sessions: []websessions.Session{},
+			},
+		},
+		{
+			Name:   "TwoFactorPageTmpl disabled",
+			Layout: true,
+			Template: &TwoFactorPageTmpl{
+				Header: &partials.HeaderTmpl{User: user},
+			},
+		},
+		{
+			Name:   "TwoFactorPageTmpl enrollment",
+			Layout: true,
+			Template: &TwoFactorPageTmpl{
+				Header:     &partials.HeaderTmpl{User: user},
+				Required:   true,
+				Enrollment: &twofactor.Enrollment{URL: "otpauth://totp/OnlyFun:user", Secret: "JBSWY3DPEHPK3PXP", QRCode: []byte("some-png")},
+				CodeError:  "Invalid code",
+			},
+		},
+		{
+			Name:   "TwoFactorPageTmpl with the recovery codes",
+			Layout: true,
+			Template: &TwoFactorPageTmpl{
+				Header:         &partials.HeaderTmpl{User: user},
+				Enabled:        true,
+				RemainingCodes: 2,
+				RecoveryCodes:  []string{"abcde-12345", "fghij-67890"},
+			},
+		},
+		{
+			Name:   "TwoFactorPageTmpl enabled",
+			Layout: true,
+			Template: &TwoFactorPageTmpl{
+				Header:         &partials.HeaderTmpl{User: user},
+				Enabled:        true,
+				RemainingCodes: 10,
+				DisableError:   "Invalid code",
+			},
+		},
+		{
+			Name:   "EmailPageTmpl",
+			Layout: true,
+			Template: &EmailPageTmpl{
+				Header:     &partials.HeaderTmpl{User: user},
+				NewEmail:   "some-user-input",
+				EmailError: "some-error-msg",
+			},
+		},
+		{
+			Name:   "EmailPageTmpl sent",
+			Layout: true,
+			Template: &EmailPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user},
+				Email:    "foo@example.com",
+				NewEmail: "bar@example.com",
+				Sent:     true,
+			},
+		},
+		{
+			Name:   "AvatarPageTmpl",
+			Layout: true,
+			Template: &AvatarPageTmpl{
+				Header: &partials.HeaderTmpl{User: user},
+				Avatar: user.Avatar(),
+				Styles: []users.AvatarStyle{users.MaleAvatar, users.FemaleAvatar},
+				Error:  "some-error-msg",
+			},
+		},
+		{
+			Name:   "SearchPageTmpl",
+			Layout: true,
+			Template: &SearchPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, PostButton: true},
+				Query:    "funny cat",
+				Posts:    []posts.Post{*post1, *post2},
+				NextPage: "/search?page=2&q=funny+cat",
+			},
+		},
+		{
+			Name:   "SearchPageTmpl without results",
+			Layout: true,
+			Template: &SearchPageTmpl{
+				Header: &partials.HeaderTmpl{},
+				Query:  "funny cat",
+				Posts:  []posts.Post{},
+			},
+		},
+		{
+			Name:   "SubmitPageTmpl",
+			Layout: true,
+			Template: &SubmitPageTmpl{
+				Header:   &partials.HeaderTmpl{User: user, PostButton: true},
+				Sections: []sections.Section{*sections.NewFakeSection(t).Build()},
+			},
+		},
+		{
+			Name:     "PostPageTmpl",
+			Layout:   true,
+			Template: postPage,
+		},
+		{
+			Name:   "PostPageTmpl with a post not listed",
+			Layout: true,
+			Template: &PostPageTmpl{
+				Header:      &partials.HeaderTmpl{User: author, PostButton: true},
+				Post:        uploaded,
+				Author:      author,
+				URL:         "https://example.com/posts/3",
+				ImageURL:    "https://example.com/medias/" + string(uploaded.FileID()),
+				VoteButtons: &partials.VoteButtonsTmpl{Post: uploaded, CanVote: false},
+				User:        author,
+				Threads:     []comments.Thread{},
+				Authors:     map[uuid.UUID]*users.User{author.ID(): author},
+			},
+		},
+		{
+			Name:     "CommentThreadTmpl",
+			Layout:   false,
+			Template: postPage.CommentThread(postPage.Threads[0]),
+		},
+		{
+			Name:   "VoteButtonsTmpl",
+			Layout: false,
+			Template: &partials.VoteButtonsTmpl{
+				Post:    post1,
+				Vote:    votes.Down,
+				CanVote: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+
+			if !test.Layout {
+				r.Header.Add("HX-Boosted", "true")
+			}
+
+			renderer.WriteHTMLTemplate(w, r, http.StatusOK, test.Template)
+
+			if !assert.Equal(t, http.StatusOK, w.Code) {
+				res := w.Result()
+				res.Body.Close()
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				t.Log(string(body))
+			}
+		})
+	}
+}
+
+func Test_ListingPageTmpl_FeedURL(t *testing.T) {
+	assert.Equal(t, "/trending", (&ListingPageTmpl{}).FeedURL(posts.Trending))
+	assert.Equal(t, "/tag/cats/fresh", (&ListingPageTmpl{Tag: "cats"}).FeedURL(posts.Fresh))
+}
@@ -1,8 +1,11 @@
 package moderation
 
 import (
+	"strings"
+
 	"github.com/Peltoche/onlyfun/internal/services/medias"
 	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/sections"
 	"github.com/Peltoche/onlyfun/internal/services/users"
 	"github.com/Peltoche/onlyfun/internal/web/html/templates/partials"
 )
@@ -10,6 +13,8 @@ import (
 type OverviewPageTmpl struct {
 	Header                 *partials.HeaderTmpl
 	PostsWaitingModeration int
+	CanManageSections      bool
+	CanManageUsers         bool
 }
 
 func (t *OverviewPageTmpl) Template() string { return "moderation/page_overview" }
@@ -21,6 +26,10 @@ type NextPostsPageTmpl struct {
 	Author       *users.User
 	AuthorAvatar *medias.FileMeta
 	AuthorStats  map[posts.Status]int
+	Sections     []sections.Section
 }
 
 func (t *NextPostsPageTmpl) Template() string { return "moderation/page_next_post" }
+
+// Tags returns the post tags formatted for the tags input.
+func (t *NextPostsPageTmpl) Tags() string { return strings.Join(t.Post.Tags(), ", ") }
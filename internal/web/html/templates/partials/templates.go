@@ -1,9 +1,53 @@
 package partials
 
-import "github.com/Peltoche/onlyfun/internal/services/users"
+import (
+	"encoding/base64"
+	"html/template"
+
+	"github.com/Peltoche/onlyfun/internal/services/posts"
+	"github.com/Peltoche/onlyfun/internal/services/twofactor"
+	"github.com/Peltoche/onlyfun/internal/services/users"
+	"github.com/Peltoche/onlyfun/internal/services/votes"
+	"github.com/Peltoche/onlyfun/internal/services/websessions"
+)
 
 type HeaderTmpl struct {
 	User        *users.User
 	CanModerate bool
 	PostButton  bool
 }
+
+type VoteButtonsTmpl struct {
+	Post    *posts.Post
+	Vote    votes.Value
+	CanVote bool
+}
+
+func (t *VoteButtonsTmpl) Template() string { return "partials/vote_buttons" }
+
+type SessionsListTmpl struct {
+	Sessions []websessions.Session
+	// Current is the session used to display the page, if it is part of
+	// the list.
+	Current *websessions.Session
+	// ActionPrefix is the url prefix of the revoke forms.
+	ActionPrefix string
+}
+
+func (t *SessionsListTmpl) IsCurrent(session websessions.Session) bool {
+	return t.Current != nil && t.Current.ID() == session.ID()
+}
+
+type TwoFactorEnrollTmpl struct {
+	Enrollment *twofactor.Enrollment
+	CodeError  string
+	// Action is the url of the confirmation form.
+	Action string
+}
+
+// QRCodeURL returns the QR code as an inline image. The page using it must
+// allow the "data:" images in its Content-Security-Policy.
+func (t *TwoFactorEnrollTmpl) QRCodeURL() template.URL {
+	//nolint:gosec // The content is a PNG generated by the server.
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(t.Enrollment.QRCode))
+}
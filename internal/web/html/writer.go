@@ -1,7 +1,9 @@
 package html
 
 import (
+	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
@@ -11,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Peltoche/onlyfun/internal/tools/csrf"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
 	"github.com/Peltoche/onlyfun/internal/tools/logger"
 	"github.com/Peltoche/onlyfun/internal/tools/uuid"
 	"github.com/dustin/go-humanize"
@@ -36,7 +40,8 @@ type Writer interface {
 }
 
 type Renderer struct {
-	render *render.Render
+	render    *render.Render
+	hotReload bool
 }
 
 func NewRenderer(cfg Config) *Renderer {
@@ -68,6 +73,8 @@ func NewRenderer(cfg Config) *Renderer {
 				"humanDate": func(t time.Time) string { return t.Format(time.DateTime) },
 				"humanSize": humanize.Bytes,
 			},
+			// Replaced by the token of the request in executeTemplate.
+			csrfFuncs(""),
 			{
 				"sub": func(a, b int) int { return a - b },
 			},
@@ -105,7 +112,17 @@ func NewRenderer(cfg Config) *Renderer {
 	renderer := render.New(opts)
 	renderer.CompileTemplates()
 
-	return &Renderer{renderer}
+	return &Renderer{renderer, cfg.HotReload}
+}
+
+// csrfFuncs returns the template funcs rendering the given CSRF token.
+func csrfFuncs(token string) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string { return token },
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + csrf.FieldName + `" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+	}
 }
 
 func (t *Renderer) writeHTML(w http.ResponseWriter, r *http.Request, status int, template string, args any) {
@@ -129,25 +146,106 @@ func (t *Renderer) writeHTML(w http.ResponseWriter, r *http.Request, status int,
 		}
 	}
 
-	if err := t.render.HTML(w, status, template, args, render.HTMLOptions{Layout: layout}); err != nil {
+	t.execute(w, r, status, template, args, layout)
+}
+
+// execute renders the template into a buffer and only then writes it to the
+// client, so that a failing template ends up on the 500 page instead of a
+// half written response.
+func (t *Renderer) execute(w http.ResponseWriter, r *http.Request, status int, template string, args any, layout string) {
+	buf, err := t.executeTemplate(r, template, args, layout)
+	if err != nil {
 		logger.LogEntrySetAttrs(r.Context(), slog.String("render-error", err.Error()))
+		t.writeInternalError(w, r)
+		return
 	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+// executeTemplate executes the template on a clone of the templates set with
+// the funcs of the request bound. The compiled set is never executed itself,
+// which is what allows all the requests to clone it concurrently.
+func (t *Renderer) executeTemplate(r *http.Request, name string, args any, layout string) (*bytes.Buffer, error) {
+	if t.hotReload {
+		t.render.CompileTemplates()
+	}
+
+	compiled := t.render.TemplateLookup(name)
+	if compiled == nil {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+
+	tmpl, err := compiled.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone the templates: %w", err)
+	}
+
+	tmpl.Funcs(csrfFuncs(csrf.Token(r.Context())))
+
+	if layout != "" {
+		tmpl.Funcs(template.FuncMap{
+			"yield": func() (template.HTML, error) {
+				var buf bytes.Buffer
+				err := tmpl.ExecuteTemplate(&buf, name, args)
+
+				return template.HTML(buf.String()), err
+			},
+			"current": func() (string, error) { return name, nil },
+		})
+
+		name = layout
+	}
+
+	var buf bytes.Buffer
+
+	err = tmpl.ExecuteTemplate(&buf, name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buf, nil
+}
+
+// writeInternalError writes the 500 page, or a plain text error if even this
+// page can't be rendered.
+func (t *Renderer) writeInternalError(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(middleware.RequestIDKey).(string)
+
+	buf, err := t.executeTemplate(r, "misc/page_500", map[string]any{
+		"requestID": reqID,
+	}, "")
+	if err != nil {
+		logger.LogEntrySetAttrs(r.Context(), slog.String("render-error", err.Error()))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = buf.WriteTo(w)
 }
 
 func (t *Renderer) WriteHTMLTemplate(w http.ResponseWriter, r *http.Request, status int, template Templater) {
 	t.writeHTML(w, r, status, template.Template(), template)
 }
 
+// WriteHTMLErrorPage renders the 404 page for the errs.ErrNotFound errors and
+// the 500 page for all the others.
 func (t *Renderer) WriteHTMLErrorPage(w http.ResponseWriter, r *http.Request, err error) {
 	layout := ""
 
-	reqID := r.Context().Value(middleware.RequestIDKey).(string)
+	if errors.Is(err, errs.ErrNotFound) {
+		logger.LogEntrySetAttrs(r.Context(), slog.String("not-found", err.Error()))
 
-	logger.LogEntrySetError(r.Context(), err)
+		t.execute(w, r, http.StatusNotFound, "misc/page_404", nil, layout)
 
-	if err := t.render.HTML(w, http.StatusInternalServerError, "misc/page_500", map[string]any{
-		"requestID": reqID,
-	}, render.HTMLOptions{Layout: layout}); err != nil {
-		logger.LogEntrySetAttrs(r.Context(), slog.String("render-error", err.Error()))
+		return
 	}
+
+	logger.LogEntrySetError(r.Context(), err)
+
+	t.writeInternalError(w, r)
 }
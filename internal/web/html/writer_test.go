@@ -3,11 +3,16 @@ package html
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"github.com/Peltoche/onlyfun/internal/tools/csrf"
+	"github.com/Peltoche/onlyfun/internal/tools/errs"
+	"github.com/Peltoche/onlyfun/internal/web/html/templates/auth"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,4 +49,120 @@ func TestWriter(t *testing.T) {
 		assert.Contains(t, rawBodyStr, "RequestID: some-request-id")
 		assert.Contains(t, rawBodyStr, "html lang=\"en\"", "must return the full page with the header")
 	})
+
+	t.Run("WriteHTMLErrorPage with a not found error", func(t *testing.T) {
+		html := NewRenderer(Config{
+			PrettyRender: false,
+			HotReload:    false,
+		})
+
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, "some-request-id")
+
+		r := httptest.NewRequest(http.MethodGet, "/invalid-url", nil)
+		r = r.WithContext(ctx)
+
+		w := httptest.NewRecorder()
+
+		html.WriteHTMLErrorPage(w, r, fmt.Errorf("failed to get the post: %w", errs.NotFound(errors.New("some-error"))))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		rawBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.NotContains(t, string(rawBody), "some-request-id")
+	})
+	t.Run("WriteHTMLTemplate with a rendering error", func(t *testing.T) {
+		html := NewRenderer(Config{
+			PrettyRender: false,
+			HotReload:    false,
+		})
+
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, "some-request-id")
+
+		r := httptest.NewRequest(http.MethodGet, "/some-url", nil)
+		r = r.WithContext(ctx)
+
+		w := httptest.NewRecorder()
+
+		html.WriteHTMLTemplate(w, r, http.StatusOK, unknownTmpl{})
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+		rawBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		rawBodyStr := string(rawBody)
+
+		assert.Contains(t, rawBodyStr, "RequestID: some-request-id")
+		assert.NotContains(t, rawBodyStr, "unknown/page", "must not leak the template error")
+	})
+
+	t.Run("WriteHTMLTemplate injects the csrf token", func(t *testing.T) {
+		html := NewRenderer(Config{
+			PrettyRender: false,
+			HotReload:    false,
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/login", nil)
+		w := httptest.NewRecorder()
+
+		var token string
+		csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token = csrf.Token(r.Context())
+			html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.LoginPageTmpl{})
+		})).ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		rawBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, token)
+		assert.Contains(t, string(rawBody), `<input type="hidden" name="csrf_token" value="`+token+`">`)
+	})
+
+	t.Run("WriteHTMLTemplate with concurrent requests", func(t *testing.T) {
+		html := NewRenderer(Config{
+			PrettyRender: false,
+			HotReload:    false,
+		})
+
+		var wg sync.WaitGroup
+
+		for range 20 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				r := httptest.NewRequest(http.MethodGet, "/login", nil)
+				w := httptest.NewRecorder()
+
+				var token string
+				csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					token = csrf.Token(r.Context())
+					html.WriteHTMLTemplate(w, r, http.StatusOK, &auth.LoginPageTmpl{})
+				})).ServeHTTP(w, r)
+
+				// Each page must contain the token of its own request.
+				assert.Contains(t, w.Body.String(), `value="`+token+`"`)
+			}()
+		}
+
+		wg.Wait()
+	})
 }
+
+type unknownTmpl struct{}
+
+func (unknownTmpl) Template() string { return "unknown/page" }